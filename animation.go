@@ -0,0 +1,161 @@
+package tmx
+
+import "time"
+
+// FrameAt returns the frame shown at time t of the looping animation.
+// Frame durations are in milliseconds and frames with no duration are never
+// shown, unless no frame has a duration in which case the first one is.
+// It returns false if the animation has no frames.
+func (a Animation) FrameAt(t time.Duration) (Frame, bool) {
+	if len(a.Frames) == 0 {
+		return Frame{}, false
+	}
+	total := a.TotalDuration()
+	if total == 0 {
+		return a.Frames[0], true
+	}
+	t %= total
+	if t < 0 {
+		t += total
+	}
+	for _, f := range a.Frames {
+		d := f.DurationD()
+		if t < d {
+			return f, true
+		}
+		t -= d
+	}
+	return a.Frames[len(a.Frames)-1], true
+}
+
+// DurationD returns the Duration of f in milliseconds as a time.Duration,
+// treating negative durations as 0.
+func (f Frame) DurationD() time.Duration {
+	if f.Duration <= 0 {
+		return 0
+	}
+	return time.Duration(f.Duration) * time.Millisecond
+}
+
+// TotalDuration returns the duration of one loop of the animation.
+func (a Animation) TotalDuration() time.Duration {
+	var total time.Duration
+	for _, f := range a.Frames {
+		total += f.DurationD()
+	}
+	return total
+}
+
+// Animator plays the animation of a tile from a running clock.
+type Animator struct {
+	tile    ID
+	anim    Animation
+	elapsed time.Duration
+}
+
+// NewAnimator returns an animator playing the animation of t from time 0.
+// Tiles without an animation always show themselves.
+func NewAnimator(t Tile) *Animator {
+	return &Animator{tile: t.ID, anim: t.Animation}
+}
+
+// Update advances the clock of the animator by dt and returns the ID of the
+// tile shown.
+func (a *Animator) Update(dt time.Duration) ID {
+	a.elapsed += dt
+	// Keep the clock within one loop so it never overflows.
+	if total := a.anim.TotalDuration(); total > 0 {
+		a.elapsed %= total
+	}
+	return a.TileID()
+}
+
+// At returns the ID of the tile shown at time t of the animation, without
+// changing the clock of the animator.
+func (a *Animator) At(t time.Duration) ID {
+	if f, ok := a.anim.FrameAt(t); ok {
+		return f.TileID
+	}
+	return a.tile
+}
+
+// TileID returns the ID of the tile shown at the current time.
+func (a *Animator) TileID() ID {
+	return a.At(a.elapsed)
+}
+
+// Elapsed returns the time into the current loop of the animation.
+func (a *Animator) Elapsed() time.Duration {
+	return a.elapsed
+}
+
+// Reset rewinds the animator to time 0.
+func (a *Animator) Reset() {
+	a.elapsed = 0
+}
+
+// AnimationState animates every tile of a map from a single clock, as Tiled
+// does, so that tiles sharing an animation stay in sync.
+type AnimationState struct {
+	Time time.Duration // Time since all animations started.
+
+	anims map[GID]animatedTile // Animated tiles by unflipped GID.
+}
+
+// animatedTile is an animated tile of a tileset.
+type animatedTile struct {
+	firstGID GID
+	anim     *Animation
+}
+
+// NewAnimationState returns the animation state of the tiles of m at time 0.
+// Make a new state after changing the tilesets of m.
+func NewAnimationState(m *Map) *AnimationState {
+	s := &AnimationState{anims: make(map[GID]animatedTile)}
+	for i := 0; i < len(m.Tilesets); i++ {
+		ts := &m.Tilesets[i]
+		for j := 0; j < len(ts.Tiles); j++ {
+			if t := &ts.Tiles[j]; len(t.Animation.Frames) > 0 {
+				s.anims[ts.FirstGID+GID(t.ID)] = animatedTile{ts.FirstGID, &t.Animation}
+			}
+		}
+	}
+	return s
+}
+
+// Update advances the clock by dt.
+func (s *AnimationState) Update(dt time.Duration) {
+	s.Time += dt
+}
+
+// CurrentGID returns the GID of the tile shown for gid at the current time,
+// with the flip bits of gid. GIDs of tiles without an animation are returned
+// unchanged.
+func (s *AnimationState) CurrentGID(gid GID) GID {
+	a, ok := s.anims[gid&^GIDFlip]
+	if !ok {
+		return gid
+	}
+	f, _ := a.anim.FrameAt(s.Time)
+	return a.firstGID + GID(f.TileID) | gid&GIDFlip
+}
+
+// ResolveAnimatedGID returns the GID of the tile shown for gid at time t of
+// its animation, with the flip bits of gid. All animations start at time 0.
+// GIDs of tiles without an animation are returned unchanged.
+func (m *Map) ResolveAnimatedGID(gid GID, t time.Duration) (GID, error) {
+	tile, err := m.DecodeGID(gid)
+	if err != nil || tile.Nil {
+		return gid, err
+	}
+	ts := tile.Tileset
+	for i := 0; i < len(ts.Tiles); i++ {
+		if ts.Tiles[i].ID == tile.ID {
+			if f, ok := ts.Tiles[i].Animation.FrameAt(t); ok {
+				return ts.FirstGID + GID(f.TileID) | gid&GIDFlip, nil
+			}
+			break
+		}
+	}
+	return gid, nil
+}
@@ -0,0 +1,137 @@
+package tmx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnimationFrameAt(t *testing.T) {
+	a := Animation{Frames: []Frame{{TileID: 0, Duration: 100}, {TileID: 1, Duration: 0}, {TileID: 2, Duration: 200}}}
+	for _, tc := range []struct {
+		t    time.Duration
+		want ID
+	}{
+		{0, 0},
+		{99 * time.Millisecond, 0},
+		{100 * time.Millisecond, 2},
+		{299 * time.Millisecond, 2},
+		{300 * time.Millisecond, 0},
+		{-1 * time.Millisecond, 2},
+		{10*time.Second + 150*time.Millisecond, 2},
+	} {
+		if f, ok := a.FrameAt(tc.t); !ok || f.TileID != tc.want {
+			t.Errorf("FrameAt(%v) = %v, %v, want tile %d", tc.t, f, ok, tc.want)
+		}
+	}
+
+	if f, ok := (Animation{Frames: []Frame{{TileID: 3}, {TileID: 4}}}).FrameAt(time.Second); !ok || f.TileID != 3 {
+		t.Errorf("FrameAt() without durations = %v, %v, want tile 3", f, ok)
+	}
+	if _, ok := (Animation{}).FrameAt(0); ok {
+		t.Error("FrameAt() of an empty animation = true")
+	}
+}
+
+func TestAnimator(t *testing.T) {
+	a := NewAnimator(Tile{ID: 7, Animation: Animation{Frames: []Frame{{TileID: 1, Duration: 100}, {TileID: 2, Duration: 0}, {TileID: 3, Duration: 50}}}})
+	if id := a.TileID(); id != 1 {
+		t.Errorf("TileID() = %d, want 1", id)
+	}
+	for i, tc := range []struct {
+		dt   time.Duration
+		want ID
+	}{
+		{60 * time.Millisecond, 1},
+		{40 * time.Millisecond, 3},
+		{49 * time.Millisecond, 3},
+		{1 * time.Millisecond, 1},
+		{10*time.Hour + 120*time.Millisecond, 3},
+	} {
+		if id := a.Update(tc.dt); id != tc.want {
+			t.Errorf("step %d: Update(%v) = %d, want %d", i, tc.dt, id, tc.want)
+		}
+	}
+	if e := a.Elapsed(); e != 120*time.Millisecond {
+		t.Errorf("Elapsed() = %v, want 120ms", e)
+	}
+	if id := a.At(20 * time.Millisecond); id != 1 || a.Elapsed() != 120*time.Millisecond {
+		t.Errorf("At(20ms) = %d with Elapsed() %v, want 1 and the clock unchanged", id, a.Elapsed())
+	}
+	a.Reset()
+	if id := a.TileID(); id != 1 {
+		t.Errorf("TileID() after Reset() = %d, want 1", id)
+	}
+
+	still := NewAnimator(Tile{ID: 7})
+	if id := still.Update(time.Second); id != 7 {
+		t.Errorf("Update() without animation = %d, want the tile itself", id)
+	}
+}
+
+func TestAnimationTotalDuration(t *testing.T) {
+	a := Animation{Frames: []Frame{{Duration: 100}, {Duration: -5}, {Duration: 250}}}
+	if d := a.Frames[0].DurationD(); d != 100*time.Millisecond {
+		t.Errorf("DurationD() = %v, want 100ms", d)
+	}
+	if d := a.Frames[1].DurationD(); d != 0 {
+		t.Errorf("negative DurationD() = %v, want 0", d)
+	}
+	if d := a.TotalDuration(); d != 350*time.Millisecond {
+		t.Errorf("TotalDuration() = %v, want 350ms", d)
+	}
+}
+
+func TestAnimationState(t *testing.T) {
+	water := Animation{Frames: []Frame{{TileID: 0, Duration: 100}, {TileID: 1, Duration: 100}}}
+	m := &Map{Tilesets: []Tileset{
+		{FirstGID: 1, Tiles: []Tile{{ID: 0, Animation: water}}},
+		{FirstGID: 10, Tiles: []Tile{{ID: 2, Animation: Animation{Frames: []Frame{{TileID: 3, Duration: 50}, {TileID: 2, Duration: 50}}}}}},
+	}}
+	s := NewAnimationState(m)
+	s.Update(150 * time.Millisecond)
+	for _, tc := range []struct{ gid, want GID }{
+		{1, 2},
+		{1 | GIDHorizontalFlip, 2 | GIDHorizontalFlip},
+		{12, 12},
+		{2, 2},
+		{0, 0},
+	} {
+		if got := s.CurrentGID(tc.gid); got != tc.want {
+			t.Errorf("CurrentGID(%#x) = %#x, want %#x", tc.gid, got, tc.want)
+		}
+	}
+	s.Update(60 * time.Millisecond)
+	if got := s.CurrentGID(1); got != 1 {
+		t.Errorf("CurrentGID(1) at %v = %d, want 1", s.Time, got)
+	}
+	if got := s.CurrentGID(12); got != 13 {
+		t.Errorf("CurrentGID(12) at %v = %d, want 13", s.Time, got)
+	}
+}
+
+func TestResolveAnimatedGID(t *testing.T) {
+	m := &Map{Tilesets: []Tileset{
+		{FirstGID: 1},
+		{FirstGID: 5, Tiles: []Tile{{ID: 1}, {ID: 2, Animation: Animation{Frames: []Frame{{TileID: 2, Duration: 100}, {TileID: 0, Duration: 100}}}}}},
+	}}
+	for _, tc := range []struct {
+		gid  GID
+		t    time.Duration
+		want GID
+	}{
+		{7, 0, 7},
+		{7, 150 * time.Millisecond, 5},
+		{7 | GIDVerticalFlip | GIDDiagonalFlip, 150 * time.Millisecond, 5 | GIDVerticalFlip | GIDDiagonalFlip},
+		{6, 150 * time.Millisecond, 6},
+		{2, 150 * time.Millisecond, 2},
+		{0, 0, 0},
+	} {
+		got, err := m.ResolveAnimatedGID(tc.gid, tc.t)
+		if err != nil || got != tc.want {
+			t.Errorf("ResolveAnimatedGID(%#x, %v) = %#x, %v, want %#x", tc.gid, tc.t, got, err, tc.want)
+		}
+	}
+	if _, err := (&Map{Tilesets: []Tileset{{FirstGID: 5}}}).ResolveAnimatedGID(1, 0); err != ErrInvalidGID {
+		t.Errorf("ResolveAnimatedGID() of unknown GID returned %v, want ErrInvalidGID", err)
+	}
+}
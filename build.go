@@ -0,0 +1,146 @@
+package tmx
+
+// FormatVersion is the TMX format version of maps made by NewMap.
+const FormatVersion = "1.10"
+
+// NewMap returns an empty map of width by height tiles, each tileWidth by
+// tileHeight pixels, to add tilesets and layers to.
+func NewMap(width, height, tileWidth, tileHeight int, orientation MapOrientation) *Map {
+	return &Map{
+		Version:          FormatVersion,
+		MapOrientation:   orientation,
+		MapRenderOrder:   RenderRightDown,
+		Width:            width,
+		Height:           height,
+		TileWidth:        tileWidth,
+		TileHeight:       tileHeight,
+		NextLayerID:      1,
+		NextObjectID:     1,
+		CompressionLevel: DefaultCompressionLevel,
+	}
+}
+
+// AddTileset adds ts to the map with the first GID after those of the
+// other tilesets, and returns the added tileset, which is valid until
+// Tilesets is next changed. Its Columns and Tilecount are derived from its
+// image when absent.
+func (m *Map) AddTileset(ts Tileset) *Tileset {
+	ts.FirstGID = m.nextFirstGID()
+	ts.index = nil
+	ts.deriveCounts()
+	m.Tilesets = append(m.Tilesets, ts)
+	return &m.Tilesets[len(m.Tilesets)-1]
+}
+
+// nextFirstGID returns the GID after the tiles of the map's tilesets.
+func (m *Map) nextFirstGID() GID {
+	next := GID(1)
+	for i := 0; i < len(m.Tilesets); i++ {
+		next = max(next, m.Tilesets[i].FirstGID+m.span(i))
+	}
+	return next
+}
+
+// AddTileLayer adds an empty tile layer the size of the map above its other
+// layers, and returns the added layer, which is valid until Layers is next
+// changed. The layers of infinite maps hold no chunks.
+func (m *Map) AddTileLayer(name string) *Layer {
+	l := Layer{
+		ID:        m.newLayerID(),
+		Name:      name,
+		Width:     m.Width,
+		Height:    m.Height,
+		Opacity:   1,
+		Visible:   true,
+		ParallaxX: 1,
+		ParallaxY: 1,
+		Data:      Data{Encoding: CSV, Bytes: encodeCSV(make([]GID, m.Width*m.Height), m.Width)},
+	}
+	if m.Infinite {
+		l.Data = Data{Encoding: CSV, chunked: true}
+	}
+	m.appendOrder(LayerRef{TileLayerKind, len(m.Layers)})
+	m.Layers = append(m.Layers, l)
+	return &m.Layers[len(m.Layers)-1]
+}
+
+// AddObjectGroup adds an empty object group above the other layers of the
+// map, and returns the added group, which is valid until ObjectGroups is next
+// changed. Add objects to it with AddObject.
+func (m *Map) AddObjectGroup(name string) *ObjectGroup {
+	g := ObjectGroup{
+		ID:        m.newLayerID(),
+		Name:      name,
+		Opacity:   1,
+		Visible:   true,
+		ParallaxX: 1,
+		ParallaxY: 1,
+		DrawOrder: DrawTopDown,
+	}
+	m.appendOrder(LayerRef{ObjectGroupKind, len(m.ObjectGroups)})
+	m.ObjectGroups = append(m.ObjectGroups, g)
+	return &m.ObjectGroups[len(m.ObjectGroups)-1]
+}
+
+// AddImageLayer adds an image layer showing img above the other layers of
+// the map, and returns the added layer, which is valid until ImageLayers is
+// next changed.
+func (m *Map) AddImageLayer(name string, img Image) *ImageLayer {
+	l := ImageLayer{
+		ID:        m.newLayerID(),
+		Name:      name,
+		Opacity:   1,
+		Visible:   true,
+		ParallaxX: 1,
+		ParallaxY: 1,
+		Image:     img,
+	}
+	m.appendOrder(LayerRef{ImageLayerKind, len(m.ImageLayers)})
+	m.ImageLayers = append(m.ImageLayers, l)
+	return &m.ImageLayers[len(m.ImageLayers)-1]
+}
+
+// AddGroup adds an empty group layer above the other layers of the map, and
+// returns the added group, which is valid until Groups is next changed.
+// Move layers into it with MoveLayer.
+func (m *Map) AddGroup(name string) *Group {
+	g := Group{
+		ID:        m.newLayerID(),
+		Name:      name,
+		Opacity:   1,
+		Visible:   true,
+		ParallaxX: 1,
+		ParallaxY: 1,
+	}
+	m.appendOrder(LayerRef{GroupKind, len(m.Groups)})
+	m.Groups = append(m.Groups, g)
+	return &m.Groups[len(m.Groups)-1]
+}
+
+// AddObject adds o to the object group g of the map with the next object ID,
+// and returns the added object, which is valid until g.Objects is next
+// changed. Objects are hidden unless o.Visible is set.
+func (m *Map) AddObject(g *ObjectGroup, o Object) *Object {
+	if m.NextObjectID == 0 {
+		m.NextObjectID = 1
+	}
+	o.ID = m.NextObjectID
+	m.NextObjectID++
+	g.Objects = append(g.Objects, o)
+	return &g.Objects[len(g.Objects)-1]
+}
+
+// newLayerID returns the next layer ID of the map and advances NextLayerID,
+// skipping the IDs of its layers.
+func (m *Map) newLayerID() ID {
+	id := max(m.NextLayerID, m.maxLayerID()+1)
+	m.NextLayerID = id + 1
+	return id
+}
+
+// appendOrder adds r to the top of Map.Order, first filling in the order of
+// the other layers if it does not refer to each of them.
+func (m *Map) appendOrder(r LayerRef) {
+	m.ensureOrder()
+	m.Order = append(m.Order, r)
+}
@@ -0,0 +1,56 @@
+package tmx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	m := NewMap(3, 2, 16, 16, MapOrthogonal)
+	a := m.AddTileset(Tileset{Name: "a", TileWidth: 16, TileHeight: 16, Image: Image{Source: "a.png", Width: 64, Height: 32}})
+	if a.FirstGID != 1 || a.Tilecount != 8 || a.Columns != 4 {
+		t.Errorf("AddTileset() = firstgid %d, %d tiles in %d columns, want 1, 8 in 4", a.FirstGID, a.Tilecount, a.Columns)
+	}
+	b := m.AddTileset(Tileset{Name: "b", TileWidth: 16, TileHeight: 16, Tiles: []Tile{{ID: 2}}})
+	if b.FirstGID != 9 {
+		t.Errorf("AddTileset() firstgid = %d, want 9", b.FirstGID)
+	}
+	if c := m.AddTileset(Tileset{Name: "c", FirstGID: 100, TileWidth: 16, TileHeight: 16}); c.FirstGID != 12 {
+		t.Errorf("AddTileset() firstgid = %d, want 12", c.FirstGID)
+	}
+
+	ground := m.AddTileLayer("ground")
+	if err := ground.Encode([]GID{1, 2, 3, 4, 5, 9}, m.DataEncoder(Base64, Zlib)); err != nil {
+		t.Fatal(err)
+	}
+	g := m.AddObjectGroup("objects")
+	o := m.AddObject(g, Object{Name: "spawn", Visible: true, Point: &struct{}{}})
+	m.AddTileLayer("top")
+	if o.ID != 1 || m.NextObjectID != 2 {
+		t.Errorf("AddObject() id = %d, next %d, want 1, 2", o.ID, m.NextObjectID)
+	}
+	if m.NextLayerID != 4 || m.Layers[0].ID != 1 || m.ObjectGroups[0].ID != 2 || m.Layers[1].ID != 3 {
+		t.Errorf("layer ids = %d, %d, %d, next %d, want 1, 2, 3, next 4", m.Layers[0].ID, m.ObjectGroups[0].ID, m.Layers[1].ID, m.NextLayerID)
+	}
+	want := []LayerRef{{TileLayerKind, 0}, {ObjectGroupKind, 0}, {TileLayerKind, 1}}
+	if got := m.DrawOrder(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DrawOrder() = %v, want %v", got, want)
+	}
+	if issues := m.Validate(); issues != nil {
+		t.Errorf("Validate() = %v, want nil", issues)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.EvictDecoded()
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Read(Write()) = %+v, want %+v", got, m)
+	}
+}
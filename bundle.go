@@ -0,0 +1,82 @@
+package tmx
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ErrBundleMaps is returned when a bundle does not hold exactly one map.
+var ErrBundleMaps = errors.New("tmx: bundle does not hold exactly one map")
+
+// Bundle is a zip archive holding maps along with the tilesets, templates
+// and images they reference, such as a level pack. References are resolved
+// within the archive and may not leave it.
+type Bundle struct {
+	Loader          // Reads from the archive.
+	Maps   []string // Sorted names of the TMX and JSON maps in the archive.
+
+	closer io.Closer
+}
+
+// OpenBundle opens the named zip archive. The bundle must be closed after use.
+func OpenBundle(name string) (*Bundle, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	b := newBundle(&zr.Reader)
+	b.closer = zr
+	return b, nil
+}
+
+// NewBundle returns a bundle reading the zip archive of the given size from r.
+func NewBundle(r io.ReaderAt, size int64) (*Bundle, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return newBundle(zr), nil
+}
+
+func newBundle(zr *zip.Reader) *Bundle {
+	b := &Bundle{Loader: Loader{FS: zr, Cache: NewCache()}}
+	for _, f := range zr.File {
+		switch strings.ToLower(path.Ext(f.Name)) {
+		case ".tmx", ".tmj":
+			b.Maps = append(b.Maps, f.Name)
+		}
+	}
+	sort.Strings(b.Maps)
+	return b
+}
+
+// Close closes the archive if it was opened by OpenBundle.
+func (b *Bundle) Close() error {
+	if b.closer == nil {
+		return nil
+	}
+	return b.closer.Close()
+}
+
+// Map reads the only map of the bundle or returns an error.
+func (b *Bundle) Map() (*Map, error) {
+	if len(b.Maps) != 1 {
+		return nil, ErrBundleMaps
+	}
+	return b.ReadFile(b.Maps[0])
+}
+
+// LoadBundle reads the only map of the named zip archive along with its
+// external tilesets and templates.
+func LoadBundle(name string) (*Map, error) {
+	b, err := OpenBundle(name)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Close()
+	return b.Map()
+}
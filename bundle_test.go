@@ -0,0 +1,93 @@
+package tmx
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// zipFiles returns a zip archive holding the named testdata files under the given names.
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, src := range files {
+		data, err := os.ReadFile(filepath.Join("testdata", src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBundle(t *testing.T) {
+	data := zipFiles(t, map[string]string{
+		"levels/1.tmx":    "world/map_0_0.tmx",
+		"levels/2.tmx":    "world/map_1_0.tmx",
+		"tiles.tsx":       "tiles.tsx",
+		"tiles.png":       "tiles.png",
+		"levels/notes.md": "tiles.tsx",
+	})
+	b, err := NewBundle(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	if want := []string{"levels/1.tmx", "levels/2.tmx"}; !reflect.DeepEqual(b.Maps, want) {
+		t.Errorf("Maps = %q, want %q", b.Maps, want)
+	}
+	if _, err := b.Map(); err != ErrBundleMaps {
+		t.Errorf("Map() error = %v, want ErrBundleMaps", err)
+	}
+	m, err := b.ReadFile(b.Maps[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Tilesets[0].Name != "external" {
+		t.Errorf("tileset = %+v", m.Tilesets[0])
+	}
+	if _, err := b.Image(m.Tilesets[0].Image.ResolvedSource()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadBundle(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "pack.zip")
+	data := zipFiles(t, map[string]string{
+		"external.tmx": "external.tmx",
+		"tiles.tsx":    "tiles.tsx",
+		"crate.tx":     "crate.tx",
+	})
+	if err := os.WriteFile(name, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadBundle(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := m.ObjectGroups[0].Objects[0]; o.Name != "crate" || o.GID != 31 {
+		t.Errorf("templated object = %+v", o)
+	}
+
+	// References may not leave the archive.
+	data = zipFiles(t, map[string]string{"map.tmx": "world/map_0_0.tmx", "tiles.tsx": "tiles.tsx"})
+	if err := os.WriteFile(name, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBundle(name); err == nil {
+		t.Error("LoadBundle() resolved a tileset outside the archive")
+	}
+}
@@ -0,0 +1,144 @@
+package tmx
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Cache shares parsed external tilesets and object templates between map loads.
+// Each file is parsed at most once per Cache, keyed by its resolved path.
+//
+// A Cache is safe for concurrent use by multiple goroutines, so many maps may
+// be loaded in parallel with a single shared Cache. Maps loaded through a Cache
+// share the slices of their cached tilesets and templates; treat them as
+// read-only, or modify a copy made with Tileset.Clone.
+// The zero Cache is empty and ready for use.
+type Cache struct {
+	mu        sync.Mutex
+	tilesets  map[string]*tilesetEntry
+	templates map[string]*templateEntry
+}
+
+type tilesetEntry struct {
+	once    sync.Once
+	tileset *Tileset
+	err     error
+}
+
+type templateEntry struct {
+	once     sync.Once
+	template *Template
+	err      error
+}
+
+// NewCache returns a new, empty Cache.
+func NewCache() *Cache {
+	return new(Cache)
+}
+
+// ReadFile reads a map from a file path, loading its external tilesets and
+// object templates through the cache.
+func (c *Cache) ReadFile(path string) (*Map, error) {
+	return (&Loader{Cache: c}).ReadFile(path)
+}
+
+// Len returns the number of tilesets and templates held by the cache.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.tilesets) + len(c.templates)
+}
+
+// tileset returns the tileset stored under key, calling load on first use.
+// Callers waiting on a load stopped by the context of another caller retry
+// with their own while ctx is live. The nil Cache calls load on every call.
+func (c *Cache) tileset(ctx context.Context, key string, load func() (*Tileset, error)) (*Tileset, error) {
+	if c == nil {
+		return load()
+	}
+
+	c.mu.Lock()
+	if c.tilesets == nil {
+		c.tilesets = make(map[string]*tilesetEntry)
+	}
+	e, ok := c.tilesets[key]
+	if !ok {
+		e = new(tilesetEntry)
+		c.tilesets[key] = e
+	}
+	c.mu.Unlock()
+
+	loaded := false
+	e.once.Do(func() {
+		e.tileset, e.err = load()
+		loaded = true
+	})
+	if isContextErr(e.err) {
+		// Let later loads with a live context retry.
+		c.mu.Lock()
+		if c.tilesets[key] == e {
+			delete(c.tilesets, key)
+		}
+		c.mu.Unlock()
+		if !loaded && ctx.Err() == nil {
+			// The load canceled was that of another caller.
+			return c.tileset(ctx, key, load)
+		}
+	}
+	return e.tileset, e.err
+}
+
+// template returns the object template stored under key, calling load on
+// first use, and retries like tileset. The nil Cache calls load on every call.
+func (c *Cache) template(ctx context.Context, key string, load func() (*Template, error)) (*Template, error) {
+	if c == nil {
+		return load()
+	}
+
+	c.mu.Lock()
+	if c.templates == nil {
+		c.templates = make(map[string]*templateEntry)
+	}
+	e, ok := c.templates[key]
+	if !ok {
+		e = new(templateEntry)
+		c.templates[key] = e
+	}
+	c.mu.Unlock()
+
+	loaded := false
+	e.once.Do(func() {
+		e.template, e.err = load()
+		loaded = true
+	})
+	if isContextErr(e.err) {
+		// Let later loads with a live context retry.
+		c.mu.Lock()
+		if c.templates[key] == e {
+			delete(c.templates, key)
+		}
+		c.mu.Unlock()
+		if !loaded && ctx.Err() == nil {
+			// The load canceled was that of another caller.
+			return c.template(ctx, key, load)
+		}
+	}
+	return e.template, e.err
+}
+
+// forget removes the tileset and template stored under key, if any.
+func (c *Cache) forget(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tilesets, key)
+	delete(c.templates, key)
+}
+
+// isContextErr reports whether err is due to a canceled or expired context.
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
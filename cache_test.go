@@ -0,0 +1,143 @@
+package tmx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCacheConcurrentReadFile(t *testing.T) {
+	c := NewCache()
+
+	var wg sync.WaitGroup
+	maps := make([]*Map, 16)
+	errs := make([]error, len(maps))
+	for i := 0; i < len(maps); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			maps[i], errs[i] = c.ReadFile("testdata/external.tmx")
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := maps[i].Tilesets[1].Name; got != "external" {
+			t.Errorf("maps[%d] external tileset name = %q", i, got)
+		}
+		if got := maps[i].ObjectGroups[0].Objects[0].GID; got != 31 {
+			t.Errorf("maps[%d] templated object GID = %d", i, got)
+		}
+	}
+	if got := c.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+
+	// Cached tilesets share their tile slices between maps.
+	if &maps[0].Tilesets[1].Tiles[0] != &maps[1].Tilesets[1].Tiles[0] {
+		t.Error("tileset tiles not shared between maps")
+	}
+}
+
+func TestCacheSharedAcrossMaps(t *testing.T) {
+	c := NewCache()
+	a, err := c.ReadFile("testdata/external.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.ReadFile("testdata/world/map_0_0.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both maps reference testdata/tiles.tsx through different relative paths.
+	if &a.Tilesets[1].Tiles[0] != &b.Tilesets[0].Tiles[0] {
+		t.Error("tileset not shared between maps")
+	}
+
+	ts := b.Tilesets[0].Clone()
+	ts.Tiles[0].Type = "changed"
+	ts.Tiles[0].Properties = append(ts.Tiles[0].Properties, Property{Name: "new"})
+	if got := a.Tilesets[1].Tiles[0].Type; got == "changed" {
+		t.Error("Clone() shares tiles with the cached tileset")
+	}
+	if reflect.DeepEqual(a.Tilesets[1].Tiles[0], ts.Tiles[0]) {
+		t.Error("Clone() shares tile properties with the cached tileset")
+	}
+	if clone := b.Tilesets[0].Clone(); !reflect.DeepEqual(*clone, b.Tilesets[0]) {
+		t.Errorf("Clone() = %+v, want %+v", *clone, b.Tilesets[0])
+	}
+}
+
+func BenchmarkReadFile(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadFile("testdata/external.tmx"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCacheReadFileParallel(b *testing.B) {
+	c := NewCache()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := c.ReadFile("testdata/external.tmx"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestCacheCanceledLoad(t *testing.T) {
+	c := NewCache()
+	started, release := make(chan struct{}), make(chan struct{})
+	var errA error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The load of this caller is stopped by its canceled context.
+		_, errA = c.tileset(context.Background(), "a.tsx", func() (*Tileset, error) {
+			close(started)
+			<-release
+			return nil, context.Canceled
+		})
+	}()
+	<-started
+
+	var got *Tileset
+	var errB error
+	waiting := make(chan struct{})
+	go func() {
+		defer close(waiting)
+		got, errB = c.tileset(context.Background(), "a.tsx", func() (*Tileset, error) {
+			return &Tileset{Name: "a"}, nil
+		})
+	}()
+	time.Sleep(10 * time.Millisecond) // Let the second caller wait on the first load.
+	close(release)
+	<-done
+	<-waiting
+
+	if !errors.Is(errA, context.Canceled) {
+		t.Errorf("canceled caller: error = %v, want %v", errA, context.Canceled)
+	}
+	if errB != nil || got == nil || got.Name != "a" {
+		t.Errorf("waiting caller: tileset = %v, error %v, want a", got, errB)
+	}
+
+	// A context error of a caller's own load with a live context is returned.
+	loads := 0
+	_, err := c.template(context.Background(), "t.tx", func() (*Template, error) {
+		loads++
+		return nil, context.DeadlineExceeded
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || loads != 1 {
+		t.Errorf("template() = %v after %d loads, want %v after 1", err, loads, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,197 @@
+package tmx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Chunk models a chunk of the tile layer data of infinite maps.
+// See: https://doc.mapeditor.org/en/stable/reference/tmx-map-format/#chunk.
+type Chunk struct {
+	X      int  `xml:"x,attr"`
+	Y      int  `xml:"y,attr"`
+	Width  int  `xml:"width,attr"`
+	Height int  `xml:"height,attr"`
+	Data   Data `xml:"-"` // Encoded like the layer data.
+}
+
+// Bounds returns the cells covered by the chunk.
+func (c Chunk) Bounds() image.Rectangle {
+	return image.Rect(c.X, c.Y, c.X+c.Width, c.Y+c.Height)
+}
+
+// Chunked reports whether the data holds the chunks of an infinite map
+// rather than tiles. Empty data of infinite maps holds no chunks.
+func (d Data) Chunked() bool {
+	b := bytes.TrimSpace(d.Bytes)
+	return bytes.HasPrefix(b, []byte("<chunk")) || d.chunked && len(b) == 0
+}
+
+// Chunks returns the chunks of chunked data, or nil for other data.
+func (d Data) Chunks() ([]Chunk, error) {
+	if !d.Chunked() {
+		return nil, nil
+	}
+	var v struct {
+		Chunks []struct {
+			Chunk
+			Bytes []byte `xml:",innerxml"`
+		} `xml:"chunk"`
+	}
+	src := io.MultiReader(
+		strings.NewReader("<data>"),
+		bytes.NewReader(d.Bytes),
+		strings.NewReader("</data>"))
+	if err := xml.NewDecoder(src).Decode(&v); err != nil {
+		return nil, err
+	}
+	out := make([]Chunk, len(v.Chunks))
+	for i, c := range v.Chunks {
+		if c.Width <= 0 || c.Height <= 0 {
+			return nil, ErrInvalidSize
+		}
+		out[i] = c.Chunk
+		out[i].Data = Data{Encoding: d.Encoding, Compression: d.Compression, Bytes: c.Bytes, maxBytes: d.maxBytes}
+	}
+	return out, nil
+}
+
+// chunkBounds returns the cells covered by chunks.
+func chunkBounds(chunks []Chunk) image.Rectangle {
+	var out image.Rectangle
+	for _, c := range chunks {
+		out = out.Union(c.Bounds())
+	}
+	return out
+}
+
+// Origin returns the cell of the first tile of the layer: the top left cell
+// of the chunks of infinite maps, or (0, 0) for other layers.
+func (l Layer) Origin() (image.Point, error) {
+	chunks, err := l.Data.Chunks()
+	if err != nil {
+		return image.Point{}, l.errorAt(-1, err)
+	}
+	return chunkBounds(chunks).Min, nil
+}
+
+// decodeChunks decodes the chunks of the data into dst like DecodeInto, as
+// the width by height cells from the top left cell of the chunks.
+func (d Data) decodeChunks(dst []GID, width, height int) ([]GID, error) {
+	chunks, err := d.Chunks()
+	if err != nil {
+		return nil, err
+	}
+	if width < 0 || height < 0 {
+		return nil, ErrInvalidDecodedDataLen
+	}
+	n := width * height
+	if d.maxBytes > 0 && int64(n) > d.maxBytes/4 {
+		return nil, ErrResourceLimit
+	}
+	gids := dst[:0]
+	if cap(gids) < n {
+		gids = make([]GID, n)
+	}
+	gids = gids[:n]
+	clear(gids)
+
+	bounds := image.Rect(0, 0, width, height).Add(chunkBounds(chunks).Min)
+	var buf []GID
+	for _, c := range chunks {
+		if !c.Bounds().In(bounds) {
+			return nil, ErrInvalidDecodedDataLen
+		}
+		if buf, err = c.DecodeInto(buf); err != nil {
+			return nil, err
+		}
+		for y := 0; y < c.Height; y++ {
+			i := (c.Y+y-bounds.Min.Y)*width + c.X - bounds.Min.X
+			copy(gids[i:i+c.Width], buf[y*c.Width:])
+		}
+	}
+	return gids, nil
+}
+
+// DecodeInto decodes the GIDs of the chunk in row major order like
+// Data.DecodeInto.
+func (c Chunk) DecodeInto(dst []GID) ([]GID, error) {
+	return c.Data.DecodeInto(dst, c.Width*c.Height)
+}
+
+// encodeChunks returns gids for a layer width tiles wide with its first
+// tile at the cell origin encoded with e in chunks of size cells. Chunks
+// are aligned to multiples of their size as Tiled expects, and empty chunks
+// are left out. It also returns the size of the layer covering the chunks.
+func (e DataEncoder) encodeChunks(gids []GID, width int, origin, size image.Point) (Data, image.Point, error) {
+	if width <= 0 || size.X <= 0 || size.Y <= 0 {
+		return Data{}, image.Point{}, ErrInvalidSize
+	}
+	layer := image.Rect(0, 0, width, len(gids)/width).Add(origin)
+	grid := image.Rect(
+		floorDiv(layer.Min.X, size.X)*size.X,
+		floorDiv(layer.Min.Y, size.Y)*size.Y,
+		-floorDiv(-layer.Max.X, size.X)*size.X,
+		-floorDiv(-layer.Max.Y, size.Y)*size.Y)
+	var chunks []Chunk
+	sub := make([]GID, size.X*size.Y)
+	for cy := grid.Min.Y; cy < grid.Max.Y; cy += size.Y {
+		for cx := grid.Min.X; cx < grid.Max.X; cx += size.X {
+			clear(sub)
+			empty := true
+			r := image.Rect(cx, cy, cx+size.X, cy+size.Y).Intersect(layer)
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				for x := r.Min.X; x < r.Max.X; x++ {
+					if gid := gids[(y-layer.Min.Y)*width+x-layer.Min.X]; gid != 0 {
+						sub[(y-cy)*size.X+x-cx] = gid
+						empty = false
+					}
+				}
+			}
+			if empty {
+				continue
+			}
+			d, err := e.Encode(sub, size.X)
+			if err != nil {
+				return Data{}, image.Point{}, err
+			}
+			chunks = append(chunks, Chunk{cx, cy, size.X, size.Y, d})
+		}
+	}
+	d := Data{Encoding: e.Encoding, Bytes: appendChunks(nil, chunks), chunked: true}
+	if e.Encoding == Base64 {
+		d.Compression = e.Compression
+	}
+	return d, grid.Size(), nil
+}
+
+// appendChunks appends chunks to b as <chunk> elements.
+func appendChunks(b []byte, chunks []Chunk) []byte {
+	for _, c := range chunks {
+		b = append(b, "\n   <chunk x=\""...)
+		b = strconv.AppendInt(b, int64(c.X), 10)
+		b = append(b, "\" y=\""...)
+		b = strconv.AppendInt(b, int64(c.Y), 10)
+		b = append(b, "\" width=\""...)
+		b = strconv.AppendInt(b, int64(c.Width), 10)
+		b = append(b, "\" height=\""...)
+		b = strconv.AppendInt(b, int64(c.Height), 10)
+		b = append(b, "\">"...)
+		b = append(b, c.Data.Bytes...)
+		b = append(b, "</chunk>"...)
+	}
+	return append(b, "\n  "...)
+}
+
+// chunkSize returns the size of the chunks, or of DefaultChunkSize chunks
+// if there are none.
+func chunkSize(chunks []Chunk) image.Point {
+	if len(chunks) == 0 {
+		return image.Pt(DefaultChunkSize, DefaultChunkSize)
+	}
+	return image.Pt(chunks[0].Width, chunks[0].Height)
+}
@@ -0,0 +1,235 @@
+package tmx
+
+import (
+	"bytes"
+	"image"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// infiniteTiles lists the tiles of the layers of testdata/infinite.tmx.
+var infiniteTiles = []map[image.Point]GID{
+	{{-16, 0}: 1, {-1, 15}: 2, {0, 0}: 3, {15, 15}: 4 | GIDHorizontalFlip},
+	{{16, -16}: 5, {20, -10}: 6},
+}
+
+// checkInfinite checks that the layers of m hold infiniteTiles.
+func checkInfinite(t *testing.T, m *Map) {
+	t.Helper()
+	if !m.Infinite {
+		t.Errorf("Infinite = false, want true")
+	}
+	for i, want := range infiniteTiles {
+		s, err := m.Layers[i].Sparse(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[image.Point]GID)
+		for r, gids := range s.Chunks() {
+			for j, gid := range gids {
+				if gid != 0 {
+					got[r.Min.Add(image.Pt(j%r.Dx(), j/r.Dx()))] = gid
+				}
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("layer %d: tiles = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestReadInfinite(t *testing.T) {
+	m, err := ReadFile("testdata/infinite.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInfinite(t, m)
+	if got := m.CompatibilityReport(); got != nil {
+		t.Errorf("CompatibilityReport() = %v, want none", got)
+	}
+
+	l := &m.Layers[0]
+	chunks, err := l.Data.Chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].Bounds() != image.Rect(-16, 0, 0, 16) || chunks[1].Bounds() != image.Rect(0, 0, 16, 16) {
+		t.Errorf("chunks = %v, want 16x16 at (-16, 0) and (0, 0)", chunks)
+	}
+	origin, err := l.Origin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if origin != image.Pt(-16, 0) {
+		t.Errorf("Origin() = %v, want (-16, 0)", origin)
+	}
+	gids, err := l.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gids) != 32*16 {
+		t.Fatalf("Decode() = %d GIDs, want %d", len(gids), 32*16)
+	}
+	for p, want := range infiniteTiles[0] {
+		p = p.Sub(origin)
+		if got := gids[p.Y*l.Width+p.X]; got != want {
+			t.Errorf("Decode() cell %v = %d, want %d", p, got, want)
+		}
+	}
+
+	l.Width = 16
+	if _, err := l.Decode(); err == nil {
+		t.Errorf("Decode() of chunks past the layer succeeded")
+	}
+}
+
+func TestWriteInfinite(t *testing.T) {
+	m, err := ReadFile("testdata/infinite.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Edits are encoded in chunks from the same cell.
+	if err := m.Layers[0].SetTile(0, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Layers[0].SetTile(31, 0, 7); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := got.Layers[0].Data.Chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].Bounds() != image.Rect(-16, 0, 0, 16) {
+		t.Errorf("chunks = %v, want 2 from (-16, 0)", chunks)
+	}
+	s, err := got.Layers[0].Sparse(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.TileAt(-16, 0) != 0 || s.TileAt(15, 0) != 7 || s.TileAt(-1, 15) != 2 {
+		t.Errorf("edited tiles = %d, %d, %d, want 0, 7, 2", s.TileAt(-16, 0), s.TileAt(15, 0), s.TileAt(-1, 15))
+	}
+}
+
+func TestInfiniteJSON(t *testing.T) {
+	m, err := ReadFile("testdata/infinite.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"chunks"`)) || !bytes.Contains(buf.Bytes(), []byte(`"startx": -16`)) {
+		t.Errorf("JSON map has no chunks from x -16")
+	}
+	got, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInfinite(t, got)
+	if d := got.Layers[1].Data; d.Encoding != Base64 || d.Compression != Zlib {
+		t.Errorf("layer 1 data = %s, %s, want base64, zlib", d.Encoding, d.Compression)
+	}
+}
+
+func TestWriteInfiniteEmpty(t *testing.T) {
+	m, err := ReadFile("testdata/infinite.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &m.Layers[1]
+	if err := l.Encode(make([]GID, l.Width*l.Height), m.DataEncoder(CSV, "")); err != nil {
+		t.Fatal(err)
+	}
+	added := m.AddTileLayer("empty")
+	if !l.Data.Chunked() || !added.Data.Chunked() {
+		t.Errorf("empty layers of an infinite map are not chunked")
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jbuf := new(bytes.Buffer)
+	if err := WriteJSON(jbuf, got); err != nil {
+		t.Fatal(err)
+	}
+	jgot, err := ReadJSON(jbuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []*Map{got, jgot} {
+		for _, i := range []int{1, 2} {
+			l := &m.Layers[i]
+			if chunks, err := l.Data.Chunks(); err != nil || !l.Data.Chunked() || len(chunks) != 0 {
+				t.Errorf("layer %d chunks = %v, %v, want none", i, chunks, err)
+			}
+			gids, err := l.Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(gids) != l.Width*l.Height || slices.ContainsFunc(gids, func(gid GID) bool { return gid != 0 }) {
+				t.Errorf("layer %d Decode() = %v, want %dx%d empty cells", i, gids, l.Width, l.Height)
+			}
+		}
+	}
+}
+
+func TestWriteInfiniteAligned(t *testing.T) {
+	// Chunks of 4x4 cells from (-3, 1) are not aligned to their size.
+	m, err := Read(strings.NewReader(`<map width="4" height="4" tilewidth="8" tileheight="8" infinite="1">
+ <layer id="1" width="4" height="4">
+  <data encoding="csv"><chunk x="-3" y="1" width="4" height="4">1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,2</chunk></data>
+ </layer>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Layers[0].SetTile(1, 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &got.Layers[0]
+	chunks, err := l.Data.Chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].Bounds() != image.Rect(-4, 0, 0, 4) || chunks[1].Bounds() != image.Rect(0, 4, 4, 8) {
+		t.Errorf("chunks = %v, want 4x4 at (-4, 0) and (0, 4)", chunks)
+	}
+	if l.Width != 8 || l.Height != 8 {
+		t.Errorf("layer size = %dx%d, want 8x8", l.Width, l.Height)
+	}
+	s, err := l.Sparse(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.TileAt(-3, 1) != 1 || s.TileAt(-2, 1) != 3 || s.TileAt(0, 4) != 2 {
+		t.Errorf("tiles = %d, %d, %d, want 1, 3, 2", s.TileAt(-3, 1), s.TileAt(-2, 1), s.TileAt(0, 4))
+	}
+	if _, err := l.Decode(); err != nil {
+		t.Errorf("Decode() = %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package tmx
+
+// Clone returns a deep copy of the tileset sharing no slices with ts.
+// Tilesets loaded through a Cache share their slices between maps; clone
+// them before making changes.
+func (ts *Tileset) Clone() *Tileset {
+	out := *ts
+	out.index = nil
+	out.Properties = cloneProperties(ts.Properties)
+	out.Terrains = append([]Terrain(nil), ts.Terrains...)
+	for i := 0; i < len(out.Terrains); i++ {
+		out.Terrains[i].Properties = cloneProperties(out.Terrains[i].Properties)
+	}
+	out.Tiles = append([]Tile(nil), ts.Tiles...)
+	for i := 0; i < len(out.Tiles); i++ {
+		out.Tiles[i] = out.Tiles[i].Clone()
+	}
+	out.WangSets = append([]WangSet(nil), ts.WangSets...)
+	for i := 0; i < len(out.WangSets); i++ {
+		w := &out.WangSets[i]
+		w.Corners = append([]WangColor(nil), w.Corners...)
+		w.Edges = append([]WangColor(nil), w.Edges...)
+		w.Colors = append([]WangColor(nil), w.Colors...)
+		w.Tiles = append([]WangTile(nil), w.Tiles...)
+	}
+	return &out
+}
+
+// Clone returns a deep copy of the tile.
+func (t Tile) Clone() Tile {
+	t.Properties = cloneProperties(t.Properties)
+	t.ObjectGroups = append([]ObjectGroup(nil), t.ObjectGroups...)
+	for i := 0; i < len(t.ObjectGroups); i++ {
+		t.ObjectGroups[i] = t.ObjectGroups[i].Clone()
+	}
+	t.Animation.Frames = append([]Frame(nil), t.Animation.Frames...)
+	return t
+}
+
+// Clone returns a deep copy of the object group.
+func (g ObjectGroup) Clone() ObjectGroup {
+	g.Properties = cloneProperties(g.Properties)
+	g.Objects = append([]Object(nil), g.Objects...)
+	for i := 0; i < len(g.Objects); i++ {
+		g.Objects[i] = g.Objects[i].Clone()
+	}
+	return g
+}
+
+// Clone returns a deep copy of the object.
+func (o Object) Clone() Object {
+	o.Polygons = append([]Polygon(nil), o.Polygons...)
+	o.PolyLines = append([]Polygon(nil), o.PolyLines...)
+	if o.Text != nil {
+		text := *o.Text
+		o.Text = &text
+	}
+	o.Properties = cloneProperties(o.Properties)
+	return o
+}
+
+func cloneProperties(props Properties) Properties {
+	out := append(Properties(nil), props...)
+	for i := 0; i < len(out); i++ {
+		out[i].Properties = cloneProperties(out[i].Properties)
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package tmx
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Color is a color attribute as Tiled writes it, "#AARRGGBB" or "#RRGGBB",
+// kept as written so that maps are written back unchanged. The '#' is
+// optional, and omitted by the trans attribute of TMX images.
+// The empty Color is unset.
+type Color string
+
+// ParseColor parses a "#AARRGGBB" or "#RRGGBB" color as used by Tiled, with
+// optional '#'. It returns ErrInvalidColor for malformed colors.
+func ParseColor(s string) (color.NRGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || (len(s) != 6 && len(s) != 8) {
+		return color.NRGBA{}, ErrInvalidColor
+	}
+	c := color.NRGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}
+	if len(s) == 8 {
+		c.A = uint8(v >> 24)
+	}
+	return c, nil
+}
+
+// FormatColor returns c as a Color: "#RRGGBB" if it is opaque, and
+// "#AARRGGBB" otherwise.
+func FormatColor(c color.Color) Color {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 0xff {
+		return Color(fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B))
+	}
+	return Color(fmt.Sprintf("#%02x%02x%02x%02x", n.A, n.R, n.G, n.B))
+}
+
+// NRGBA parses the color like ParseColor.
+func (c Color) NRGBA() (color.NRGBA, error) {
+	return ParseColor(string(c))
+}
+
+// RGBA implements color.Color. Unset and malformed colors are transparent.
+func (c Color) RGBA() (r, g, b, a uint32) {
+	n, _ := c.NRGBA()
+	return n.RGBA()
+}
+
+// String returns the color formatted like FormatColor, or as written if it
+// is unset or malformed.
+func (c Color) String() string {
+	n, err := c.NRGBA()
+	if err != nil {
+		return string(c)
+	}
+	return string(FormatColor(n))
+}
@@ -0,0 +1,78 @@
+package tmx
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want color.NRGBA
+		err  error
+	}{
+		{"#ff8000", color.NRGBA{0xff, 0x80, 0x00, 0xff}, nil},
+		{"80ff8000", color.NRGBA{0xff, 0x80, 0x00, 0x80}, nil},
+		{"#fff", color.NRGBA{}, ErrInvalidColor},
+		{"#gg8000", color.NRGBA{}, ErrInvalidColor},
+		{"", color.NRGBA{}, ErrInvalidColor},
+	} {
+		got, err := ParseColor(tc.s)
+		if got != tc.want || !errors.Is(err, tc.err) {
+			t.Errorf("ParseColor(%q) = %v, %v, want %v, %v", tc.s, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestColor(t *testing.T) {
+	for _, tc := range []struct {
+		c    Color
+		str  string
+		rgba color.NRGBA
+	}{
+		{"#FF8000", "#ff8000", color.NRGBA{0xff, 0x80, 0x00, 0xff}},
+		{"ff8000", "#ff8000", color.NRGBA{0xff, 0x80, 0x00, 0xff}},
+		{"#80ff8000", "#80ff8000", color.NRGBA{0xff, 0x80, 0x00, 0x80}},
+		{"#ffff8000", "#ff8000", color.NRGBA{0xff, 0x80, 0x00, 0xff}},
+		{"", "", color.NRGBA{}},
+		{"pink", "pink", color.NRGBA{}},
+	} {
+		if got := tc.c.String(); got != tc.str {
+			t.Errorf("Color(%q).String() = %q, want %q", string(tc.c), got, tc.str)
+		}
+		if got := color.NRGBAModel.Convert(tc.c); got != tc.rgba {
+			t.Errorf("Color(%q) as color.Color = %v, want %v", string(tc.c), got, tc.rgba)
+		}
+	}
+	if got, want := FormatColor(color.NRGBA{1, 2, 3, 4}), Color("#04010203"); got != want {
+		t.Errorf("FormatColor() = %q, want %q", got, want)
+	}
+}
+
+func TestBackgroundColor(t *testing.T) {
+	m, err := Read(strings.NewReader(`<map backgroundcolor="#20304050"/>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.BackgroundColor, Color("#20304050"); got != want {
+		t.Fatalf("Read() background color = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = ReadJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := Write(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `backgroundcolor="#20304050"`) {
+		t.Errorf("Write() after JSON round trip lost the background color:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,126 @@
+package tmx
+
+import (
+	"image"
+	"iter"
+)
+
+// CompactLayer holds the decoded tiles of a tile layer in a packed form:
+// one GID per cell and a table of the tilesets the layer uses. It takes a
+// sixth of the memory of a DecodedLayer while providing the same tiles.
+type CompactLayer struct {
+	Width, Height int
+	Origin        image.Point // Cell of the first tile. See Layer.Origin.
+	Tileset       *Tileset    // Only set when the layer uses a single tileset and Empty is false.
+	Empty         bool        // Set when all cells of the layer are empty.
+
+	cells    []GID
+	tilesets []*Tileset // Tilesets used by the layer in increasing FirstGID order.
+}
+
+// CompactLayer decodes the tiles of l into a compact layer.
+// The error will be a *LayerError wrapping ErrInvalidGID if a GID of l is
+// not found in m.
+func (m *Map) CompactLayer(l *Layer) (*CompactLayer, error) {
+	gids, err := l.Decoded()
+	if err != nil {
+		return nil, err
+	}
+	origin, err := l.Origin()
+	if err != nil {
+		return nil, err
+	}
+	c := &CompactLayer{Width: l.Width, Height: l.Height, Origin: origin, cells: gids}
+	var prev *Tileset
+	for i, gid := range gids {
+		t, err := m.DecodeGID(gid)
+		if err != nil {
+			return nil, l.errorAt(i, err)
+		}
+		if t.Nil || t.Tileset == prev {
+			continue
+		}
+		prev = t.Tileset
+		c.addTileset(t.Tileset)
+	}
+	c.Empty = len(c.tilesets) == 0
+	if len(c.tilesets) == 1 {
+		c.Tileset = c.tilesets[0]
+	}
+	return c, nil
+}
+
+// addTileset adds ts to the table of tilesets unless it is present.
+func (c *CompactLayer) addTileset(ts *Tileset) {
+	i := 0
+	for ; i < len(c.tilesets); i++ {
+		if c.tilesets[i] == ts {
+			return
+		}
+		if c.tilesets[i].FirstGID > ts.FirstGID {
+			break
+		}
+	}
+	c.tilesets = append(c.tilesets, nil)
+	copy(c.tilesets[i+1:], c.tilesets[i:])
+	c.tilesets[i] = ts
+}
+
+// GID returns the GID of cell (x, y), or 0 if it is out of bounds.
+func (c *CompactLayer) GID(x, y int) GID {
+	x, y = x-c.Origin.X, y-c.Origin.Y
+	if x < 0 || y < 0 || x >= c.Width || y >= c.Height {
+		return 0
+	}
+	return c.cells[y*c.Width+x]
+}
+
+// At returns the tile of cell (x, y), or NilTile if it is out of bounds.
+func (c *CompactLayer) At(x, y int) DecodedTile {
+	return c.tile(c.GID(x, y))
+}
+
+// tile decodes gid with the tileset table.
+func (c *CompactLayer) tile(gid GID) DecodedTile {
+	id := gid &^ GIDFlip
+	for i := len(c.tilesets) - 1; i >= 0 && gid != 0; i-- {
+		if ts := c.tilesets[i]; ts.FirstGID <= id {
+			return DecodedTile{
+				ID:             ID(id - ts.FirstGID),
+				Tileset:        ts,
+				HorizontalFlip: gid&GIDHorizontalFlip != 0,
+				VerticalFlip:   gid&GIDVerticalFlip != 0,
+				DiagonalFlip:   gid&GIDDiagonalFlip != 0,
+			}
+		}
+	}
+	return NilTile
+}
+
+// Decoded returns the tiles of the layer as a DecodedLayer.
+func (c *CompactLayer) Decoded() DecodedLayer {
+	d := DecodedLayer{
+		Width:        c.Width,
+		Height:       c.Height,
+		Origin:       c.Origin,
+		DecodedTiles: make([]DecodedTile, len(c.cells)),
+		Tileset:      c.Tileset,
+		Empty:        c.Empty,
+	}
+	for i, gid := range c.cells {
+		d.DecodedTiles[i] = c.tile(gid)
+	}
+	return d
+}
+
+// All returns an iterator over the cells of the layer and their tiles in
+// row major order.
+func (c *CompactLayer) All() iter.Seq2[image.Point, DecodedTile] {
+	return func(yield func(image.Point, DecodedTile) bool) {
+		for i, gid := range c.cells {
+			if !yield(c.Origin.Add(image.Pt(i%c.Width, i/c.Width)), c.tile(gid)) {
+				return
+			}
+		}
+	}
+}
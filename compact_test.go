@@ -0,0 +1,70 @@
+package tmx
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompactLayer(t *testing.T) {
+	m, err := Read(strings.NewReader(`<map width="3" height="2">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8" tilecount="4"/>
+ <tileset firstgid="5" name="b" tilewidth="8" tileheight="8" tilecount="4"/>
+ <layer width="3" height="2"><data encoding="csv">6,0,1,2147483650,0,8</data></layer>
+ <layer width="3" height="2"><data encoding="csv">0,0,0,0,0,0</data></layer>
+ <layer width="3" height="2"><data encoding="csv">5,5,0,0,7,0</data></layer>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := &m.Tilesets[0], &m.Tilesets[1]
+
+	c, err := m.CompactLayer(&m.Layers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Width != 3 || c.Height != 2 || c.Empty || c.Tileset != nil || !reflect.DeepEqual(c.tilesets, []*Tileset{a, b}) {
+		t.Errorf("CompactLayer() = %+v", c)
+	}
+	if got, want := c.At(0, 0), (DecodedTile{ID: 1, Tileset: b}); got != want {
+		t.Errorf("At(0, 0) = %+v, want %+v", got, want)
+	}
+	if got, want := c.At(0, 1), (DecodedTile{ID: 1, Tileset: a, HorizontalFlip: true}); got != want {
+		t.Errorf("At(0, 1) = %+v, want %+v", got, want)
+	}
+	if got := c.At(1, 0); !got.Nil {
+		t.Errorf("At(1, 0) = %+v, want NilTile", got)
+	}
+	if got := c.At(3, 0); !got.Nil {
+		t.Errorf("At(3, 0) out of bounds = %+v, want NilTile", got)
+	}
+	if got := c.GID(2, 1); got != 8 {
+		t.Errorf("GID(2, 1) = %d, want 8", got)
+	}
+
+	want, err := m.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range m.Layers {
+		c, err := m.CompactLayer(&m.Layers[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Decoded(); !reflect.DeepEqual(got, want.Decoded[i]) {
+			t.Errorf("layer %d Decoded() = %+v, want %+v", i, got, want.Decoded[i])
+		}
+	}
+	if c, _ := m.CompactLayer(&m.Layers[1]); !c.Empty || c.Tileset != nil {
+		t.Errorf("empty layer = {Tileset: %p, Empty: %v}", c.Tileset, c.Empty)
+	}
+	if c, _ := m.CompactLayer(&m.Layers[2]); c.Empty || c.Tileset != b {
+		t.Errorf("layer of one tileset = {Tileset: %p, Empty: %v}, want {%p, false}", c.Tileset, c.Empty, b)
+	}
+
+	m.Tilesets = m.Tilesets[1:]
+	if _, err := m.CompactLayer(&m.Layers[0]); !errors.Is(err, ErrInvalidGID) {
+		t.Errorf("CompactLayer() with a missing tileset = %v, want %v", err, ErrInvalidGID)
+	}
+}
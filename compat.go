@@ -0,0 +1,64 @@
+package tmx
+
+import "fmt"
+
+// Feature identifies a TMX feature that the package does not fully support.
+type Feature string
+
+// Features reported by CompatibilityReport.
+const (
+	FeatureCompression      Feature = "compression"       // Layer data compressed with a zstd dictionary, which does not decode.
+	FeatureExternalTilesets Feature = "external-tilesets" // External tilesets that were not loaded.
+	FeatureTemplates        Feature = "templates"         // Object templates that were not applied.
+	FeatureUnknownValue     Feature = "unknown-value"     // Attributes holding values the package does not know.
+)
+
+// Incompatibility is a feature of a map that the package does not fully support.
+type Incompatibility struct {
+	Feature Feature
+	Message string
+}
+
+func (i Incompatibility) String() string {
+	return string(i.Feature) + ": " + i.Message
+}
+
+// CompatibilityReport lists the features of the map that the package does
+// not fully support, so that applications can warn about them rather than
+// render the map incorrectly. It returns nil if there are none.
+func (m *Map) CompatibilityReport() []Incompatibility {
+	var out []Incompatibility
+	add := func(f Feature, format string, args ...any) {
+		out = append(out, Incompatibility{f, fmt.Sprintf(format, args...)})
+	}
+
+	if err := m.checkEnums(); err != nil {
+		add(FeatureUnknownValue, "%v", err)
+	}
+	for i := 0; i < len(m.Tilesets); i++ {
+		ts := &m.Tilesets[i]
+		if ts.Source != "" && ts.TileWidth == 0 && ts.TileHeight == 0 && ts.Name == "" {
+			add(FeatureExternalTilesets, "tileset %q (firstgid %d) is not loaded", ts.Source, ts.FirstGID)
+		}
+	}
+	for i := 0; i < len(m.Layers); i++ {
+		l := &m.Layers[i]
+		if l.Data.Compression == Zstd && zstdUsesDictionary(l.Data.Bytes) {
+			add(FeatureCompression, "layer %q (id %d) data is compressed with a zstd dictionary", l.Name, l.ID)
+		}
+	}
+	if !m.templates {
+		n := 0
+		for i := 0; i < len(m.ObjectGroups); i++ {
+			for _, o := range m.ObjectGroups[i].Objects {
+				if o.Template != "" {
+					n++
+				}
+			}
+		}
+		if n > 0 {
+			add(FeatureTemplates, "%d objects use templates that were not loaded", n)
+		}
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package tmx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompatibilityReport(t *testing.T) {
+	m, err := ReadFile("testdata/external.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := m.CompatibilityReport(); report != nil {
+		t.Errorf("CompatibilityReport() = %v, want nil", report)
+	}
+
+	m, err = ReadFile("testdata/external.tmx", WithExternalTilesets(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Feature
+	for _, i := range m.CompatibilityReport() {
+		got = append(got, i.Feature)
+	}
+	if want := []Feature{FeatureExternalTilesets, FeatureTemplates}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CompatibilityReport() without external resources = %v, want %v", got, want)
+	}
+
+	m, err = Read(strings.NewReader(`<map orientation="spherical" infinite="1">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8">
+  <wangsets>
+   <wangset name="w" type="corner" tile="0">
+    <wangcolor name="grass" color="#00ff00" tile="0" probability="1"/>
+    <wangtile tileid="0" wangid="0,1,0,1,0,1,0,1"/>
+   </wangset>
+  </wangsets>
+ </tileset>
+ <layer id="1" width="2" height="1"><data encoding="base64" compression="zstd">KLUv/SEHCEEAAAEAAAACAAAA</data></layer>
+ <layer id="2" width="2" height="1"><data encoding="csv"><chunk x="0" y="0" width="2" height="1">1,2</chunk></data></layer>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, i := range m.CompatibilityReport() {
+		got = append(got, i.Feature)
+		if i.Message == "" {
+			t.Errorf("CompatibilityReport() %s without message", i.Feature)
+		}
+	}
+	want := []Feature{FeatureUnknownValue, FeatureCompression}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CompatibilityReport() = %v, want %v", got, want)
+	}
+	for _, issue := range m.Validate() {
+		if issue.Code == IssueWangColor {
+			t.Errorf("Validate() reported %v for a wang set using its colors", issue)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package tmx
+
+import (
+	"image"
+	"math"
+)
+
+// TileToPixel returns the map pixel position of the cell (x, y), as Tiled
+// places them: the top corner of isometric diamonds and the top-left corner
+// of the bounding box of other cells.
+func (m *Map) TileToPixel(x, y int) (px, py float64) {
+	switch m.MapOrientation {
+	case MapIsometric:
+		return m.isoToPixel(float64(x), float64(y))
+	case MapStaggered, MapHexagonal:
+		px, py := m.grid().origin(x, y)
+		return float64(px), float64(py)
+	}
+	return float64(x * m.TileWidth), float64(y * m.TileHeight)
+}
+
+// PixelToTile returns the cell containing the map pixel position (px, py).
+// Positions outside of the map give cells outside of it.
+func (m *Map) PixelToTile(px, py float64) (x, y int) {
+	if m.TileWidth <= 0 || m.TileHeight <= 0 {
+		return 0, 0
+	}
+	tx, ty := px/float64(m.TileWidth), py/float64(m.TileHeight)
+	switch m.MapOrientation {
+	case MapIsometric:
+		tx, ty = m.pixelToIso(px, py)
+	case MapStaggered, MapHexagonal:
+		return m.grid().cell(px, py)
+	}
+	return int(math.Floor(tx)), int(math.Floor(ty))
+}
+
+// LayerTileToPixel is like TileToPixel for the cells of l, which are moved by
+// the effective offset of the layer.
+func (m *Map) LayerTileToPixel(l AnyLayer, x, y int) (px, py float64) {
+	ox, oy := m.EffectiveOffset(l)
+	px, py = m.TileToPixel(x, y)
+	return px + float64(ox), py + float64(oy)
+}
+
+// LayerPixelToTile is like PixelToTile for the cells of l, which are moved by
+// the effective offset of the layer.
+func (m *Map) LayerPixelToTile(l AnyLayer, px, py float64) (x, y int) {
+	ox, oy := m.EffectiveOffset(l)
+	return m.PixelToTile(px-float64(ox), py-float64(oy))
+}
+
+// ObjectToPixel returns the map pixel position of the object position (x, y).
+// Tiled stores the positions of objects on isometric maps along the tile axes,
+// in units of the tile height; other maps store map pixel positions.
+func (m *Map) ObjectToPixel(x, y float64) (px, py float64) {
+	if m.MapOrientation != MapIsometric || m.TileHeight <= 0 {
+		return x, y
+	}
+	th := float64(m.TileHeight)
+	return m.isoToPixel(x/th, y/th)
+}
+
+// PixelToObject returns the object position of the map pixel position (px, py).
+// It is the inverse of ObjectToPixel.
+func (m *Map) PixelToObject(px, py float64) (x, y float64) {
+	if m.MapOrientation != MapIsometric || m.TileWidth <= 0 || m.TileHeight <= 0 {
+		return px, py
+	}
+	tx, ty := m.pixelToIso(px, py)
+	th := float64(m.TileHeight)
+	return tx * th, ty * th
+}
+
+// isoToPixel returns the map pixel position of the fractional cell position
+// (tx, ty) of an isometric map, whose cell (0, 0) has its top corner at the
+// top of the map.
+func (m *Map) isoToPixel(tx, ty float64) (px, py float64) {
+	tw, th := float64(m.TileWidth), float64(m.TileHeight)
+	return (tx - ty + float64(m.Height)) * tw / 2, (tx + ty) * th / 2
+}
+
+// pixelToIso is the inverse of isoToPixel.
+func (m *Map) pixelToIso(px, py float64) (tx, ty float64) {
+	x := px/float64(m.TileWidth) - float64(m.Height)/2
+	y := py / float64(m.TileHeight)
+	return y + x, y - x
+}
+
+// Bounds returns the pixel bounds of the map: the bounds of its grid grown to
+// hold tiles larger than the map cells or moved by tileset tile offsets,
+// moved by the offsets of tile layers, and the images of image layers.
+// Repeating image layers count once.
+func (m *Map) Bounds() image.Rectangle {
+	// Tile images stand on the bottom-left corner of their cell.
+	var margin image.Rectangle
+	for i := 0; i < len(m.Tilesets); i++ {
+		ts := &m.Tilesets[i]
+		w, h := ts.TileWidth, ts.TileHeight
+		for j := 0; j < len(ts.Tiles); j++ {
+			w, h = max(w, ts.Tiles[j].Image.Width), max(h, ts.Tiles[j].Image.Height)
+		}
+		off := ts.TileOffset
+		margin.Min.X = min(margin.Min.X, off.X)
+		margin.Min.Y = min(margin.Min.Y, off.Y+m.TileHeight-h)
+		margin.Max.X = max(margin.Max.X, off.X+w-m.TileWidth)
+		margin.Max.Y = max(margin.Max.Y, off.Y)
+	}
+	grid := m.gridBounds()
+	tiles := image.Rectangle{grid.Min.Add(margin.Min), grid.Max.Add(margin.Max)}
+
+	out := tiles
+	for i := 0; i < len(m.Layers); i++ {
+		out = out.Union(tiles.Add(image.Pt(m.EffectiveOffset(&m.Layers[i]))))
+	}
+	for i := 0; i < len(m.ImageLayers); i++ {
+		l := &m.ImageLayers[i]
+		r := image.Rect(0, 0, l.Image.Width, l.Image.Height)
+		out = out.Union(r.Add(image.Pt(m.EffectiveOffset(l))))
+	}
+	return out
+}
+
+// TileDrawRect returns the map pixel rectangle the image of the decoded tile t
+// is drawn at in the cell (x, y). As in Tiled, tile images stand on the
+// bottom-left corner of the cell's bounding box, so tiles taller than the map
+// cells extend upwards and wider ones to the right, and they are moved by the
+// tile offset of their tileset. Diagonally flipped tiles swap their width and
+// height. TileDrawRect returns the empty rectangle for nil tiles.
+func (m *Map) TileDrawRect(x, y int, t DecodedTile) image.Rectangle {
+	if t.Nil || t.Tileset == nil {
+		return image.Rectangle{}
+	}
+	ts := t.Tileset
+	size := image.Pt(ts.TileWidth, ts.TileHeight)
+	for i := 0; i < len(ts.Tiles); i++ {
+		if img := ts.Tiles[i].Image; ts.Tiles[i].ID == t.ID && img.Width > 0 && img.Height > 0 {
+			size = image.Pt(img.Width, img.Height)
+			break
+		}
+	}
+	if t.DiagonalFlip {
+		size.X, size.Y = size.Y, size.X
+	}
+	px, py := m.TileToPixel(x, y)
+	anchor := image.Pt(int(px), int(py)+m.TileHeight)
+	switch m.MapOrientation {
+	case MapIsometric:
+		anchor.X -= m.TileWidth / 2
+	case MapStaggered, MapHexagonal:
+		anchor.Y = int(py) + m.grid().tileHeight
+	}
+	min := anchor.Add(image.Pt(ts.TileOffset.X, ts.TileOffset.Y-size.Y))
+	return image.Rectangle{min, min.Add(size)}
+}
+
+// gridBounds returns the pixel bounds of the cells of the map.
+func (m *Map) gridBounds() image.Rectangle {
+	w, h, tw, th := m.Width, m.Height, m.TileWidth, m.TileHeight
+	switch m.MapOrientation {
+	case MapIsometric:
+		return image.Rect(0, 0, (w+h)*tw/2, (w+h)*th/2)
+	case MapStaggered, MapHexagonal:
+		g := m.grid()
+		if g.staggerX {
+			size := image.Pt(w*g.columnWidth+g.sideOffsetX, h*(g.tileHeight+g.sideLengthY))
+			if w > 1 {
+				size.Y += g.rowHeight
+			}
+			return image.Rectangle{Max: size}
+		}
+		size := image.Pt(w*(g.tileWidth+g.sideLengthX), h*g.rowHeight+g.sideOffsetY)
+		if h > 1 {
+			size.X += g.columnWidth
+		}
+		return image.Rectangle{Max: size}
+	}
+	return image.Rect(0, 0, w*tw, h*th)
+}
+
+// hexGrid holds the layout of a hexagonal map as Tiled computes it. Every
+// other column (stagger axis x) or row (stagger axis y) is shifted by half a
+// cell. Staggered maps are laid out as hexagonal maps with sides of length
+// zero.
+type hexGrid struct {
+	tileWidth, tileHeight    int // Tile size rounded down to even numbers.
+	sideLengthX, sideLengthY int
+	sideOffsetX, sideOffsetY int
+	columnWidth, rowHeight   int
+	staggerX, staggerEven    bool
+}
+
+// hexGrid returns the layout of the map with hexagon sides of length sideLength.
+func (m *Map) hexGrid(sideLength int) hexGrid {
+	g := hexGrid{
+		tileWidth:   m.TileWidth &^ 1,
+		tileHeight:  m.TileHeight &^ 1,
+		staggerX:    m.StaggerAxis == StaggerX,
+		staggerEven: m.StaggerIndex == StaggerEven,
+	}
+	if g.staggerX {
+		g.sideLengthX = sideLength
+	} else {
+		g.sideLengthY = sideLength
+	}
+	g.sideOffsetX = (g.tileWidth - g.sideLengthX) / 2
+	g.sideOffsetY = (g.tileHeight - g.sideLengthY) / 2
+	g.columnWidth = g.sideOffsetX + g.sideLengthX
+	g.rowHeight = g.sideOffsetY + g.sideLengthY
+	return g
+}
+
+// staggered reports whether the column or row at index i is shifted.
+func (g hexGrid) staggered(i int) bool {
+	return (i&1 != 0) != g.staggerEven
+}
+
+// origin returns the top-left corner of the bounding box of cell (x, y).
+func (g hexGrid) origin(x, y int) (px, py int) {
+	if g.staggerX {
+		px, py = x*g.columnWidth, y*(g.tileHeight+g.sideLengthY)
+		if g.staggered(x) {
+			py += g.rowHeight
+		}
+		return px, py
+	}
+	px, py = x*(g.tileWidth+g.sideLengthX), y*g.rowHeight
+	if g.staggered(y) {
+		px += g.columnWidth
+	}
+	return px, py
+}
+
+// cell returns the cell whose hexagon contains (px, py). It picks the cell
+// nearest to the point among those around the rows and columns the point
+// falls in.
+func (g hexGrid) cell(px, py float64) (x, y int) {
+	if g.tileWidth <= 0 || g.tileHeight <= 0 {
+		return 0, 0
+	}
+	stepX, stepY := g.tileWidth+g.sideLengthX, g.rowHeight
+	if g.staggerX {
+		stepX, stepY = g.columnWidth, g.tileHeight+g.sideLengthY
+	}
+	cx, cy := int(math.Floor(px/float64(max(stepX, 1)))), int(math.Floor(py/float64(max(stepY, 1))))
+	best := math.Inf(1)
+	for j := cy - 1; j <= cy+1; j++ {
+		for i := cx - 1; i <= cx+1; i++ {
+			ox, oy := g.origin(i, j)
+			if d := g.norm(px-float64(ox), py-float64(oy)); d < best {
+				best, x, y = d, i, j
+			}
+		}
+	}
+	return x, y
+}
+
+// norm returns the distance of the point (dx, dy) of a cell bounding box
+// from its center, scaled so that the hexagon holds the points within
+// distance 1.
+func (g hexGrid) norm(dx, dy float64) float64 {
+	a, b := float64(g.tileWidth)/2, float64(g.tileHeight)/2
+	dx, dy = math.Abs(dx-a), math.Abs(dy-b)
+	if g.staggerX {
+		s := float64(g.sideLengthX) / 2
+		return math.Max(dy/b, (dx+(a-s)*dy/b)/a)
+	}
+	s := float64(g.sideLengthY) / 2
+	return math.Max(dx/a, (dy+(b-s)*dx/a)/b)
+}
+
+// grid returns the layout of a staggered or hexagonal map.
+func (m *Map) grid() hexGrid {
+	if m.MapOrientation == MapHexagonal {
+		return m.hexGrid(m.HexSideLength)
+	}
+	return m.hexGrid(0)
+}
+
+// Axial is a cell position in the axial coordinates of a hexagonal grid.
+// Unlike the offset coordinates of map cells, axial coordinates do not
+// depend on the stagger of the map, so moving to a neighbor always adds the
+// same vector. Q runs along the stagger axis and R across it.
+// See: https://www.redblobgames.com/grids/hexagons/#coordinates-axial.
+type Axial struct {
+	Q, R int
+}
+
+// Cube returns the cube coordinates of a, which sum to zero.
+func (a Axial) Cube() (q, r, s int) {
+	return a.Q, a.R, -a.Q - a.R
+}
+
+// Add returns the vector a+b.
+func (a Axial) Add(b Axial) Axial {
+	return Axial{a.Q + b.Q, a.R + b.R}
+}
+
+// Distance returns the number of steps between the cells a and b.
+func (a Axial) Distance(b Axial) int {
+	q, r, s := Axial{a.Q - b.Q, a.R - b.R}.Cube()
+	return (abs(q) + abs(r) + abs(s)) / 2
+}
+
+// Range returns the cells within n steps of a.
+func (a Axial) Range(n int) []Axial {
+	var out []Axial
+	for q := -n; q <= n; q++ {
+		for r := max(-n, -q-n); r <= min(n, -q+n); r++ {
+			out = append(out, a.Add(Axial{q, r}))
+		}
+	}
+	return out
+}
+
+// TileToAxial returns the axial coordinates of the cell (x, y) of a hexagonal
+// map, laid out by its stagger axis and index.
+func (m *Map) TileToAxial(x, y int) Axial {
+	g := m.hexGrid(0)
+	if g.staggerX {
+		return Axial{x, y - g.shift(x)}
+	}
+	return Axial{x - g.shift(y), y}
+}
+
+// AxialToTile returns the cell of a hexagonal map at the axial coordinates a.
+// It is the inverse of TileToAxial.
+func (m *Map) AxialToTile(a Axial) (x, y int) {
+	g := m.hexGrid(0)
+	if g.staggerX {
+		return a.Q, a.R + g.shift(a.Q)
+	}
+	return a.Q + g.shift(a.R), a.R
+}
+
+// shift returns the difference between the offset and axial coordinates of
+// the cells of row i (stagger axis y) or column i (stagger axis x).
+func (g hexGrid) shift(i int) int {
+	if g.staggerEven {
+		return (i + i&1) / 2
+	}
+	return (i - i&1) / 2
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
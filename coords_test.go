@@ -0,0 +1,245 @@
+package tmx
+
+import (
+	"image"
+	"testing"
+)
+
+func TestOrthogonalCoords(t *testing.T) {
+	m := &Map{MapOrientation: MapOrthogonal, TileWidth: 16, TileHeight: 8}
+	for _, tc := range []struct {
+		x, y   int
+		px, py float64
+	}{
+		{0, 0, 0, 0},
+		{2, 3, 32, 24},
+		{-1, -2, -16, -16},
+	} {
+		if px, py := m.TileToPixel(tc.x, tc.y); px != tc.px || py != tc.py {
+			t.Errorf("TileToPixel(%d, %d) = %v, %v, want %v, %v", tc.x, tc.y, px, py, tc.px, tc.py)
+		}
+		// Every pixel of the cell maps back to it.
+		for _, d := range [][2]float64{{0, 0}, {15.5, 7.5}, {8, 4}} {
+			if x, y := m.PixelToTile(tc.px+d[0], tc.py+d[1]); x != tc.x || y != tc.y {
+				t.Errorf("PixelToTile(%v, %v) = %d, %d, want %d, %d", tc.px+d[0], tc.py+d[1], x, y, tc.x, tc.y)
+			}
+		}
+	}
+
+	m.Groups = []Group{{ID: 1, OffsetX: 4, Opacity: 1, Visible: true}}
+	l := &Layer{OffsetY: -2, Parent: 1}
+	if px, py := m.LayerTileToPixel(l, 1, 1); px != 20 || py != 6 {
+		t.Errorf("LayerTileToPixel(1, 1) = %v, %v, want 20, 6", px, py)
+	}
+	if x, y := m.LayerPixelToTile(l, 19, 6); x != 0 || y != 1 {
+		t.Errorf("LayerPixelToTile(19, 6) = %d, %d, want 0, 1", x, y)
+	}
+}
+
+func TestIsometricCoords(t *testing.T) {
+	m := &Map{MapOrientation: MapIsometric, Width: 3, Height: 2, TileWidth: 16, TileHeight: 8}
+	for _, tc := range []struct {
+		x, y   int
+		px, py float64
+	}{
+		{0, 0, 16, 0},
+		{1, 0, 24, 4},
+		{0, 1, 8, 4},
+		{2, 1, 24, 12},
+	} {
+		if px, py := m.TileToPixel(tc.x, tc.y); px != tc.px || py != tc.py {
+			t.Errorf("TileToPixel(%d, %d) = %v, %v, want %v, %v", tc.x, tc.y, px, py, tc.px, tc.py)
+		}
+		// The top, center and sides of the diamond map back to the cell.
+		for _, d := range [][2]float64{{0, 0.5}, {0, 4}, {-7, 4}, {7, 4}, {0, 7.5}} {
+			if x, y := m.PixelToTile(tc.px+d[0], tc.py+d[1]); x != tc.x || y != tc.y {
+				t.Errorf("PixelToTile(%v, %v) = %d, %d, want %d, %d", tc.px+d[0], tc.py+d[1], x, y, tc.x, tc.y)
+			}
+		}
+	}
+	if x, y := m.PixelToTile(0, 0); x != -1 || y != 1 {
+		t.Errorf("PixelToTile(0, 0) = %d, %d, want -1, 1", x, y)
+	}
+
+	// Object positions run along the tile axes in units of the tile height.
+	for _, tc := range []struct{ x, y, px, py float64 }{
+		{0, 0, 16, 0},
+		{8, 0, 24, 4},
+		{4, 12, 8, 8},
+	} {
+		if px, py := m.ObjectToPixel(tc.x, tc.y); px != tc.px || py != tc.py {
+			t.Errorf("ObjectToPixel(%v, %v) = %v, %v, want %v, %v", tc.x, tc.y, px, py, tc.px, tc.py)
+		}
+		if x, y := m.PixelToObject(tc.px, tc.py); x != tc.x || y != tc.y {
+			t.Errorf("PixelToObject(%v, %v) = %v, %v, want %v, %v", tc.px, tc.py, x, y, tc.x, tc.y)
+		}
+	}
+	m.MapOrientation = MapOrthogonal
+	if px, py := m.ObjectToPixel(4, 12); px != 4 || py != 12 {
+		t.Errorf("orthogonal ObjectToPixel(4, 12) = %v, %v", px, py)
+	}
+}
+
+func TestStaggeredCoords(t *testing.T) {
+	for _, tc := range []struct {
+		axis  StaggerAxis
+		index StaggerIndex
+		cells map[[2]int][2]float64 // Top-left corners of cell bounding boxes.
+	}{
+		{StaggerY, StaggerOdd, map[[2]int][2]float64{{0, 0}: {0, 0}, {1, 0}: {16, 0}, {0, 1}: {8, 4}, {1, 2}: {16, 8}, {0, 3}: {8, 12}}},
+		{StaggerY, StaggerEven, map[[2]int][2]float64{{0, 0}: {8, 0}, {0, 1}: {0, 4}, {1, 1}: {16, 4}, {0, 2}: {8, 8}}},
+		{StaggerX, StaggerOdd, map[[2]int][2]float64{{0, 0}: {0, 0}, {1, 0}: {8, 4}, {2, 0}: {16, 0}, {1, 1}: {8, 12}}},
+		{StaggerX, StaggerEven, map[[2]int][2]float64{{0, 0}: {0, 4}, {1, 0}: {8, 0}, {0, 1}: {0, 12}, {3, 1}: {24, 8}}},
+	} {
+		m := &Map{MapOrientation: MapStaggered, StaggerAxis: tc.axis, StaggerIndex: tc.index, TileWidth: 16, TileHeight: 8}
+		for c, p := range tc.cells {
+			if px, py := m.TileToPixel(c[0], c[1]); px != p[0] || py != p[1] {
+				t.Errorf("%s %s: TileToPixel(%d, %d) = %v, %v, want %v, %v", tc.axis, tc.index, c[0], c[1], px, py, p[0], p[1])
+			}
+			// The corners of the diamond, moved inside, map back to the cell.
+			for _, d := range [][2]float64{{8, 0.5}, {0.5, 4}, {15.5, 4}, {8, 7.5}, {8, 4}} {
+				if x, y := m.PixelToTile(p[0]+d[0], p[1]+d[1]); x != c[0] || y != c[1] {
+					t.Errorf("%s %s: PixelToTile(%v, %v) = %d, %d, want %d, %d", tc.axis, tc.index, p[0]+d[0], p[1]+d[1], x, y, c[0], c[1])
+				}
+			}
+		}
+	}
+}
+
+func TestHexagonalCoords(t *testing.T) {
+	// Pointy hexagons 14 wide and 16 high with vertical sides of length 8.
+	m := &Map{MapOrientation: MapHexagonal, StaggerAxis: StaggerY, StaggerIndex: StaggerOdd, TileWidth: 14, TileHeight: 16, HexSideLength: 8}
+	for c, p := range map[[2]int][2]float64{{0, 0}: {0, 0}, {1, 0}: {14, 0}, {0, 1}: {7, 12}, {1, 2}: {14, 24}} {
+		if px, py := m.TileToPixel(c[0], c[1]); px != p[0] || py != p[1] {
+			t.Errorf("TileToPixel(%d, %d) = %v, %v, want %v, %v", c[0], c[1], px, py, p[0], p[1])
+		}
+		// Points near the corners of the hexagon map back to the cell.
+		for _, d := range [][2]float64{{7, 0.5}, {0.5, 4.5}, {0.5, 11.5}, {13.5, 11.5}, {7, 15.5}, {7, 8}} {
+			if x, y := m.PixelToTile(p[0]+d[0], p[1]+d[1]); x != c[0] || y != c[1] {
+				t.Errorf("PixelToTile(%v, %v) = %d, %d, want %d, %d", p[0]+d[0], p[1]+d[1], x, y, c[0], c[1])
+			}
+		}
+	}
+	// Flat hexagons shift columns down.
+	m = &Map{MapOrientation: MapHexagonal, StaggerAxis: StaggerX, StaggerIndex: StaggerEven, TileWidth: 16, TileHeight: 14, HexSideLength: 8}
+	for c, p := range map[[2]int][2]float64{{0, 0}: {0, 7}, {1, 0}: {12, 0}, {2, 1}: {24, 21}} {
+		if px, py := m.TileToPixel(c[0], c[1]); px != p[0] || py != p[1] {
+			t.Errorf("x even: TileToPixel(%d, %d) = %v, %v, want %v, %v", c[0], c[1], px, py, p[0], p[1])
+		}
+		if x, y := m.PixelToTile(p[0]+15.5, p[1]+7); x != c[0] || y != c[1] {
+			t.Errorf("x even: PixelToTile(%v, %v) = %d, %d, want %d, %d", p[0]+15.5, p[1]+7, x, y, c[0], c[1])
+		}
+	}
+}
+
+func TestAxial(t *testing.T) {
+	for _, tc := range []struct {
+		axis  StaggerAxis
+		index StaggerIndex
+		// The six neighbors of cell (2, 2) and of cell (3, 3).
+		even, odd [][2]int
+	}{
+		{StaggerY, StaggerOdd, [][2]int{{1, 2}, {3, 2}, {1, 1}, {2, 1}, {1, 3}, {2, 3}}, [][2]int{{2, 3}, {4, 3}, {3, 2}, {4, 2}, {3, 4}, {4, 4}}},
+		{StaggerY, StaggerEven, [][2]int{{1, 2}, {3, 2}, {2, 1}, {3, 1}, {2, 3}, {3, 3}}, [][2]int{{2, 3}, {4, 3}, {2, 2}, {3, 2}, {2, 4}, {3, 4}}},
+		{StaggerX, StaggerOdd, [][2]int{{2, 1}, {2, 3}, {1, 1}, {1, 2}, {3, 1}, {3, 2}}, [][2]int{{3, 2}, {3, 4}, {2, 3}, {2, 4}, {4, 3}, {4, 4}}},
+	} {
+		m := &Map{MapOrientation: MapHexagonal, StaggerAxis: tc.axis, StaggerIndex: tc.index}
+		for _, c := range []struct {
+			x, y      int
+			neighbors [][2]int
+		}{{2, 2, tc.even}, {3, 3, tc.odd}} {
+			a := m.TileToAxial(c.x, c.y)
+			if x, y := m.AxialToTile(a); x != c.x || y != c.y {
+				t.Errorf("%s %s: AxialToTile(%v) = %d, %d, want %d, %d", tc.axis, tc.index, a, x, y, c.x, c.y)
+			}
+			for _, n := range c.neighbors {
+				if d := a.Distance(m.TileToAxial(n[0], n[1])); d != 1 {
+					t.Errorf("%s %s: distance from (%d, %d) to %v = %d, want 1", tc.axis, tc.index, c.x, c.y, n, d)
+				}
+			}
+		}
+	}
+
+	a := Axial{1, -2}
+	if q, r, s := a.Cube(); q != 1 || r != -2 || s != 1 {
+		t.Errorf("Cube() = %d, %d, %d", q, r, s)
+	}
+	if d := a.Distance(Axial{-1, 1}); d != 3 {
+		t.Errorf("Distance() = %d, want 3", d)
+	}
+	cells := a.Range(2)
+	if len(cells) != 19 {
+		t.Errorf("Range(2) returned %d cells, want 19", len(cells))
+	}
+	for _, c := range cells {
+		if d := a.Distance(c); d > 2 {
+			t.Errorf("Range(2) returned %v at distance %d", c, d)
+		}
+	}
+}
+
+func TestMapBounds(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		m    Map
+		want image.Rectangle
+	}{{
+		name: "orthogonal",
+		m:    Map{Width: 4, Height: 3, TileWidth: 16, TileHeight: 16},
+		want: image.Rect(0, 0, 64, 48),
+	}, {
+		name: "isometric",
+		m:    Map{MapOrientation: MapIsometric, Width: 4, Height: 2, TileWidth: 16, TileHeight: 8},
+		want: image.Rect(0, 0, 48, 24),
+	}, {
+		name: "staggered",
+		m:    Map{MapOrientation: MapStaggered, StaggerAxis: StaggerY, Width: 4, Height: 3, TileWidth: 16, TileHeight: 8},
+		want: image.Rect(0, 0, 72, 16),
+	}, {
+		name: "hexagonal",
+		m:    Map{MapOrientation: MapHexagonal, StaggerAxis: StaggerX, Width: 2, Height: 3, TileWidth: 14, TileHeight: 12, HexSideLength: 6},
+		want: image.Rect(0, 0, 24, 42),
+	}, {
+		name: "oversized tiles",
+		m: Map{Width: 4, Height: 3, TileWidth: 16, TileHeight: 16, Tilesets: []Tileset{
+			{TileWidth: 32, TileHeight: 24, TileOffset: TileOffset{X: -4, Y: 2}},
+			{Tiles: []Tile{{Image: Image{Width: 8, Height: 40}}}},
+		}},
+		want: image.Rect(-4, -24, 76, 50),
+	}, {
+		name: "layers",
+		m: Map{Width: 4, Height: 3, TileWidth: 16, TileHeight: 16,
+			Layers:      []Layer{{OffsetX: -8}, {OffsetY: 4, Parent: 1}},
+			Groups:      []Group{{ID: 1, OffsetY: 2}},
+			ImageLayers: []ImageLayer{{OffsetX: 60, Image: Image{Width: 10, Height: 10}}},
+		},
+		want: image.Rect(-8, 0, 70, 54),
+	}} {
+		if got := tc.m.Bounds(); got != tc.want {
+			t.Errorf("%s: Bounds() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTileDrawRect(t *testing.T) {
+	ts := &Tileset{TileWidth: 32, TileHeight: 24, TileOffset: TileOffset{X: -4, Y: 2}, Tiles: []Tile{{ID: 3, Image: Image{Width: 10, Height: 20}}}}
+	m := &Map{Width: 4, Height: 3, TileWidth: 16, TileHeight: 16}
+	for _, tc := range []struct {
+		name string
+		m    *Map
+		x, y int
+		t    DecodedTile
+		want image.Rectangle
+	}{
+		{"orthogonal", m, 1, 2, DecodedTile{Tileset: ts}, image.Rect(12, 26, 44, 50)},
+		{"diagonal flip", m, 1, 2, DecodedTile{Tileset: ts, DiagonalFlip: true}, image.Rect(12, 18, 36, 50)},
+		{"tile image", m, 0, 0, DecodedTile{ID: 3, Tileset: ts}, image.Rect(-4, -2, 6, 18)},
+		{"nil", m, 0, 0, NilTile, image.Rectangle{}},
+		{"isometric", &Map{MapOrientation: MapIsometric, Width: 3, Height: 2, TileWidth: 16, TileHeight: 8}, 0, 0, DecodedTile{Tileset: &Tileset{TileWidth: 16, TileHeight: 16}}, image.Rect(8, -8, 24, 8)},
+		{"staggered", &Map{MapOrientation: MapStaggered, StaggerAxis: StaggerY, TileWidth: 16, TileHeight: 8}, 0, 1, DecodedTile{Tileset: &Tileset{TileWidth: 16, TileHeight: 16}}, image.Rect(8, -4, 24, 12)},
+	} {
+		if got := tc.m.TileDrawRect(tc.x, tc.y, tc.t); got != tc.want {
+			t.Errorf("%s: TileDrawRect(%d, %d) = %v, want %v", tc.name, tc.x, tc.y, got, tc.want)
+		}
+	}
+}
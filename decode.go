@@ -0,0 +1,108 @@
+package tmx
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// Pools of decompressors and buffers reused while decoding layer data.
+var (
+	gzipReaders sync.Pool // Of *gzip.Reader.
+	zlibReaders sync.Pool // Of zlib readers, which implement zlib.Resetter.
+	byteBuffers = sync.Pool{New: func() any { return new([]byte) }}
+)
+
+// gzipReader returns a pooled gzip reader decompressing r.
+// Put it back into gzipReaders when done.
+func gzipReader(r io.Reader) (*gzip.Reader, error) {
+	zr, ok := gzipReaders.Get().(*gzip.Reader)
+	if !ok {
+		return gzip.NewReader(r)
+	}
+	if err := zr.Reset(r); err != nil {
+		gzipReaders.Put(zr)
+		return nil, err
+	}
+	return zr, nil
+}
+
+// zlibReader returns a pooled zlib reader decompressing r.
+// Put it back into zlibReaders when done.
+func zlibReader(r io.Reader) (io.ReadCloser, error) {
+	zr, ok := zlibReaders.Get().(io.ReadCloser)
+	if !ok {
+		return zlib.NewReader(r)
+	}
+	if err := zr.(zlib.Resetter).Reset(r, nil); err != nil {
+		zlibReaders.Put(zr)
+		return nil, err
+	}
+	return zr, nil
+}
+
+// DecodeLayers decodes the data of every map layer concurrently with up to
+// workers goroutines, or GOMAXPROCS if workers is not positive, caching it
+// like Layer.Decoded. It returns the error of the first layer failing to decode.
+func (m *Map) DecodeLayers(workers int) error {
+	return parallel(len(m.Layers), workers, func(i int) error {
+		_, err := m.Layers[i].Decoded()
+		return err
+	})
+}
+
+// parallel calls fn for 0 <= i < n with up to workers goroutines, or
+// GOMAXPROCS if workers is not positive. It returns the error of the
+// smallest i for which fn fails. Indices after a failure may be skipped.
+func parallel(n, workers int, fn func(i int) error) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, n)
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	errs := make([]error, n)
+	var (
+		mu     sync.Mutex
+		next   int
+		failed bool
+		wg     sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				i := next
+				next++
+				stop := failed || i >= n
+				mu.Unlock()
+				if stop {
+					return
+				}
+				if errs[i] = fn(i); errs[i] != nil {
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
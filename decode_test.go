@@ -0,0 +1,171 @@
+package tmx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDecodeLayers(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(`<map width="2" height="1"><tileset firstgid="1" name="a" tilewidth="8" tileheight="8" tilecount="16"/>`)
+	for i := 0; i < 16; i++ {
+		fmt.Fprintf(&b, `<layer width="2" height="1"><data encoding="csv">%d,0</data></layer>`, i)
+	}
+	b.WriteString(`</map>`)
+	m, err := Read(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.DecodeLayers(4); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(m.Layers); i++ {
+		if got, want := m.Layers[i].decoded, []GID{GID(i), 0}; !reflect.DeepEqual(got, want) {
+			t.Errorf("layer %d decoded = %v, want %v", i, got, want)
+		}
+	}
+	layers, err := m.DecodedLayers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, l := range layers {
+		if got := l.DecodedTiles[0]; got.Nil != (i == 0) || !got.Nil && got.ID != ID(i-1) {
+			t.Errorf("layer %d tile 0 = %+v", i, got)
+		}
+	}
+
+	m.EvictDecoded()
+	m.Layers[3].Data.Bytes = []byte("1")
+	m.Layers[9].Data.Bytes = []byte("x,y")
+	if err := m.DecodeLayers(0); !errors.Is(err, ErrInvalidDecodedDataLen) {
+		t.Errorf("DecodeLayers() = %v, want %v", err, ErrInvalidDecodedDataLen)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 100} {
+		var calls atomic.Int32
+		seen := make([]bool, 50)
+		if err := parallel(len(seen), workers, func(i int) error {
+			calls.Add(1)
+			seen[i] = true
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if calls.Load() != int32(len(seen)) {
+			t.Errorf("parallel(%d workers) made %d calls, want %d", workers, calls.Load(), len(seen))
+		}
+		for i, ok := range seen {
+			if !ok {
+				t.Errorf("parallel(%d workers) skipped %d", workers, i)
+			}
+		}
+
+		errA, errB := errors.New("a"), errors.New("b")
+		err := parallel(50, workers, func(i int) error {
+			switch i {
+			case 10:
+				return errA
+			case 11, 40:
+				return errB
+			}
+			return nil
+		})
+		if err != errA {
+			t.Errorf("parallel(%d workers) = %v, want %v", workers, err, errA)
+		}
+	}
+}
+
+func TestDecodePooled(t *testing.T) {
+	var layers []Layer
+	for _, name := range testfiles {
+		m, err := ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		layers = append(layers, m.Layers[0])
+	}
+	var want [][]GID
+	for _, l := range layers {
+		gids, err := l.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, gids)
+	}
+
+	for i := 0; i < 3; i++ {
+		for j, l := range layers {
+			corrupt := l
+			corrupt.Data.Bytes = []byte("AAAA")
+			if _, err := corrupt.Decode(); err == nil {
+				t.Errorf("Decode() of corrupt %s data succeeded", l.Data.Compression)
+			}
+			if got, err := l.Decode(); err != nil || !reflect.DeepEqual(got, want[j]) {
+				t.Errorf("Decode() of %s data after reuse = %v, %v", l.Data.Compression, got, err)
+			}
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	m, err := ReadFile("testdata/base64-zlib.tmx")
+	if err != nil {
+		b.Fatal(err)
+	}
+	l := m.Layers[0]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := l.Decode(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDecodeLength(t *testing.T) {
+	for _, comp := range []LayerCompression{Uncompressed, Gzip, Zlib} {
+		d, err := (DataEncoder{Encoding: Base64, Compression: comp}).Encode([]GID{1, 2, 3}, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := d.DecodeInto(nil, 3); err != nil || !reflect.DeepEqual(got, []GID{1, 2, 3}) {
+			t.Errorf("%q: DecodeInto(3) = %v, %v", comp, got, err)
+		}
+		for _, n := range []int{0, 2, 4} {
+			if _, err := d.DecodeInto(nil, n); !errors.Is(err, ErrInvalidDecodedDataLen) {
+				t.Errorf("%q: DecodeInto(%d) = %v, want %v", comp, n, err, ErrInvalidDecodedDataLen)
+			}
+		}
+	}
+}
+
+func TestDecodeBomb(t *testing.T) {
+	// 16 MiB of zeros, compressing to a few kilobytes.
+	d, err := (DataEncoder{Encoding: Base64, Compression: Zlib}).Encode(make([]GID, 4<<20), 4<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocs := testing.AllocsPerRun(1, func() {
+		if _, err := d.DecodeInto(nil, 4); !errors.Is(err, ErrInvalidDecodedDataLen) {
+			t.Errorf("DecodeInto(4) = %v, want %v", err, ErrInvalidDecodedDataLen)
+		}
+	})
+	if allocs > 100 {
+		t.Errorf("DecodeInto(4) made %v allocations", allocs)
+	}
+
+	d.maxBytes = 1 << 20
+	if _, err := d.DecodeInto(nil, 4<<20); !errors.Is(err, ErrResourceLimit) {
+		t.Errorf("DecodeInto() past the limit = %v, want %v", err, ErrResourceLimit)
+	}
+	if _, err := d.DecodeInto(nil, -1); !errors.Is(err, ErrInvalidDecodedDataLen) {
+		t.Errorf("DecodeInto(-1) = %v, want %v", err, ErrInvalidDecodedDataLen)
+	}
+}
@@ -0,0 +1,70 @@
+package tmx
+
+import "image"
+
+// SetTile sets the GID of tile (x, y) of the layer, decoding its data like
+// Decoded first. Edits are seen by Decoded and Decode, and are encoded with
+// the encoding and compression of Data when the map is written or the layer
+// is reencoded. Edits to a layer whose data does not decode fail with its
+// error, and edits outside of the layer with ErrOutOfBounds.
+func (l *Layer) SetTile(x, y int, gid GID) error {
+	if x < 0 || y < 0 || x >= l.Width || y >= l.Height {
+		return l.errorAt(-1, ErrOutOfBounds)
+	}
+	gids, err := l.Decoded()
+	if err != nil {
+		return err
+	}
+	if !l.edited {
+		// Decoded results may be shared, so edit a copy.
+		gids = append([]GID(nil), gids...)
+		l.decoded, l.edited = gids, true
+	}
+	gids[y*l.Width+x] = gid
+	return nil
+}
+
+// ClearTile empties tile (x, y) of the layer like SetTile.
+func (l *Layer) ClearTile(x, y int) error {
+	return l.SetTile(x, y, 0)
+}
+
+// encodedData returns the layer data with the edits made by SetTile
+// encoded at the compression level, and the size of the layer holding it.
+func (l *Layer) encodedData(level int) (Data, image.Point, error) {
+	if !l.edited {
+		return l.Data, image.Pt(l.Width, l.Height), nil
+	}
+	return l.encodeLike(l.decoded, l.Width, level)
+}
+
+// encodeLike returns gids for a layer width tiles wide encoded with the
+// encoding and compression of the layer data at the compression level, and
+// the size of the layer holding it.
+func (l *Layer) encodeLike(gids []GID, width, level int) (Data, image.Point, error) {
+	e := DataEncoder{Encoding: l.Data.Encoding, Compression: l.Data.Compression, Level: level}
+	d, size, err := l.encode(e, gids, width)
+	if err != nil {
+		return Data{}, image.Point{}, l.errorAt(-1, err)
+	}
+	d.maxBytes = l.Data.maxBytes
+	return d, size, nil
+}
+
+// encode returns gids for a layer width tiles wide encoded with e, and the
+// size of the layer holding it. Chunked data is encoded in chunks from the
+// same cell as the layer data, and the layer covers whole chunks.
+func (l *Layer) encode(e DataEncoder, gids []GID, width int) (Data, image.Point, error) {
+	if !l.Data.Chunked() {
+		d, err := e.Encode(gids, width)
+		if width <= 0 {
+			return d, image.Point{}, err
+		}
+		return d, image.Pt(width, len(gids)/width), err
+	}
+	chunks, err := l.Data.Chunks()
+	if err != nil {
+		return Data{}, image.Point{}, err
+	}
+	return e.encodeChunks(gids, width, chunkBounds(chunks).Min, chunkSize(chunks))
+}
@@ -0,0 +1,73 @@
+package tmx
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSetTile(t *testing.T) {
+	m, err := ReadFile("testdata/base64-zlib.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &m.Layers[0]
+	before, err := l.Decoded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := before[l.Width+1]
+	if err := l.SetTile(1, 1, 3|GIDHorizontalFlip); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.ClearTile(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if before[l.Width+1] != old {
+		t.Error("SetTile() changed a slice returned by Decoded")
+	}
+	if err := l.SetTile(l.Width, 0, 1); !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("SetTile() outside of the layer = %v, want %v", err, ErrOutOfBounds)
+	}
+	l.EvictDecoded()
+
+	check := func(name string, l *Layer) {
+		t.Helper()
+		gids, err := l.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gids[0] != 0 || gids[l.Width+1] != 3|GIDHorizontalFlip {
+			t.Errorf("%s: tiles = %d, %d, want 0, %d", name, gids[0], gids[l.Width+1], 3|GIDHorizontalFlip)
+		}
+		if l.Data.Encoding != Base64 || l.Data.Compression != Zlib {
+			t.Errorf("%s: data encoded as %q %q, want base64 zlib", name, l.Data.Encoding, l.Data.Compression)
+		}
+	}
+	check("SetTile()", l)
+
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Read(Write())", &got.Layers[0])
+
+	buf.Reset()
+	if err := WriteJSON(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = ReadJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	check("ReadJSON(WriteJSON())", &got.Layers[0])
+
+	if err := l.Reencode(m.DataEncoder(Base64, Zlib)); err != nil {
+		t.Fatal(err)
+	}
+	l.EvictDecoded()
+	check("Reencode()", l)
+}
@@ -0,0 +1,156 @@
+package tmx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"io"
+	"strconv"
+)
+
+// DataEncoder encodes tile GIDs into layer <data> as written by Tiled.
+type DataEncoder struct {
+	Encoding    LayerEncoding
+	Compression LayerCompression // Only used by the Base64 encoding.
+	Level       int              // Compression level or DefaultCompressionLevel.
+}
+
+// DataEncoder returns an encoder using the map's compression level.
+func (m *Map) DataEncoder(enc LayerEncoding, comp LayerCompression) DataEncoder {
+	return DataEncoder{Encoding: enc, Compression: comp, Level: m.CompressionLevel}
+}
+
+// Encode returns layer data holding gids for a layer width tiles wide.
+// The width only affects the line breaks of the CSV encoding.
+func (e DataEncoder) Encode(gids []GID, width int) (Data, error) {
+	d := Data{Encoding: e.Encoding}
+
+	var err error
+	switch e.Encoding {
+	case Base64:
+		d.Compression = e.Compression
+		d.Bytes, err = e.encodeBase64(gids)
+	case CSV:
+		d.Bytes = encodeCSV(gids, width)
+	case XML:
+		d.Bytes = encodeXML(gids)
+	default:
+		err = ErrUnsupportedEncoding
+	}
+	if err != nil {
+		return Data{}, err
+	}
+	return d, nil
+}
+
+// Encode sets the layer data to gids encoded with e. Chunked data of infinite
+// maps is encoded in chunks from the same cell, aligned to multiples of the
+// chunk size, and the layer grows to cover whole chunks.
+func (l *Layer) Encode(gids []GID, e DataEncoder) error {
+	if len(gids) != l.Width*l.Height {
+		return ErrInvalidDecodedDataLen
+	}
+	d, size, err := l.encode(e, gids, l.Width)
+	if err != nil {
+		return err
+	}
+	l.Width, l.Height = size.X, size.Y
+	l.Data = d
+	l.decoded = nil
+	l.edited = false
+	return nil
+}
+
+// Reencode converts the layer data to the encoding and compression of e.
+func (l *Layer) Reencode(e DataEncoder) error {
+	gids, err := l.Decoded()
+	if err != nil {
+		return err
+	}
+	return l.Encode(gids, e)
+}
+
+// Reencode converts the data of every map layer to the encoding and compression of e.
+func (m *Map) Reencode(e DataEncoder) error {
+	for i := 0; i < len(m.Layers); i++ {
+		if err := m.Layers[i].Reencode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e DataEncoder) encodeBase64(gids []GID) ([]byte, error) {
+	raw := make([]byte, 4*len(gids))
+	for i, gid := range gids {
+		raw[4*i] = byte(gid)
+		raw[4*i+1] = byte(gid >> 8)
+		raw[4*i+2] = byte(gid >> 16)
+		raw[4*i+3] = byte(gid >> 24)
+	}
+
+	var buf bytes.Buffer
+	var zw io.WriteCloser
+	var err error
+	switch e.Compression {
+	case Uncompressed:
+	case Gzip:
+		zw, err = gzip.NewWriterLevel(&buf, e.Level)
+	case Zlib:
+		zw, err = zlib.NewWriterLevel(&buf, e.Level)
+	case Zstd:
+		raw, err = zstdCompress(raw, e.Level)
+	default:
+		return nil, ErrUnsupportedCompression
+	}
+	if err != nil {
+		return nil, err
+	}
+	if zw != nil {
+		if _, err := zw.Write(raw); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		raw = buf.Bytes()
+	}
+
+	out := make([]byte, 0, base64.StdEncoding.EncodedLen(len(raw))+6)
+	out = append(out, "\n   "...)
+	out = append(out, base64.StdEncoding.EncodeToString(raw)...)
+	return append(out, "\n  "...), nil
+}
+
+func encodeCSV(gids []GID, width int) []byte {
+	if width <= 0 {
+		width = len(gids)
+	}
+	out := []byte{'\n'}
+	for i, gid := range gids {
+		out = strconv.AppendUint(out, uint64(gid), 10)
+		if i == len(gids)-1 {
+			break
+		}
+		out = append(out, ',')
+		if (i+1)%width == 0 {
+			out = append(out, '\n')
+		}
+	}
+	return append(out, '\n')
+}
+
+func encodeXML(gids []GID) []byte {
+	var out []byte
+	for _, gid := range gids {
+		if gid == 0 {
+			out = append(out, "\n   <tile/>"...)
+			continue
+		}
+		out = append(out, "\n   <tile gid=\""...)
+		out = strconv.AppendUint(out, uint64(gid), 10)
+		out = append(out, "\"/>"...)
+	}
+	return append(out, "\n  "...)
+}
@@ -0,0 +1,78 @@
+package tmx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDataEncoderRoundTrip(t *testing.T) {
+	gids := []GID{0, 1, 2, 3, 4, 5 | GIDHorizontalFlip, 6 | GIDFlip}
+
+	for _, e := range []DataEncoder{
+		{Encoding: XML},
+		{Encoding: CSV},
+		{Encoding: Base64},
+		{Encoding: Base64, Compression: Gzip, Level: DefaultCompressionLevel},
+		{Encoding: Base64, Compression: Zlib, Level: 9},
+		{Encoding: Base64, Compression: Zstd},
+		{Encoding: Base64, Compression: Zstd, Level: 19},
+	} {
+		l := Layer{Width: 2, Height: 4}
+		if err := l.Encode(gids[:7], e); err == nil {
+			t.Errorf("%+v: Encode of short data succeeded", e)
+		}
+		if err := l.Encode(append(gids, 7), e); err != nil {
+			t.Fatalf("%+v: %v", e, err)
+		}
+		if l.Data.Encoding != e.Encoding || l.Data.Compression != e.Compression {
+			t.Errorf("%+v: data = %+v", e, l.Data)
+		}
+
+		got, err := l.Decode()
+		if err != nil {
+			t.Fatalf("%+v: %v", e, err)
+		}
+		if want := append(gids, 7); !reflect.DeepEqual(got, want) {
+			t.Errorf("%+v: Decode() = %v, want %v", e, got, want)
+		}
+	}
+}
+
+func TestDataEncoderFormat(t *testing.T) {
+	d, err := DataEncoder{Encoding: CSV}.Encode([]GID{1, 2, 3, 4}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(d.Bytes), "\n1,2,\n3,4\n"; got != want {
+		t.Errorf("CSV = %q, want %q", got, want)
+	}
+
+	d, err = DataEncoder{Encoding: XML}.Encode([]GID{0, 2}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(d.Bytes), "\n   <tile/>\n   <tile gid=\"2\"/>\n  "; got != want {
+		t.Errorf("XML = %q, want %q", got, want)
+	}
+
+	if _, err := (DataEncoder{Encoding: Base64, Compression: "lz4"}).Encode([]GID{1}, 1); err != ErrUnsupportedCompression {
+		t.Errorf("lz4 error = %v, want %v", err, ErrUnsupportedCompression)
+	}
+}
+
+func TestMapReencode(t *testing.T) {
+	m, err := ReadFile("testdata/base64-gzip.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reencode(m.DataEncoder(CSV, Uncompressed)); err != nil {
+		t.Fatal(err)
+	}
+	gids, err := m.Layers[0].Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gids, layer0Data) {
+		t.Error("reencoded layer data differs")
+	}
+}
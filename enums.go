@@ -0,0 +1,223 @@
+package tmx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownValue is returned in strict mode for attributes holding a value
+// that is not among the valid values of their type.
+var ErrUnknownValue = errors.New("tmx: unknown attribute value")
+
+// IsValid reports whether o is a known orientation. The empty orientation
+// is orthogonal.
+func (o MapOrientation) IsValid() bool {
+	switch o {
+	case "", MapOrthogonal, MapIsometric, MapStaggered, MapHexagonal:
+		return true
+	}
+	return false
+}
+
+func (o MapOrientation) String() string {
+	if o == "" {
+		return string(MapOrthogonal)
+	}
+	return string(o)
+}
+
+// IsValid reports whether o is a known render order. The empty render order
+// is right-down.
+func (o MapRenderOrder) IsValid() bool {
+	switch o {
+	case "", RenderRightDown, RenderRightUp, RenderLeftDown, RenderLeftUp:
+		return true
+	}
+	return false
+}
+
+func (o MapRenderOrder) String() string {
+	if o == "" {
+		return string(RenderRightDown)
+	}
+	return string(o)
+}
+
+// IsValid reports whether a is a known stagger axis. The empty stagger axis
+// is y.
+func (a StaggerAxis) IsValid() bool {
+	switch a {
+	case "", StaggerX, StaggerY:
+		return true
+	}
+	return false
+}
+
+func (a StaggerAxis) String() string {
+	if a == "" {
+		return string(StaggerY)
+	}
+	return string(a)
+}
+
+// IsValid reports whether i is a known stagger index. The empty stagger
+// index is odd.
+func (i StaggerIndex) IsValid() bool {
+	switch i {
+	case "", StaggerOdd, StaggerEven:
+		return true
+	}
+	return false
+}
+
+func (i StaggerIndex) String() string {
+	if i == "" {
+		return string(StaggerOdd)
+	}
+	return string(i)
+}
+
+// IsValid reports whether o is a known draw order. The empty draw order is
+// topdown.
+func (o DrawOrder) IsValid() bool {
+	switch o {
+	case "", DrawTopDown, DrawIndex:
+		return true
+	}
+	return false
+}
+
+func (o DrawOrder) String() string {
+	if o == "" {
+		return string(DrawTopDown)
+	}
+	return string(o)
+}
+
+// IsValid reports whether o is a known tileset orientation. The empty
+// orientation is orthogonal.
+func (o TileOrientation) IsValid() bool {
+	switch o {
+	case "", TileOrthogonal, TileIsometric:
+		return true
+	}
+	return false
+}
+
+func (o TileOrientation) String() string {
+	if o == "" {
+		return string(TileOrthogonal)
+	}
+	return string(o)
+}
+
+// IsValid reports whether e is a known encoding.
+func (e LayerEncoding) IsValid() bool {
+	switch e {
+	case XML, CSV, Base64:
+		return true
+	}
+	return false
+}
+
+func (e LayerEncoding) String() string {
+	if e == XML {
+		return "xml"
+	}
+	return string(e)
+}
+
+// IsValid reports whether c is a known compression method, though not
+// necessarily a supported one.
+func (c LayerCompression) IsValid() bool {
+	switch c {
+	case Uncompressed, Gzip, Zlib, Zstd:
+		return true
+	}
+	return false
+}
+
+func (c LayerCompression) String() string {
+	if c == Uncompressed {
+		return "uncompressed"
+	}
+	return string(c)
+}
+
+// normalizeEnum returns v in lower case without surrounding space, as
+// Tiled writes enumerated attributes.
+func normalizeEnum[T ~string](v T) T {
+	return T(strings.ToLower(strings.TrimSpace(string(v))))
+}
+
+// normalizeEnums normalizes the enumerated attributes of the map, its
+// tilesets and its layers.
+func (m *Map) normalizeEnums() {
+	m.MapOrientation = normalizeEnum(m.MapOrientation)
+	m.MapRenderOrder = normalizeEnum(m.MapRenderOrder)
+	m.StaggerAxis = normalizeEnum(m.StaggerAxis)
+	m.StaggerIndex = normalizeEnum(m.StaggerIndex)
+	for i := 0; i < len(m.Tilesets); i++ {
+		m.Tilesets[i].normalizeEnums()
+	}
+	for i := 0; i < len(m.Layers); i++ {
+		d := &m.Layers[i].Data
+		d.Encoding = normalizeEnum(d.Encoding)
+		d.Compression = normalizeEnum(d.Compression)
+	}
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		g := &m.ObjectGroups[i]
+		g.DrawOrder = normalizeEnum(g.DrawOrder)
+	}
+}
+
+// normalizeEnums normalizes the grid orientation of the tileset, accepting
+// the misspelled orientation this package once defined.
+func (ts *Tileset) normalizeEnums() {
+	o := &ts.Grid.TileOrientation
+	if *o = normalizeEnum(*o); *o == "orthoganal" {
+		*o = TileOrthogonal
+	}
+}
+
+// checkEnums returns an error wrapping ErrUnknownValue if an enumerated
+// attribute of the map, its tilesets or its layers holds an unknown value.
+func (m *Map) checkEnums() error {
+	if !m.MapOrientation.IsValid() {
+		return unknownValue("orientation", m.MapOrientation)
+	}
+	if !m.MapRenderOrder.IsValid() {
+		return unknownValue("renderorder", m.MapRenderOrder)
+	}
+	if !m.StaggerAxis.IsValid() {
+		return unknownValue("staggeraxis", m.StaggerAxis)
+	}
+	if !m.StaggerIndex.IsValid() {
+		return unknownValue("staggerindex", m.StaggerIndex)
+	}
+	for i := 0; i < len(m.Tilesets); i++ {
+		if o := m.Tilesets[i].Grid.TileOrientation; !o.IsValid() {
+			return unknownValue("grid orientation", o)
+		}
+	}
+	for i := 0; i < len(m.Layers); i++ {
+		l := &m.Layers[i]
+		if !l.Data.Encoding.IsValid() {
+			return l.errorAt(-1, unknownValue("encoding", l.Data.Encoding))
+		}
+		if !l.Data.Compression.IsValid() {
+			return l.errorAt(-1, unknownValue("compression", l.Data.Compression))
+		}
+	}
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		if o := m.ObjectGroups[i].DrawOrder; !o.IsValid() {
+			return unknownValue("draworder", o)
+		}
+	}
+	return nil
+}
+
+func unknownValue[T ~string](attr string, v T) error {
+	return fmt.Errorf("%w: %s %q", ErrUnknownValue, attr, string(v))
+}
@@ -0,0 +1,102 @@
+package tmx
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEnumIsValid(t *testing.T) {
+	for _, tc := range []struct {
+		v interface {
+			IsValid() bool
+			String() string
+		}
+		valid bool
+		str   string
+	}{
+		{MapOrientation(""), true, "orthogonal"},
+		{MapHexagonal, true, "hexagonal"},
+		{MapOrientation("diagonal"), false, "diagonal"},
+		{MapRenderOrder(""), true, "right-down"},
+		{RenderLeftUp, true, "left-up"},
+		{MapRenderOrder("up-left"), false, "up-left"},
+		{StaggerAxis(""), true, "y"},
+		{StaggerX, true, "x"},
+		{StaggerAxis("z"), false, "z"},
+		{StaggerIndex(""), true, "odd"},
+		{StaggerEven, true, "even"},
+		{StaggerIndex("evne"), false, "evne"},
+		{DrawOrder(""), true, "topdown"},
+		{DrawIndex, true, "index"},
+		{DrawOrder("bottomup"), false, "bottomup"},
+		{TileOrientation(""), true, "orthogonal"},
+		{TileIsometric, true, "isometric"},
+		{TileOrientation("orthoganal"), false, "orthoganal"},
+		{XML, true, "xml"},
+		{Base64, true, "base64"},
+		{LayerEncoding("hex"), false, "hex"},
+		{Uncompressed, true, "uncompressed"},
+		{Zstd, true, "zstd"},
+		{LayerCompression("lz4"), false, "lz4"},
+	} {
+		if got := tc.v.IsValid(); got != tc.valid {
+			t.Errorf("%T(%q).IsValid() = %v, want %v", tc.v, tc.v, got, tc.valid)
+		}
+		if got := fmt.Sprint(tc.v); got != tc.str {
+			t.Errorf("%T.String() = %q, want %q", tc.v, got, tc.str)
+		}
+	}
+}
+
+func TestReadNormalizesEnums(t *testing.T) {
+	const data = `<map orientation=" Isometric" renderorder="LEFT-UP" staggeraxis="X" staggerindex=" Even" width="1" height="1">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8"><grid orientation="orthoganal" width="8" height="8"/></tileset>
+ <layer width="1" height="1"><data encoding="CSV">1</data></layer>
+ <objectgroup draworder="Index"/>
+</map>`
+	m, err := Read(strings.NewReader(data), WithStrictMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MapOrientation != MapIsometric || m.MapRenderOrder != RenderLeftUp {
+		t.Errorf("Read() orientation, render order = %q, %q, want %q, %q", m.MapOrientation, m.MapRenderOrder, MapIsometric, RenderLeftUp)
+	}
+	if got := m.Tilesets[0].Grid.TileOrientation; got != TileOrthogonal {
+		t.Errorf("Read() grid orientation = %q, want %q", got, TileOrthogonal)
+	}
+	if got := m.Layers[0].Data.Encoding; got != CSV {
+		t.Errorf("Read() encoding = %q, want %q", got, CSV)
+	}
+	if m.StaggerAxis != StaggerX || m.StaggerIndex != StaggerEven {
+		t.Errorf("Read() stagger axis, index = %q, %q, want %q, %q", m.StaggerAxis, m.StaggerIndex, StaggerX, StaggerEven)
+	}
+	if got := m.ObjectGroups[0].DrawOrder; got != DrawIndex {
+		t.Errorf("Read() draw order = %q, want %q", got, DrawIndex)
+	}
+}
+
+func TestReadStrictEnums(t *testing.T) {
+	for _, data := range []string{
+		`<map orientation="diagonal"/>`,
+		`<map renderorder="up"/>`,
+		`<map staggeraxis="z"/>`,
+		`<map staggerindex="evne"/>`,
+		`<map><objectgroup draworder="bottomup"/></map>`,
+		`<map><tileset firstgid="1" name="a"><grid orientation="hex"/></tileset></map>`,
+		`<map width="1" height="1"><layer width="1" height="1"><data encoding="hex">01</data></layer></map>`,
+		`<map width="1" height="1"><layer width="1" height="1"><data encoding="base64" compression="lz4">AQAAAA==</data></layer></map>`,
+	} {
+		m, err := Read(strings.NewReader(data), WithoutLayerDecode())
+		if err != nil {
+			t.Errorf("Read(%s) = %v, want nil", data, err)
+		} else if !slices.ContainsFunc(m.Validate(), func(i Issue) bool { return i.Code == IssueUnknownValue }) {
+			t.Errorf("Read(%s).Validate() reports no %s", data, IssueUnknownValue)
+		}
+		if _, err := Read(strings.NewReader(data), WithStrictMode(), WithoutLayerDecode()); !errors.Is(err, ErrUnknownValue) {
+			t.Errorf("Read(%s) in strict mode = %v, want %v", data, err, ErrUnknownValue)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package tmx
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Template models a v1.2 object template <template> stored in a TX file.
+// See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#template-files.
+type Template struct {
+	Tileset *Tileset `xml:"tileset"` // Only set for tile object templates.
+	Object  Object   `xml:"object"`
+}
+
+// ReadTileset reads an external TSX tileset from the reader r or returns an error.
+func ReadTileset(r io.Reader) (*Tileset, error) {
+	out := new(Tileset)
+	if err := xml.NewDecoder(r).Decode(out); err != nil {
+		return nil, err
+	}
+	out.normalizeEnums()
+	return out, nil
+}
+
+// ReadTilesetFile reads an external TSX tileset from a file path or returns an error.
+func ReadTilesetFile(path string) (*Tileset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTileset(f)
+}
+
+// decodeTileset reads a TSX or JSON tileset named name from r.
+// The format is chosen by the file extension, or by sniffing the content of
+// files with other extensions.
+func decodeTileset(name string, r io.Reader) (*Tileset, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".tsx", ".xml":
+		return ReadTileset(r)
+	case ".tsj", ".json":
+		return ReadTilesetJSON(r)
+	}
+
+	br := bufio.NewReader(r)
+	if isJSON(br) {
+		return ReadTilesetJSON(br)
+	}
+	return ReadTileset(br)
+}
+
+// isJSON reports whether the first non-space byte buffered by br opens a JSON object.
+func isJSON(br *bufio.Reader) bool {
+	for n := 1; ; n++ {
+		b, err := br.Peek(n)
+		if err != nil || len(b) < n {
+			return false
+		}
+		switch b[n-1] {
+		case ' ', '\t', '\r', '\n', 0xef, 0xbb, 0xbf: // Whitespace and UTF-8 BOM.
+			continue
+		case '{':
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// ReadTemplate reads an object template from the reader r or returns an error.
+func ReadTemplate(r io.Reader) (*Template, error) {
+	out := new(Template)
+	if err := xml.NewDecoder(r).Decode(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReadTemplateFile reads an object template from a file path or returns an error.
+func ReadTemplateFile(path string) (*Template, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTemplate(f)
+}
+
+// applyTemplate fills the fields o leaves unset from the template object.
+// A tile template's GID is rebased onto the map's copy of the template tileset.
+// Tileset sources are compared after joining them with their directories,
+// and file properties of the template are made relative to the map.
+func (m *Map) applyTemplate(o *Object, tpl *Template, mapDir, tplDir string, l *Loader) {
+	t := tpl.Object
+
+	if o.Name == "" {
+		o.Name = t.Name
+	}
+	if o.Type == "" {
+		o.Type = t.Type
+	}
+	if o.Width == 0 {
+		o.Width = t.Width
+	}
+	if o.Height == 0 {
+		o.Height = t.Height
+	}
+	if o.Rotation == 0 {
+		o.Rotation = t.Rotation
+	}
+	if o.Ellipse == nil {
+		o.Ellipse = t.Ellipse
+	}
+	if o.Point == nil {
+		o.Point = t.Point
+	}
+	if o.Polygons == nil {
+		o.Polygons = t.Polygons
+	}
+	if o.PolyLines == nil {
+		o.PolyLines = t.PolyLines
+	}
+	if o.Text == nil && t.Text != nil {
+		text := *t.Text
+		o.Text = &text
+	}
+	o.Properties = mergeProperties(l.rebaseFiles(t.Properties, tplDir, mapDir), o.Properties)
+
+	if o.GID == 0 && t.GID != 0 && tpl.Tileset != nil {
+		tplSource := l.join(tplDir, tpl.Tileset.Source)
+		for i := 0; i < len(m.Tilesets); i++ {
+			ts := m.Tilesets[i]
+			if ts.Source != "" && l.join(mapDir, ts.Source) == tplSource {
+				o.GID = t.GID - tpl.Tileset.FirstGID + ts.FirstGID
+				o.HorizontalFlip, o.VerticalFlip = t.HorizontalFlip, t.VerticalFlip
+				break
+			}
+		}
+	}
+}
+
+// rebaseFiles returns props with the relative file properties referenced
+// from the directory from made relative to the directory to, including those
+// of class members. props is copied before any change.
+func (l *Loader) rebaseFiles(props Properties, from, to string) Properties {
+	if from == to {
+		return props
+	}
+	var out Properties
+	for i, p := range props {
+		switch {
+		case p.Type == "file" && p.Value != "" && !filepath.IsAbs(p.Value) && !path.IsAbs(p.Value):
+			p.Value = l.rel(to, l.join(from, p.Value))
+		case p.Type == "class":
+			p.Properties = l.rebaseFiles(p.Properties, from, to)
+		default:
+			continue
+		}
+		if out == nil {
+			out = cloneProperties(props)
+		}
+		out[i] = p
+	}
+	if out == nil {
+		return props
+	}
+	return out
+}
+
+// mergeProperties returns base with the same-named entries replaced by those in overrides.
+func mergeProperties(base, overrides Properties) Properties {
+	if len(base) == 0 {
+		return overrides
+	}
+	out := make(Properties, 0, len(base)+len(overrides))
+	for _, p := range base {
+		overridden := false
+		for _, q := range overrides {
+			if q.Name == p.Name {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			out = append(out, p)
+		}
+	}
+	return append(out, overrides...)
+}
@@ -0,0 +1,29 @@
+package tmx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadFileExternal(t *testing.T) {
+	m, err := ReadFile("testdata/external.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := m.Tilesets[1]
+	if ts.Name != "external" || ts.FirstGID != 29 || ts.Source != "tiles.tsx" || ts.Tilecount != 28 {
+		t.Errorf("external tileset = %+v", ts)
+	}
+
+	o := m.ObjectGroups[0].Objects[0]
+	if o.Name != "crate" || o.Type != "prop" || o.Width != 8 || o.Height != 8 {
+		t.Errorf("templated object = %+v", o)
+	}
+	if o.GID != 31 {
+		t.Errorf("templated object GID = %d, want 31", o.GID)
+	}
+	if want := (Properties{{Name: "weight", Value: "10"}, {Name: "color", Value: "red"}}); !reflect.DeepEqual(o.Properties, want) {
+		t.Errorf("templated object properties = %+v", o.Properties)
+	}
+}
@@ -0,0 +1,140 @@
+package tmx
+
+import (
+	"image"
+	"slices"
+)
+
+// Extract returns a new map holding the cells of the map within rect, which
+// is clipped to the map, and the objects placed on them. All layers are kept
+// with their IDs, tile layers cropped to rect and objects and image layers
+// moved with the cells. The new map only holds the tilesets its tiles use,
+// with first GIDs assigned in order and GIDs rebased accordingly. The map is
+// not changed.
+func (m *Map) Extract(rect image.Rectangle) (*Map, error) {
+	rect = rect.Intersect(image.Rect(0, 0, m.Width, m.Height))
+	if rect.Empty() {
+		return nil, ErrInvalidSize
+	}
+
+	// The tilesets of the remaining tiles are kept.
+	used := make(map[GID]bool)
+	use := func(gid GID) error {
+		if gid&GIDMask == 0 {
+			return nil
+		}
+		ts := m.TilesetForGID(gid & GIDMask)
+		if ts == nil {
+			return ErrInvalidGID
+		}
+		used[ts.FirstGID] = true
+		return nil
+	}
+	cells := make([][]GID, len(m.Layers))
+	for i := 0; i < len(m.Layers); i++ {
+		l := &m.Layers[i]
+		gids, err := l.Decoded()
+		if err != nil {
+			return nil, err
+		}
+		out := make([]GID, 0, rect.Dx()*rect.Dy())
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				if x >= l.Width || y >= l.Height {
+					out = append(out, 0)
+					continue
+				}
+				j := y*l.Width + x
+				if err := use(gids[j]); err != nil {
+					return nil, l.errorAt(j, err)
+				}
+				out = append(out, gids[j])
+			}
+		}
+		cells[i] = out
+	}
+	dx, dy := m.objectOffset(rect.Min.Mul(-1))
+	groups := make([]ObjectGroup, len(m.ObjectGroups))
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		g := m.ObjectGroups[i]
+		g.Objects = nil
+		for _, o := range m.ObjectGroups[i].Objects {
+			if x, y := m.PixelToTile(m.ObjectToPixel(o.X, o.Y)); image.Pt(x, y).In(rect) {
+				o = o.Clone()
+				o.X += dx
+				o.Y += dy
+				g.Objects = append(g.Objects, o)
+			}
+		}
+		g.Properties = cloneProperties(g.Properties)
+		groups[i] = g
+	}
+
+	for _, g := range groups {
+		for _, o := range g.Objects {
+			if err := use(o.GID); err != nil {
+				return nil, &ObjectError{o.ID, err}
+			}
+		}
+	}
+	out := *m
+	out.Width, out.Height = rect.Dx(), rect.Dy()
+	out.Properties = cloneProperties(m.Properties)
+	out.Tilesets = nil
+	out.pos = nil
+	firstGIDs := make(map[GID]GID)
+	next := GID(1)
+	for i := range m.Tilesets {
+		if !used[m.Tilesets[i].FirstGID] {
+			continue
+		}
+		ts := m.Tilesets[i].Clone()
+		firstGIDs[ts.FirstGID] = next
+		ts.FirstGID = next
+		next += m.span(i)
+		out.Tilesets = append(out.Tilesets, *ts)
+	}
+	rebase := func(gid GID) GID {
+		if gid&GIDMask == 0 {
+			return gid
+		}
+		ts := m.TilesetForGID(gid & GIDMask)
+		return gid&GIDMask - ts.FirstGID + firstGIDs[ts.FirstGID] | gid&^GIDMask
+	}
+
+	out.Layers = make([]Layer, len(m.Layers))
+	for i := range m.Layers {
+		l := m.Layers[i]
+		for j, gid := range cells[i] {
+			cells[i][j] = rebase(gid)
+		}
+		d, size, err := l.encodeLike(cells[i], rect.Dx(), m.CompressionLevel)
+		if err != nil {
+			return nil, err
+		}
+		l.Width, l.Height = size.X, size.Y
+		l.Properties = cloneProperties(l.Properties)
+		l.Data, l.decoded, l.edited = d, nil, false
+		out.Layers[i] = l
+	}
+	for i := range groups {
+		for j := range groups[i].Objects {
+			o := &groups[i].Objects[j]
+			o.GID = rebase(o.GID)
+		}
+	}
+	out.ObjectGroups = groups
+	out.ImageLayers = slices.Clone(m.ImageLayers)
+	for i := range out.ImageLayers {
+		l := &out.ImageLayers[i]
+		l.OffsetX += int(dx)
+		l.OffsetY += int(dy)
+		l.Properties = cloneProperties(l.Properties)
+	}
+	out.Groups = slices.Clone(m.Groups)
+	for i := range out.Groups {
+		out.Groups[i].Properties = cloneProperties(out.Groups[i].Properties)
+	}
+	out.Order = slices.Clone(m.Order)
+	return &out, nil
+}
@@ -0,0 +1,66 @@
+package tmx
+
+import (
+	"errors"
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	m := NewMap(4, 3, 8, 8, MapOrthogonal)
+	for _, name := range []string{"a", "b", "c"} {
+		m.AddTileset(Tileset{Name: name, TileWidth: 8, TileHeight: 8, Tilecount: 2})
+	}
+	ground := []GID{
+		1, 1, 2, 1,
+		1, 3, 4 | GIDVerticalFlip, 1,
+		1, 0, 5, 1,
+	}
+	if err := m.AddTileLayer("ground").Encode(ground, m.DataEncoder(Base64, Gzip)); err != nil {
+		t.Fatal(err)
+	}
+	g := m.AddObjectGroup("objects")
+	m.AddObject(g, Object{Name: "in", GID: 6, X: 20, Y: 12, Visible: true})
+	m.AddObject(g, Object{Name: "out", X: 4, Y: 4, Visible: true})
+	m.AddImageLayer("sky", Image{Source: "sky.png"})
+
+	sub, err := m.Extract(image.Rect(1, 1, 3, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Width != 2 || sub.Height != 2 {
+		t.Errorf("Extract() size = %dx%d, want 2x2", sub.Width, sub.Height)
+	}
+	var names []string
+	for _, ts := range sub.Tilesets {
+		names = append(names, ts.Name)
+	}
+	if want := []string{"b", "c"}; !reflect.DeepEqual(names, want) || sub.Tilesets[1].FirstGID != 3 {
+		t.Errorf("Extract() tilesets = %q, want %q", names, want)
+	}
+	want := []GID{1, 2 | GIDVerticalFlip, 0, 3}
+	if got, err := sub.Layers[0].Decode(); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() layer = %v, %v, want %v", got, err, want)
+	}
+	if d := sub.Layers[0].Data; d.Compression != Gzip || sub.Layers[0].ID != m.Layers[0].ID {
+		t.Errorf("Extract() layer %d data compressed with %q, want %d gzip", sub.Layers[0].ID, d.Compression, m.Layers[0].ID)
+	}
+	objects := sub.ObjectGroups[0].Objects
+	if len(objects) != 1 || objects[0].Name != "in" || objects[0].X != 12 || objects[0].Y != 4 || objects[0].GID != 4 {
+		t.Errorf("Extract() objects = %+v, want \"in\" with GID 4 at (12, 4)", objects)
+	}
+	if l := sub.ImageLayers[0]; l.OffsetX != -8 || l.OffsetY != -8 {
+		t.Errorf("Extract() image layer offset = (%d, %d), want (-8, -8)", l.OffsetX, l.OffsetY)
+	}
+	if issues := sub.Validate(); issues != nil {
+		t.Errorf("Validate() = %v, want nil", issues)
+	}
+	if got, _ := m.Layers[0].Decode(); !reflect.DeepEqual(got, ground) || len(m.ObjectGroups[0].Objects) != 2 || m.Width != 4 {
+		t.Error("Extract() changed the map")
+	}
+
+	if _, err := m.Extract(image.Rect(4, 0, 6, 2)); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Extract() outside of the map = %v, want %v", err, ErrInvalidSize)
+	}
+}
@@ -0,0 +1,81 @@
+package tmx
+
+// FlipHorizontal mirrors the tiles of the layer from left to right and
+// toggles their horizontal flip, like flipping a selection in Tiled. The
+// change is kept like the edits of SetTile.
+func (l *Layer) FlipHorizontal() error {
+	w := l.Width
+	return l.transform(w, l.Height, func(x, y int) int { return y*w + w - 1 - x }, func(gid GID) GID {
+		return gid ^ GIDHorizontalFlip
+	})
+}
+
+// FlipVertical mirrors the tiles of the layer from top to bottom and toggles
+// their vertical flip like FlipHorizontal.
+func (l *Layer) FlipVertical() error {
+	w, h := l.Width, l.Height
+	return l.transform(w, h, func(x, y int) int { return (h-1-y)*w + x }, func(gid GID) GID {
+		return gid ^ GIDVerticalFlip
+	})
+}
+
+// Rotate90 rotates the tiles of the layer by 90 degrees clockwise and sets
+// their flips so each tile is rotated too, like rotating a selection in
+// Tiled. The layer becomes Height tiles wide and Width tiles high. The
+// change is kept like FlipHorizontal.
+func (l *Layer) Rotate90() error {
+	w, h := l.Width, l.Height
+	return l.transform(h, w, func(x, y int) int { return (h-1-x)*w + y }, rotateFlips)
+}
+
+// rotateRight maps the flips of a tile, as bits HVD from high to low, to
+// the flips of the tile rotated by 90 degrees clockwise.
+var rotateRight = [8]GID{5, 4, 1, 0, 7, 6, 3, 2}
+
+// rotateFlips returns gid with its flips set to rotate the tile by 90
+// degrees clockwise.
+func rotateFlips(gid GID) GID {
+	var flips GID
+	if gid&GIDHorizontalFlip != 0 {
+		flips |= 4
+	}
+	if gid&GIDVerticalFlip != 0 {
+		flips |= 2
+	}
+	if gid&GIDDiagonalFlip != 0 {
+		flips |= 1
+	}
+	flips = rotateRight[flips]
+	gid &^= GIDFlip
+	if flips&4 != 0 {
+		gid |= GIDHorizontalFlip
+	}
+	if flips&2 != 0 {
+		gid |= GIDVerticalFlip
+	}
+	if flips&1 != 0 {
+		gid |= GIDDiagonalFlip
+	}
+	return gid
+}
+
+// transform sets the layer to width by height tiles, with tile (x, y) taken
+// from the index src returns and the flips of tiles changed by flip. Empty
+// cells are kept empty.
+func (l *Layer) transform(width, height int, src func(x, y int) int, flip func(GID) GID) error {
+	gids, err := l.Decoded()
+	if err != nil {
+		return err
+	}
+	out := make([]GID, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if gid := gids[src(x, y)]; gid&GIDMask != 0 {
+				out[y*width+x] = flip(gid)
+			}
+		}
+	}
+	l.Width, l.Height = width, height
+	l.decoded, l.edited = out, true
+	return nil
+}
@@ -0,0 +1,88 @@
+package tmx
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestFlipLayer(t *testing.T) {
+	const (
+		H = GIDHorizontalFlip
+		V = GIDVerticalFlip
+		D = GIDDiagonalFlip
+	)
+	// 3 by 2 tiles:
+	// 1 2 0
+	// 4 5 6
+	src := []GID{1, 2 | H, 0, 4 | D, 5 | V | D, 6}
+	for _, tc := range []struct {
+		name string
+		fn   func(*Layer) error
+		w, h int
+		want []GID
+	}{{
+		name: "FlipHorizontal",
+		fn:   (*Layer).FlipHorizontal,
+		w:    3, h: 2,
+		want: []GID{0, 2, 1 | H, 6 | H, 5 | H | V | D, 4 | H | D},
+	}, {
+		name: "FlipVertical",
+		fn:   (*Layer).FlipVertical,
+		w:    3, h: 2,
+		want: []GID{4 | V | D, 5 | D, 6 | V, 1 | V, 2 | H | V, 0},
+	}, {
+		name: "Rotate90",
+		fn:   (*Layer).Rotate90,
+		w:    2, h: 3,
+		want: []GID{4 | H, 1 | H | D, 5, 2 | H | V | D, 6 | H | D, 0},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMap(3, 2, 16, 16, MapOrthogonal)
+			l := m.AddTileLayer("a")
+			for i, gid := range src {
+				if err := l.SetTile(i%3, i/3, gid); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tc.fn(l); err != nil {
+				t.Fatal(err)
+			}
+			if l.Width != tc.w || l.Height != tc.h {
+				t.Errorf("size = %dx%d, want %dx%d", l.Width, l.Height, tc.w, tc.h)
+			}
+			var buf bytes.Buffer
+			if err := Write(&buf, m); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Read(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gids, err := got.Layers[0].Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(gids, tc.want) {
+				t.Errorf("tiles = %v, want %v", gids, tc.want)
+			}
+		})
+	}
+}
+
+func TestRotate90Flips(t *testing.T) {
+	// Four rotations restore every combination of flips.
+	for flips := GID(0); flips < 8; flips++ {
+		gid := 7 | flips<<29
+		got := gid
+		for range 4 {
+			got = rotateFlips(got)
+		}
+		if got != gid {
+			t.Errorf("4 rotations of %#x = %#x", gid, got)
+		}
+		if rotateFlips(rotateFlips(gid)) != gid^GIDHorizontalFlip^GIDVerticalFlip {
+			t.Errorf("2 rotations of %#x do not flip it both ways", gid)
+		}
+	}
+}
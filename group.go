@@ -0,0 +1,312 @@
+package tmx
+
+import (
+	"encoding/xml"
+	"image/color"
+	"io"
+)
+
+// Group models a v1.2 <group> layer. The layers nested in a group are
+// flattened into the map and refer to the group by Parent.
+// See: https://doc.mapeditor.org/en/stable/reference/tmx-map-format/#group.
+type Group struct {
+	ID         ID         `xml:"id,attr"`
+	Name       string     `xml:"name,attr"`
+	OffsetX    int        `xml:"offsetx,attr"`
+	OffsetY    int        `xml:"offsety,attr"`
+	Opacity    float32    `xml:"opacity,attr"`
+	Visible    bool       `xml:"visible,attr"`
+	TintColor  Color      `xml:"tintcolor,attr"`
+	ParallaxX  float64    `xml:"parallaxx,attr"`
+	ParallaxY  float64    `xml:"parallaxy,attr"`
+	Properties Properties `xml:"properties>property"`
+	Parent     ID         `xml:"-"` // ID of the parent group or 0 at the top level.
+}
+
+// xmlGroup is a <group> element or the <map> root along with its child
+// layers in document order. Children are a *Layer, *ObjectGroup,
+// *ImageLayer or *xmlGroup.
+type xmlGroup struct {
+	Group
+	children []any
+}
+
+// UnmarshalXML decodes the group, defaulting Visible, Opacity and parallax
+// factors when absent.
+func (g *xmlGroup) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	g.Group = Group{Visible: true, Opacity: 1, ParallaxX: 1, ParallaxY: 1}
+	if err := decodeAttrs(start, &g.Group); err != nil {
+		return err
+	}
+	return decodeChildren(d, func(se xml.StartElement) error {
+		if se.Name.Local == "properties" {
+			return d.DecodeElement(&propertyList{&g.Properties}, &se)
+		}
+		return g.decodeChild(d, se)
+	})
+}
+
+// decodeChild decodes the child layer se, skipping other elements.
+func (g *xmlGroup) decodeChild(d *xml.Decoder, se xml.StartElement) error {
+	var child any
+	switch se.Name.Local {
+	case "layer":
+		child = new(Layer)
+	case "objectgroup":
+		child = new(ObjectGroup)
+	case "imagelayer":
+		child = new(ImageLayer)
+	case "group":
+		child = new(xmlGroup)
+	default:
+		return d.Skip()
+	}
+	if err := d.DecodeElement(child, &se); err != nil {
+		return err
+	}
+	g.children = append(g.children, child)
+	return nil
+}
+
+// propertyList decodes a <properties> element, appending to the list.
+type propertyList struct {
+	list *Properties
+}
+
+func (p propertyList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	v := struct {
+		Properties Properties `xml:"property"`
+	}{}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*p.list = append(*p.list, v.Properties...)
+	return nil
+}
+
+// UnmarshalXML decodes the map, flattening the children of group layers and
+// recording their document order in Order.
+func (m *Map) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type tmxMap Map
+	if err := decodeAttrs(start, (*tmxMap)(m)); err != nil {
+		return err
+	}
+	var root xmlGroup
+	err := decodeChildren(d, func(se xml.StartElement) error {
+		switch se.Name.Local {
+		case "properties":
+			return d.DecodeElement(&propertyList{&m.Properties}, &se)
+		case "tileset":
+			var ts Tileset
+			if err := d.DecodeElement(&ts, &se); err != nil {
+				return err
+			}
+			m.Tilesets = append(m.Tilesets, ts)
+			return nil
+		}
+		return root.decodeChild(d, se)
+	})
+	if err != nil {
+		return err
+	}
+	next := root.maxID(m.maxLayerID()) + 1
+	m.addChildren(root.children, 0, &next)
+	return nil
+}
+
+// decodeAttrs decodes the attributes of start into v, ignoring its children.
+func decodeAttrs(start xml.StartElement, v any) error {
+	ts := tokens{start, start.End()}
+	return xml.NewTokenDecoder(&ts).Decode(v)
+}
+
+// tokens is a xml.TokenReader returning a fixed list of tokens.
+type tokens []xml.Token
+
+func (ts *tokens) Token() (xml.Token, error) {
+	if len(*ts) == 0 {
+		return nil, io.EOF
+	}
+	t := (*ts)[0]
+	*ts = (*ts)[1:]
+	return t, nil
+}
+
+// decodeChildren calls fn with each child element of the element being
+// decoded until its end. fn must consume the child.
+func decodeChildren(d *xml.Decoder, fn func(xml.StartElement) error) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if err := fn(t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// maxLayerID returns the largest ID of the map's layers.
+func (m *Map) maxLayerID() ID {
+	var max ID
+	for _, r := range m.defaultOrder() {
+		if id := m.layerID(r); id > max {
+			max = id
+		}
+	}
+	return max
+}
+
+// layerID returns the ID of the layer r refers to.
+func (m *Map) layerID(r LayerRef) ID {
+	switch r.Kind {
+	case TileLayerKind:
+		return m.Layers[r.Index].ID
+	case ObjectGroupKind:
+		return m.ObjectGroups[r.Index].ID
+	case ImageLayerKind:
+		return m.ImageLayers[r.Index].ID
+	}
+	return m.Groups[r.Index].ID
+}
+
+// maxID returns the largest of max and the IDs of the group's children.
+func (g *xmlGroup) maxID(max ID) ID {
+	for _, c := range g.children {
+		var id ID
+		switch c := c.(type) {
+		case *Layer:
+			id = c.ID
+		case *ObjectGroup:
+			id = c.ID
+		case *ImageLayer:
+			id = c.ID
+		case *xmlGroup:
+			id = c.ID
+			max = c.maxID(max)
+		}
+		if id > max {
+			max = id
+		}
+	}
+	return max
+}
+
+// addChildren adds the children of the group parent to the map in order,
+// recursing into groups. Groups without an ID are assigned next, which is
+// then incremented.
+func (m *Map) addChildren(children []any, parent ID, next *ID) {
+	for _, c := range children {
+		switch c := c.(type) {
+		case *Layer:
+			c.Parent = parent
+			m.Order = append(m.Order, LayerRef{TileLayerKind, len(m.Layers)})
+			m.Layers = append(m.Layers, *c)
+		case *ObjectGroup:
+			c.Parent = parent
+			m.Order = append(m.Order, LayerRef{ObjectGroupKind, len(m.ObjectGroups)})
+			m.ObjectGroups = append(m.ObjectGroups, *c)
+		case *ImageLayer:
+			c.Parent = parent
+			m.Order = append(m.Order, LayerRef{ImageLayerKind, len(m.ImageLayers)})
+			m.ImageLayers = append(m.ImageLayers, *c)
+		case *xmlGroup:
+			g := c.Group
+			if g.ID == 0 {
+				g.ID = *next
+				*next++
+			}
+			g.Parent = parent
+			m.Order = append(m.Order, LayerRef{GroupKind, len(m.Groups)})
+			m.Groups = append(m.Groups, g)
+			m.addChildren(c.children, g.ID, next)
+		}
+	}
+}
+
+// Group returns the group layer with the given ID or nil.
+func (m *Map) Group(id ID) *Group {
+	if id == 0 {
+		return nil
+	}
+	for i := 0; i < len(m.Groups); i++ {
+		if m.Groups[i].ID == id {
+			return &m.Groups[i]
+		}
+	}
+	return nil
+}
+
+// parents calls fn with each group containing the group parent, innermost first.
+// Cyclic parents are visited once.
+func (m *Map) parents(parent ID, fn func(g *Group)) {
+	for i := 0; i <= len(m.Groups); i++ {
+		g := m.Group(parent)
+		if g == nil {
+			return
+		}
+		fn(g)
+		parent = g.Parent
+	}
+}
+
+// EffectiveVisible reports whether l and all of its parent groups are visible.
+func (m *Map) EffectiveVisible(l AnyLayer) bool {
+	c := l.common()
+	visible := c.visible
+	m.parents(c.parent, func(g *Group) { visible = visible && g.Visible })
+	return visible
+}
+
+// EffectiveOpacity returns the opacity of l multiplied by those of its parent groups.
+func (m *Map) EffectiveOpacity(l AnyLayer) float32 {
+	c := l.common()
+	opacity := c.opacity
+	m.parents(c.parent, func(g *Group) { opacity *= g.Opacity })
+	return opacity
+}
+
+// EffectiveOffset returns the offset of l plus those of its parent groups.
+func (m *Map) EffectiveOffset(l AnyLayer) (x, y int) {
+	c := l.common()
+	x, y = c.offsetX, c.offsetY
+	m.parents(c.parent, func(g *Group) { x, y = x+g.OffsetX, y+g.OffsetY })
+	return x, y
+}
+
+// EffectiveTint returns the tint color of l multiplied by those of its parent
+// groups. Layers without a tint color have a white tint. Invalid colors are ignored.
+func (m *Map) EffectiveTint(l AnyLayer) color.NRGBA {
+	c := l.common()
+	tint := multiplyTint(color.NRGBA{0xff, 0xff, 0xff, 0xff}, c.tint)
+	m.parents(c.parent, func(g *Group) { tint = multiplyTint(tint, g.TintColor) })
+	return tint
+}
+
+// EffectiveParallax returns the parallax factors of l multiplied by those of
+// its parent groups.
+// See: https://doc.mapeditor.org/en/stable/manual/layers/#parallax-scrolling-factor.
+func (m *Map) EffectiveParallax(l AnyLayer) (x, y float64) {
+	c := l.common()
+	x, y = c.parallaxX, c.parallaxY
+	m.parents(c.parent, func(g *Group) { x, y = x*g.ParallaxX, y*g.ParallaxY })
+	return x, y
+}
+
+// multiplyTint returns c multiplied by the tint color s.
+func multiplyTint(c color.NRGBA, s Color) color.NRGBA {
+	if s == "" {
+		return c
+	}
+	t, err := s.NRGBA()
+	if err != nil {
+		return c
+	}
+	mul := func(a, b uint8) uint8 { return uint8((uint32(a)*uint32(b) + 127) / 255) }
+	return color.NRGBA{mul(c.R, t.R), mul(c.G, t.G), mul(c.B, t.B), mul(c.A, t.A)}
+}
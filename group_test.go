@@ -0,0 +1,60 @@
+package tmx
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestReadGroups(t *testing.T) {
+	m, err := ReadFile("testdata/groups.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Groups) != 2 || len(m.Layers) != 2 || len(m.ObjectGroups) != 1 {
+		t.Fatalf("groups = %+v, layers = %d, object groups = %d", m.Groups, len(m.Layers), len(m.ObjectGroups))
+	}
+	outer, inner := m.Group(2), m.Group(3)
+	if outer == nil || outer.Name != "Outer" || outer.Parent != 0 || !outer.Visible || outer.Opacity != 0.5 {
+		t.Errorf("outer group = %+v", outer)
+	}
+	if inner == nil || inner.Name != "Inner" || inner.Parent != 2 || inner.Visible || inner.Opacity != 1 {
+		t.Errorf("inner group = %+v", inner)
+	}
+	if l := m.Layers[1]; l.Name != "Nested" || l.Parent != 3 {
+		t.Errorf("nested layer = %+v", l)
+	}
+	if g := m.ObjectGroups[0]; g.Parent != 2 {
+		t.Errorf("object group parent = %d, want 2", g.Parent)
+	}
+
+	top, nested := &m.Layers[0], &m.Layers[1]
+	if !m.EffectiveVisible(top) || m.EffectiveVisible(nested) {
+		t.Error("EffectiveVisible() does not fold in parent groups")
+	}
+	if got := m.EffectiveOpacity(nested); got != 0.5 {
+		t.Errorf("EffectiveOpacity() = %v, want 0.5", got)
+	}
+	if x, y := m.EffectiveOffset(nested); x != 5 || y != 2 {
+		t.Errorf("EffectiveOffset() = %d, %d, want 5, 2", x, y)
+	}
+	if got, want := m.EffectiveTint(nested), (color.NRGBA{0x80, 0x40, 0x40, 0x80}); got != want {
+		t.Errorf("EffectiveTint() = %v, want %v", got, want)
+	}
+	if got, want := m.EffectiveTint(top), (color.NRGBA{0xff, 0xff, 0xff, 0xff}); got != want {
+		t.Errorf("EffectiveTint(top) = %v, want %v", got, want)
+	}
+}
+
+func TestReadGroupsAssignsIDs(t *testing.T) {
+	m, err := Read(strings.NewReader(`<map version="1.2"><layer id="3"/><group><group><layer/></group></group></map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Groups) != 2 || m.Groups[0].ID != 4 || m.Groups[1].ID != 5 || m.Groups[1].Parent != 4 {
+		t.Errorf("groups = %+v", m.Groups)
+	}
+	if m.Layers[1].Parent != 5 {
+		t.Errorf("nested layer parent = %d, want 5", m.Layers[1].Parent)
+	}
+}
@@ -0,0 +1,94 @@
+package tmx
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// HTTPFS is a read-only file system serving files from below a base URL.
+// Use it as a Loader's FS to load maps and the external tilesets, templates
+// and images they reference from an asset server or CDN.
+type HTTPFS struct {
+	Base   *url.URL     // Names are resolved below the path of Base.
+	Client *http.Client // If nil, http.DefaultClient is used.
+}
+
+// NewHTTPLoader returns a loader reading from below the base URL with client.
+// If client is nil, http.DefaultClient is used.
+func NewHTTPLoader(base string, client *http.Client) (*Loader, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	return NewLoader(&HTTPFS{Base: u, Client: client}), nil
+}
+
+// Open fetches the named file with a GET request.
+// Responses with status 404 fail with an error wrapping fs.ErrNotExist.
+func (h *HTTPFS) Open(name string) (fs.File, error) {
+	return h.OpenContext(context.Background(), name)
+}
+
+// OpenContext is like Open but sends the request with ctx.
+func (h *HTTPFS) OpenContext(ctx context.Context, name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	u := *h.Base
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	u.Path = path.Join(u.Path, name)
+	u.RawPath = ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case resp.StatusCode/100 != 2:
+		resp.Body.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fmt.Errorf("tmx: unexpected HTTP status %q", resp.Status)}
+	}
+	return &httpFile{name: name, resp: resp}, nil
+}
+
+// httpFile is the response body of an opened HTTPFS file.
+type httpFile struct {
+	name string
+	resp *http.Response
+}
+
+func (f *httpFile) Read(p []byte) (int, error) { return f.resp.Body.Read(p) }
+func (f *httpFile) Close() error               { return f.resp.Body.Close() }
+func (f *httpFile) Stat() (fs.FileInfo, error) { return httpFileInfo{f}, nil }
+
+// httpFileInfo describes an httpFile from its response headers.
+type httpFileInfo struct{ f *httpFile }
+
+func (fi httpFileInfo) Name() string      { return path.Base(fi.f.name) }
+func (fi httpFileInfo) Size() int64       { return fi.f.resp.ContentLength }
+func (fi httpFileInfo) Mode() fs.FileMode { return 0444 }
+func (fi httpFileInfo) IsDir() bool       { return false }
+func (fi httpFileInfo) Sys() interface{}  { return fi.f.resp }
+func (fi httpFileInfo) ModTime() time.Time {
+	t, _ := http.ParseTime(fi.f.resp.Header.Get("Last-Modified"))
+	return t
+}
@@ -0,0 +1,65 @@
+package tmx
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPLoader(t *testing.T) {
+	srv := httptest.NewServer(http.StripPrefix("/assets/", http.FileServer(http.Dir("testdata"))))
+	defer srv.Close()
+
+	l, err := NewHTTPLoader(srv.URL+"/assets", srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := l.ReadFile("world/map_0_0.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := m.Tilesets[0]
+	if ts.Name != "external" {
+		t.Errorf("tileset = %+v", ts)
+	}
+	img, err := l.Image(ts.Image.ResolvedSource())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 112 || b.Dy() != 16 {
+		t.Errorf("image bounds = %v", b)
+	}
+
+	if _, err := l.ReadFile("missing.tmx"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile(missing) error = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := l.ReadFile("../testdata/external.tmx"); err == nil {
+		t.Error("ReadFile() read outside the base URL")
+	}
+}
+
+func TestHTTPLoaderDeadline(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	l, err := NewHTTPLoader(srv.URL, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.ReadFileContext(ctx, "map.tmx"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ReadFileContext() error = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,48 @@
+package tmx
+
+import (
+	"context"
+	"image"
+	"image/color"
+)
+
+// KeyedImage returns a copy of src with the pixels of the transparent color
+// trans cleared, as declared by the trans attribute of an image for formats
+// without alpha. It returns src unchanged if trans is empty.
+func KeyedImage(src image.Image, trans Color) (image.Image, error) {
+	if trans == "" {
+		return src, nil
+	}
+	key, err := trans.NRGBA()
+	if err != nil {
+		return nil, err
+	}
+
+	b := src.Bounds()
+	out := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			if c == key {
+				c = color.NRGBA{}
+			}
+			out.SetNRGBA(x, y, c)
+		}
+	}
+	return out, nil
+}
+
+// DecodeImage reads and decodes the image referenced by img and applies its
+// transparent color. The source is resolved against the file referencing img.
+func (l *Loader) DecodeImage(img Image) (image.Image, error) {
+	return l.DecodeImageContext(context.Background(), img)
+}
+
+// DecodeImageContext is like DecodeImage but stops loading once ctx is done.
+func (l *Loader) DecodeImageContext(ctx context.Context, img Image) (image.Image, error) {
+	src, err := l.ImageContext(ctx, img.ResolvedSource())
+	if err != nil {
+		return nil, err
+	}
+	return KeyedImage(src, img.Trans)
+}
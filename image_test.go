@@ -0,0 +1,52 @@
+package tmx
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestKeyedImage(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.RGBA{0xff, 0x00, 0xff, 0xff})
+	src.Set(1, 0, color.RGBA{0x10, 0x20, 0x30, 0xff})
+
+	for _, trans := range []Color{"ff00ff", "#ff00ff"} {
+		img, err := KeyedImage(src, trans)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+			t.Errorf("%s: keyed pixel alpha = %d, want 0", trans, a)
+		}
+		if got := color.NRGBAModel.Convert(img.At(1, 0)); got != (color.NRGBA{0x10, 0x20, 0x30, 0xff}) {
+			t.Errorf("%s: pixel (1,0) = %v", trans, got)
+		}
+	}
+
+	if img, err := KeyedImage(src, ""); err != nil || img != image.Image(src) {
+		t.Errorf("KeyedImage(no trans) = %v, %v, want src", img, err)
+	}
+	if _, err := KeyedImage(src, "pink"); err != ErrInvalidColor {
+		t.Errorf("KeyedImage(invalid) error = %v, want ErrInvalidColor", err)
+	}
+}
+
+func TestLoaderDecodeImage(t *testing.T) {
+	m, err := ReadFile("testdata/external.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := m.Tilesets[0]
+	ts.Image.Trans = "000000"
+	img, err := new(Loader).DecodeImage(ts.Image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 112 || b.Dy() != 16 {
+		t.Errorf("image bounds = %v", b)
+	}
+	if _, ok := img.(*image.NRGBA); !ok {
+		t.Errorf("keyed image type = %T, want *image.NRGBA", img)
+	}
+}
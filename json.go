@@ -0,0 +1,1253 @@
+package tmx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The json types mirror Tiled's JSON map format.
+// See: https://doc.mapeditor.org/en/stable/reference/json-map-format/.
+
+type jsonMap struct {
+	Type             string         `json:"type"`
+	Version          jsonString     `json:"version,omitempty"`
+	TiledVersion     string         `json:"tiledversion,omitempty"`
+	Orientation      MapOrientation `json:"orientation"`
+	RenderOrder      MapRenderOrder `json:"renderorder,omitempty"`
+	CompressionLevel *int           `json:"compressionlevel,omitempty"`
+	Width            int            `json:"width"`
+	Height           int            `json:"height"`
+	TileWidth        int            `json:"tilewidth"`
+	TileHeight       int            `json:"tileheight"`
+	HexSideLength    int            `json:"hexsidelength,omitempty"`
+	StaggerAxis      StaggerAxis    `json:"staggeraxis,omitempty"`
+	StaggerIndex     StaggerIndex   `json:"staggerindex,omitempty"`
+	ParallaxOriginX  float64        `json:"parallaxoriginx,omitempty"`
+	ParallaxOriginY  float64        `json:"parallaxoriginy,omitempty"`
+	BackgroundColor  Color          `json:"backgroundcolor,omitempty"`
+	Infinite         bool           `json:"infinite"`
+	NextLayerID      ID             `json:"nextlayerid,omitempty"`
+	NextObjectID     ID             `json:"nextobjectid,omitempty"`
+	Properties       []jsonProperty `json:"properties,omitempty"`
+	Tilesets         []jsonTileset  `json:"tilesets"`
+	Layers           []jsonLayer    `json:"layers"`
+}
+
+type jsonProperty struct {
+	Name         string          `json:"name"`
+	Type         string          `json:"type"`
+	PropertyType string          `json:"propertytype,omitempty"`
+	Value        json.RawMessage `json:"value"`
+}
+
+type jsonTileset struct {
+	FirstGID         GID            `json:"firstgid,omitempty"`
+	Source           string         `json:"source,omitempty"`
+	Type             string         `json:"type,omitempty"`
+	Name             string         `json:"name,omitempty"`
+	TileWidth        int            `json:"tilewidth,omitempty"`
+	TileHeight       int            `json:"tileheight,omitempty"`
+	Spacing          int            `json:"spacing,omitempty"`
+	Margin           int            `json:"margin,omitempty"`
+	TileCount        int            `json:"tilecount,omitempty"`
+	Columns          int            `json:"columns,omitempty"`
+	TileOffset       *TileOffset    `json:"tileoffset,omitempty"`
+	Grid             *jsonGrid      `json:"grid,omitempty"`
+	Properties       []jsonProperty `json:"properties,omitempty"`
+	Image            string         `json:"image,omitempty"`
+	ImageWidth       int            `json:"imagewidth,omitempty"`
+	ImageHeight      int            `json:"imageheight,omitempty"`
+	TransparentColor Color          `json:"transparentcolor,omitempty"`
+	Terrains         []jsonTerrain  `json:"terrains,omitempty"`
+	Tiles            []jsonTile     `json:"tiles,omitempty"`
+	WangSets         []jsonWangSet  `json:"wangsets,omitempty"`
+}
+
+type jsonGrid struct {
+	Orientation TileOrientation `json:"orientation"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+}
+
+type jsonTerrain struct {
+	Name       string         `json:"name"`
+	Tile       ID             `json:"tile"`
+	Properties []jsonProperty `json:"properties,omitempty"`
+}
+
+type jsonTile struct {
+	ID          ID             `json:"id"`
+	Type        string         `json:"type,omitempty"`
+	Terrain     []int          `json:"terrain,omitempty"`
+	Probability *float32       `json:"probability,omitempty"`
+	Properties  []jsonProperty `json:"properties,omitempty"`
+	Image       string         `json:"image,omitempty"`
+	ImageWidth  int            `json:"imagewidth,omitempty"`
+	ImageHeight int            `json:"imageheight,omitempty"`
+	ObjectGroup *jsonLayer     `json:"objectgroup,omitempty"`
+	Animation   []Frame        `json:"animation,omitempty"`
+}
+
+type jsonWangSet struct {
+	Name         string          `json:"name"`
+	Type         string          `json:"type,omitempty"`
+	Tile         ID              `json:"tile"`
+	CornerColors []jsonWangColor `json:"cornercolors,omitempty"`
+	EdgeColors   []jsonWangColor `json:"edgecolors,omitempty"`
+	Colors       []jsonWangColor `json:"colors,omitempty"`
+	WangTiles    []jsonWangTile  `json:"wangtiles,omitempty"`
+}
+
+type jsonWangColor struct {
+	Name        string  `json:"name"`
+	Color       string  `json:"color"`
+	TileID      ID      `json:"tile"`
+	Probability float32 `json:"probability"`
+}
+
+type jsonWangTile struct {
+	TileID ID     `json:"tileid"`
+	WangID [8]int `json:"wangid"`
+}
+
+type jsonLayer struct {
+	Type        string           `json:"type"`
+	ID          ID               `json:"id,omitempty"`
+	Name        string           `json:"name"`
+	Width       int              `json:"width,omitempty"`
+	Height      int              `json:"height,omitempty"`
+	X           int              `json:"x"`
+	Y           int              `json:"y"`
+	Opacity     *float32         `json:"opacity,omitempty"`
+	Visible     *bool            `json:"visible,omitempty"`
+	OffsetX     int              `json:"offsetx,omitempty"`
+	OffsetY     int              `json:"offsety,omitempty"`
+	TintColor   Color            `json:"tintcolor,omitempty"`
+	ParallaxX   *float64         `json:"parallaxx,omitempty"`
+	ParallaxY   *float64         `json:"parallaxy,omitempty"`
+	Color       Color            `json:"color,omitempty"`
+	DrawOrder   DrawOrder        `json:"draworder,omitempty"`
+	Encoding    LayerEncoding    `json:"encoding,omitempty"`
+	Compression LayerCompression `json:"compression,omitempty"`
+	Data        json.RawMessage  `json:"data,omitempty"`
+	Chunks      []jsonChunk      `json:"chunks,omitempty"`
+	StartX      int              `json:"startx,omitempty"`
+	StartY      int              `json:"starty,omitempty"`
+	Objects     []jsonObject     `json:"objects,omitempty"`
+	Layers      []jsonLayer      `json:"layers,omitempty"`
+	Image       string           `json:"image,omitempty"`
+	ImageWidth  int              `json:"imagewidth,omitempty"`
+	ImageHeight int              `json:"imageheight,omitempty"`
+	Trans       Color            `json:"transparentcolor,omitempty"`
+	RepeatX     bool             `json:"repeatx,omitempty"`
+	RepeatY     bool             `json:"repeaty,omitempty"`
+	Properties  []jsonProperty   `json:"properties,omitempty"`
+}
+
+type jsonChunk struct {
+	Data   json.RawMessage `json:"data"`
+	X      int             `json:"x"`
+	Y      int             `json:"y"`
+	Width  int             `json:"width"`
+	Height int             `json:"height"`
+}
+
+type jsonObject struct {
+	ID         ID             `json:"id"`
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Class      string         `json:"class,omitempty"`
+	GID        GID            `json:"gid,omitempty"`
+	X          float64        `json:"x"`
+	Y          float64        `json:"y"`
+	Width      float64        `json:"width"`
+	Height     float64        `json:"height"`
+	Rotation   float64        `json:"rotation"`
+	Visible    *bool          `json:"visible,omitempty"`
+	Template   string         `json:"template,omitempty"`
+	Ellipse    bool           `json:"ellipse,omitempty"`
+	Point      bool           `json:"point,omitempty"`
+	Polygon    []jsonPoint    `json:"polygon,omitempty"`
+	Polyline   []jsonPoint    `json:"polyline,omitempty"`
+	Text       *jsonText      `json:"text,omitempty"`
+	Properties []jsonProperty `json:"properties,omitempty"`
+}
+
+type jsonText struct {
+	Text       string `json:"text"`
+	FontFamily string `json:"fontfamily,omitempty"`
+	PixelSize  *int   `json:"pixelsize,omitempty"`
+	Wrap       bool   `json:"wrap,omitempty"`
+	Color      Color  `json:"color,omitempty"`
+	Bold       bool   `json:"bold,omitempty"`
+	Italic     bool   `json:"italic,omitempty"`
+	Underline  bool   `json:"underline,omitempty"`
+	Strikeout  bool   `json:"strikeout,omitempty"`
+	Kerning    *bool  `json:"kerning,omitempty"`
+	HAlign     string `json:"halign,omitempty"`
+	VAlign     string `json:"valign,omitempty"`
+}
+
+type jsonPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// jsonString is a string Tiled may also write as a JSON number, like older map versions.
+type jsonString string
+
+func (s *jsonString) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var v string
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		*s = jsonString(v)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*s = jsonString(n)
+	return nil
+}
+
+// ReadJSON reads a map in Tiled's JSON format from the reader r or returns an error.
+// Group layers are flattened into the map's layers and object groups.
+// See: https://doc.mapeditor.org/en/stable/reference/json-map-format/.
+func ReadJSON(r io.Reader) (*Map, error) {
+	var j jsonMap
+	if err := json.NewDecoder(r).Decode(&j); err != nil {
+		return nil, err
+	}
+
+	out, err := j.toMap()
+	if err != nil {
+		return nil, err
+	}
+	if err := out.init(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// readJSONMetadata reads a JSON map like ReadJSON without its tile layer data.
+func readJSONMetadata(r io.Reader) (*Map, error) {
+	m, err := ReadJSON(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(m.Layers); i++ {
+		m.Layers[i].Data.Bytes = nil
+	}
+	return m, nil
+}
+
+// ReadJSONFile reads a JSON map from a file path or returns an error.
+// External tilesets and object templates are loaded relative to the map file.
+func ReadJSONFile(path string) (*Map, error) {
+	return new(Loader).readMap(context.Background(), path, ReadJSON, true)
+}
+
+func (j *jsonMap) toMap() (*Map, error) {
+	m := &Map{
+		Version:          string(j.Version),
+		TiledVersion:     j.TiledVersion,
+		MapOrientation:   j.Orientation,
+		MapRenderOrder:   j.RenderOrder,
+		Width:            j.Width,
+		Height:           j.Height,
+		TileWidth:        j.TileWidth,
+		TileHeight:       j.TileHeight,
+		HexSideLength:    j.HexSideLength,
+		StaggerAxis:      j.StaggerAxis,
+		StaggerIndex:     j.StaggerIndex,
+		ParallaxOriginX:  j.ParallaxOriginX,
+		ParallaxOriginY:  j.ParallaxOriginY,
+		BackgroundColor:  j.BackgroundColor,
+		Infinite:         j.Infinite,
+		NextLayerID:      j.NextLayerID,
+		NextObjectID:     j.NextObjectID,
+		CompressionLevel: DefaultCompressionLevel,
+		Properties:       propertiesFromJSON(j.Properties),
+	}
+	if j.CompressionLevel != nil {
+		m.CompressionLevel = *j.CompressionLevel
+	}
+
+	for i := 0; i < len(j.Tilesets); i++ {
+		m.Tilesets = append(m.Tilesets, j.Tilesets[i].toTileset())
+	}
+	if err := m.addJSONLayers(j.Layers, 0); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// addJSONLayers adds the layers in ls to the map in order with the given
+// parent, recursing into groups.
+func (m *Map) addJSONLayers(ls []jsonLayer, parent ID) error {
+	for i := 0; i < len(ls); i++ {
+		jl := &ls[i]
+		switch jl.Type {
+		case "tilelayer":
+			l, err := jl.toLayer(m.Infinite)
+			if err != nil {
+				return err
+			}
+			l.Parent = parent
+			m.Order = append(m.Order, LayerRef{TileLayerKind, len(m.Layers)})
+			m.Layers = append(m.Layers, l)
+		case "objectgroup":
+			g := jl.toObjectGroup()
+			g.Parent = parent
+			m.Order = append(m.Order, LayerRef{ObjectGroupKind, len(m.ObjectGroups)})
+			m.ObjectGroups = append(m.ObjectGroups, g)
+		case "imagelayer":
+			il := jl.toImageLayer()
+			il.Parent = parent
+			m.Order = append(m.Order, LayerRef{ImageLayerKind, len(m.ImageLayers)})
+			m.ImageLayers = append(m.ImageLayers, il)
+		case "group":
+			g := jl.toGroup()
+			g.Parent = parent
+			m.Order = append(m.Order, LayerRef{GroupKind, len(m.Groups)})
+			m.Groups = append(m.Groups, g)
+			if err := m.addJSONLayers(jl.Layers, g.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func propertiesFromJSON(ps []jsonProperty) Properties {
+	var out Properties
+	for _, p := range ps {
+		out = append(out, propertyFromJSON(p.Name, p.Type, p.PropertyType, p.Value))
+	}
+	return out
+}
+
+// propertyFromJSON converts a JSON property value. Class values hold a JSON
+// object of member values which become the nested Properties.
+func propertyFromJSON(name, typ, propertyType string, value json.RawMessage) Property {
+	prop := Property{Name: name, Type: typ, PropertyType: propertyType}
+	if typ == "string" {
+		prop.Type = ""
+	}
+
+	value = bytes.TrimSpace(value)
+	if len(value) > 0 && value[0] == '{' {
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(value, &members); err == nil {
+			if prop.Type == "" {
+				prop.Type = "class"
+			}
+			names := make([]string, 0, len(members))
+			for name := range members {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				prop.Properties = append(prop.Properties, propertyFromJSON(name, "", "", members[name]))
+			}
+			return prop
+		}
+	}
+
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		prop.Value = s
+	} else {
+		prop.Value = string(value)
+	}
+	return prop
+}
+
+func (j *jsonTileset) toTileset() Tileset {
+	ts := Tileset{
+		FirstGID:   j.FirstGID,
+		Source:     j.Source,
+		Name:       j.Name,
+		TileWidth:  j.TileWidth,
+		TileHeight: j.TileHeight,
+		Spacing:    j.Spacing,
+		Margin:     j.Margin,
+		Tilecount:  j.TileCount,
+		Columns:    j.Columns,
+		Properties: propertiesFromJSON(j.Properties),
+		Image: Image{
+			Source: j.Image,
+			Trans:  Color(strings.TrimPrefix(string(j.TransparentColor), "#")),
+			Width:  j.ImageWidth,
+			Height: j.ImageHeight,
+		},
+	}
+	if j.TileOffset != nil {
+		ts.TileOffset = *j.TileOffset
+	}
+	if j.Grid != nil {
+		ts.Grid = Grid{TileOrientation: j.Grid.Orientation, Width: j.Grid.Width, Height: j.Grid.Height}
+	}
+	for _, t := range j.Terrains {
+		ts.Terrains = append(ts.Terrains, Terrain{Name: t.Name, TileID: t.Tile, Properties: propertiesFromJSON(t.Properties)})
+	}
+	for i := 0; i < len(j.Tiles); i++ {
+		ts.Tiles = append(ts.Tiles, j.Tiles[i].toTile())
+	}
+	for _, ws := range j.WangSets {
+		ts.WangSets = append(ts.WangSets, ws.toWangSet())
+	}
+	return ts
+}
+
+func (j *jsonTile) toTile() Tile {
+	t := Tile{
+		ID:          j.ID,
+		Type:        j.Type,
+		Probability: 1,
+		Properties:  propertiesFromJSON(j.Properties),
+		Image:       Image{Source: j.Image, Width: j.ImageWidth, Height: j.ImageHeight},
+		Animation:   Animation{Frames: j.Animation},
+	}
+	if j.Probability != nil {
+		t.Probability = *j.Probability
+	}
+	if len(j.Terrain) > 0 {
+		parts := make([]string, len(j.Terrain))
+		for i, terrain := range j.Terrain {
+			if terrain >= 0 {
+				parts[i] = strconv.Itoa(terrain)
+			}
+		}
+		t.Terrain = strings.Join(parts, ",")
+	}
+	if j.ObjectGroup != nil {
+		t.ObjectGroups = []ObjectGroup{j.ObjectGroup.toObjectGroup()}
+	}
+	return t
+}
+
+func (j *jsonWangSet) toWangSet() WangSet {
+	ws := WangSet{Name: j.Name, Type: j.Type, TileID: j.Tile}
+	for _, c := range j.CornerColors {
+		ws.Corners = append(ws.Corners, WangColor(c))
+	}
+	for _, c := range j.EdgeColors {
+		ws.Edges = append(ws.Edges, WangColor(c))
+	}
+	for _, c := range j.Colors {
+		ws.Colors = append(ws.Colors, WangColor(c))
+	}
+	for _, t := range j.WangTiles {
+		var id WangID
+		for i, c := range t.WangID {
+			id |= WangID(c&0xf) << (4 * uint(i))
+		}
+		ws.Tiles = append(ws.Tiles, WangTile{TileID: t.TileID, WangID: id})
+	}
+	return ws
+}
+
+func (j *jsonLayer) toLayer(infinite bool) (Layer, error) {
+	l := Layer{
+		ID:         j.ID,
+		Name:       j.Name,
+		Width:      j.Width,
+		Height:     j.Height,
+		Opacity:    1,
+		Visible:    true,
+		OffsetX:    j.OffsetX,
+		OffsetY:    j.OffsetY,
+		TintColor:  j.TintColor,
+		Properties: propertiesFromJSON(j.Properties),
+	}
+	l.ParallaxX, l.ParallaxY = j.parallax()
+	if j.Opacity != nil {
+		l.Opacity = *j.Opacity
+	}
+	if j.Visible != nil {
+		l.Visible = *j.Visible
+	}
+
+	if infinite || len(j.Chunks) > 0 {
+		chunks := make([]Chunk, len(j.Chunks))
+		for i, c := range j.Chunks {
+			d, err := dataFromJSON(c.Data, j.Compression, c.Width)
+			if err != nil {
+				return Layer{}, err
+			}
+			chunks[i] = Chunk{c.X, c.Y, c.Width, c.Height, d}
+		}
+		l.Data = Data{Encoding: CSV, Bytes: appendChunks(nil, chunks), chunked: true}
+		if j.Encoding == Base64 {
+			l.Data.Encoding, l.Data.Compression = Base64, j.Compression
+		}
+		return l, nil
+	}
+	if len(j.Data) > 0 && j.Data[0] == '"' {
+		var err error
+		l.Data, err = dataFromJSON(j.Data, j.Compression, j.Width)
+		return l, err
+	}
+
+	var gids []GID
+	if len(j.Data) > 0 {
+		if err := json.Unmarshal(j.Data, &gids); err != nil {
+			return Layer{}, err
+		}
+	}
+	if err := l.Encode(gids, DataEncoder{Encoding: CSV}); err != nil {
+		return Layer{}, err
+	}
+	return l, nil
+}
+
+// dataFromJSON returns the JSON layer or chunk data, a base64 string or an
+// array of GIDs width tiles wide, as Base64 or CSV data.
+func dataFromJSON(data json.RawMessage, compression LayerCompression, width int) (Data, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return Data{}, err
+		}
+		return Data{Encoding: Base64, Compression: compression, Bytes: []byte(s)}, nil
+	}
+	var gids []GID
+	if err := json.Unmarshal(data, &gids); err != nil {
+		return Data{}, err
+	}
+	return DataEncoder{Encoding: CSV}.Encode(gids, width)
+}
+
+func (j *jsonLayer) toGroup() Group {
+	g := Group{
+		ID:         j.ID,
+		Name:       j.Name,
+		OffsetX:    j.OffsetX,
+		OffsetY:    j.OffsetY,
+		Opacity:    1,
+		Visible:    true,
+		TintColor:  j.TintColor,
+		Properties: propertiesFromJSON(j.Properties),
+	}
+	g.ParallaxX, g.ParallaxY = j.parallax()
+	if j.Opacity != nil {
+		g.Opacity = *j.Opacity
+	}
+	if j.Visible != nil {
+		g.Visible = *j.Visible
+	}
+	return g
+}
+
+func (j *jsonLayer) toObjectGroup() ObjectGroup {
+	g := ObjectGroup{
+		ID:         j.ID,
+		Name:       j.Name,
+		Color:      j.Color,
+		Opacity:    1,
+		Visible:    true,
+		OffsetX:    j.OffsetX,
+		OffsetY:    j.OffsetY,
+		TintColor:  j.TintColor,
+		DrawOrder:  j.DrawOrder,
+		Properties: propertiesFromJSON(j.Properties),
+	}
+	g.ParallaxX, g.ParallaxY = j.parallax()
+	if j.Opacity != nil {
+		g.Opacity = *j.Opacity
+	}
+	if j.Visible != nil {
+		g.Visible = *j.Visible
+	}
+	for i := 0; i < len(j.Objects); i++ {
+		g.Objects = append(g.Objects, j.Objects[i].toObject())
+	}
+	return g
+}
+
+func (j *jsonLayer) toImageLayer() ImageLayer {
+	l := ImageLayer{
+		ID:         j.ID,
+		Name:       j.Name,
+		OffsetX:    j.OffsetX,
+		OffsetY:    j.OffsetY,
+		Opacity:    1,
+		Visible:    true,
+		TintColor:  j.TintColor,
+		RepeatX:    j.RepeatX,
+		RepeatY:    j.RepeatY,
+		Properties: propertiesFromJSON(j.Properties),
+		Image: Image{
+			Source: j.Image,
+			Trans:  Color(strings.TrimPrefix(string(j.Trans), "#")),
+			Width:  j.ImageWidth,
+			Height: j.ImageHeight,
+		},
+	}
+	l.ParallaxX, l.ParallaxY = j.parallax()
+	if j.Opacity != nil {
+		l.Opacity = *j.Opacity
+	}
+	if j.Visible != nil {
+		l.Visible = *j.Visible
+	}
+	return l
+}
+
+// parallax returns the parallax factors of the layer, which default to 1.
+func (j *jsonLayer) parallax() (x, y float64) {
+	x, y = 1, 1
+	if j.ParallaxX != nil {
+		x = *j.ParallaxX
+	}
+	if j.ParallaxY != nil {
+		y = *j.ParallaxY
+	}
+	return x, y
+}
+
+// parallaxToJSON returns the JSON parallax factor v, omitting the default of 1.
+func parallaxToJSON(v float64) *float64 {
+	if v == 1 {
+		return nil
+	}
+	return &v
+}
+
+func (j *jsonObject) toObject() Object {
+	o := Object{
+		ID:         j.ID,
+		Name:       j.Name,
+		Type:       j.Type,
+		X:          j.X,
+		Y:          j.Y,
+		Width:      j.Width,
+		Height:     j.Height,
+		Rotation:   j.Rotation,
+		GID:        j.GID,
+		Visible:    true,
+		Template:   j.Template,
+		Properties: propertiesFromJSON(j.Properties),
+	}
+	o.setGID(o.GID)
+	if o.Type == "" {
+		o.Type = j.Class
+	}
+	if j.Visible != nil {
+		o.Visible = *j.Visible
+	}
+	if j.Ellipse {
+		o.Ellipse = new(struct{})
+	}
+	if j.Point {
+		o.Point = new(struct{})
+	}
+	if j.Polygon != nil {
+		o.Polygons = []Polygon{{Points: formatJSONPoints(j.Polygon)}}
+	}
+	if j.Polyline != nil {
+		o.PolyLines = []Polygon{{Points: formatJSONPoints(j.Polyline)}}
+	}
+	if j.Text != nil {
+		o.Text = j.Text.toText()
+	}
+	return o
+}
+
+func (j *jsonText) toText() *Text {
+	t := &Text{
+		FontFamily: j.FontFamily,
+		PixelSize:  DefaultPixelSize,
+		Wrap:       j.Wrap,
+		Color:      j.Color,
+		Bold:       j.Bold,
+		Italic:     j.Italic,
+		Underline:  j.Underline,
+		Strikeout:  j.Strikeout,
+		Kerning:    true,
+		HAlign:     j.HAlign,
+		VAlign:     j.VAlign,
+		Text:       j.Text,
+	}
+	if j.PixelSize != nil {
+		t.PixelSize = *j.PixelSize
+	}
+	if j.Kerning != nil {
+		t.Kerning = *j.Kerning
+	}
+	return t
+}
+
+// formatJSONPoints formats points in the TMX "x,y x,y" form.
+func formatJSONPoints(ps []jsonPoint) string {
+	parts := make([]string, len(ps))
+	for i, p := range ps {
+		parts[i] = formatFloat(p.X) + "," + formatFloat(p.Y)
+	}
+	return strings.Join(parts, " ")
+}
+
+// WriteJSON writes the map m to w in Tiled's JSON format or returns an error.
+// Base64 layer data is written as a string; other encodings as an array of GIDs.
+func WriteJSON(w io.Writer, m *Map) error {
+	j, err := m.toJSON()
+	if err != nil {
+		return err
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", " ")
+	return e.Encode(j)
+}
+
+// WriteJSONFile writes the map m to a JSON file path or returns an error.
+func WriteJSONFile(path string, m *Map) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteJSON(f, m); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (m *Map) toJSON() (*jsonMap, error) {
+	j := &jsonMap{
+		Type:            "map",
+		Version:         jsonString(m.Version),
+		TiledVersion:    m.TiledVersion,
+		Orientation:     m.MapOrientation,
+		RenderOrder:     m.MapRenderOrder,
+		Width:           m.Width,
+		Height:          m.Height,
+		TileWidth:       m.TileWidth,
+		TileHeight:      m.TileHeight,
+		HexSideLength:   m.HexSideLength,
+		StaggerAxis:     m.StaggerAxis,
+		StaggerIndex:    m.StaggerIndex,
+		ParallaxOriginX: m.ParallaxOriginX,
+		ParallaxOriginY: m.ParallaxOriginY,
+		BackgroundColor: m.BackgroundColor,
+		Infinite:        m.Infinite,
+		NextLayerID:     m.NextLayerID,
+		NextObjectID:    m.NextObjectID,
+		Tilesets:        []jsonTileset{},
+		Layers:          []jsonLayer{},
+	}
+	level := m.CompressionLevel
+	j.CompressionLevel = &level
+
+	var err error
+	if j.Properties, err = propertiesToJSON(m.Properties); err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(m.Tilesets); i++ {
+		ts, err := tilesetToJSON(&m.Tilesets[i], true)
+		if err != nil {
+			return nil, err
+		}
+		j.Tilesets = append(j.Tilesets, ts)
+	}
+	if j.Layers, err = m.childrenToJSON(m.DrawOrder(), 0, []jsonLayer{}); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// childrenToJSON appends the layers in order with the given parent to out.
+func (m *Map) childrenToJSON(order []LayerRef, parent ID, out []jsonLayer) ([]jsonLayer, error) {
+	for _, r := range order {
+		if m.Layer(r).common().parent != parent {
+			continue
+		}
+		switch r.Kind {
+		case TileLayerKind:
+			l, err := layerToJSON(&m.Layers[r.Index], m.CompressionLevel)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, l)
+		case ObjectGroupKind:
+			g, err := objectGroupToJSON(&m.ObjectGroups[r.Index])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, g)
+		case ImageLayerKind:
+			l, err := imageLayerToJSON(&m.ImageLayers[r.Index])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, l)
+		case GroupKind:
+			g := &m.Groups[r.Index]
+			if g.ID == parent {
+				continue
+			}
+			opacity, visible := g.Opacity, g.Visible
+			jg := jsonLayer{
+				Type:      "group",
+				ID:        g.ID,
+				Name:      g.Name,
+				Opacity:   &opacity,
+				Visible:   &visible,
+				OffsetX:   g.OffsetX,
+				OffsetY:   g.OffsetY,
+				TintColor: g.TintColor,
+				ParallaxX: parallaxToJSON(g.ParallaxX),
+				ParallaxY: parallaxToJSON(g.ParallaxY),
+			}
+			var err error
+			if jg.Properties, err = propertiesToJSON(g.Properties); err != nil {
+				return nil, err
+			}
+			if jg.Layers, err = m.childrenToJSON(order, g.ID, []jsonLayer{}); err != nil {
+				return nil, err
+			}
+			out = append(out, jg)
+		}
+	}
+	return out, nil
+}
+
+// propertiesToJSON converts properties, writing bool and numeric values as JSON literals.
+func propertiesToJSON(ps Properties) ([]jsonProperty, error) {
+	var out []jsonProperty
+	for _, p := range ps {
+		jp := jsonProperty{Name: p.Name, Type: p.Type, PropertyType: p.PropertyType}
+		if jp.Type == "" {
+			jp.Type = "string"
+		}
+		var err error
+		if jp.Value, err = propertyValueToJSON(p); err != nil {
+			return nil, err
+		}
+		out = append(out, jp)
+	}
+	return out, nil
+}
+
+// propertyValueToJSON converts the value of p. Bool and numeric values are
+// written as JSON literals, numbers as written unless their text is not valid
+// JSON, and values that do not parse or are not finite as strings.
+func propertyValueToJSON(p Property) (json.RawMessage, error) {
+	var v any = p.Value
+	switch p.Type {
+	case "class":
+		members := make(map[string]json.RawMessage, len(p.Properties))
+		for _, m := range p.Properties {
+			var err error
+			if members[m.Name], err = propertyValueToJSON(m); err != nil {
+				return nil, err
+			}
+		}
+		return json.Marshal(members)
+	case "bool":
+		if b, err := strconv.ParseBool(p.Value); err == nil {
+			v = b
+		}
+	case "int", "object":
+		if i, err := strconv.ParseInt(p.Value, 10, 64); err == nil {
+			v = i
+			break
+		}
+		fallthrough
+	case "float":
+		if f, err := strconv.ParseFloat(p.Value, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			v = f
+		}
+	}
+	switch v.(type) {
+	case int64, float64:
+		if json.Valid([]byte(p.Value)) {
+			return json.RawMessage(p.Value), nil
+		}
+	}
+	return json.Marshal(v)
+}
+
+// tilesetToJSON converts ts. Within a map, tilesets with a Source are written
+// as a reference to the external file.
+func tilesetToJSON(ts *Tileset, inMap bool) (jsonTileset, error) {
+	if inMap && ts.Source != "" {
+		return jsonTileset{FirstGID: ts.FirstGID, Source: ts.Source}, nil
+	}
+	j := jsonTileset{
+		Name:        ts.Name,
+		TileWidth:   ts.TileWidth,
+		TileHeight:  ts.TileHeight,
+		Spacing:     ts.Spacing,
+		Margin:      ts.Margin,
+		TileCount:   ts.Tilecount,
+		Columns:     ts.Columns,
+		Image:       ts.Image.Source,
+		ImageWidth:  ts.Image.Width,
+		ImageHeight: ts.Image.Height,
+	}
+	var err error
+	if j.Properties, err = propertiesToJSON(ts.Properties); err != nil {
+		return jsonTileset{}, err
+	}
+	if inMap {
+		j.FirstGID = ts.FirstGID
+	} else {
+		j.Type = "tileset"
+	}
+	if ts.Image.Trans != "" {
+		j.TransparentColor = "#" + ts.Image.Trans
+	}
+	if ts.TileOffset != (TileOffset{}) {
+		offset := ts.TileOffset
+		j.TileOffset = &offset
+	}
+	if ts.Grid != (Grid{}) {
+		j.Grid = &jsonGrid{Orientation: ts.Grid.TileOrientation, Width: ts.Grid.Width, Height: ts.Grid.Height}
+	}
+	for _, t := range ts.Terrains {
+		jt := jsonTerrain{Name: t.Name, Tile: t.TileID}
+		if jt.Properties, err = propertiesToJSON(t.Properties); err != nil {
+			return jsonTileset{}, err
+		}
+		j.Terrains = append(j.Terrains, jt)
+	}
+	for i := 0; i < len(ts.Tiles); i++ {
+		t, err := tileToJSON(&ts.Tiles[i])
+		if err != nil {
+			return jsonTileset{}, err
+		}
+		j.Tiles = append(j.Tiles, t)
+	}
+	for i := 0; i < len(ts.WangSets); i++ {
+		j.WangSets = append(j.WangSets, wangSetToJSON(&ts.WangSets[i]))
+	}
+	return j, nil
+}
+
+func tileToJSON(t *Tile) (jsonTile, error) {
+	j := jsonTile{
+		ID:          t.ID,
+		Type:        t.Type,
+		Image:       t.Image.Source,
+		ImageWidth:  t.Image.Width,
+		ImageHeight: t.Image.Height,
+		Animation:   t.Animation.Frames,
+	}
+	var err error
+	if j.Properties, err = propertiesToJSON(t.Properties); err != nil {
+		return jsonTile{}, err
+	}
+	if t.Probability != 1 {
+		p := t.Probability
+		j.Probability = &p
+	}
+	if t.Terrain != "" {
+		for _, part := range strings.Split(t.Terrain, ",") {
+			terrain, err := strconv.Atoi(part)
+			if err != nil {
+				terrain = -1
+			}
+			j.Terrain = append(j.Terrain, terrain)
+		}
+	}
+	if len(t.ObjectGroups) > 0 {
+		g, err := objectGroupToJSON(&t.ObjectGroups[0])
+		if err != nil {
+			return jsonTile{}, err
+		}
+		j.ObjectGroup = &g
+	}
+	return j, nil
+}
+
+func wangSetToJSON(ws *WangSet) jsonWangSet {
+	j := jsonWangSet{Name: ws.Name, Type: ws.Type, Tile: ws.TileID}
+	for _, c := range ws.Corners {
+		j.CornerColors = append(j.CornerColors, jsonWangColor(c))
+	}
+	for _, c := range ws.Edges {
+		j.EdgeColors = append(j.EdgeColors, jsonWangColor(c))
+	}
+	for _, c := range ws.Colors {
+		j.Colors = append(j.Colors, jsonWangColor(c))
+	}
+	for _, t := range ws.Tiles {
+		jt := jsonWangTile{TileID: t.TileID}
+		for i := range jt.WangID {
+			jt.WangID[i] = int(t.WangID>>(4*uint(i))) & 0xf
+		}
+		j.WangTiles = append(j.WangTiles, jt)
+	}
+	return j
+}
+
+func layerToJSON(l *Layer, level int) (jsonLayer, error) {
+	opacity, visible := l.Opacity, l.Visible
+	j := jsonLayer{
+		Type:      "tilelayer",
+		ID:        l.ID,
+		Name:      l.Name,
+		Width:     l.Width,
+		Height:    l.Height,
+		Opacity:   &opacity,
+		Visible:   &visible,
+		OffsetX:   l.OffsetX,
+		OffsetY:   l.OffsetY,
+		TintColor: l.TintColor,
+		ParallaxX: parallaxToJSON(l.ParallaxX),
+		ParallaxY: parallaxToJSON(l.ParallaxY),
+	}
+	var err error
+	if j.Properties, err = propertiesToJSON(l.Properties); err != nil {
+		return jsonLayer{}, err
+	}
+
+	if l.Data.Chunked() {
+		return chunksToJSON(l, j, level)
+	}
+	if l.Data.Encoding == Base64 {
+		d, _, err := l.encodedData(level)
+		if err != nil {
+			return jsonLayer{}, err
+		}
+		j.Encoding, j.Compression = Base64, d.Compression
+		j.Data, err = json.Marshal(string(bytes.TrimSpace(d.Bytes)))
+		return j, err
+	}
+
+	gids, err := l.Decode()
+	if err != nil {
+		return jsonLayer{}, err
+	}
+	if gids == nil {
+		gids = []GID{}
+	}
+	j.Data, err = json.Marshal(gids)
+	return j, err
+}
+
+// chunksToJSON sets the chunks of j to those of the chunked layer data of l
+// with its edits encoded at the compression level.
+func chunksToJSON(l *Layer, j jsonLayer, level int) (jsonLayer, error) {
+	d, size, err := l.encodedData(level)
+	if err != nil {
+		return jsonLayer{}, err
+	}
+	chunks, err := d.Chunks()
+	if err != nil {
+		return jsonLayer{}, l.errorAt(-1, err)
+	}
+	origin := chunkBounds(chunks).Min
+	j.Width, j.Height = size.X, size.Y
+	j.StartX, j.StartY = origin.X, origin.Y
+	if d.Encoding == Base64 {
+		j.Encoding, j.Compression = Base64, d.Compression
+	}
+	for _, c := range chunks {
+		jc := jsonChunk{X: c.X, Y: c.Y, Width: c.Width, Height: c.Height}
+		if d.Encoding == Base64 {
+			jc.Data, err = json.Marshal(string(bytes.TrimSpace(c.Data.Bytes)))
+		} else {
+			var gids []GID
+			if gids, err = c.DecodeInto(nil); err != nil {
+				return jsonLayer{}, l.errorAt(-1, err)
+			}
+			jc.Data, err = json.Marshal(gids)
+		}
+		if err != nil {
+			return jsonLayer{}, err
+		}
+		j.Chunks = append(j.Chunks, jc)
+	}
+	return j, nil
+}
+
+func objectGroupToJSON(g *ObjectGroup) (jsonLayer, error) {
+	opacity, visible := g.Opacity, g.Visible
+	j := jsonLayer{
+		Type:      "objectgroup",
+		ID:        g.ID,
+		Name:      g.Name,
+		Color:     g.Color,
+		Opacity:   &opacity,
+		Visible:   &visible,
+		OffsetX:   g.OffsetX,
+		OffsetY:   g.OffsetY,
+		TintColor: g.TintColor,
+		ParallaxX: parallaxToJSON(g.ParallaxX),
+		ParallaxY: parallaxToJSON(g.ParallaxY),
+		DrawOrder: g.DrawOrder,
+		Objects:   []jsonObject{},
+	}
+	var err error
+	if j.Properties, err = propertiesToJSON(g.Properties); err != nil {
+		return jsonLayer{}, err
+	}
+	for i := 0; i < len(g.Objects); i++ {
+		o, err := objectToJSON(&g.Objects[i])
+		if err != nil {
+			return jsonLayer{}, err
+		}
+		j.Objects = append(j.Objects, o)
+	}
+	return j, nil
+}
+
+func imageLayerToJSON(l *ImageLayer) (jsonLayer, error) {
+	opacity, visible := l.Opacity, l.Visible
+	j := jsonLayer{
+		Type:        "imagelayer",
+		ID:          l.ID,
+		Name:        l.Name,
+		Opacity:     &opacity,
+		Visible:     &visible,
+		OffsetX:     l.OffsetX,
+		OffsetY:     l.OffsetY,
+		TintColor:   l.TintColor,
+		ParallaxX:   parallaxToJSON(l.ParallaxX),
+		ParallaxY:   parallaxToJSON(l.ParallaxY),
+		Image:       l.Image.Source,
+		ImageWidth:  l.Image.Width,
+		ImageHeight: l.Image.Height,
+		RepeatX:     l.RepeatX,
+		RepeatY:     l.RepeatY,
+	}
+	if l.Image.Trans != "" {
+		j.Trans = "#" + l.Image.Trans
+	}
+	var err error
+	j.Properties, err = propertiesToJSON(l.Properties)
+	return j, err
+}
+
+func objectToJSON(o *Object) (jsonObject, error) {
+	visible := o.Visible
+	j := jsonObject{
+		ID:       o.ID,
+		Name:     o.Name,
+		Type:     o.Type,
+		GID:      o.RawGID(),
+		X:        o.X,
+		Y:        o.Y,
+		Width:    o.Width,
+		Height:   o.Height,
+		Rotation: o.Rotation,
+		Visible:  &visible,
+		Template: o.Template,
+		Ellipse:  o.Ellipse != nil,
+		Point:    o.Point != nil,
+	}
+	if len(o.Polygons) > 0 {
+		j.Polygon = parseJSONPoints(o.Polygons[0].Points)
+	}
+	if len(o.PolyLines) > 0 {
+		j.Polyline = parseJSONPoints(o.PolyLines[0].Points)
+	}
+	if o.Text != nil {
+		j.Text = textToJSON(o.Text)
+	}
+	var err error
+	j.Properties, err = propertiesToJSON(o.Properties)
+	return j, err
+}
+
+func textToJSON(t *Text) *jsonText {
+	j := &jsonText{
+		Text:       t.Text,
+		FontFamily: t.FontFamily,
+		Wrap:       t.Wrap,
+		Color:      t.Color,
+		Bold:       t.Bold,
+		Italic:     t.Italic,
+		Underline:  t.Underline,
+		Strikeout:  t.Strikeout,
+		HAlign:     t.HAlign,
+		VAlign:     t.VAlign,
+	}
+	if t.PixelSize != DefaultPixelSize {
+		size := t.PixelSize
+		j.PixelSize = &size
+	}
+	if !t.Kerning {
+		kerning := false
+		j.Kerning = &kerning
+	}
+	return j
+}
+
+// parseJSONPoints parses points in the TMX "x,y x,y" form, skipping malformed pairs.
+func parseJSONPoints(s string) []jsonPoint {
+	out := []jsonPoint{}
+	for _, part := range strings.Fields(s) {
+		coords := strings.Split(part, ",")
+		if len(coords) != 2 {
+			continue
+		}
+		x, errX := strconv.ParseFloat(coords[0], 64)
+		y, errY := strconv.ParseFloat(coords[1], 64)
+		if errX != nil || errY != nil {
+			continue
+		}
+		out = append(out, jsonPoint{X: x, Y: y})
+	}
+	return out
+}
+
+// ReadTilesetJSON reads an external tileset in Tiled's JSON format from the
+// reader r or returns an error.
+func ReadTilesetJSON(r io.Reader) (*Tileset, error) {
+	var j jsonTileset
+	if err := json.NewDecoder(r).Decode(&j); err != nil {
+		return nil, err
+	}
+	ts := j.toTileset()
+	ts.FirstGID = 0
+	ts.normalizeEnums()
+	return &ts, nil
+}
+
+// ReadTilesetJSONFile reads an external JSON tileset from a file path or returns an error.
+func ReadTilesetJSONFile(path string) (*Tileset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTilesetJSON(f)
+}
+
+// WriteTilesetJSON writes ts to w as a standalone JSON tileset or returns an error.
+// The FirstGID and Source of ts are not written.
+func WriteTilesetJSON(w io.Writer, ts *Tileset) error {
+	j, err := tilesetToJSON(ts, false)
+	if err != nil {
+		return err
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", " ")
+	return e.Encode(j)
+}
+
+// WriteTilesetJSONFile writes ts to a JSON tileset file path or returns an error.
+func WriteTilesetJSONFile(path string, ts *Tileset) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteTilesetJSON(f, ts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,279 @@
+package tmx
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadJSONFile(t *testing.T) {
+	m, err := ReadJSONFile("testdata/map.tmj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Version != "1.4" || m.MapOrientation != MapOrthogonal || m.Width != 2 || m.TileWidth != 8 || m.NextLayerID != 5 {
+		t.Errorf("map = %+v", m)
+	}
+	if want := (Properties{{Name: "music", Value: "theme.ogg"}, {Name: "dark", Type: "bool", Value: "true"}}); !reflect.DeepEqual(m.Properties, want) {
+		t.Errorf("Properties = %+v, want %+v", m.Properties, want)
+	}
+
+	if len(m.Tilesets) != 2 || m.Tilesets[1].Name != "external" || m.Tilesets[1].FirstGID != 29 {
+		t.Fatalf("Tilesets = %+v", m.Tilesets)
+	}
+	tile := m.Tilesets[0].Tiles[0]
+	if tile.Terrain != "0,0,,1" || tile.Probability != 1 || len(tile.Animation.Frames) != 2 || tile.Animation.Frames[1].Duration != 200 {
+		t.Errorf("tile = %+v", tile)
+	}
+
+	if len(m.Layers) != 2 {
+		t.Fatalf("len(Layers) = %d, want 2", len(m.Layers))
+	}
+	for i, want := range [][]GID{{1, 2, 3, 4}, {1, 2, 3, 4}} {
+		gids, err := m.Layers[i].Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(gids, want) {
+			t.Errorf("Layers[%d] = %v, want %v", i, gids, want)
+		}
+	}
+	if l := m.Layers[1]; l.Name != "Decor" || l.Visible || l.Opacity != 0.5 {
+		t.Errorf("Layers[1] = %+v", l)
+	}
+
+	objects := m.ObjectGroups[0].Objects
+	if objects[0].PolyLines[0].Points != "0,0 16,8" || objects[0].Type != "route" {
+		t.Errorf("objects[0] = %+v", objects[0])
+	}
+	if objects[1].GID != 29 || !reflect.DeepEqual(objects[1].Properties[0], Property{Name: "hp", Type: "int", Value: "12"}) {
+		t.Errorf("objects[1] = %+v", objects[1])
+	}
+}
+
+func TestWriteJSONRoundTrip(t *testing.T) {
+	for _, name := range []string{"testdata/map.tmj"} {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ReadJSON(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadJSON(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: round trip = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestWriteJSONFromTMX(t *testing.T) {
+	for _, name := range []string{"testdata/animated.tmx", "testdata/poly.tmx", "testdata/legacy.tmx", "testdata/groups.tmx", "testdata/imagelayer.tmx", "testdata/text.tmx", "testdata/shapes.tmx"} {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := Read(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadJSON(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// JSON holds base64 data without the surrounding XML whitespace.
+		for i := range want.Layers {
+			want.Layers[i].Data.Bytes = bytes.TrimSpace(want.Layers[i].Data.Bytes)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: TMX to JSON = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestWriteJSONTypedProperties(t *testing.T) {
+	m := &Map{Properties: []Property{
+		{Name: "a", Value: "x"},
+		{Name: "b", Type: "int", Value: "3"},
+		{Name: "c", Type: "bool", Value: "true"},
+		{Name: "d", Type: "float", Value: "oops"},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"name": "a",
+   "type": "string",
+   "value": "x"`,
+		`"value": 3`,
+		`"value": true`,
+		`"value": "oops"`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %s:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestWriteJSONPropertyValues(t *testing.T) {
+	for _, tc := range []struct {
+		typ, value string
+		want       any
+	}{
+		{"bool", "True", true},
+		{"bool", "0", false},
+		{"int", "+5", 5.0},
+		{"int", "007", 7.0},
+		{"object", "12", 12.0},
+		{"float", "1.50", 1.5},
+		{"float", "0x1p-2", 0.25},
+		{"float", "NaN", "NaN"},
+		{"float", "Inf", "Inf"},
+		{"float", "-Infinity", "-Infinity"},
+		{"int", "1e400", "1e400"},
+	} {
+		m := &Map{Properties: []Property{{Name: "p", Type: tc.typ, Value: tc.value}}}
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, m); err != nil {
+			t.Errorf("%s %q: WriteJSON() = %v", tc.typ, tc.value, err)
+			continue
+		}
+		var j struct {
+			Properties []struct {
+				Value any `json:"value"`
+			} `json:"properties"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &j); err != nil {
+			t.Fatal(err)
+		}
+		if got := j.Properties[0].Value; got != tc.want {
+			t.Errorf("%s %q: value = %#v, want %#v", tc.typ, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestWriteJSONClassPropertyValues(t *testing.T) {
+	m := &Map{Properties: []Property{{Name: "c", Type: "class", Properties: []Property{
+		{Name: "b", Type: "bool", Value: "True"},
+		{Name: "inner", Type: "class", Properties: []Property{{Name: "f", Type: "float", Value: "NaN"}}},
+	}}}}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Properties{{Name: "c", Type: "class", Properties: []Property{
+		{Name: "b", Value: "true"},
+		{Name: "inner", Type: "class", Properties: []Property{{Name: "f", Value: "NaN"}}},
+	}}}
+	if !reflect.DeepEqual(got.Properties, want) {
+		t.Errorf("class property = %+v, want %+v", got.Properties, want)
+	}
+}
+
+func TestReadTilesetJSON(t *testing.T) {
+	want, err := ReadTilesetFile("testdata/tiles.tsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadTilesetJSONFile("testdata/tiles.tsj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadTilesetJSONFile() = %+v, want %+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTilesetJSON(&buf, got); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "firstgid") {
+		t.Errorf("standalone tileset contains firstgid:\n%s", buf.String())
+	}
+	got, err = ReadTilesetJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadFileJSONTileset(t *testing.T) {
+	m, err := ReadFile("testdata/external-json.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts := m.Tilesets[1]; ts.Name != "external" || ts.FirstGID != 29 || ts.Source != "tiles.tsj" {
+		t.Errorf("external tileset = %+v", ts)
+	}
+}
+
+func TestReadTilesetSniffing(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"json.tileset": "testdata/tiles.tsj",
+		"xml.tileset":  "testdata/tiles.tsx",
+	} {
+		b, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		ts, err := new(Loader).ReadTileset(path)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if ts.Name != "external" {
+			t.Errorf("%s: Name = %q", name, ts.Name)
+		}
+	}
+}
+
+func TestJSONHexagonal(t *testing.T) {
+	want, err := ReadFile("testdata/hexagonal.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.HexSideLength != 6 || got.StaggerAxis != StaggerY || got.StaggerIndex != StaggerOdd {
+		t.Errorf("JSON hexagonal map = %+v", got)
+	}
+}
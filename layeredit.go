@@ -0,0 +1,272 @@
+package tmx
+
+import (
+	"errors"
+	"slices"
+)
+
+// Errors returned by the layer management methods.
+var (
+	ErrLayerNotFound = errors.New("tmx: layer not found")
+	ErrInvalidParent = errors.New("tmx: invalid parent group")
+)
+
+// LayerRefByID returns the reference to the layer with the given ID, or
+// false if the map has none.
+func (m *Map) LayerRefByID(id ID) (LayerRef, bool) {
+	if id == 0 {
+		return LayerRef{}, false
+	}
+	for _, r := range m.defaultOrder() {
+		if m.layerID(r) == id {
+			return r, true
+		}
+	}
+	return LayerRef{}, false
+}
+
+// RenameLayer sets the name of the layer r refers to.
+func (m *Map) RenameLayer(r LayerRef, name string) error {
+	if m.Layer(r) == nil {
+		return ErrLayerNotFound
+	}
+	*m.layerName(r) = name
+	return nil
+}
+
+// RemoveLayer removes the layer r refers to, along with the layers of groups.
+// References to the remaining layers of the same kinds and pointers into the
+// map's layer slices are invalidated; Map.Order is updated.
+func (m *Map) RemoveLayer(r LayerRef) error {
+	if m.Layer(r) == nil {
+		return ErrLayerNotFound
+	}
+	m.ensureOrder()
+	removed := map[LayerRef]bool{r: true}
+	if r.Kind == GroupKind {
+		m.descendants(m.Groups[r.Index].ID, func(c LayerRef) { removed[c] = true })
+	}
+
+	var remap [GroupKind + 1][]int
+	remap[TileLayerKind], m.Layers = deleteRefs(m.Layers, TileLayerKind, removed)
+	remap[ObjectGroupKind], m.ObjectGroups = deleteRefs(m.ObjectGroups, ObjectGroupKind, removed)
+	remap[ImageLayerKind], m.ImageLayers = deleteRefs(m.ImageLayers, ImageLayerKind, removed)
+	remap[GroupKind], m.Groups = deleteRefs(m.Groups, GroupKind, removed)
+	m.remapRefs(func(r LayerRef) (LayerRef, bool) {
+		i := remap[r.Kind][r.Index]
+		return LayerRef{r.Kind, i}, i >= 0
+	})
+	return nil
+}
+
+// deleteRefs deletes the elements of s removed as layers of kind, and
+// returns the new index of each old index, or -1 for deleted elements.
+func deleteRefs[T any](s []T, kind LayerKind, removed map[LayerRef]bool) ([]int, []T) {
+	remap := make([]int, len(s))
+	out := s[:0]
+	for i := range s {
+		if removed[LayerRef{kind, i}] {
+			remap[i] = -1
+			continue
+		}
+		remap[i] = len(out)
+		out = append(out, s[i])
+	}
+	clear(s[len(out):])
+	return remap, out
+}
+
+// remapRefs replaces the layer references of Map.Order and the recorded
+// positions by fn, dropping those it rejects.
+func (m *Map) remapRefs(fn func(LayerRef) (LayerRef, bool)) {
+	order := m.Order[:0]
+	for _, r := range m.Order {
+		if r, ok := fn(r); ok {
+			order = append(order, r)
+		}
+	}
+	m.Order = order
+	if m.pos == nil {
+		return
+	}
+	layers := make(map[LayerRef]Position, len(m.pos.layers))
+	for r, p := range m.pos.layers {
+		if r, ok := fn(r); ok {
+			layers[r] = p
+		}
+	}
+	m.pos.layers = layers
+}
+
+// DuplicateLayer adds a copy of the layer r refers to above it, named like
+// Tiled names copies, and returns the reference to the copy. Copies of groups
+// hold copies of their layers. Copies get new layer and object IDs.
+func (m *Map) DuplicateLayer(r LayerRef) (LayerRef, error) {
+	if m.Layer(r) == nil {
+		return LayerRef{}, ErrLayerNotFound
+	}
+	m.ensureOrder()
+	at := slices.Index(m.Order, r) + 1
+	for at < len(m.Order) && m.isDescendant(m.Order[at], r) {
+		at++
+	}
+	var added []LayerRef
+	dup := m.duplicate(r, *m.layerParent(r), &added)
+	*m.layerName(dup) += " copy"
+	m.Order = slices.Insert(m.Order, at, added...)
+	return dup, nil
+}
+
+// duplicate appends a copy of the layer r refers to with the given parent
+// to the map, followed by copies of its children if it is a group, adding
+// their references to added. It returns the reference to the copy.
+func (m *Map) duplicate(r LayerRef, parent ID, added *[]LayerRef) LayerRef {
+	id := m.newLayerID()
+	var out LayerRef
+	switch r.Kind {
+	case TileLayerKind:
+		l := m.Layers[r.Index]
+		l.ID, l.Parent = id, parent
+		l.Properties = cloneProperties(l.Properties)
+		l.Data.Bytes = slices.Clone(l.Data.Bytes)
+		if l.edited {
+			l.decoded = slices.Clone(l.decoded)
+		}
+		out = LayerRef{TileLayerKind, len(m.Layers)}
+		m.Layers = append(m.Layers, l)
+	case ObjectGroupKind:
+		g := m.ObjectGroups[r.Index].Clone()
+		g.ID, g.Parent = id, parent
+		for i := range g.Objects {
+			if m.NextObjectID == 0 {
+				m.NextObjectID = 1
+			}
+			g.Objects[i].ID = m.NextObjectID
+			m.NextObjectID++
+		}
+		out = LayerRef{ObjectGroupKind, len(m.ObjectGroups)}
+		m.ObjectGroups = append(m.ObjectGroups, g)
+	case ImageLayerKind:
+		l := m.ImageLayers[r.Index]
+		l.ID, l.Parent = id, parent
+		l.Properties = cloneProperties(l.Properties)
+		out = LayerRef{ImageLayerKind, len(m.ImageLayers)}
+		m.ImageLayers = append(m.ImageLayers, l)
+	case GroupKind:
+		g := m.Groups[r.Index]
+		src := g.ID
+		g.ID, g.Parent = id, parent
+		g.Properties = cloneProperties(g.Properties)
+		out = LayerRef{GroupKind, len(m.Groups)}
+		m.Groups = append(m.Groups, g)
+		*added = append(*added, out)
+		for _, c := range m.Order {
+			if *m.layerParent(c) == src {
+				m.duplicate(c, id, added)
+			}
+		}
+		return out
+	}
+	*added = append(*added, out)
+	return out
+}
+
+// MoveLayer moves the layer r refers to into the group with ID parent, or to
+// the top level if parent is 0, at position index among the layers of the
+// group from bottom to top. Indexes past the top layer move it to the top.
+// Groups cannot be moved into themselves or their layers.
+func (m *Map) MoveLayer(r LayerRef, parent ID, index int) error {
+	if m.Layer(r) == nil {
+		return ErrLayerNotFound
+	}
+	if parent != 0 {
+		p, ok := m.LayerRefByID(parent)
+		if !ok || p.Kind != GroupKind || p == r || m.isDescendant(p, r) {
+			return ErrInvalidParent
+		}
+	}
+	m.ensureOrder()
+	m.Order = slices.DeleteFunc(m.Order, func(o LayerRef) bool { return o == r })
+	*m.layerParent(r) = parent
+
+	at := len(m.Order)
+	n := 0
+	for i, o := range m.Order {
+		if *m.layerParent(o) != parent {
+			continue
+		}
+		if n == index {
+			at = i
+			break
+		}
+		n++
+		at = i + 1
+	}
+	m.Order = slices.Insert(m.Order, at, r)
+	return nil
+}
+
+// ensureOrder fills in Map.Order if it does not refer to each layer.
+func (m *Map) ensureOrder() {
+	if !m.validOrder() {
+		m.Order = m.defaultOrder()
+	}
+}
+
+// descendants calls fn with the layers nested in the group with the given ID.
+func (m *Map) descendants(group ID, fn func(LayerRef)) {
+	for _, r := range m.defaultOrder() {
+		if *m.layerParent(r) != group {
+			continue
+		}
+		fn(r)
+		if r.Kind == GroupKind {
+			m.descendants(m.Groups[r.Index].ID, fn)
+		}
+	}
+}
+
+// isDescendant reports whether the layer r is nested in the group g refers to.
+func (m *Map) isDescendant(r, g LayerRef) bool {
+	if g.Kind != GroupKind {
+		return false
+	}
+	id := m.Groups[g.Index].ID
+	for p := *m.layerParent(r); p != 0; {
+		if p == id {
+			return true
+		}
+		pg := m.Group(p)
+		if pg == nil {
+			return false
+		}
+		p = pg.Parent
+	}
+	return false
+}
+
+// layerName returns a pointer to the name of the layer r refers to.
+func (m *Map) layerName(r LayerRef) *string {
+	switch r.Kind {
+	case TileLayerKind:
+		return &m.Layers[r.Index].Name
+	case ObjectGroupKind:
+		return &m.ObjectGroups[r.Index].Name
+	case ImageLayerKind:
+		return &m.ImageLayers[r.Index].Name
+	}
+	return &m.Groups[r.Index].Name
+}
+
+// layerParent returns a pointer to the parent of the layer r refers to.
+func (m *Map) layerParent(r LayerRef) *ID {
+	switch r.Kind {
+	case TileLayerKind:
+		return &m.Layers[r.Index].Parent
+	case ObjectGroupKind:
+		return &m.ObjectGroups[r.Index].Parent
+	case ImageLayerKind:
+		return &m.ImageLayers[r.Index].Parent
+	}
+	return &m.Groups[r.Index].Parent
+}
@@ -0,0 +1,91 @@
+package tmx
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// drawNames returns the names of the layers of m in draw order, with those of
+// nested layers prefixed by their group's.
+func drawNames(m *Map) []string {
+	var out []string
+	for _, r := range m.DrawOrder() {
+		name := *m.layerName(r)
+		for p := m.Group(*m.layerParent(r)); p != nil; p = m.Group(p.Parent) {
+			name = p.Name + "/" + name
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+func TestLayerManagement(t *testing.T) {
+	m := NewMap(2, 2, 8, 8, MapOrthogonal)
+	m.AddTileLayer("ground")
+	m.AddObject(m.AddObjectGroup("objects"), Object{Name: "spawn", Visible: true})
+	m.AddGroup("group")
+	m.AddImageLayer("sky", Image{Source: "sky.png"})
+
+	objects, _ := m.LayerRefByID(2)
+	if err := m.MoveLayer(objects, 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	sky := LayerRef{ImageLayerKind, 0}
+	if err := m.MoveLayer(sky, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sky", "ground", "group", "group/objects"}; !reflect.DeepEqual(drawNames(m), want) {
+		t.Errorf("MoveLayer() order = %q, want %q", drawNames(m), want)
+	}
+	group, _ := m.LayerRefByID(3)
+	if err := m.MoveLayer(group, 2, 0); !errors.Is(err, ErrInvalidParent) {
+		t.Errorf("MoveLayer() into its own layer = %v, want %v", err, ErrInvalidParent)
+	}
+
+	dup, err := m.DuplicateLayer(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sky", "ground", "group", "group/objects", "group copy", "group copy/objects"}; !reflect.DeepEqual(drawNames(m), want) {
+		t.Errorf("DuplicateLayer() order = %q, want %q", drawNames(m), want)
+	}
+	if g := m.Groups[dup.Index]; g.ID != 5 || m.NextLayerID != 7 {
+		t.Errorf("DuplicateLayer() id = %d, next %d, want 5, 7", g.ID, m.NextLayerID)
+	}
+	if o := m.ObjectGroups[1].Objects[0]; o.ID != 2 || o.Name != "spawn" || m.NextObjectID != 3 {
+		t.Errorf("DuplicateLayer() object = %d %q, next %d, want 2 \"spawn\", 3", o.ID, o.Name, m.NextObjectID)
+	}
+
+	if err := m.RenameLayer(dup, "copy"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemoveLayer(group); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sky", "ground", "copy", "copy/objects"}; !reflect.DeepEqual(drawNames(m), want) {
+		t.Errorf("RemoveLayer() order = %q, want %q", drawNames(m), want)
+	}
+	if len(m.Groups) != 1 || len(m.ObjectGroups) != 1 {
+		t.Errorf("RemoveLayer() left %d groups and %d object groups, want 1 and 1", len(m.Groups), len(m.ObjectGroups))
+	}
+	if err := m.RemoveLayer(LayerRef{GroupKind, 1}); !errors.Is(err, ErrLayerNotFound) {
+		t.Errorf("RemoveLayer() of a missing layer = %v, want %v", err, ErrLayerNotFound)
+	}
+	if issues := m.Validate(); issues != nil {
+		t.Errorf("Validate() = %v, want nil", issues)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(drawNames(got), drawNames(m)) {
+		t.Errorf("Read(Write()) order = %q, want %q", drawNames(got), drawNames(m))
+	}
+}
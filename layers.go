@@ -0,0 +1,172 @@
+package tmx
+
+import "encoding/xml"
+
+// ImageLayer models a v1.2 <imagelayer> drawing a single image.
+// See: https://doc.mapeditor.org/en/stable/reference/tmx-map-format/#imagelayer.
+type ImageLayer struct {
+	ID         ID         `xml:"id,attr"`
+	Name       string     `xml:"name,attr"`
+	OffsetX    int        `xml:"offsetx,attr"`
+	OffsetY    int        `xml:"offsety,attr"`
+	Opacity    float32    `xml:"opacity,attr"`
+	Visible    bool       `xml:"visible,attr"`
+	TintColor  Color      `xml:"tintcolor,attr"`
+	ParallaxX  float64    `xml:"parallaxx,attr"`
+	ParallaxY  float64    `xml:"parallaxy,attr"`
+	RepeatX    bool       `xml:"repeatx,attr"` // Repeat the image horizontally.
+	RepeatY    bool       `xml:"repeaty,attr"` // Repeat the image vertically.
+	Properties Properties `xml:"properties>property"`
+	Image      Image      `xml:"image"`
+	Parent     ID         `xml:"-"` // ID of the parent group or 0 at the top level.
+}
+
+// UnmarshalXML decodes the image layer, defaulting Visible, Opacity and
+// parallax factors when absent.
+func (l *ImageLayer) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type imageLayer ImageLayer
+	v := imageLayer{Visible: true, Opacity: 1, ParallaxX: 1, ParallaxY: 1}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*l = ImageLayer(v)
+	return nil
+}
+
+// LayerKind identifies the map slice holding a layer.
+type LayerKind uint8
+
+// LayerKind values.
+const (
+	TileLayerKind   LayerKind = iota // Map.Layers
+	ObjectGroupKind                  // Map.ObjectGroups
+	ImageLayerKind                   // Map.ImageLayers
+	GroupKind                        // Map.Groups
+)
+
+// LayerRef refers to the layer at Index of the map slice of its Kind.
+type LayerRef struct {
+	Kind  LayerKind
+	Index int
+}
+
+// AnyLayer is implemented by *Layer, *ObjectGroup, *ImageLayer and *Group.
+type AnyLayer interface {
+	common() layerCommon
+}
+
+// layerCommon holds the attributes shared by all kinds of layers.
+type layerCommon struct {
+	name                 string
+	properties           Properties
+	visible              bool
+	opacity              float32
+	offsetX, offsetY     int
+	tint                 Color
+	parallaxX, parallaxY float64
+	parent               ID
+}
+
+func (l *Layer) common() layerCommon {
+	return layerCommon{l.Name, l.Properties, l.Visible, l.Opacity, l.OffsetX, l.OffsetY, l.TintColor, l.ParallaxX, l.ParallaxY, l.Parent}
+}
+
+func (g *ObjectGroup) common() layerCommon {
+	return layerCommon{g.Name, g.Properties, g.Visible, g.Opacity, g.OffsetX, g.OffsetY, g.TintColor, g.ParallaxX, g.ParallaxY, g.Parent}
+}
+
+func (l *ImageLayer) common() layerCommon {
+	return layerCommon{l.Name, l.Properties, l.Visible, l.Opacity, l.OffsetX, l.OffsetY, l.TintColor, l.ParallaxX, l.ParallaxY, l.Parent}
+}
+
+func (g *Group) common() layerCommon {
+	return layerCommon{g.Name, g.Properties, g.Visible, g.Opacity, g.OffsetX, g.OffsetY, g.TintColor, g.ParallaxX, g.ParallaxY, g.Parent}
+}
+
+// Layer returns the layer r refers to or nil if it is out of range.
+func (m *Map) Layer(r LayerRef) AnyLayer {
+	switch r.Kind {
+	case TileLayerKind:
+		if r.Index >= 0 && r.Index < len(m.Layers) {
+			return &m.Layers[r.Index]
+		}
+	case ObjectGroupKind:
+		if r.Index >= 0 && r.Index < len(m.ObjectGroups) {
+			return &m.ObjectGroups[r.Index]
+		}
+	case ImageLayerKind:
+		if r.Index >= 0 && r.Index < len(m.ImageLayers) {
+			return &m.ImageLayers[r.Index]
+		}
+	case GroupKind:
+		if r.Index >= 0 && r.Index < len(m.Groups) {
+			return &m.Groups[r.Index]
+		}
+	}
+	return nil
+}
+
+// DrawOrder returns every layer of the map from bottom to top, with each
+// group followed by its children.
+// Layers are ordered by Map.Order when it refers to each layer exactly once.
+// Otherwise the children of a group are its tile layers, then object groups,
+// image layers and groups.
+func (m *Map) DrawOrder() []LayerRef {
+	order := m.Order
+	if !m.validOrder() {
+		order = m.defaultOrder()
+	}
+	out := make([]LayerRef, 0, len(order))
+	visited := make(map[ID]bool)
+	var walk func(parent ID)
+	walk = func(parent ID) {
+		for _, r := range order {
+			if m.Layer(r).common().parent != parent {
+				continue
+			}
+			out = append(out, r)
+			if r.Kind == GroupKind {
+				if id := m.Groups[r.Index].ID; !visited[id] {
+					visited[id] = true
+					walk(id)
+				}
+			}
+		}
+	}
+	visited[0] = true
+	walk(0)
+	return out
+}
+
+// validOrder reports whether Map.Order refers to each layer exactly once.
+func (m *Map) validOrder() bool {
+	if len(m.Order) != len(m.Layers)+len(m.ObjectGroups)+len(m.ImageLayers)+len(m.Groups) {
+		return false
+	}
+	seen := make(map[LayerRef]bool, len(m.Order))
+	for _, r := range m.Order {
+		if seen[r] || m.Layer(r) == nil {
+			return false
+		}
+		seen[r] = true
+	}
+	return true
+}
+
+// defaultOrder returns the tile layers, object groups, image layers and groups of the map.
+func (m *Map) defaultOrder() []LayerRef {
+	var out []LayerRef
+	for i := 0; i < len(m.Layers); i++ {
+		out = append(out, LayerRef{TileLayerKind, i})
+	}
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		out = append(out, LayerRef{ObjectGroupKind, i})
+	}
+	for i := 0; i < len(m.ImageLayers); i++ {
+		out = append(out, LayerRef{ImageLayerKind, i})
+	}
+	for i := 0; i < len(m.Groups); i++ {
+		out = append(out, LayerRef{GroupKind, i})
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package tmx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadImageLayers(t *testing.T) {
+	m, err := ReadFile("testdata/imagelayer.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ParallaxOriginX != 4 || m.ParallaxOriginY != 2 {
+		t.Errorf("parallax origin = %v, %v, want 4, 2", m.ParallaxOriginX, m.ParallaxOriginY)
+	}
+	if len(m.ImageLayers) != 2 {
+		t.Fatalf("image layers = %+v", m.ImageLayers)
+	}
+	sky, fog := &m.ImageLayers[0], &m.ImageLayers[1]
+	if sky.Name != "Sky" || !sky.RepeatX || sky.RepeatY || sky.ParallaxX != 0.5 || sky.ParallaxY != 0 || !sky.Visible || sky.Opacity != 1 {
+		t.Errorf("sky = %+v", sky)
+	}
+	if sky.Image.Source != "tiles.png" || sky.Image.Trans != "ff00ff" || sky.Image.ResolvedSource() != "testdata/tiles.png" {
+		t.Errorf("sky image = %+v", sky.Image)
+	}
+	if fog.Parent != 3 || !fog.RepeatY || fog.OffsetX != 3 || fog.Opacity != 0.5 {
+		t.Errorf("fog = %+v", fog)
+	}
+	if x, y := m.EffectiveParallax(fog); x != 1 || y != 1 {
+		t.Errorf("EffectiveParallax(fog) = %v, %v, want 1, 1", x, y)
+	}
+	if x, y := m.EffectiveParallax(&m.Layers[0]); x != 1 || y != 1 {
+		t.Errorf("EffectiveParallax(ground) = %v, %v, want 1, 1", x, y)
+	}
+	if g := m.ObjectGroups[0]; g.OffsetX != 1 || g.OffsetY != 2 {
+		t.Errorf("object group offset = %d, %d, want 1, 2", g.OffsetX, g.OffsetY)
+	}
+	if deps := m.Dependencies(); !reflect.DeepEqual(deps, []string{"testdata/tiles.png"}) {
+		t.Errorf("Dependencies() = %v", deps)
+	}
+
+	want := []LayerRef{
+		{ImageLayerKind, 0},
+		{TileLayerKind, 0},
+		{GroupKind, 0},
+		{ImageLayerKind, 1},
+		{ObjectGroupKind, 0},
+	}
+	if got := m.DrawOrder(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DrawOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestDrawOrderDefault(t *testing.T) {
+	m := &Map{
+		Layers:       []Layer{{ID: 1}, {ID: 2, Parent: 4}},
+		ObjectGroups: []ObjectGroup{{ID: 3}},
+		ImageLayers:  []ImageLayer{{ID: 5, Parent: 4}},
+		Groups:       []Group{{ID: 4}},
+		Order:        []LayerRef{{TileLayerKind, 0}, {TileLayerKind, 0}},
+	}
+	want := []LayerRef{
+		{TileLayerKind, 0},
+		{ObjectGroupKind, 0},
+		{GroupKind, 0},
+		{TileLayerKind, 1},
+		{ImageLayerKind, 0},
+	}
+	if got := m.DrawOrder(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DrawOrder() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,183 @@
+package tmx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// IsLegacy returns whether the map was written by Tiled 0.x, 1.0 or 1.1.
+// Maps without a version attribute are considered legacy.
+func (m *Map) IsLegacy() bool {
+	major, minor, ok := parseVersion(m.Version)
+	if !ok {
+		return true
+	}
+	return major < 1 || major == 1 && minor < 2
+}
+
+// parseVersion parses the major and minor components of a "major.minor" version.
+func parseVersion(v string) (major, minor int, ok bool) {
+	parts := strings.SplitN(v, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	var err error
+	if major, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, false
+	}
+	if minor, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// normalizeLegacy fills in the parts of the current model older maps omit.
+// Missing layer and object IDs are assigned, tileset counts are derived from
+// the tileset image and tile terrain attributes are converted into a WangSet.
+func (m *Map) normalizeLegacy() {
+	if m.MapRenderOrder == "" {
+		m.MapRenderOrder = RenderRightDown
+	}
+
+	m.assignLayerIDs()
+	m.assignObjectIDs()
+
+	for i := 0; i < len(m.Tilesets); i++ {
+		ts := &m.Tilesets[i]
+		ts.deriveCounts()
+		ts.convertTerrains()
+	}
+}
+
+// assignLayerIDs gives layers, object groups and image layers without an ID a
+// unique one.
+func (m *Map) assignLayerIDs() {
+	next := m.NextLayerID
+	for i := 0; i < len(m.Layers); i++ {
+		if m.Layers[i].ID >= next {
+			next = m.Layers[i].ID + 1
+		}
+	}
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		if m.ObjectGroups[i].ID >= next {
+			next = m.ObjectGroups[i].ID + 1
+		}
+	}
+	for i := 0; i < len(m.ImageLayers); i++ {
+		if m.ImageLayers[i].ID >= next {
+			next = m.ImageLayers[i].ID + 1
+		}
+	}
+	for i := 0; i < len(m.Groups); i++ {
+		if m.Groups[i].ID >= next {
+			next = m.Groups[i].ID + 1
+		}
+	}
+	if next == 0 {
+		next = 1
+	}
+
+	for i := 0; i < len(m.Layers); i++ {
+		if m.Layers[i].ID == 0 {
+			m.Layers[i].ID = next
+			next++
+		}
+	}
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		if m.ObjectGroups[i].ID == 0 {
+			m.ObjectGroups[i].ID = next
+			next++
+		}
+	}
+	for i := 0; i < len(m.ImageLayers); i++ {
+		if m.ImageLayers[i].ID == 0 {
+			m.ImageLayers[i].ID = next
+			next++
+		}
+	}
+	m.NextLayerID = next
+}
+
+// assignObjectIDs gives objects without an ID a unique one.
+func (m *Map) assignObjectIDs() {
+	next := m.NextObjectID
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		objects := m.ObjectGroups[i].Objects
+		for j := 0; j < len(objects); j++ {
+			if objects[j].ID >= next {
+				next = objects[j].ID + 1
+			}
+		}
+	}
+	if next == 0 {
+		next = 1
+	}
+
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		objects := m.ObjectGroups[i].Objects
+		for j := 0; j < len(objects); j++ {
+			if objects[j].ID == 0 {
+				objects[j].ID = next
+				next++
+			}
+		}
+	}
+	m.NextObjectID = next
+}
+
+// deriveCounts computes Columns and Tilecount from the tileset image when absent.
+func (ts *Tileset) deriveCounts() {
+	if ts.Source != "" || ts.TileWidth <= 0 || ts.TileHeight <= 0 {
+		return
+	}
+	if ts.Columns == 0 && ts.Image.Width > 0 {
+		ts.Columns = ts.columns(ts.Image.Width)
+	}
+	if ts.Tilecount == 0 && ts.Image.Height > 0 {
+		rows := (ts.Image.Height - 2*ts.Margin + ts.Spacing) / (ts.TileHeight + ts.Spacing)
+		ts.Tilecount = ts.Columns * rows
+	}
+}
+
+// Corner positions of a legacy tile terrain attribute within a WangID.
+// The WangID stores one color index per nibble, clockwise from the top edge.
+var terrainWangShifts = [4]uint{
+	7 * 4, // top-left
+	1 * 4, // top-right
+	5 * 4, // bottom-left
+	3 * 4, // bottom-right
+}
+
+// convertTerrains adds a corner WangSet equivalent to the tileset's terrain types.
+// The legacy Terrain attributes of tiles are left intact.
+func (ts *Tileset) convertTerrains() {
+	if len(ts.Terrains) == 0 || len(ts.WangSets) > 0 {
+		return
+	}
+
+	ws := WangSet{Name: "Terrains", TileID: ts.Terrains[0].TileID}
+	for _, t := range ts.Terrains {
+		ws.Corners = append(ws.Corners, WangColor{
+			Name:        t.Name,
+			TileID:      t.TileID,
+			Probability: 1,
+		})
+	}
+
+	for _, tile := range ts.Tiles {
+		if tile.Terrain == "" {
+			continue
+		}
+		var wangID WangID
+		for i, part := range strings.SplitN(tile.Terrain, ",", 4) {
+			terrain, err := strconv.Atoi(part)
+			if err != nil || terrain < 0 || terrain >= len(ts.Terrains) || terrain >= 0xf {
+				continue
+			}
+			wangID |= WangID(terrain+1) << terrainWangShifts[i]
+		}
+		ws.Tiles = append(ws.Tiles, WangTile{TileID: tile.ID, WangID: wangID})
+	}
+
+	ts.WangSets = append(ts.WangSets, ws)
+}
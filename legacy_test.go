@@ -0,0 +1,43 @@
+package tmx
+
+import "testing"
+
+func TestReadLegacy(t *testing.T) {
+	m, err := ReadFile("testdata/legacy.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.IsLegacy() {
+		t.Error("IsLegacy() = false")
+	}
+	if m.MapRenderOrder != RenderRightDown {
+		t.Errorf("MapRenderOrder = %q", m.MapRenderOrder)
+	}
+
+	if m.Layers[0].ID != 1 || m.Layers[1].ID != 2 || m.ObjectGroups[0].ID != 3 || m.NextLayerID != 4 {
+		t.Errorf("layer IDs = %d, %d, %d; next %d", m.Layers[0].ID, m.Layers[1].ID, m.ObjectGroups[0].ID, m.NextLayerID)
+	}
+	if !m.Layers[0].Visible || m.Layers[0].Opacity != 1 {
+		t.Errorf("Layers[0] visible = %v, opacity = %v", m.Layers[0].Visible, m.Layers[0].Opacity)
+	}
+	if m.Layers[1].Visible || m.Layers[1].Opacity != 0.5 {
+		t.Errorf("Layers[1] visible = %v, opacity = %v", m.Layers[1].Visible, m.Layers[1].Opacity)
+	}
+
+	objects := m.ObjectGroups[0].Objects
+	if objects[0].ID != 8 || objects[1].ID != 7 || m.NextObjectID != 9 {
+		t.Errorf("object IDs = %d, %d; next %d", objects[0].ID, objects[1].ID, m.NextObjectID)
+	}
+
+	ts := m.Tilesets[0]
+	if ts.Columns != 14 || ts.Tilecount != 28 {
+		t.Errorf("columns = %d, tilecount = %d", ts.Columns, ts.Tilecount)
+	}
+	if len(ts.WangSets) != 1 || len(ts.WangSets[0].Corners) != 2 {
+		t.Fatalf("WangSets = %+v", ts.WangSets)
+	}
+	if got, want := ts.WangSets[0].Tiles[0].WangID, WangID(0x10002010); got != want {
+		t.Errorf("WangID = %#x, want %#x", got, want)
+	}
+}
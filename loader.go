@@ -0,0 +1,318 @@
+package tmx
+
+import (
+	"context"
+	"errors"
+	"image"
+	_ "image/gif"  // Register GIF for tileset images.
+	_ "image/jpeg" // Register JPEG for tileset images.
+	_ "image/png"  // Register PNG for tileset images.
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Loader loads maps along with the external tilesets, object templates and
+// images they reference. Relative references are resolved against the
+// directory of the referencing file.
+//
+// The zero Loader reads from the OS file system without caching.
+type Loader struct {
+	// FS is the file system resources are read from.
+	// Names are slash-separated paths as required by fs.FS.
+	// If nil, the OS file system is used with OS paths.
+	FS fs.FS
+
+	// Resolver opens resources when FS is nil.
+	// Names are slash-separated paths, as with FS.
+	Resolver Resolver
+
+	// Cache optionally shares external tilesets and templates between loads.
+	// A Cache should only be shared between loaders reading the same file system.
+	Cache *Cache
+
+	// SkipData reads maps without their tile layer data, like ReadMetadata.
+	SkipData bool
+
+	// Strict rejects maps with attributes holding unknown values, and maps
+	// whose tile layers do not decode or hold GIDs outside of their tilesets.
+	Strict bool
+
+	// Limits bounds the size of the files and maps read.
+	Limits ResourceLimits
+
+	// Positions records the positions of the layers and tilesets of TMX
+	// maps in their documents, reported by Map.Position and Validate.
+	Positions bool
+}
+
+// NewLoader returns a loader reading from fsys.
+func NewLoader(fsys fs.FS) *Loader {
+	return &Loader{FS: fsys}
+}
+
+// ContextFS is implemented by file systems that can honor a context while
+// opening files, such as HTTPFS.
+type ContextFS interface {
+	fs.FS
+	OpenContext(ctx context.Context, name string) (fs.File, error)
+}
+
+// open opens the named resource of the given kind.
+// Reads from the resource fail once ctx is done.
+func (l *Loader) open(ctx context.Context, kind ResourceKind, name string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var f io.ReadCloser
+	var err error
+	switch fsys := l.FS.(type) {
+	case nil:
+		if l.Resolver != nil {
+			f, err = l.Resolver.Open(kind, name)
+		} else {
+			f, err = os.Open(name)
+		}
+	case ContextFS:
+		f, err = fsys.OpenContext(ctx, name)
+	default:
+		f, err = fsys.Open(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l.Limits.MaxBytes > 0 {
+		f = struct {
+			io.Reader
+			io.Closer
+		}{l.limitReader(f), f}
+	}
+	return contextReadCloser{ctx, f}, nil
+}
+
+// contextReadCloser fails reads once its context is done.
+type contextReadCloser struct {
+	ctx context.Context
+	io.ReadCloser
+}
+
+func (r contextReadCloser) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.ReadCloser.Read(p)
+}
+
+// osPaths reports whether resource names are OS paths.
+func (l *Loader) osPaths() bool {
+	return l.FS == nil && l.Resolver == nil
+}
+
+// join resolves the reference name relative to the directory dir.
+func (l *Loader) join(dir, name string) string {
+	if l.osPaths() {
+		if filepath.IsAbs(name) {
+			return filepath.Clean(name)
+		}
+		return filepath.Join(dir, name)
+	}
+	return path.Join(dir, name)
+}
+
+// dir returns the directory of the named resource.
+func (l *Loader) dir(name string) string {
+	if l.osPaths() {
+		return filepath.Dir(name)
+	}
+	return path.Dir(name)
+}
+
+// rel returns the resolved name relative to the directory dir, or name if
+// it cannot be made relative.
+func (l *Loader) rel(dir, name string) string {
+	if l.osPaths() {
+		if r, err := filepath.Rel(dir, name); err == nil {
+			return r
+		}
+		return name
+	}
+	r, err := filepath.Rel(filepath.FromSlash(dir), filepath.FromSlash(name))
+	if err != nil {
+		return name
+	}
+	return filepath.ToSlash(r)
+}
+
+// location returns the location of references made by the named resource.
+func (l *Loader) location(name string) location {
+	return location{dir: l.dir(name), fs: !l.osPaths()}
+}
+
+// stat returns the file info of the named resource.
+func (l *Loader) stat(name string) (fs.FileInfo, error) {
+	switch {
+	case l.FS != nil:
+		return fs.Stat(l.FS, name)
+	case l.Resolver != nil:
+		return nil, errors.ErrUnsupported
+	}
+	return os.Stat(name)
+}
+
+// key returns the cache key of the named resource.
+func (l *Loader) key(name string) string {
+	if l.osPaths() {
+		if abs, err := filepath.Abs(name); err == nil {
+			return abs
+		}
+	}
+	return name
+}
+
+// ReadFile reads the named map and its external resources or returns an error.
+// Files with a .tmj or .json extension are read as JSON maps, others as TMX.
+func (l *Loader) ReadFile(name string) (*Map, error) {
+	return l.ReadFileContext(context.Background(), name)
+}
+
+// ReadFileContext is like ReadFile but stops loading once ctx is done.
+func (l *Loader) ReadFileContext(ctx context.Context, name string) (*Map, error) {
+	return l.readFile(ctx, name, true)
+}
+
+// readFile reads the named map, loading its external resources if external
+// is set. Files are read as JSON or TMX depending on their extension.
+func (l *Loader) readFile(ctx context.Context, name string, external bool) (*Map, error) {
+	read := func(r io.Reader) (*Map, error) { return readTMX(ctx, r, l) }
+	switch strings.ToLower(path.Ext(name)) {
+	case ".tmj", ".json":
+		read = ReadJSON
+		if l.SkipData {
+			read = readJSONMetadata
+		}
+	}
+	return l.readMap(ctx, name, read, external)
+}
+
+// readMap reads the named map with read and loads its external resources if
+// external is set.
+func (l *Loader) readMap(ctx context.Context, name string, read func(io.Reader) (*Map, error), external bool) (*Map, error) {
+	f, err := l.open(ctx, MapResource, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	out, err := read(f)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.checkLimits(out); err != nil {
+		return nil, err
+	}
+	out.setLocation(name, l.location(name))
+	if external {
+		if err := l.loadExternal(ctx, out, l.dir(name)); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.check(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReadTileset reads the named TSX or JSON tileset or returns an error.
+func (l *Loader) ReadTileset(name string) (*Tileset, error) {
+	return l.ReadTilesetContext(context.Background(), name)
+}
+
+// ReadTilesetContext is like ReadTileset but stops loading once ctx is done.
+func (l *Loader) ReadTilesetContext(ctx context.Context, name string) (*Tileset, error) {
+	return l.Cache.tileset(ctx, l.key(name), func() (*Tileset, error) {
+		f, err := l.open(ctx, TilesetResource, name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		ts, err := decodeTileset(name, f)
+		if err != nil {
+			return nil, err
+		}
+		ts.setLocation(name, l.location(name))
+		return ts, nil
+	})
+}
+
+// ReadTemplate reads the named object template or returns an error.
+func (l *Loader) ReadTemplate(name string) (*Template, error) {
+	return l.ReadTemplateContext(context.Background(), name)
+}
+
+// ReadTemplateContext is like ReadTemplate but stops loading once ctx is done.
+func (l *Loader) ReadTemplateContext(ctx context.Context, name string) (*Template, error) {
+	return l.Cache.template(ctx, l.key(name), func() (*Template, error) {
+		f, err := l.open(ctx, TemplateResource, name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ReadTemplate(f)
+	})
+}
+
+// Image reads and decodes the named PNG, GIF or JPEG image or returns an error.
+func (l *Loader) Image(name string) (image.Image, error) {
+	return l.ImageContext(context.Background(), name)
+}
+
+// ImageContext is like Image but stops loading once ctx is done.
+func (l *Loader) ImageContext(ctx context.Context, name string) (image.Image, error) {
+	f, err := l.open(ctx, ImageResource, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// loadExternal loads the external tilesets and object templates referenced by m.
+// Relative sources are resolved against dir.
+func (l *Loader) loadExternal(ctx context.Context, m *Map, dir string) error {
+	for i := 0; i < len(m.Tilesets); i++ {
+		ref := m.Tilesets[i]
+		if ref.Source == "" {
+			continue
+		}
+		ts, err := l.ReadTilesetContext(ctx, l.join(dir, ref.Source))
+		if err != nil {
+			return err
+		}
+		m.Tilesets[i] = *ts
+		m.Tilesets[i].FirstGID = ref.FirstGID
+		m.Tilesets[i].Source = ref.Source
+	}
+
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		objects := m.ObjectGroups[i].Objects
+		for j := 0; j < len(objects); j++ {
+			o := &objects[j]
+			if o.Template == "" {
+				continue
+			}
+			name := l.join(dir, o.Template)
+			tpl, err := l.ReadTemplateContext(ctx, name)
+			if err != nil {
+				return err
+			}
+			m.applyTemplate(o, tpl, dir, l.dir(name), l)
+		}
+	}
+	m.templates = true
+	return nil
+}
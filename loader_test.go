@@ -0,0 +1,117 @@
+package tmx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoaderFS(t *testing.T) {
+	l := NewLoader(os.DirFS("testdata"))
+
+	m, err := l.ReadFile("external.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts := m.Tilesets[1]; ts.Name != "external" || ts.FirstGID != 29 {
+		t.Errorf("external tileset = %+v", ts)
+	}
+	if o := m.ObjectGroups[0].Objects[0]; o.Name != "crate" || o.GID != 31 {
+		t.Errorf("templated object = %+v", o)
+	}
+
+	img, err := l.Image(m.Tilesets[0].Image.Source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 112 || b.Dy() != 16 {
+		t.Errorf("image bounds = %v", b)
+	}
+
+	m, err = l.ReadFile("world/map_0_0.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Tilesets[0].Name != "external" {
+		t.Errorf("parent directory tileset = %+v", m.Tilesets[0])
+	}
+
+	m, err = l.ReadFile("map.tmj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Layers) != 2 {
+		t.Errorf("JSON map layers = %d", len(m.Layers))
+	}
+}
+
+func TestLoaderMapFS(t *testing.T) {
+	tsx, err := os.ReadFile("testdata/tiles.tsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"maps/a.tmx":     {Data: []byte(`<map version="1.2"><tileset firstgid="1" source="../sets/tiles.tsx"/></map>`)},
+		"maps/b.tmx":     {Data: []byte(`<map version="1.2"><tileset firstgid="1" source="../../tiles.tsx"/></map>`)},
+		"sets/tiles.tsx": {Data: tsx},
+	}
+
+	l := &Loader{FS: fsys, Cache: NewCache()}
+	m, err := l.ReadFile("maps/a.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Tilesets[0].Name != "external" {
+		t.Errorf("tileset = %+v", m.Tilesets[0])
+	}
+	if _, err := l.ReadFile("maps/b.tmx"); err == nil {
+		t.Error("ReadFile() resolved a tileset outside the file system")
+	}
+}
+
+func TestLoaderContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ReadFileContext(ctx, "testdata/external.tmx"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadFileContext() error = %v, want context.Canceled", err)
+	}
+	if _, err := ReadContext(ctx, strings.NewReader("<map></map>")); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadContext() error = %v, want context.Canceled", err)
+	}
+
+	// Canceled loads are not cached.
+	l := &Loader{Cache: NewCache()}
+	if _, err := l.ReadTilesetContext(ctx, "testdata/tiles.tsx"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadTilesetContext() error = %v, want context.Canceled", err)
+	}
+	if _, err := l.ReadFileContext(context.Background(), "testdata/external.tmx"); err != nil {
+		t.Errorf("ReadFileContext() after cancellation: %v", err)
+	}
+}
+
+func TestLoaderSkipData(t *testing.T) {
+	for _, name := range []string{"testdata/external.tmx", "testdata/map.tmj"} {
+		want, err := ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := (&Loader{SkipData: true}).ReadFile(name)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		for i := range want.Layers {
+			if got.Layers[i].Data.Bytes != nil {
+				t.Errorf("%s: layer %d has data %q", name, i, got.Layers[i].Data.Bytes)
+			}
+			want.Layers[i].Data.Bytes = nil
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: ReadFile() without data = %+v, want %+v", name, got, want)
+		}
+	}
+}
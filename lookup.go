@@ -0,0 +1,163 @@
+package tmx
+
+import (
+	"image"
+	"strings"
+)
+
+// LayerByName returns the first layer of any kind named name in draw order,
+// or nil if there is none.
+func (m *Map) LayerByName(name string) AnyLayer {
+	return m.findLayer(func(c layerCommon) bool { return c.name == name })
+}
+
+// LayerByNameFold is like LayerByName but matches names case-insensitively.
+func (m *Map) LayerByNameFold(name string) AnyLayer {
+	return m.findLayer(func(c layerCommon) bool { return strings.EqualFold(c.name, name) })
+}
+
+// LayersWithProperty returns the layers of any kind in draw order that have
+// a property named name holding value.
+func (m *Map) LayersWithProperty(name, value string) []AnyLayer {
+	return m.filterLayers(func(c layerCommon) bool {
+		p, ok := c.properties.Get(name)
+		return ok && p.Value == value
+	})
+}
+
+// LayersWithPropertyFold is like LayersWithProperty but matches property
+// names and values case-insensitively.
+func (m *Map) LayersWithPropertyFold(name, value string) []AnyLayer {
+	return m.filterLayers(func(c layerCommon) bool {
+		for _, p := range c.properties {
+			if strings.EqualFold(p.Name, name) {
+				return strings.EqualFold(p.Value, value)
+			}
+		}
+		return false
+	})
+}
+
+// TilesetByName returns the first tileset named name, or nil if there is none.
+func (m *Map) TilesetByName(name string) *Tileset {
+	for i := 0; i < len(m.Tilesets); i++ {
+		if m.Tilesets[i].Name == name {
+			return &m.Tilesets[i]
+		}
+	}
+	return nil
+}
+
+// findLayer returns the first layer in draw order matching fn, or nil.
+func (m *Map) findLayer(fn func(layerCommon) bool) AnyLayer {
+	for _, r := range m.DrawOrder() {
+		if l := m.Layer(r); fn(l.common()) {
+			return l
+		}
+	}
+	return nil
+}
+
+// filterLayers returns the layers in draw order matching fn.
+func (m *Map) filterLayers(fn func(layerCommon) bool) []AnyLayer {
+	var out []AnyLayer
+	for _, r := range m.DrawOrder() {
+		if l := m.Layer(r); fn(l.common()) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// ObjectByName returns the first object of the group named name, or nil if
+// there is none.
+func (g *ObjectGroup) ObjectByName(name string) *Object {
+	for i := 0; i < len(g.Objects); i++ {
+		if g.Objects[i].Name == name {
+			return &g.Objects[i]
+		}
+	}
+	return nil
+}
+
+// ObjectsByType returns the objects of the group of the given type, which
+// Tiled calls the class of the object.
+func (g *ObjectGroup) ObjectsByType(typ string) []*Object {
+	var out []*Object
+	for i := 0; i < len(g.Objects); i++ {
+		if g.Objects[i].Type == typ {
+			out = append(out, &g.Objects[i])
+		}
+	}
+	return out
+}
+
+// FoundObject is an object found in an object group of a map.
+type FoundObject struct {
+	Group  *ObjectGroup
+	Object *Object
+}
+
+// FindObjects returns the objects of every object group of the map for which
+// match returns true, with the groups in draw order.
+func (m *Map) FindObjects(match func(g *ObjectGroup, o *Object) bool) []FoundObject {
+	var out []FoundObject
+	for _, r := range m.DrawOrder() {
+		if r.Kind != ObjectGroupKind {
+			continue
+		}
+		g := &m.ObjectGroups[r.Index]
+		for i := 0; i < len(g.Objects); i++ {
+			if o := &g.Objects[i]; match(g, o) {
+				out = append(out, FoundObject{g, o})
+			}
+		}
+	}
+	return out
+}
+
+// TilePosition is the cell of a tile layer of a map holding a tile.
+type TilePosition struct {
+	Layer *Layer
+	X, Y  int
+}
+
+// FindTiles returns the cells of every tile layer of the map holding tile id
+// of ts, with any flips, with the layers in draw order and the cells of each
+// in row major order. Cells of infinite maps are those of their chunks. It returns an error if a layer fails to decode.
+func (m *Map) FindTiles(ts *Tileset, id ID) ([]TilePosition, error) {
+	want := ts.FirstGID + GID(id)
+	var out []TilePosition
+	for _, r := range m.DrawOrder() {
+		if r.Kind != TileLayerKind {
+			continue
+		}
+		l := &m.Layers[r.Index]
+		gids, err := l.Decoded()
+		if err != nil {
+			return nil, err
+		}
+		origin, err := l.Origin()
+		if err != nil {
+			return nil, err
+		}
+		for i, gid := range gids {
+			if gid.TileID() == want {
+				out = append(out, TilePosition{l, origin.X + i%l.Width, origin.Y + i/l.Width})
+			}
+		}
+	}
+	return out, nil
+}
+
+// Positions returns the cells of the layer holding the tile of gid, with any
+// flips, in row major order.
+func (l DecodedLayer) Positions(gid GID) []image.Point {
+	var out []image.Point
+	for p, t := range l.All() {
+		if !t.Nil && t.Tileset != nil && t.Tileset.FirstGID+GID(t.ID) == gid.TileID() {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package tmx
+
+import (
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLayerByName(t *testing.T) {
+	m, err := ReadFile("testdata/groups.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		want AnyLayer
+	}{
+		{"Top", &m.Layers[0]},
+		{"Inner", &m.Groups[1]},
+		{"Objects", &m.ObjectGroups[0]},
+		{"objects", nil},
+		{"Missing", nil},
+	} {
+		if got := m.LayerByName(tc.name); got != tc.want {
+			t.Errorf("LayerByName(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if got, want := m.LayerByNameFold("NESTED"), AnyLayer(&m.Layers[1]); got != want {
+		t.Errorf("LayerByNameFold() = %v, want %v", got, want)
+	}
+	if got := m.LayerByNameFold("Missing"); got != nil {
+		t.Errorf("LayerByNameFold() of a missing layer = %v", got)
+	}
+}
+
+func TestLayersWithProperty(t *testing.T) {
+	m := &Map{
+		Layers: []Layer{
+			{Name: "ground", Properties: Properties{{Name: "collide", Type: "bool", Value: "true"}}},
+			{Name: "decor", Properties: Properties{{Name: "collide", Type: "bool", Value: "false"}}},
+		},
+		ObjectGroups: []ObjectGroup{{Name: "walls", Properties: Properties{{Name: "Collide", Value: "TRUE"}}}},
+	}
+	if got, want := m.LayersWithProperty("collide", "true"), []AnyLayer{&m.Layers[0]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LayersWithProperty() = %v, want %v", got, want)
+	}
+	if got, want := m.LayersWithPropertyFold("collide", "true"), []AnyLayer{&m.Layers[0], &m.ObjectGroups[0]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LayersWithPropertyFold() = %v, want %v", got, want)
+	}
+	if got := m.LayersWithProperty("solid", "true"); got != nil {
+		t.Errorf("LayersWithProperty() of a missing property = %v", got)
+	}
+}
+
+func TestTilesetByName(t *testing.T) {
+	m := &Map{Tilesets: []Tileset{{Name: "ground"}, {Name: "props"}, {Name: "props"}}}
+	if got := m.TilesetByName("props"); got != &m.Tilesets[1] {
+		t.Errorf("TilesetByName() = %p, want %p", got, &m.Tilesets[1])
+	}
+	if got := m.TilesetByName("missing"); got != nil {
+		t.Errorf("TilesetByName() of a missing tileset = %p", got)
+	}
+}
+
+func TestObjectLookups(t *testing.T) {
+	m := &Map{
+		ObjectGroups: []ObjectGroup{
+			{Name: "spawns", Objects: []Object{{Name: "start", Type: "spawn"}, {Name: "boss", Type: "spawn"}, {Name: "start"}}},
+			{Name: "triggers", Objects: []Object{{Name: "door", Type: "trigger"}, {Name: "trap", Type: "spawn"}}},
+		},
+		Order: []LayerRef{{ObjectGroupKind, 1}, {ObjectGroupKind, 0}},
+	}
+	spawns, triggers := &m.ObjectGroups[0], &m.ObjectGroups[1]
+
+	if got := spawns.ObjectByName("start"); got != &spawns.Objects[0] {
+		t.Errorf("ObjectByName() = %p, want %p", got, &spawns.Objects[0])
+	}
+	if got := spawns.ObjectByName("door"); got != nil {
+		t.Errorf("ObjectByName() of a missing object = %p", got)
+	}
+	if got, want := spawns.ObjectsByType("spawn"), []*Object{&spawns.Objects[0], &spawns.Objects[1]}; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ObjectsByType() = %v, want %v", got, want)
+	}
+
+	got := m.FindObjects(func(g *ObjectGroup, o *Object) bool { return o.Type == "spawn" })
+	want := []FoundObject{{triggers, &triggers.Objects[1]}, {spawns, &spawns.Objects[0]}, {spawns, &spawns.Objects[1]}}
+	if len(got) != len(want) {
+		t.Fatalf("FindObjects() = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("FindObjects()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFindTiles(t *testing.T) {
+	m, err := ReadDecoded(strings.NewReader(`<map width="3" height="2">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8" tilecount="4"/>
+ <tileset firstgid="5" name="b" tilewidth="8" tileheight="8" tilecount="4"/>
+ <layer id="1" width="3" height="2"><data encoding="csv">2,0,6,0,2147483650,2</data></layer>
+ <layer id="2" width="3" height="2"><data encoding="csv">0,0,0,2,0,0</data></layer>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.FindTiles(m.TilesetByName("a"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TilePosition{
+		{&m.Layers[0], 0, 0},
+		{&m.Layers[0], 1, 1},
+		{&m.Layers[0], 2, 1},
+		{&m.Layers[1], 0, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindTiles(a, 1) = %v, want %v", got, want)
+	}
+	if got, err := m.FindTiles(m.TilesetByName("b"), 0); err != nil || len(got) != 0 {
+		t.Errorf("FindTiles(b, 0) = %v, %v, want none", got, err)
+	}
+
+	if got, want := m.Decoded[0].Positions(6), []image.Point{{2, 0}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Positions(6) = %v, want %v", got, want)
+	}
+	if got, want := m.Decoded[0].Positions(2|GIDVerticalFlip), []image.Point{{0, 0}, {1, 1}, {2, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Positions(2) = %v, want %v", got, want)
+	}
+	if got := m.Decoded[1].Positions(6); got != nil {
+		t.Errorf("Positions(6) of second layer = %v, want nil", got)
+	}
+
+	// Cells of chunked layers are those of the chunks, from (-16, 0).
+	if m, err = ReadDecodedFile("testdata/infinite.tmx"); err != nil {
+		t.Fatal(err)
+	}
+	got, err = m.FindTiles(&m.Tilesets[0], 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []TilePosition{{&m.Layers[0], 15, 15}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindTiles(default, 3) = %v, want %v", got, want)
+	}
+	if got, want := m.Decoded[0].Positions(4), []image.Point{{15, 15}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Positions(4) of chunked layer = %v, want %v", got, want)
+	}
+}
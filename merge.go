@@ -0,0 +1,184 @@
+package tmx
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+// ErrIncompatibleMaps is returned when merging maps of different
+// orientations or tile sizes.
+var ErrIncompatibleMaps = errors.New("tmx: incompatible maps")
+
+// Merge copies the layers of src into dst above its layers, with the cells
+// of src moved by (offsetX, offsetY) tiles. Tile layers are copied into
+// layers the size of dst, cropping the cells outside of it, and objects and
+// image layers are moved with the cells. Tilesets of src that dst shares,
+// by source or by name, tile size and image, are reused and the others are
+// appended to dst, with the GIDs of src rebased accordingly. Copied layers
+// and tilesets get new IDs and first GIDs, and are renamed like "name 2"
+// when their names are taken in dst. src is not changed, and dst is not
+// changed on error.
+func Merge(dst, src *Map, offsetX, offsetY int) error {
+	if dst.MapOrientation != src.MapOrientation || dst.TileWidth != src.TileWidth || dst.TileHeight != src.TileHeight {
+		return ErrIncompatibleMaps
+	}
+
+	var added []Tileset
+	firstGIDs := make(map[GID]GID, len(src.Tilesets))
+	tilesetNames := make(map[string]bool)
+	for i := 0; i < len(dst.Tilesets); i++ {
+		tilesetNames[dst.Tilesets[i].Name] = true
+	}
+	next := dst.nextFirstGID()
+	for i := 0; i < len(src.Tilesets); i++ {
+		ts := &src.Tilesets[i]
+		if shared := dst.sharedTileset(ts); shared != nil {
+			firstGIDs[ts.FirstGID] = shared.FirstGID
+			continue
+		}
+		c := ts.Clone()
+		c.Name = uniqueName(c.Name, tilesetNames)
+		c.FirstGID = next
+		c.deriveCounts()
+		next += src.spanOf(i, c)
+		firstGIDs[ts.FirstGID] = c.FirstGID
+		added = append(added, *c)
+	}
+	rebase := func(gid GID) (GID, error) {
+		if gid&GIDMask == 0 {
+			return gid, nil
+		}
+		ts := src.TilesetForGID(gid & GIDMask)
+		if ts == nil {
+			return 0, ErrInvalidGID
+		}
+		return gid&GIDMask - ts.FirstGID + firstGIDs[ts.FirstGID] | gid&^GIDMask, nil
+	}
+
+	// Encode the tile layers and rebase the objects first, as they may fail.
+	off := image.Pt(offsetX, offsetY)
+	data := make([]Data, len(src.Layers))
+	sizes := make([]image.Point, len(src.Layers))
+	for i := 0; i < len(src.Layers); i++ {
+		l := &src.Layers[i]
+		gids, err := l.Decoded()
+		if err != nil {
+			return err
+		}
+		out := make([]GID, dst.Width*dst.Height)
+		for y := max(0, -off.Y); y < l.Height && y+off.Y < dst.Height; y++ {
+			for x := max(0, -off.X); x < l.Width && x+off.X < dst.Width; x++ {
+				j := y*l.Width + x
+				if out[(y+off.Y)*dst.Width+x+off.X], err = rebase(gids[j]); err != nil {
+					return l.errorAt(j, err)
+				}
+			}
+		}
+		if data[i], sizes[i], err = l.encodeLike(out, dst.Width, dst.CompressionLevel); err != nil {
+			return err
+		}
+	}
+	dx, dy := src.objectOffset(off)
+	groups := make([]ObjectGroup, len(src.ObjectGroups))
+	for i := 0; i < len(src.ObjectGroups); i++ {
+		g := src.ObjectGroups[i].Clone()
+		for j := 0; j < len(g.Objects); j++ {
+			o := &g.Objects[j]
+			gid, err := rebase(o.GID)
+			if err != nil {
+				return &ObjectError{o.ID, err}
+			}
+			o.GID = gid
+			o.X += dx
+			o.Y += dy
+		}
+		groups[i] = g
+	}
+
+	dst.Tilesets = append(dst.Tilesets, added...)
+	layerNames := make(map[string]bool)
+	for _, r := range dst.defaultOrder() {
+		layerNames[*dst.layerName(r)] = true
+	}
+	dst.ensureOrder()
+	parents := map[ID]ID{0: 0}
+	for _, r := range src.DrawOrder() {
+		id := dst.newLayerID()
+		parent := parents[*src.layerParent(r)]
+		var out LayerRef
+		switch r.Kind {
+		case TileLayerKind:
+			l := src.Layers[r.Index]
+			l.ID, l.Parent = id, parent
+			l.Width, l.Height = sizes[r.Index].X, sizes[r.Index].Y
+			l.Properties = cloneProperties(l.Properties)
+			l.Data, l.decoded, l.edited = data[r.Index], nil, false
+			out = LayerRef{TileLayerKind, len(dst.Layers)}
+			dst.Layers = append(dst.Layers, l)
+		case ObjectGroupKind:
+			g := groups[r.Index]
+			g.ID, g.Parent = id, parent
+			for j := range g.Objects {
+				if dst.NextObjectID == 0 {
+					dst.NextObjectID = 1
+				}
+				g.Objects[j].ID = dst.NextObjectID
+				dst.NextObjectID++
+			}
+			out = LayerRef{ObjectGroupKind, len(dst.ObjectGroups)}
+			dst.ObjectGroups = append(dst.ObjectGroups, g)
+		case ImageLayerKind:
+			l := src.ImageLayers[r.Index]
+			l.ID, l.Parent = id, parent
+			l.OffsetX += int(dx)
+			l.OffsetY += int(dy)
+			l.Properties = cloneProperties(l.Properties)
+			out = LayerRef{ImageLayerKind, len(dst.ImageLayers)}
+			dst.ImageLayers = append(dst.ImageLayers, l)
+		case GroupKind:
+			g := src.Groups[r.Index]
+			parents[g.ID] = id
+			g.ID, g.Parent = id, parent
+			g.Properties = cloneProperties(g.Properties)
+			out = LayerRef{GroupKind, len(dst.Groups)}
+			dst.Groups = append(dst.Groups, g)
+		}
+		name := dst.layerName(out)
+		*name = uniqueName(*name, layerNames)
+		dst.Order = append(dst.Order, out)
+	}
+	return nil
+}
+
+// sharedTileset returns the tileset of the map that is the same as ts: one
+// with the same source for external tilesets, or else with the same name,
+// tile size and image. External tilesets are compared by their resolved
+// sources when both are loaded.
+func (m *Map) sharedTileset(ts *Tileset) *Tileset {
+	for i := 0; i < len(m.Tilesets); i++ {
+		t := &m.Tilesets[i]
+		if ts.Source != "" || t.Source != "" {
+			a, b := t.ResolvedSource(), ts.ResolvedSource()
+			if a != "" && a == b || (a == "" || b == "") && t.Source == ts.Source {
+				return t
+			}
+			continue
+		}
+		if t.Name == ts.Name && t.TileWidth == ts.TileWidth && t.TileHeight == ts.TileHeight && t.Image.Source == ts.Image.Source {
+			return t
+		}
+	}
+	return nil
+}
+
+// uniqueName returns name, or name followed by the first number from 2 on
+// that makes it unique, and marks it as taken.
+func uniqueName(name string, taken map[string]bool) string {
+	out := name
+	for n := 2; taken[out]; n++ {
+		out = fmt.Sprintf("%s %d", name, n)
+	}
+	taken[out] = true
+	return out
+}
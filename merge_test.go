@@ -0,0 +1,71 @@
+package tmx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tileset := func(name string, n int) Tileset {
+		return Tileset{Name: name, TileWidth: 8, TileHeight: 8, Tilecount: n, Image: Image{Source: name + ".png"}}
+	}
+	dst := NewMap(4, 2, 8, 8, MapOrthogonal)
+	dst.AddTileset(tileset("a", 4))
+	dst.AddTileset(tileset("c", 2))
+	if err := dst.AddTileLayer("ground").Encode([]GID{1, 1, 1, 1, 1, 1, 1, 1}, dst.DataEncoder(CSV, Uncompressed)); err != nil {
+		t.Fatal(err)
+	}
+
+	src := NewMap(2, 2, 8, 8, MapOrthogonal)
+	other := tileset("c", 1)
+	other.Image.Source = "other.png"
+	src.AddTileset(other)
+	src.AddTileset(tileset("a", 4))
+	ground := src.AddTileLayer("ground")
+	if err := ground.Encode([]GID{2, 3 | GIDHorizontalFlip, 0, 1}, src.DataEncoder(Base64, Zlib)); err != nil {
+		t.Fatal(err)
+	}
+	group := src.AddGroup("things")
+	g := src.AddObjectGroup("objects")
+	src.AddObject(g, Object{GID: 1, X: 4, Y: 16, Visible: true})
+	if err := src.MoveLayer(LayerRef{ObjectGroupKind, 0}, group.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Merge(dst, src, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	var firsts []GID
+	for _, ts := range dst.Tilesets {
+		names = append(names, ts.Name)
+		firsts = append(firsts, ts.FirstGID)
+	}
+	if want := []string{"a", "c", "c 2"}; !reflect.DeepEqual(names, want) || !reflect.DeepEqual(firsts, []GID{1, 5, 7}) {
+		t.Errorf("Merge() tilesets = %q %v, want %q [1 5 7]", names, firsts, want)
+	}
+	if want := []string{"ground", "ground 2", "things", "things/objects"}; !reflect.DeepEqual(drawNames(dst), want) {
+		t.Errorf("Merge() layers = %q, want %q", drawNames(dst), want)
+	}
+	want := []GID{0, 0, 1, 2 | GIDHorizontalFlip, 0, 0, 0, 7}
+	if got, err := dst.Layers[1].Decode(); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() layer = %v, %v, want %v", got, err, want)
+	}
+	if d := dst.Layers[1].Data; d.Encoding != Base64 || d.Compression != Zlib {
+		t.Errorf("Merge() layer data encoded as %q %q, want base64 zlib", d.Encoding, d.Compression)
+	}
+	if o := dst.ObjectGroups[0].Objects[0]; o.ID != 1 || o.GID != 7 || o.X != 20 || o.Y != 16 {
+		t.Errorf("Merge() object = id %d, gid %d at (%v, %v), want id 1, gid 7 at (20, 16)", o.ID, o.GID, o.X, o.Y)
+	}
+	if issues := dst.Validate(); issues != nil {
+		t.Errorf("Validate() = %v, want nil", issues)
+	}
+	if o := src.ObjectGroups[0].Objects[0]; o.GID != 1 || o.X != 4 || src.Layers[0].Name != "ground" {
+		t.Errorf("Merge() changed src object to gid %d at x %v", o.GID, o.X)
+	}
+
+	if err := Merge(dst, NewMap(1, 1, 16, 16, MapOrthogonal), 0, 0); !errors.Is(err, ErrIncompatibleMaps) {
+		t.Errorf("Merge() of maps with other tile sizes = %v, want %v", err, ErrIncompatibleMaps)
+	}
+}
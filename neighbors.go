@@ -0,0 +1,85 @@
+package tmx
+
+import "image"
+
+// Neighbors returns the cells of the map sharing an edge with the cell
+// (x, y): four on orthogonal, isometric and staggered maps and six on
+// hexagonal maps. Cells outside of the map are left out.
+func (m *Map) Neighbors(x, y int) []image.Point {
+	var out []image.Point
+	switch m.MapOrientation {
+	case MapHexagonal:
+		a := m.TileToAxial(x, y)
+		for _, d := range axialDirections {
+			out = append(out, image.Pt(m.AxialToTile(a.Add(d))))
+		}
+	case MapStaggered:
+		out = m.hexGrid(0).diagonals(x, y)
+	default:
+		out = []image.Point{{x, y - 1}, {x + 1, y}, {x, y + 1}, {x - 1, y}}
+	}
+	return m.clip(out)
+}
+
+// AllNeighbors returns the cells of the map sharing an edge or a corner with
+// the cell (x, y): eight on orthogonal, isometric and staggered maps and six
+// on hexagonal maps, whose cells do not meet at corners only. Cells outside
+// of the map are left out.
+func (m *Map) AllNeighbors(x, y int) []image.Point {
+	var out []image.Point
+	switch m.MapOrientation {
+	case MapHexagonal:
+		return m.Neighbors(x, y)
+	case MapStaggered:
+		out = m.hexGrid(0).diagonals(x, y)
+		if m.StaggerAxis == StaggerX {
+			out = append(out, image.Pt(x, y-1), image.Pt(x+2, y), image.Pt(x, y+1), image.Pt(x-2, y))
+		} else {
+			out = append(out, image.Pt(x, y-2), image.Pt(x+1, y), image.Pt(x, y+2), image.Pt(x-1, y))
+		}
+	default:
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dx != 0 || dy != 0 {
+					out = append(out, image.Pt(x+dx, y+dy))
+				}
+			}
+		}
+	}
+	return m.clip(out)
+}
+
+// axialDirections holds the steps to the six neighbors of a hexagonal cell.
+var axialDirections = []Axial{{1, 0}, {1, -1}, {0, -1}, {-1, 0}, {-1, 1}, {0, 1}}
+
+// diagonals returns the top-left, top-right, bottom-right and bottom-left
+// neighbors of the cell (x, y) of a staggered grid, whose diamonds share an
+// edge with it.
+func (g hexGrid) diagonals(x, y int) []image.Point {
+	if g.staggerX {
+		// Shifted columns are lower than their neighbors.
+		up := y - 1
+		if g.staggered(x) {
+			up = y
+		}
+		return []image.Point{{x - 1, up}, {x + 1, up}, {x + 1, up + 1}, {x - 1, up + 1}}
+	}
+	// Shifted rows are right of their neighbors.
+	left := x - 1
+	if g.staggered(y) {
+		left = x
+	}
+	return []image.Point{{left, y - 1}, {left + 1, y - 1}, {left + 1, y + 1}, {left, y + 1}}
+}
+
+// clip removes the cells outside of the map from cells.
+func (m *Map) clip(cells []image.Point) []image.Point {
+	bounds := image.Rect(0, 0, m.Width, m.Height)
+	out := cells[:0]
+	for _, c := range cells {
+		if c.In(bounds) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package tmx
+
+import (
+	"image"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// sorted returns cells sorted by row and column.
+func sorted(cells []image.Point) []image.Point {
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Y != cells[j].Y {
+			return cells[i].Y < cells[j].Y
+		}
+		return cells[i].X < cells[j].X
+	})
+	return cells
+}
+
+func TestNeighbors(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		m         Map
+		x, y      int
+		neighbors []image.Point
+		all       []image.Point
+	}{{
+		name:      "orthogonal",
+		m:         Map{Width: 4, Height: 4},
+		x:         1,
+		y:         1,
+		neighbors: []image.Point{{1, 0}, {0, 1}, {2, 1}, {1, 2}},
+		all:       []image.Point{{0, 0}, {1, 0}, {2, 0}, {0, 1}, {2, 1}, {0, 2}, {1, 2}, {2, 2}},
+	}, {
+		name:      "orthogonal corner",
+		m:         Map{Width: 4, Height: 4},
+		neighbors: []image.Point{{1, 0}, {0, 1}},
+		all:       []image.Point{{1, 0}, {0, 1}, {1, 1}},
+	}, {
+		name:      "staggered y odd, shifted row",
+		m:         Map{MapOrientation: MapStaggered, StaggerAxis: StaggerY, StaggerIndex: StaggerOdd, Width: 4, Height: 6},
+		x:         1,
+		y:         3,
+		neighbors: []image.Point{{1, 2}, {2, 2}, {1, 4}, {2, 4}},
+		all:       []image.Point{{1, 1}, {1, 2}, {2, 2}, {0, 3}, {2, 3}, {1, 4}, {2, 4}, {1, 5}},
+	}, {
+		name:      "staggered y odd",
+		m:         Map{MapOrientation: MapStaggered, StaggerAxis: StaggerY, StaggerIndex: StaggerOdd, Width: 4, Height: 6},
+		x:         1,
+		y:         2,
+		neighbors: []image.Point{{0, 1}, {1, 1}, {0, 3}, {1, 3}},
+		all:       []image.Point{{1, 0}, {0, 1}, {1, 1}, {0, 2}, {2, 2}, {0, 3}, {1, 3}, {1, 4}},
+	}, {
+		name:      "staggered x even",
+		m:         Map{MapOrientation: MapStaggered, StaggerAxis: StaggerX, StaggerIndex: StaggerEven, Width: 6, Height: 4},
+		x:         2,
+		y:         1,
+		neighbors: []image.Point{{1, 1}, {3, 1}, {1, 2}, {3, 2}},
+		all:       []image.Point{{2, 0}, {1, 1}, {3, 1}, {0, 1}, {4, 1}, {1, 2}, {3, 2}, {2, 2}},
+	}, {
+		name:      "hexagonal y odd",
+		m:         Map{MapOrientation: MapHexagonal, StaggerAxis: StaggerY, StaggerIndex: StaggerOdd, Width: 4, Height: 4},
+		x:         1,
+		y:         1,
+		neighbors: []image.Point{{1, 0}, {2, 0}, {0, 1}, {2, 1}, {1, 2}, {2, 2}},
+		all:       []image.Point{{1, 0}, {2, 0}, {0, 1}, {2, 1}, {1, 2}, {2, 2}},
+	}, {
+		name:      "hexagonal x odd",
+		m:         Map{MapOrientation: MapHexagonal, StaggerAxis: StaggerX, StaggerIndex: StaggerOdd, Width: 4, Height: 4},
+		x:         2,
+		y:         1,
+		neighbors: []image.Point{{1, 0}, {2, 0}, {3, 0}, {1, 1}, {3, 1}, {2, 2}},
+		all:       []image.Point{{1, 0}, {2, 0}, {3, 0}, {1, 1}, {3, 1}, {2, 2}},
+	}} {
+		if got, want := sorted(tc.m.Neighbors(tc.x, tc.y)), sorted(tc.neighbors); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Neighbors(%d, %d) = %v, want %v", tc.name, tc.x, tc.y, got, want)
+		}
+		if got, want := sorted(tc.m.AllNeighbors(tc.x, tc.y)), sorted(tc.all); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: AllNeighbors(%d, %d) = %v, want %v", tc.name, tc.x, tc.y, got, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package tmx
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"os"
+)
+
+// ObjectTypes models an objecttypes.xml file of older Tiled versions.
+// See: https://doc.mapeditor.org/en/stable/manual/custom-properties/#predefining-properties.
+type ObjectTypes struct {
+	Types []ObjectType `xml:"objecttype"`
+}
+
+// ObjectType models an <objecttype> holding the defaults of objects of that type.
+type ObjectType struct {
+	Name       string               `xml:"name,attr"`
+	Color      string               `xml:"color,attr"`
+	Properties []ObjectTypeProperty `xml:"property"`
+}
+
+// ObjectTypeProperty models an object type <property> and its default value.
+type ObjectTypeProperty struct {
+	Name    string `xml:"name,attr"`
+	Type    string `xml:"type,attr"`
+	Default string `xml:"default,attr"`
+}
+
+// ReadObjectTypes reads object types from the reader r or returns an error.
+func ReadObjectTypes(r io.Reader) (*ObjectTypes, error) {
+	out := new(ObjectTypes)
+	if err := xml.NewDecoder(r).Decode(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReadObjectTypesFile reads object types from a file path or returns an error.
+func ReadObjectTypesFile(path string) (*ObjectTypes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadObjectTypes(f)
+}
+
+// Lookup returns the object type named name.
+func (t *ObjectTypes) Lookup(name string) (ObjectType, bool) {
+	for _, ot := range t.Types {
+		if ot.Name == name {
+			return ot, true
+		}
+	}
+	return ObjectType{}, false
+}
+
+// Properties returns the properties of o including the defaults of its type.
+// Properties set on o override the defaults of the same name.
+func (t *ObjectTypes) Properties(o Object) Properties {
+	ot, ok := t.Lookup(o.Type)
+	if !ok {
+		return o.Properties
+	}
+	return mergeProperties(ot.defaults(), o.Properties)
+}
+
+// Color returns the display color of o's type, or false if it has none.
+func (t *ObjectTypes) Color(o Object) (string, bool) {
+	ot, ok := t.Lookup(o.Type)
+	if !ok || ot.Color == "" {
+		return "", false
+	}
+	return ot.Color, true
+}
+
+func (ot ObjectType) defaults() Properties {
+	out := make(Properties, len(ot.Properties))
+	for i, p := range ot.Properties {
+		out[i] = Property{Name: p.Name, Type: p.Type, Value: p.Default}
+		if p.Type == "string" {
+			out[i].Type = ""
+		}
+	}
+	return out
+}
+
+// PropertyTypes converts the object types to class property types usable
+// as object classes, as Tiled does when importing objecttypes.xml.
+func (t *ObjectTypes) PropertyTypes() []PropertyType {
+	out := make([]PropertyType, len(t.Types))
+	for i, ot := range t.Types {
+		pt := PropertyType{
+			ID:    i + 1,
+			Name:  ot.Name,
+			Type:  "class",
+			Color: ot.Color,
+			UseAs: []string{"object"},
+		}
+		for _, p := range ot.defaults() {
+			j, _ := propertyValueToJSON(p) // Defaults are not classes, so they convert.
+			pt.Members = append(pt.Members, PropertyMember{Name: p.Name, Type: p.Type, Value: json.RawMessage(j)})
+		}
+		out[i] = pt
+	}
+	return out
+}
@@ -0,0 +1,36 @@
+package tmx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestObjectTypes(t *testing.T) {
+	types, err := ReadObjectTypesFile("testdata/objecttypes.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := Object{Type: "enemy", Properties: []Property{{Name: "hp", Type: "int", Value: "30"}}}
+	want := Properties{{Name: "name", Value: "grunt"}, {Name: "hp", Type: "int", Value: "30"}}
+	if got := types.Properties(o); !reflect.DeepEqual(got, want) {
+		t.Errorf("Properties() = %+v, want %+v", got, want)
+	}
+	if c, ok := types.Color(o); !ok || c != "#ff0000" {
+		t.Errorf("Color() = %q, %v", c, ok)
+	}
+	if _, ok := types.Color(Object{Type: "chest"}); ok {
+		t.Error("Color() of unknown type found a color")
+	}
+
+	r := NewTypeRegistry()
+	r.Register(types.PropertyTypes()...)
+	resolved, err := r.Resolve(Property{Name: "e", Type: "class", PropertyType: "enemy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = Properties{{Name: "hp", Type: "int", Value: "10"}, {Name: "name", Value: "grunt"}}
+	if !reflect.DeepEqual(resolved.Properties, want) {
+		t.Errorf("registry members = %+v, want %+v", resolved.Properties, want)
+	}
+}
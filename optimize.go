@@ -0,0 +1,212 @@
+package tmx
+
+import (
+	"image"
+	"image/draw"
+	"maps"
+	"slices"
+)
+
+// StripUnusedTiles shrinks the tilesets of the map to the tiles used by its
+// tile layers and tile objects, along with the frames of their animations.
+// Tilesets without used tiles are removed, and the metadata of unused tiles
+// is removed from the others, with their wang tiles. External tilesets are
+// kept whole as their files are shared, and tilesets of unknown size, such
+// as external tilesets that are not loaded, keep the GIDs up to the next
+// tileset.
+//
+// images holds decoded tileset images by index in Tilesets. The images of
+// these tilesets are repacked to hold only the used tiles, in order of their
+// IDs and without margin or spacing, and the tiles are renumbered from 0. The
+// repacked images are returned by index in the new Tilesets, and must be
+// saved to the sources of their tilesets by the caller.
+//
+// First GIDs are then assigned in order like AddTileset and the GIDs of tile
+// layers and tile objects are remapped like RemapGIDs. Nothing is changed on
+// error.
+func (m *Map) StripUnusedTiles(images map[int]image.Image) (map[int]image.Image, error) {
+	index := make(map[GID]int, len(m.Tilesets))
+	for i := range m.Tilesets {
+		index[m.Tilesets[i].FirstGID] = i
+	}
+	used := make([]map[ID]bool, len(m.Tilesets))
+	use := func(gid GID) error {
+		if gid&GIDMask == 0 {
+			return nil
+		}
+		ts := m.TilesetForGID(gid & GIDMask)
+		if ts == nil {
+			return ErrInvalidGID
+		}
+		i := index[ts.FirstGID]
+		if used[i] == nil {
+			used[i] = make(map[ID]bool)
+		}
+		used[i][ID(gid&GIDMask-ts.FirstGID)] = true
+		return nil
+	}
+	for i := 0; i < len(m.Layers); i++ {
+		l := &m.Layers[i]
+		gids, err := l.Decoded()
+		if err != nil {
+			return nil, err
+		}
+		for j, gid := range gids {
+			if err := use(gid); err != nil {
+				return nil, l.errorAt(j, err)
+			}
+		}
+	}
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		for _, o := range m.ObjectGroups[i].Objects {
+			if err := use(o.GID); err != nil {
+				return nil, &ObjectError{o.ID, err}
+			}
+		}
+	}
+
+	var (
+		tilesets []Tileset
+		from     []int
+		ids      = make([]map[ID]ID, len(m.Tilesets)) // New IDs of repacked tilesets.
+		firsts   = make([]GID, len(m.Tilesets))
+		out      = make(map[int]image.Image)
+		next     = GID(1)
+	)
+	for i := range m.Tilesets {
+		if len(used[i]) == 0 {
+			continue
+		}
+		c := m.Tilesets[i]
+		if c.Source == "" {
+			ts := &m.Tilesets[i]
+			ts.addFrames(used[i])
+			c = *ts.stripped(used[i])
+			if img, ok := images[i]; ok && ts.Image.Source != "" {
+				keep := slices.Sorted(maps.Keys(used[i]))
+				packed, err := c.repack(img, keep)
+				if err != nil {
+					return nil, err
+				}
+				ids[i] = make(map[ID]ID, len(keep))
+				for j, id := range keep {
+					ids[i][id] = ID(j)
+				}
+				c.renumber(ids[i])
+				out[len(tilesets)] = packed
+			} else if ts.Image.Source == "" {
+				c.Tilecount = len(c.Tiles)
+			}
+		}
+		c.FirstGID = next
+		firsts[i] = next
+		next += m.spanOf(i, &c)
+		tilesets = append(tilesets, c)
+		from = append(from, i)
+	}
+
+	err := m.mapGIDs(func(gid GID) GID {
+		ts := m.TilesetForGID(gid & GIDMask)
+		if ts == nil {
+			return gid
+		}
+		i := index[ts.FirstGID]
+		id := ID(gid&GIDMask - ts.FirstGID)
+		if ids[i] != nil {
+			id = ids[i][id]
+		}
+		return firsts[i] + GID(id) | gid&^GIDMask
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.setTilesets(tilesets, from)
+	return out, nil
+}
+
+// addFrames adds the frames of the animations of the used tiles of the
+// tileset to used.
+func (ts *Tileset) addFrames(used map[ID]bool) {
+	for added := true; added; {
+		added = false
+		for i := 0; i < len(ts.Tiles); i++ {
+			t := &ts.Tiles[i]
+			if !used[t.ID] {
+				continue
+			}
+			for _, f := range t.Animation.Frames {
+				if !used[f.TileID] {
+					used[f.TileID] = true
+					added = true
+				}
+			}
+		}
+	}
+}
+
+// stripped returns a copy of the tileset without the tiles and wang tiles
+// of the tiles not in used.
+func (ts *Tileset) stripped(used map[ID]bool) *Tileset {
+	c := ts.Clone()
+	c.Tiles = slices.DeleteFunc(c.Tiles, func(t Tile) bool { return !used[t.ID] })
+	for i := range c.WangSets {
+		w := &c.WangSets[i]
+		w.Tiles = slices.DeleteFunc(w.Tiles, func(t WangTile) bool { return !used[t.TileID] })
+	}
+	return c
+}
+
+// repack returns a copy of the tiles keep of the tileset image img, laid out
+// in order with the columns of the tileset, and sets the tileset to it.
+func (ts *Tileset) repack(img image.Image, keep []ID) (image.Image, error) {
+	cols := min(ts.columns(img.Bounds().Dx()), len(keep))
+	if cols <= 0 {
+		return nil, ErrInvalidSize
+	}
+	rows := (len(keep) + cols - 1) / cols
+	out := image.NewNRGBA(image.Rect(0, 0, cols*ts.TileWidth, rows*ts.TileHeight))
+	for i, id := range keep {
+		src := ts.SubImage(img, id)
+		if src == nil {
+			return nil, ErrOutOfBounds
+		}
+		at := image.Pt(i%cols*ts.TileWidth, i/cols*ts.TileHeight)
+		draw.Draw(out, image.Rectangle{at, at.Add(src.Bounds().Size())}, src, src.Bounds().Min, draw.Src)
+	}
+	ts.Margin, ts.Spacing = 0, 0
+	ts.Columns, ts.Tilecount = cols, len(keep)
+	ts.Image.Width, ts.Image.Height = out.Bounds().Dx(), out.Bounds().Dy()
+	return out, nil
+}
+
+// renumber replaces the tile IDs of the tileset by the new IDs in ids.
+// Icons of removed tiles are reset to tile 0.
+func (ts *Tileset) renumber(ids map[ID]ID) {
+	for i := range ts.Tiles {
+		t := &ts.Tiles[i]
+		t.ID = ids[t.ID]
+		for j := range t.Animation.Frames {
+			t.Animation.Frames[j].TileID = ids[t.Animation.Frames[j].TileID]
+		}
+	}
+	for i := range ts.Terrains {
+		ts.Terrains[i].TileID = ids[ts.Terrains[i].TileID]
+	}
+	for i := range ts.WangSets {
+		w := &ts.WangSets[i]
+		w.TileID = ids[w.TileID]
+		for j := range w.Corners {
+			w.Corners[j].TileID = ids[w.Corners[j].TileID]
+		}
+		for j := range w.Edges {
+			w.Edges[j].TileID = ids[w.Edges[j].TileID]
+		}
+		for j := range w.Colors {
+			w.Colors[j].TileID = ids[w.Colors[j].TileID]
+		}
+		for j := range w.Tiles {
+			w.Tiles[j].TileID = ids[w.Tiles[j].TileID]
+		}
+	}
+	ts.index = nil
+}
@@ -0,0 +1,133 @@
+package tmx
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+// stripFixture returns a map using tiles 1 and 3 of a tileset of 4 tiles,
+// tile 1 being animated with tile 2, no tile of a second tileset and tile 5
+// of an image collection, and the image of the first tileset.
+func stripFixture(t *testing.T) (*Map, image.Image) {
+	t.Helper()
+	m := NewMap(3, 1, 8, 8, MapOrthogonal)
+	m.AddTileset(Tileset{
+		Name: "a", TileWidth: 8, TileHeight: 8, Margin: 1, Spacing: 2, Tilecount: 4, Columns: 4,
+		Image: Image{Source: "a.png", Width: 39, Height: 10},
+		Tiles: []Tile{
+			{ID: 0, Type: "unused"},
+			{ID: 1, Animation: Animation{Frames: []Frame{{TileID: 1, Duration: 100}, {TileID: 2, Duration: 100}}}},
+		},
+		WangSets: []WangSet{{Name: "w", Tiles: []WangTile{{TileID: 0}, {TileID: 3}}}},
+	})
+	m.AddTileset(Tileset{Name: "b", TileWidth: 8, TileHeight: 8, Tilecount: 2})
+	m.AddTileset(Tileset{Name: "c", TileWidth: 8, TileHeight: 8, Tilecount: 2, Tiles: []Tile{
+		{ID: 0, Image: Image{Source: "0.png"}},
+		{ID: 5, Image: Image{Source: "5.png"}},
+	}})
+	l := m.AddTileLayer("ground")
+	if err := l.Encode([]GID{2 | GIDHorizontalFlip, 0, 7 + 5}, m.DataEncoder(CSV, "")); err != nil {
+		t.Fatal(err)
+	}
+	m.AddObject(m.AddObjectGroup("objects"), Object{GID: 4, VerticalFlip: true, Visible: true})
+
+	img := image.NewNRGBA(image.Rect(0, 0, 39, 10))
+	for id := ID(0); id < 4; id++ {
+		r := m.Tilesets[0].TileRect(id)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				img.Set(x, y, color.NRGBA{uint8(id), 0, 0, 255})
+			}
+		}
+	}
+	return m, img
+}
+
+func TestStripUnusedTiles(t *testing.T) {
+	m, _ := stripFixture(t)
+	images, err := m.StripUnusedTiles(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 0 {
+		t.Errorf("StripUnusedTiles() returned %d images, want none", len(images))
+	}
+	if len(m.Tilesets) != 2 || m.Tilesets[0].Name != "a" || m.Tilesets[1].Name != "c" {
+		t.Fatalf("tilesets = %v, want a, c", m.Tilesets)
+	}
+	a, c := m.Tilesets[0], m.Tilesets[1]
+	if len(a.Tiles) != 1 || a.Tiles[0].ID != 1 || a.Tilecount != 4 {
+		t.Errorf("tileset a: tiles %v, tile count %d, want tile 1 of 4", a.Tiles, a.Tilecount)
+	}
+	if w := a.WangSets[0].Tiles; len(w) != 1 || w[0].TileID != 3 {
+		t.Errorf("tileset a: wang tiles %v, want tile 3", w)
+	}
+	if len(c.Tiles) != 1 || c.Tiles[0].ID != 5 || c.FirstGID != 5 {
+		t.Errorf("tileset c: tiles %v, first GID %d, want tile 5 from 5", c.Tiles, c.FirstGID)
+	}
+	gids, err := m.Layers[0].Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []GID{2 | GIDHorizontalFlip, 0, 5 + 5}; !slices.Equal(gids, want) {
+		t.Errorf("tiles = %v, want %v", gids, want)
+	}
+	if got := m.ObjectGroups[0].Objects[0].GID; got != 4 {
+		t.Errorf("object GID = %d, want 4", got)
+	}
+}
+
+func TestStripUnusedTilesRepack(t *testing.T) {
+	m, img := stripFixture(t)
+	images, err := m.StripUnusedTiles(map[int]image.Image{0: img})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := m.Tilesets[0]
+	if a.Tilecount != 3 || a.Columns != 3 || a.Margin != 0 || a.Spacing != 0 || a.Image.Width != 24 || a.Image.Height != 8 {
+		t.Errorf("tileset a = %d tiles in %d columns, margin %d, spacing %d, %dx%d image, want 3 in 3, 0, 0, 24x8",
+			a.Tilecount, a.Columns, a.Margin, a.Spacing, a.Image.Width, a.Image.Height)
+	}
+	if frames := a.Tiles[0].Animation.Frames; a.Tiles[0].ID != 0 || frames[0].TileID != 0 || frames[1].TileID != 1 {
+		t.Errorf("tileset a: animated tile %d with frames %v, want tile 0 with 0, 1", a.Tiles[0].ID, frames)
+	}
+	if w := a.WangSets[0].Tiles; len(w) != 1 || w[0].TileID != 2 {
+		t.Errorf("tileset a: wang tiles %v, want tile 2", w)
+	}
+	packed := images[0]
+	if packed == nil || packed.Bounds() != image.Rect(0, 0, 24, 8) {
+		t.Fatalf("repacked image = %v, want 24x8", packed)
+	}
+	for i, old := range []uint8{1, 2, 3} {
+		if r, _, _, _ := packed.At(i*8+4, 4).RGBA(); uint8(r>>8) != old {
+			t.Errorf("repacked tile %d shows tile %d, want %d", i, r>>8, old)
+		}
+	}
+
+	gids, err := m.Layers[0].Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []GID{1 | GIDHorizontalFlip, 0, 4 + 5}; !slices.Equal(gids, want) {
+		t.Errorf("tiles = %v, want %v", gids, want)
+	}
+	if o := m.ObjectGroups[0].Objects[0]; o.GID != 3 || !o.VerticalFlip {
+		t.Errorf("object GID = %d, vertical flip %v, want 3, true", o.GID, o.VerticalFlip)
+	}
+}
+
+func TestStripUnusedTilesInvalidGID(t *testing.T) {
+	m, _ := stripFixture(t)
+	if err := m.Layers[0].SetTile(1, 0, 100); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.StripUnusedTiles(nil); !errors.Is(err, ErrInvalidGID) {
+		t.Errorf("StripUnusedTiles() = %v, want %v", err, ErrInvalidGID)
+	}
+	if len(m.Tilesets) != 3 {
+		t.Errorf("tilesets changed on error")
+	}
+}
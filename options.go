@@ -0,0 +1,182 @@
+package tmx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+)
+
+// ErrResourceLimit is returned when a map exceeds the ResourceLimits it is
+// read with.
+var ErrResourceLimit = errors.New("tmx: resource limit exceeded")
+
+// ResourceLimits bounds the resources of untrusted maps. Zero fields are
+// unlimited.
+type ResourceLimits struct {
+	MaxBytes  int64 // Bytes read from each file.
+	MaxLayers int   // Layers of any kind.
+	MaxTiles  int   // Cells of each tile layer.
+
+	// MaxDecodedBytes bounds the decoded data of each tile layer, at four
+	// bytes per cell. Unlike the other limits, it is checked when layers
+	// are decoded, so it also bounds maps read with WithoutLayerDecode.
+	MaxDecodedBytes int64
+}
+
+// ReadOption configures Read, ReadFile and their context variants.
+type ReadOption func(*readOptions)
+
+// readOptions holds the options of a read: the loader it reads with and
+// whether external resources are loaded.
+type readOptions struct {
+	loader   Loader
+	external bool
+}
+
+// newReadOptions applies opts to the defaults of a read.
+func newReadOptions(external bool, opts []ReadOption) *readOptions {
+	o := &readOptions{external: external}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithExternalTilesets sets whether the external tilesets and object
+// templates of the map are loaded. ReadFile loads them by default, relative
+// to the map file. Read does not; when enabled, they are loaded relative to
+// the root of the file system set by WithFS or the working directory.
+func WithExternalTilesets(load bool) ReadOption {
+	return func(o *readOptions) { o.external = load }
+}
+
+// WithStrictMode rejects maps with attributes holding unknown values, and
+// maps whose tile layers do not decode or hold GIDs outside of the map's
+// tilesets when they are read, rather than when the layers are first decoded.
+func WithStrictMode() ReadOption {
+	return func(o *readOptions) { o.loader.Strict = true }
+}
+
+// WithoutLayerDecode reads maps without their tile layer data, like
+// ReadMetadata.
+func WithoutLayerDecode() ReadOption {
+	return func(o *readOptions) { o.loader.SkipData = true }
+}
+
+// WithResourceLimits rejects maps exceeding limits with ErrResourceLimit.
+func WithResourceLimits(limits ResourceLimits) ReadOption {
+	return func(o *readOptions) { o.loader.Limits = limits }
+}
+
+// WithPositions records the positions of the layers and tilesets of TMX
+// maps in their documents, reported by Map.Position and Validate.
+func WithPositions() ReadOption {
+	return func(o *readOptions) { o.loader.Positions = true }
+}
+
+// WithFS reads files and external resources from fsys.
+func WithFS(fsys fs.FS) ReadOption {
+	return func(o *readOptions) { o.loader.FS = fsys }
+}
+
+// read reads a TMX map from r with the options.
+func (o *readOptions) read(ctx context.Context, r io.Reader) (*Map, error) {
+	l := &o.loader
+	r = l.limitReader(r)
+	m, err := readTMX(ctx, r, l)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.checkLimits(m); err != nil {
+		return nil, err
+	}
+	if o.external {
+		m.setLocation("", l.location("."))
+		if err := l.loadExternal(ctx, m, "."); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.check(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// limitReader returns r limited to Limits.MaxBytes.
+func (l *Loader) limitReader(r io.Reader) io.Reader {
+	if l.Limits.MaxBytes <= 0 {
+		return r
+	}
+	return &limitedReader{r, l.Limits.MaxBytes}
+}
+
+// limitedReader fails with ErrResourceLimit once more than n bytes are read.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > r.n+1 {
+		p = p[:r.n+1]
+	}
+	n, err := r.r.Read(p)
+	if r.n -= int64(n); r.n < 0 {
+		return 0, ErrResourceLimit
+	}
+	return n, err
+}
+
+// checkLimits checks m against the layer limits of l, and bounds the
+// decoded data of its tile layers.
+func (l *Loader) checkLimits(m *Map) error {
+	if max := l.Limits.MaxDecodedBytes; max > 0 {
+		for i := 0; i < len(m.Layers); i++ {
+			m.Layers[i].Data.maxBytes = max
+		}
+	}
+	if max := l.Limits.MaxLayers; max > 0 && len(m.Layers)+len(m.ObjectGroups)+len(m.ImageLayers)+len(m.Groups) > max {
+		return ErrResourceLimit
+	}
+	if max := l.Limits.MaxTiles; max > 0 {
+		for i := 0; i < len(m.Layers); i++ {
+			if w, h := m.Layers[i].Width, m.Layers[i].Height; w > max || h > max || w*h > max {
+				return ErrResourceLimit
+			}
+		}
+	}
+	return nil
+}
+
+// check checks the attributes of m in strict mode, and its layer data
+// unless it was skipped.
+func (l *Loader) check(m *Map) error {
+	if !l.Strict {
+		return nil
+	}
+	if err := m.checkEnums(); err != nil {
+		return err
+	}
+	if l.SkipData {
+		return nil
+	}
+	return m.checkStrict()
+}
+
+// checkStrict decodes the tile layers of m and checks their GIDs.
+func (m *Map) checkStrict() error {
+	for i := 0; i < len(m.Layers); i++ {
+		l := &m.Layers[i]
+		gids, err := l.Decoded()
+		if err != nil {
+			return err
+		}
+		for j, gid := range gids {
+			if gid != 0 && m.TilesetForGID(gid) == nil {
+				return l.errorAt(j, ErrInvalidGID)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package tmx
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadOptionsExternal(t *testing.T) {
+	m, err := ReadFile("testdata/external.tmx", WithExternalTilesets(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts := m.Tilesets[1]; ts.Source != "tiles.tsx" || ts.Name != "" {
+		t.Errorf("ReadFile() without external tilesets loaded %+v", ts)
+	}
+	if o := m.ObjectGroups[0].Objects[0]; o.Template != "crate.tx" || o.Name != "" {
+		t.Errorf("ReadFile() without external tilesets applied the template to %+v", o)
+	}
+
+	fsys := os.DirFS("testdata")
+	m, err = ReadFile("external.tmx", WithFS(fsys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Tilesets[1].Name; got != "external" {
+		t.Errorf("ReadFile() with FS tileset name = %q, want %q", got, "external")
+	}
+
+	f, err := os.Open("testdata/external.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m, err = Read(f, WithFS(fsys), WithExternalTilesets(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Tilesets[1].Name; got != "external" {
+		t.Errorf("Read() with external tilesets tileset name = %q, want %q", got, "external")
+	}
+}
+
+func TestReadOptionsStrict(t *testing.T) {
+	const data = `<map width="2" height="1">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8" tilecount="2"/>
+ <layer width="2" height="1"><data encoding="csv">1,5</data></layer>
+</map>`
+	if _, err := Read(strings.NewReader(data)); err != nil {
+		t.Errorf("Read() = %v, want nil", err)
+	}
+	if _, err := Read(strings.NewReader(data), WithStrictMode()); !errors.Is(err, ErrInvalidGID) {
+		t.Errorf("Read() in strict mode = %v, want %v", err, ErrInvalidGID)
+	}
+	m, err := Read(strings.NewReader(data), WithStrictMode(), WithoutLayerDecode())
+	if err != nil {
+		t.Fatalf("Read() in strict mode without layer data = %v, want nil", err)
+	}
+	if len(m.Layers[0].Data.Bytes) != 0 {
+		t.Errorf("Read() without layer decode kept data %q", m.Layers[0].Data.Bytes)
+	}
+}
+
+func TestReadOptionsLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		limits ResourceLimits
+		err    error
+	}{
+		{"none", ResourceLimits{}, nil},
+		{"bytes", ResourceLimits{MaxBytes: 100}, ErrResourceLimit},
+		{"enough bytes", ResourceLimits{MaxBytes: 1 << 20}, nil},
+		{"layers", ResourceLimits{MaxLayers: 1}, ErrResourceLimit},
+		{"tiles", ResourceLimits{MaxTiles: 3}, ErrResourceLimit},
+		{"enough tiles", ResourceLimits{MaxLayers: 2, MaxTiles: 4}, nil},
+	} {
+		if _, err := ReadFile("testdata/external.tmx", WithResourceLimits(tc.limits)); !errors.Is(err, tc.err) {
+			t.Errorf("ReadFile() with %s limit = %v, want %v", tc.name, err, tc.err)
+		}
+	}
+
+	const huge = `<map><layer width="1000000" height="1000000"><data encoding="base64" compression="zlib">eJxjZGBgYAJiZiBmAWIAAGAACw==</data></layer></map>`
+	m, err := Read(strings.NewReader(huge), WithResourceLimits(ResourceLimits{MaxDecodedBytes: 1 << 20}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Layers[0].Decode(); !errors.Is(err, ErrResourceLimit) {
+		t.Errorf("Decode() past the decoded bytes limit = %v, want %v", err, ErrResourceLimit)
+	}
+}
@@ -0,0 +1,153 @@
+package tmx
+
+import (
+	"image"
+	"iter"
+	"sort"
+)
+
+// Cells returns the cells of rect in the render order, in which tiles that
+// overlap their neighbors are drawn. The empty render order is right-down.
+func (o MapRenderOrder) Cells(rect image.Rectangle) []image.Point {
+	out := make([]image.Point, 0, max(rect.Dx()*rect.Dy(), 0))
+	for x, y := range o.All(rect) {
+		out = append(out, image.Pt(x, y))
+	}
+	return out
+}
+
+// All returns an iterator over the x and y coordinates of the cells of rect
+// in the render order, like Cells.
+func (o MapRenderOrder) All(rect image.Rectangle) iter.Seq2[int, int] {
+	return func(yield func(x, y int) bool) {
+		if rect.Empty() {
+			return
+		}
+		x0, x1, dx := rect.Min.X, rect.Max.X, 1
+		y0, y1, dy := rect.Min.Y, rect.Max.Y, 1
+		switch o {
+		case RenderRightUp:
+			y0, y1, dy = y1-1, y0-1, -1
+		case RenderLeftDown:
+			x0, x1, dx = x1-1, x0-1, -1
+		case RenderLeftUp:
+			x0, x1, dx = x1-1, x0-1, -1
+			y0, y1, dy = y1-1, y0-1, -1
+		}
+		for y := y0; y != y1; y += dy {
+			for x := x0; x != x1; x += dx {
+				if !yield(x, y) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Cells returns an iterator over the x and y coordinates of the cells of
+// the map in its render order.
+func (m *Map) Cells() iter.Seq2[int, int] {
+	return m.MapRenderOrder.All(image.Rect(0, 0, m.Width, m.Height))
+}
+
+// All returns an iterator over the cells of the layer, from the Origin, and
+// their tiles in row major order.
+func (l DecodedLayer) All() iter.Seq2[image.Point, DecodedTile] {
+	return func(yield func(image.Point, DecodedTile) bool) {
+		for i, t := range l.DecodedTiles {
+			if !yield(l.Origin.Add(image.Pt(i%l.Width, i/l.Width)), t) {
+				return
+			}
+		}
+	}
+}
+
+// Each calls fn with the coordinates of the cells of the layer and their
+// tiles in row major order until fn returns false.
+func (l DecodedLayer) Each(fn func(x, y int, t DecodedTile) bool) {
+	for p, t := range l.All() {
+		if !fn(p.X, p.Y, t) {
+			return
+		}
+	}
+}
+
+// Tiles returns an iterator over the cells of l, from its Origin, and their
+// tiles in m in row major order, decoding tiles as they are reached. The layer data is decoded
+// and cached like Decoded. It returns an error if the data does not decode
+// or holds a GID not found in m.
+func (l *Layer) Tiles(m *Map) (iter.Seq2[image.Point, DecodedTile], error) {
+	gids, err := l.Decoded()
+	if err != nil {
+		return nil, err
+	}
+	for i, gid := range gids {
+		if gid != 0 && m.TilesetForGID(gid) == nil {
+			return nil, l.errorAt(i, ErrInvalidGID)
+		}
+	}
+	origin, err := l.Origin()
+	if err != nil {
+		return nil, err
+	}
+	width := l.Width
+	return func(yield func(image.Point, DecodedTile) bool) {
+		for i, gid := range gids {
+			t, _ := m.DecodeGID(gid)
+			if !yield(origin.Add(image.Pt(i%width, i/width)), t) {
+				return
+			}
+		}
+	}, nil
+}
+
+// ForEachTile calls fn with the cells of l, from its Origin, and their
+// decoded tiles in the map's render order and stops at the first error fn
+// returns.
+func (m *Map) ForEachTile(l *Layer, fn func(x, y int, t DecodedTile) error) error {
+	gids, err := l.Decoded()
+	if err != nil {
+		return err
+	}
+	origin, err := l.Origin()
+	if err != nil {
+		return err
+	}
+	for x, y := range m.MapRenderOrder.All(image.Rect(0, 0, l.Width, l.Height)) {
+		i := y*l.Width + x
+		t, err := m.DecodeGID(gids[i])
+		if err != nil {
+			return l.errorAt(i, err)
+		}
+		if err := fn(origin.X+x, origin.Y+y, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SortOffsetProperty names an optional float property of objects added to
+// their Y coordinate when sorting them by depth.
+const SortOffsetProperty = "sortoffset"
+
+// DepthOrder returns the indices of the objects of g in the order they are
+// drawn. Objects of topdown groups, the default, are sorted by their Y
+// coordinate plus their SortOffsetProperty so that objects lower on screen
+// are drawn in front. Ties and objects of index groups keep their order of
+// appearance.
+func (g *ObjectGroup) DepthOrder() []int {
+	out := make([]int, len(g.Objects))
+	for i := range out {
+		out[i] = i
+	}
+	if g.DrawOrder == DrawIndex {
+		return out
+	}
+	depth := make([]float64, len(g.Objects))
+	for i := 0; i < len(g.Objects); i++ {
+		offset, _ := g.Objects[i].Properties.Float(SortOffsetProperty)
+		depth[i] = g.Objects[i].Y + offset
+	}
+	sort.SliceStable(out, func(i, j int) bool { return depth[out[i]] < depth[out[j]] })
+	return out
+}
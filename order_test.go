@@ -0,0 +1,150 @@
+package tmx
+
+import (
+	"errors"
+	"image"
+	"iter"
+	"reflect"
+	"testing"
+)
+
+func TestRenderOrderCells(t *testing.T) {
+	rect := image.Rect(1, 1, 3, 3)
+	for _, tc := range []struct {
+		order MapRenderOrder
+		want  []image.Point
+	}{
+		{"", []image.Point{{1, 1}, {2, 1}, {1, 2}, {2, 2}}},
+		{RenderRightDown, []image.Point{{1, 1}, {2, 1}, {1, 2}, {2, 2}}},
+		{RenderRightUp, []image.Point{{1, 2}, {2, 2}, {1, 1}, {2, 1}}},
+		{RenderLeftDown, []image.Point{{2, 1}, {1, 1}, {2, 2}, {1, 2}}},
+		{RenderLeftUp, []image.Point{{2, 2}, {1, 2}, {2, 1}, {1, 1}}},
+	} {
+		if got := tc.order.Cells(rect); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q.Cells() = %v, want %v", tc.order, got, tc.want)
+		}
+	}
+	if got := RenderLeftUp.Cells(image.Rectangle{}); len(got) != 0 {
+		t.Errorf("Cells(empty) = %v", got)
+	}
+}
+
+func TestForEachTile(t *testing.T) {
+	m := &Map{MapRenderOrder: RenderLeftUp, Tilesets: []Tileset{{FirstGID: 1}}}
+	l := &Layer{Width: 2, Height: 2}
+	if err := l.Encode([]GID{1, 2, 3, 4}, DataEncoder{Encoding: CSV}); err != nil {
+		t.Fatal(err)
+	}
+	var got []ID
+	err := m.ForEachTile(l, func(x, y int, tile DecodedTile) error {
+		got = append(got, tile.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []ID{3, 2, 1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ForEachTile() IDs = %v, want %v", got, want)
+	}
+}
+
+func TestTileIterators(t *testing.T) {
+	m := &Map{Tilesets: []Tileset{{FirstGID: 1}}}
+	l := &Layer{Width: 2, Height: 2}
+	if err := l.Encode([]GID{1, 0, 3, 4}, DataEncoder{Encoding: CSV}); err != nil {
+		t.Fatal(err)
+	}
+	tiles, err := l.Tiles(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := m.CompactLayer(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := c.Decoded()
+	wantCells := []image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+	for name, seq := range map[string]iter.Seq2[image.Point, DecodedTile]{
+		"Layer.Tiles":      tiles,
+		"DecodedLayer.All": d.All(),
+		"CompactLayer.All": c.All(),
+	} {
+		var cells []image.Point
+		var got []DecodedTile
+		for p, tile := range seq {
+			cells = append(cells, p)
+			got = append(got, tile)
+		}
+		if !reflect.DeepEqual(cells, wantCells) || !reflect.DeepEqual(got, d.DecodedTiles) {
+			t.Errorf("%s() = %v, %+v, want %v, %+v", name, cells, got, wantCells, d.DecodedTiles)
+		}
+		for range seq {
+			break
+		}
+	}
+
+	var cells []image.Point
+	d.Each(func(x, y int, tile DecodedTile) bool {
+		if tile != d.DecodedTiles[y*d.Width+x] {
+			t.Errorf("Each() tile at (%d, %d) = %+v, want %+v", x, y, tile, d.DecodedTiles[y*d.Width+x])
+		}
+		cells = append(cells, image.Pt(x, y))
+		return len(cells) < 3
+	})
+	if !reflect.DeepEqual(cells, wantCells[:3]) {
+		t.Errorf("Each() stopped after 3 cells = %v, want %v", cells, wantCells[:3])
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		for _, tile := range d.All() {
+			if tile.Tileset == nil && !tile.Nil {
+				t.Fatal("tile without tileset")
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("DecodedLayer.All() allocates %v times per scan", allocs)
+	}
+
+	if _, err := l.Tiles(&Map{Tilesets: []Tileset{{FirstGID: 2}}}); !errors.Is(err, ErrInvalidGID) {
+		t.Errorf("Tiles() with a missing tileset = %v, want %v", err, ErrInvalidGID)
+	}
+}
+
+func TestDepthOrder(t *testing.T) {
+	g := ObjectGroup{Objects: []Object{
+		{Y: 30},
+		{Y: 10, Properties: []Property{{Name: SortOffsetProperty, Type: "float", Value: "15"}}},
+		{Y: 20},
+		{Y: 10},
+		{Y: 20, Properties: []Property{{Name: SortOffsetProperty, Value: "x"}}},
+	}}
+	if got, want := g.DepthOrder(), []int{3, 2, 4, 1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DepthOrder() = %v, want %v", got, want)
+	}
+	g.DrawOrder = DrawIndex
+	if got, want := g.DepthOrder(), []int{0, 1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("index DepthOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestMapCells(t *testing.T) {
+	m := &Map{Width: 3, Height: 2, MapRenderOrder: RenderLeftUp}
+	var got []image.Point
+	for x, y := range m.Cells() {
+		got = append(got, image.Pt(x, y))
+	}
+	if want := []image.Point{{2, 1}, {1, 1}, {0, 1}, {2, 0}, {1, 0}, {0, 0}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Cells() = %v, want %v", got, want)
+	}
+
+	n := 0
+	for range RenderRightDown.All(image.Rect(0, 0, 4, 4)) {
+		if n++; n == 3 {
+			break
+		}
+	}
+	if n != 3 {
+		t.Errorf("All() yielded %d cells after break, want 3", n)
+	}
+}
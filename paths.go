@@ -0,0 +1,154 @@
+package tmx
+
+import (
+	"path"
+	"path/filepath"
+)
+
+// location records the directory that references of a loaded element are
+// relative to. The zero location resolves references against the working
+// directory.
+type location struct {
+	dir string
+	fs  bool // Whether dir is a slash-separated path within a Loader's fs.FS.
+}
+
+// resolve returns the reference ref resolved against the location.
+func (loc location) resolve(ref string) string {
+	if ref == "" {
+		return ""
+	}
+	if loc.fs {
+		return path.Join(loc.dir, ref)
+	}
+	if filepath.IsAbs(ref) {
+		return filepath.Clean(ref)
+	}
+	return filepath.Join(loc.dir, ref)
+}
+
+// abs returns the resolved reference as an absolute OS path.
+// References within an fs.FS are rooted at the file system instead.
+func (loc location) abs(ref string) (string, error) {
+	if loc.fs {
+		return "/" + loc.resolve(ref), nil
+	}
+	return filepath.Abs(loc.resolve(ref))
+}
+
+// Path returns the name the map was loaded from, or "" if it was read from a reader.
+func (m *Map) Path() string {
+	return m.path
+}
+
+// ResolvePath resolves a reference made by the map, like a file property,
+// to a path in the file system the map was loaded from.
+func (m *Map) ResolvePath(ref string) string {
+	return m.loc.resolve(ref)
+}
+
+// AbsPath resolves a reference made by the map to an absolute path.
+// For maps loaded from an fs.FS the path is rooted at the file system.
+func (m *Map) AbsPath(ref string) (string, error) {
+	return m.loc.abs(ref)
+}
+
+// FileProperty returns the value of the named file property of props set on
+// the map, its layers or objects, resolved like ResolvePath.
+// It returns false if props has no file property with that name.
+func (m *Map) FileProperty(props Properties, name string) (string, bool) {
+	return fileProperty(props, name, m.loc)
+}
+
+// ResolvedSource returns the path of the external tileset file in the file
+// system it was loaded from, or "" for embedded tilesets.
+func (ts *Tileset) ResolvedSource() string {
+	return ts.source
+}
+
+// ResolvePath resolves a reference made by the tileset, like a file property,
+// to a path in the file system it was loaded from. References in external
+// tilesets are relative to the tileset file rather than the map.
+func (ts *Tileset) ResolvePath(ref string) string {
+	return ts.loc.resolve(ref)
+}
+
+// FileProperty returns the value of the named file property of props set on
+// the tileset, its tiles or their objects, resolved like ResolvePath.
+// It returns false if props has no file property with that name.
+func (ts *Tileset) FileProperty(props Properties, name string) (string, bool) {
+	return fileProperty(props, name, ts.loc)
+}
+
+func fileProperty(props Properties, name string, loc location) (string, bool) {
+	p, ok := props.Get(name)
+	if !ok || p.Type != "file" {
+		return "", false
+	}
+	return loc.resolve(p.Value), true
+}
+
+// ResolvedSource returns the path of the image file in the file system the
+// referencing map or tileset was loaded from.
+func (img Image) ResolvedSource() string {
+	return img.loc.resolve(img.Source)
+}
+
+// AbsPath returns the absolute path of the image file.
+// For images loaded from an fs.FS the path is rooted at the file system.
+func (img Image) AbsPath() (string, error) {
+	return img.loc.abs(img.Source)
+}
+
+// setLocation records the location of the map, its embedded tilesets and
+// image layers.
+func (m *Map) setLocation(name string, loc location) {
+	m.path, m.loc = name, loc
+	for i := 0; i < len(m.Tilesets); i++ {
+		if m.Tilesets[i].Source == "" {
+			m.Tilesets[i].setLocation("", loc)
+		}
+	}
+	for i := 0; i < len(m.ImageLayers); i++ {
+		m.ImageLayers[i].Image.loc = loc
+	}
+}
+
+// setLocation records the location of the tileset and its images.
+func (ts *Tileset) setLocation(source string, loc location) {
+	ts.source, ts.loc = source, loc
+	ts.Image.loc = loc
+	for i := 0; i < len(ts.Tiles); i++ {
+		ts.Tiles[i].Image.loc = loc
+	}
+}
+
+// Dependencies returns the resolved paths of the external tilesets, object
+// templates and images the map references, without duplicates.
+func (m *Map) Dependencies() []string {
+	var deps []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			deps = append(deps, name)
+		}
+	}
+	for i := 0; i < len(m.Tilesets); i++ {
+		ts := &m.Tilesets[i]
+		add(ts.ResolvedSource())
+		add(ts.Image.ResolvedSource())
+		for j := 0; j < len(ts.Tiles); j++ {
+			add(ts.Tiles[j].Image.ResolvedSource())
+		}
+	}
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		for _, o := range m.ObjectGroups[i].Objects {
+			add(m.ResolvePath(o.Template))
+		}
+	}
+	for i := 0; i < len(m.ImageLayers); i++ {
+		add(m.ImageLayers[i].Image.ResolvedSource())
+	}
+	return deps
+}
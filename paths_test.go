@@ -0,0 +1,120 @@
+package tmx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolvedSourceFS(t *testing.T) {
+	tsx, err := os.ReadFile("testdata/tiles.tsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"maps/a.tmx": {Data: []byte(`<map version="1.2">
+ <tileset firstgid="1" name="embedded" tilewidth="8" tileheight="8"><image source="img/a.png"/></tileset>
+ <tileset firstgid="2" source="../sets/tiles.tsx"/>
+</map>`)},
+		"sets/tiles.tsx": {Data: tsx},
+	}
+
+	m, err := NewLoader(fsys).ReadFile("maps/a.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Path(); got != "maps/a.tmx" {
+		t.Errorf("Path() = %q", got)
+	}
+	if got := m.ResolvePath("../data/level.txt"); got != "data/level.txt" {
+		t.Errorf("ResolvePath() = %q", got)
+	}
+	if got := m.Tilesets[0].ResolvedSource(); got != "" {
+		t.Errorf("embedded ResolvedSource() = %q", got)
+	}
+	if got := m.Tilesets[0].Image.ResolvedSource(); got != "maps/img/a.png" {
+		t.Errorf("embedded image ResolvedSource() = %q", got)
+	}
+	if got := m.Tilesets[1].ResolvedSource(); got != "sets/tiles.tsx" {
+		t.Errorf("external ResolvedSource() = %q", got)
+	}
+	if got := m.Tilesets[1].Image.ResolvedSource(); got != "sets/tiles.png" {
+		t.Errorf("external image ResolvedSource() = %q", got)
+	}
+	if got, err := m.Tilesets[1].Image.AbsPath(); err != nil || got != "/sets/tiles.png" {
+		t.Errorf("external image AbsPath() = %q, %v", got, err)
+	}
+}
+
+func TestResolvedSourceOS(t *testing.T) {
+	m, err := ReadFile(filepath.Join("testdata", "world", "map_0_0.tmx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := m.Tilesets[0].Image
+	if got, want := img.ResolvedSource(), filepath.Join("testdata", "tiles.png"); got != want {
+		t.Errorf("ResolvedSource() = %q, want %q", got, want)
+	}
+	got, err := img.AbsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := filepath.Abs(filepath.Join("testdata", "tiles.png"))
+	if got != want {
+		t.Errorf("AbsPath() = %q, want %q", got, want)
+	}
+	if _, err := os.Stat(got); err != nil {
+		t.Error(err)
+	}
+
+	// Images of maps read from a reader resolve against the working directory.
+	if got := (Image{Source: "tiles.png"}).ResolvedSource(); got != "tiles.png" {
+		t.Errorf("unloaded ResolvedSource() = %q", got)
+	}
+}
+
+func TestFileProperty(t *testing.T) {
+	fsys := fstest.MapFS{
+		"maps/a.tmx": {Data: []byte(`<map version="1.2">
+ <properties><property name="music" type="file" value="../audio/a.ogg"/><property name="title" value="a"/></properties>
+ <tileset firstgid="1" source="../sets/s.tsx"/>
+ <objectgroup>
+  <object id="1" template="../templates/door.tx"/>
+ </objectgroup>
+</map>`)},
+		"sets/s.tsx": {Data: []byte(`<tileset name="s" tilewidth="8" tileheight="8">
+ <properties><property name="sound" type="file" value="step.wav"/></properties>
+</tileset>`)},
+		"templates/door.tx": {Data: []byte(`<template>
+ <object name="door">
+  <properties>
+   <property name="script" type="file" value="scripts/door.lua"/>
+   <property name="lock" type="class" propertytype="lock"><properties><property name="key" type="file" value="key.png"/></properties></property>
+  </properties>
+ </object>
+</template>`)},
+	}
+	m, err := NewLoader(fsys).ReadFile("maps/a.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := m.FileProperty(m.Properties, "music"); !ok || got != "audio/a.ogg" {
+		t.Errorf("map FileProperty() = %q, %v", got, ok)
+	}
+	if got, ok := m.FileProperty(m.Properties, "title"); ok {
+		t.Errorf("FileProperty() of a string property = %q, want false", got)
+	}
+	ts := &m.Tilesets[0]
+	if got, ok := ts.FileProperty(ts.Properties, "sound"); !ok || got != "sets/step.wav" {
+		t.Errorf("tileset FileProperty() = %q, %v", got, ok)
+	}
+	o := m.ObjectGroups[0].Objects[0]
+	if got, ok := m.FileProperty(o.Properties, "script"); !ok || got != "templates/scripts/door.lua" {
+		t.Errorf("template FileProperty() = %q, %v", got, ok)
+	}
+	lock, _ := o.Properties.Get("lock")
+	if got, ok := m.FileProperty(lock.Properties, "key"); !ok || got != "templates/key.png" {
+		t.Errorf("template class member FileProperty() = %q, %v", got, ok)
+	}
+}
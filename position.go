@@ -0,0 +1,115 @@
+package tmx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Position is a position in a TMX document.
+type Position struct {
+	Offset       int64 // Byte offset from the start of the document.
+	Line, Column int   // 1-based line and column.
+}
+
+// IsValid reports whether the position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+}
+
+// ParseError reports an error reading a TMX document at the position of
+// the element being read.
+type ParseError struct {
+	Pos Position
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("tmx: %v: %v", e.Pos, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// inputPos returns the position d has read up to.
+func inputPos(d *xml.Decoder) Position {
+	line, col := d.InputPos()
+	return Position{d.InputOffset(), line, col}
+}
+
+// positions holds the positions of the layers and tilesets of a map in the
+// document it was read from.
+type positions struct {
+	layers   map[LayerRef]Position
+	tilesets []Position
+}
+
+// Position returns the position of the element of the layer r refers to in
+// the TMX document the map was read from. Positions are only recorded when
+// reading with WithPositions or Loader.Positions.
+func (m *Map) Position(r LayerRef) (Position, bool) {
+	if m.pos == nil {
+		return Position{}, false
+	}
+	p, ok := m.pos.layers[r]
+	return p, ok
+}
+
+// TilesetPosition returns the position of the element of the tileset
+// Tilesets[i] in the TMX document the map was read from, like Position.
+func (m *Map) TilesetPosition(i int) (Position, bool) {
+	if m.pos == nil || i < 0 || i >= len(m.pos.tilesets) {
+		return Position{}, false
+	}
+	return m.pos.tilesets[i], true
+}
+
+var layerKinds = map[string]LayerKind{
+	"layer":       TileLayerKind,
+	"objectgroup": ObjectGroupKind,
+	"imagelayer":  ImageLayerKind,
+	"group":       GroupKind,
+}
+
+// recordPositions returns the positions of the layer and tileset elements
+// of the map in the TMX document r. Layers are numbered by kind in document
+// order, as they are added to the map. Elements nested in tilesets are skipped.
+func recordPositions(r io.Reader) *positions {
+	var (
+		out    positions
+		counts [GroupKind + 1]int
+		nested int
+	)
+	d := xml.NewDecoder(r)
+	for {
+		pos := inputPos(d)
+		tok, err := d.RawToken()
+		if err != nil {
+			return &out
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "tileset" {
+				if nested == 0 {
+					out.tilesets = append(out.tilesets, pos)
+				}
+				nested++
+				break
+			}
+			if kind, ok := layerKinds[t.Name.Local]; ok && nested == 0 {
+				if out.layers == nil {
+					out.layers = make(map[LayerRef]Position)
+				}
+				out.layers[LayerRef{kind, counts[kind]}] = pos
+				counts[kind]++
+			}
+		case xml.EndElement:
+			if t.Name.Local == "tileset" {
+				nested--
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tmx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorPosition(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		line int
+	}{
+		{"<map>\n <layer id=\"1\"/>\n <layer id=\"x\"/>\n</map>", 3},
+		{"<map>\n <layer>\n</map>", 3},
+	} {
+		_, err := Read(strings.NewReader(tc.data))
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("Read(%q) = %v, want *ParseError", tc.data, err)
+			continue
+		}
+		if pe.Pos.Line != tc.line {
+			t.Errorf("Read(%q) error at %v, want line %d", tc.data, pe.Pos, tc.line)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	const data = `<map width="1" height="1" tilewidth="8" tileheight="8">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8" tilecount="1">
+  <tile id="0"><objectgroup><object id="1"/></objectgroup></tile>
+ </tileset>
+ <layer id="1" width="1" height="1"><data encoding="csv">1</data></layer>
+ <group id="2">
+  <objectgroup id="3"/>
+    <layer id="4" width="1" height="1"><data encoding="csv">2</data></layer>
+ </group>
+</map>`
+	m, err := Read(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Position(LayerRef{TileLayerKind, 0}); ok {
+		t.Error("Position() recorded without WithPositions")
+	}
+
+	m, err = Read(strings.NewReader(data), WithPositions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		r         LayerRef
+		line, col int
+	}{
+		{LayerRef{TileLayerKind, 0}, 5, 2},
+		{LayerRef{GroupKind, 0}, 6, 2},
+		{LayerRef{ObjectGroupKind, 0}, 7, 3},
+		{LayerRef{TileLayerKind, 1}, 8, 5},
+	} {
+		pos, ok := m.Position(tc.r)
+		if !ok || pos.Line != tc.line || pos.Column != tc.col {
+			t.Errorf("Position(%v) = %v, %v, want line %d, column %d", tc.r, pos, ok, tc.line, tc.col)
+		}
+		if got := data[pos.Offset:]; !strings.HasPrefix(got, "<") {
+			t.Errorf("Position(%v) offset %d points at %.10q", tc.r, pos.Offset, got)
+		}
+	}
+	if _, ok := m.Position(LayerRef{ObjectGroupKind, 1}); ok {
+		t.Error("Position() recorded the object group of a tile")
+	}
+	if pos, ok := m.TilesetPosition(0); !ok || pos.Line != 2 {
+		t.Errorf("TilesetPosition(0) = %v, %v, want line 2", pos, ok)
+	}
+
+	issues := m.Validate()
+	if len(issues) != 1 || issues[0].Code != IssueInvalidGID || issues[0].Pos.Line != 8 {
+		t.Errorf("Validate() = %v, want invalid GID at line 8", issues)
+	}
+}
@@ -0,0 +1,161 @@
+package tmx
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrUnknownPropertyType is returned when a property references an unregistered custom type.
+var ErrUnknownPropertyType = errors.New("tmx: unknown property type")
+
+// Project models the parts of a Tiled .tiled-project file relevant to maps.
+// See: https://doc.mapeditor.org/en/stable/manual/projects/.
+type Project struct {
+	Folders        []string       `json:"folders"`
+	ExtensionsPath string         `json:"extensionsPath"`
+	PropertyTypes  []PropertyType `json:"propertyTypes"`
+}
+
+// PropertyType models a custom class or enum property type of a project.
+// See: https://doc.mapeditor.org/en/stable/manual/custom-properties/#custom-property-types.
+type PropertyType struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Type  string `json:"type"` // "class" or "enum".
+	Color string `json:"color,omitempty"`
+
+	// Class types.
+	Members []PropertyMember `json:"members,omitempty"`
+	UseAs   []string         `json:"useAs,omitempty"`
+
+	// Enum types.
+	StorageType   string   `json:"storageType,omitempty"` // "string" or "int".
+	Values        []string `json:"values,omitempty"`
+	ValuesAsFlags bool     `json:"valuesAsFlags,omitempty"`
+}
+
+// PropertyMember models a member of a custom class and its default value.
+type PropertyMember struct {
+	Name         string          `json:"name"`
+	Type         string          `json:"type"`
+	PropertyType string          `json:"propertyType,omitempty"`
+	Value        json.RawMessage `json:"value"`
+}
+
+// Default returns the member as a property holding its default value.
+func (m PropertyMember) Default() Property {
+	return propertyFromJSON(m.Name, m.Type, m.PropertyType, m.Value)
+}
+
+// ReadProject reads a Tiled project from the reader r or returns an error.
+func ReadProject(r io.Reader) (*Project, error) {
+	out := new(Project)
+	if err := json.NewDecoder(r).Decode(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReadProjectFile reads a Tiled project from a file path or returns an error.
+func ReadProjectFile(path string) (*Project, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadProject(f)
+}
+
+// Types returns a registry holding the project's property types.
+func (p *Project) Types() *TypeRegistry {
+	r := NewTypeRegistry()
+	r.Register(p.PropertyTypes...)
+	return r
+}
+
+// TypeRegistry resolves custom property types by name.
+type TypeRegistry struct {
+	types map[string]PropertyType
+}
+
+// NewTypeRegistry returns an empty registry.
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{types: make(map[string]PropertyType)}
+}
+
+// Register adds the types to the registry, replacing same-named types.
+func (r *TypeRegistry) Register(types ...PropertyType) {
+	for _, t := range types {
+		r.types[t.Name] = t
+	}
+}
+
+// Lookup returns the type registered under name.
+func (r *TypeRegistry) Lookup(name string) (PropertyType, bool) {
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// Resolve returns the class property p with every declared member present.
+// Members p does not set take the declared default, members it sets take the
+// declared type, and nested class members are merged and resolved recursively.
+// Properties that are not classes are returned unchanged.
+func (r *TypeRegistry) Resolve(p Property) (Property, error) {
+	if p.Type != "class" {
+		return p, nil
+	}
+	t, ok := r.types[p.PropertyType]
+	if !ok || t.Type != "class" {
+		return Property{}, ErrUnknownPropertyType
+	}
+
+	out := p
+	out.Properties = make(Properties, 0, len(t.Members))
+	for _, m := range t.Members {
+		member := m.Default()
+		for _, set := range p.Properties {
+			if set.Name == m.Name {
+				member.Value = set.Value
+				member.Properties = mergeProperties(member.Properties, set.Properties)
+				break
+			}
+		}
+		resolved, err := r.Resolve(member)
+		if err != nil {
+			return Property{}, err
+		}
+		out.Properties = append(out.Properties, resolved)
+	}
+	return out, nil
+}
+
+// ResolveAll resolves each class property of props.
+func (r *TypeRegistry) ResolveAll(props Properties) (Properties, error) {
+	out := make(Properties, len(props))
+	for i, p := range props {
+		var err error
+		if out[i], err = r.Resolve(p); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Defaults returns the default value of each member of the class named
+// class, or nil if no such class is registered.
+func (r *TypeRegistry) Defaults(class string) Properties {
+	if r == nil {
+		return nil
+	}
+	t, ok := r.types[class]
+	if !ok || t.Type != "class" {
+		return nil
+	}
+	out := make(Properties, len(t.Members))
+	for i, m := range t.Members {
+		out[i] = m.Default()
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package tmx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProjectResolve(t *testing.T) {
+	p, err := ReadProjectFile("testdata/test.tiled-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.PropertyTypes) != 3 || p.PropertyTypes[0].Values[1] != "Blue" {
+		t.Fatalf("PropertyTypes = %+v", p.PropertyTypes)
+	}
+
+	m, err := Read(strings.NewReader(`<map version="1.8">
+ <properties>
+  <property name="spawn" type="class" propertytype="Spawn">
+   <properties>
+    <property name="hp" type="int" value="25"/>
+    <property name="origin" type="class" propertytype="Point">
+     <properties>
+      <property name="y" type="int" value="7"/>
+     </properties>
+    </property>
+   </properties>
+  </property>
+  <property name="plain" value="text"/>
+ </properties>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := p.Types().ResolveAll(m.Properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Properties{
+		{Name: "spawn", Type: "class", PropertyType: "Spawn", Properties: []Property{
+			{Name: "hp", Type: "int", Value: "25"},
+			{Name: "team", PropertyType: "Team", Value: "Red"},
+			{Name: "origin", Type: "class", PropertyType: "Point", Properties: []Property{
+				{Name: "x", Type: "int", Value: "1"},
+				{Name: "y", Type: "int", Value: "7"},
+			}},
+		}},
+		{Name: "plain", Value: "text"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveAll() = %+v, want %+v", got, want)
+	}
+
+	if _, err := NewTypeRegistry().Resolve(m.Properties[0]); err != ErrUnknownPropertyType {
+		t.Errorf("Resolve() error = %v, want %v", err, ErrUnknownPropertyType)
+	}
+}
@@ -0,0 +1,229 @@
+package tmx
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"reflect"
+	"strconv"
+)
+
+// Flag error values returned by Properties.Unmarshal.
+var (
+	ErrInvalidUnmarshal = errors.New("tmx: Unmarshal needs a non-nil pointer to a struct")
+	ErrUnsupportedField = errors.New("tmx: unsupported field type")
+)
+
+// Properties is a list of custom properties.
+// See: https://doc.mapeditor.org/en/stable/manual/custom-properties/.
+type Properties []Property
+
+// Get returns the first property with the given name.
+func (p Properties) Get(name string) (Property, bool) {
+	for i := 0; i < len(p); i++ {
+		if p[i].Name == name {
+			return p[i], true
+		}
+	}
+	return Property{}, false
+}
+
+// Has reports whether p has a property with the given name.
+func (p Properties) Has(name string) bool {
+	_, ok := p.Get(name)
+	return ok
+}
+
+// String returns the value of the named property.
+func (p Properties) String(name string) (string, bool) {
+	v, ok := p.Get(name)
+	return v.Value, ok
+}
+
+// Int returns the value of the named int or object property.
+// It returns false if the property is missing or not an integer.
+func (p Properties) Int(name string) (int, bool) {
+	v, ok := p.Get(name)
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.Atoi(v.Value)
+	return i, err == nil
+}
+
+// Float returns the value of the named float property.
+// It returns false if the property is missing or not a number.
+func (p Properties) Float(name string) (float64, bool) {
+	v, ok := p.Get(name)
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(v.Value, 64)
+	return f, err == nil
+}
+
+// Bool returns the value of the named bool property.
+// It returns false if the property is missing or not a boolean.
+func (p Properties) Bool(name string) (value, ok bool) {
+	v, ok := p.Get(name)
+	if !ok {
+		return false, false
+	}
+	b, err := strconv.ParseBool(v.Value)
+	return b, err == nil
+}
+
+// Color returns the value of the named color property.
+// It returns false if the property is missing, empty or not a color.
+func (p Properties) Color(name string) (color.NRGBA, bool) {
+	v, ok := p.Get(name)
+	if !ok || v.Value == "" {
+		return color.NRGBA{}, false
+	}
+	c, err := ParseColor(v.Value)
+	return c, err == nil
+}
+
+// WithDefault returns the properties of defaults that p does not override,
+// such as the defaults of an object's class, followed by p.
+func (p Properties) WithDefault(defaults Properties) Properties {
+	return mergeProperties(defaults, p)
+}
+
+// PropertyError reports a property that could not be stored in a field.
+type PropertyError struct {
+	Name string
+	Err  error
+}
+
+func (e *PropertyError) Error() string {
+	return fmt.Sprintf("tmx: property %q: %v", e.Name, e.Err)
+}
+
+func (e *PropertyError) Unwrap() error { return e.Err }
+
+// Unmarshal stores the properties in the exported fields of the struct dst
+// points to. Fields are matched by the name in their `tmx:"name"` tag, or
+// by their field name without one; fields tagged `tmx:"-"` are skipped and
+// fields without a property are left unchanged.
+//
+// Property values are parsed into string, bool, integer and float fields,
+// colors into color.NRGBA fields, and class properties into struct fields
+// by their members. Pointer fields are allocated as needed.
+func (p Properties) Unmarshal(dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrInvalidUnmarshal
+	}
+	return p.unmarshal(v.Elem())
+}
+
+func (p Properties) unmarshal(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("tmx"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+		prop, ok := p.Get(name)
+		if !ok {
+			continue
+		}
+		if err := setField(v.Field(i), prop); err != nil {
+			return &PropertyError{Name: name, Err: err}
+		}
+	}
+	return nil
+}
+
+var nrgbaType = reflect.TypeOf(color.NRGBA{})
+
+// setField parses the value of prop into v.
+func setField(v reflect.Value, prop Property) error {
+	if v.Type() == nrgbaType {
+		var c color.NRGBA
+		if prop.Value != "" {
+			var err error
+			if c, err = ParseColor(prop.Value); err != nil {
+				return err
+			}
+		}
+		v.Set(reflect.ValueOf(c))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(prop.Value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(prop.Value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(prop.Value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(prop.Value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(prop.Value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Struct:
+		return prop.Properties.unmarshal(v)
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setField(v.Elem(), prop)
+	default:
+		return ErrUnsupportedField
+	}
+	return nil
+}
+
+// ObjectProperties returns the effective properties of o as the Tiled editor
+// shows them, from lowest to highest precedence:
+//   - the member defaults of o's class registered in types,
+//   - for tile objects, the member defaults of the tile's class and the
+//     properties of the tile,
+//   - the properties of o's template and of o itself.
+//
+// Template properties are merged into o.Properties when the map is loaded,
+// with those set on the instance taking precedence.
+// Class properties are resolved with types, which may be nil. Register
+// ObjectTypes.PropertyTypes to use the types of an objecttypes.xml file.
+func (m *Map) ObjectProperties(o *Object, types *TypeRegistry) (Properties, error) {
+	out := types.Defaults(o.Type)
+	if o.GID != 0 {
+		t, err := m.DecodeGID(o.GID)
+		if err != nil {
+			return nil, &ObjectError{ID: o.ID, Err: err}
+		}
+		if tile := t.Meta(); tile != nil {
+			out = mergeProperties(out, types.Defaults(tile.Type))
+			out = mergeProperties(out, tile.Properties)
+		}
+	}
+	out = mergeProperties(out, o.Properties)
+	if types == nil {
+		return out, nil
+	}
+	return types.ResolveAll(out)
+}
@@ -0,0 +1,175 @@
+package tmx
+
+import (
+	"encoding/json"
+	"errors"
+	"image/color"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestPropertiesGetters(t *testing.T) {
+	p := Properties{
+		{Name: "name", Value: "door"},
+		{Name: "hp", Type: "int", Value: "12"},
+		{Name: "speed", Type: "float", Value: "1.5"},
+		{Name: "locked", Type: "bool", Value: "true"},
+		{Name: "tint", Type: "color", Value: "#80ff0000"},
+		{Name: "none", Type: "color"},
+		{Name: "name", Value: "shadowed"},
+	}
+	if v, ok := p.Get("name"); !ok || v.Value != "door" {
+		t.Errorf("Get(name) = %+v, %v, want the first property", v, ok)
+	}
+	if !p.Has("hp") || p.Has("missing") {
+		t.Errorf("Has() = %v, %v, want true, false", p.Has("hp"), p.Has("missing"))
+	}
+	if v, ok := p.String("name"); !ok || v != "door" {
+		t.Errorf("String(name) = %q, %v", v, ok)
+	}
+	if v, ok := p.Int("hp"); !ok || v != 12 {
+		t.Errorf("Int(hp) = %d, %v", v, ok)
+	}
+	if _, ok := p.Int("speed"); ok {
+		t.Error("Int(speed) succeeded on a float")
+	}
+	if v, ok := p.Float("speed"); !ok || v != 1.5 {
+		t.Errorf("Float(speed) = %v, %v", v, ok)
+	}
+	if v, ok := p.Bool("locked"); !ok || !v {
+		t.Errorf("Bool(locked) = %v, %v", v, ok)
+	}
+	if _, ok := p.Bool("name"); ok {
+		t.Error("Bool(name) succeeded on a string")
+	}
+	if v, ok := p.Color("tint"); !ok || v != (color.NRGBA{0xff, 0, 0, 0x80}) {
+		t.Errorf("Color(tint) = %v, %v", v, ok)
+	}
+	if _, ok := p.Color("none"); ok {
+		t.Error("Color(none) succeeded on an empty color")
+	}
+	for _, get := range []func(string) bool{
+		func(n string) bool { _, ok := p.String(n); return ok },
+		func(n string) bool { _, ok := p.Int(n); return ok },
+		func(n string) bool { _, ok := p.Float(n); return ok },
+		func(n string) bool { _, ok := p.Bool(n); return ok },
+		func(n string) bool { _, ok := p.Color(n); return ok },
+	} {
+		if get("missing") {
+			t.Error("getter of a missing property succeeded")
+		}
+	}
+
+	defaults := Properties{{Name: "hp", Type: "int", Value: "30"}, {Name: "armor", Type: "int", Value: "2"}}
+	got := Properties{{Name: "hp", Type: "int", Value: "12"}}.WithDefault(defaults)
+	if want := (Properties{{Name: "armor", Type: "int", Value: "2"}, {Name: "hp", Type: "int", Value: "12"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("WithDefault() = %v, want %v", got, want)
+	}
+}
+
+func TestPropertiesUnmarshal(t *testing.T) {
+	type stats struct {
+		HP    int `tmx:"hp"`
+		Armor uint8
+	}
+	type enemy struct {
+		Name    string
+		Speed   float32     `tmx:"speed"`
+		Boss    bool        `tmx:"boss"`
+		Tint    color.NRGBA `tmx:"tint"`
+		Stats   stats       `tmx:"stats"`
+		Loot    *stats      `tmx:"loot"`
+		Ignored string      `tmx:"-"`
+		Kept    string
+		hidden  string
+	}
+	p := Properties{
+		{Name: "Name", Value: "grunt"},
+		{Name: "speed", Type: "float", Value: "2.5"},
+		{Name: "boss", Type: "bool", Value: "false"},
+		{Name: "tint", Type: "color", Value: "#ff00ff00"},
+		{Name: "stats", Type: "class", Properties: Properties{{Name: "hp", Type: "int", Value: "30"}, {Name: "Armor", Type: "int", Value: "4"}}},
+		{Name: "loot", Type: "class", Properties: Properties{{Name: "hp", Type: "int", Value: "5"}}},
+		{Name: "Ignored", Value: "x"},
+		{Name: "-", Value: "x"},
+		{Name: "hidden", Value: "x"},
+	}
+	got := enemy{Boss: true, Kept: "kept"}
+	if err := p.Unmarshal(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := enemy{
+		Name:  "grunt",
+		Speed: 2.5,
+		Tint:  color.NRGBA{0, 0xff, 0, 0xff},
+		Stats: stats{HP: 30, Armor: 4},
+		Loot:  &stats{HP: 5},
+		Kept:  "kept",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, want)
+	}
+
+	for _, tc := range []struct {
+		dst  any
+		want error
+	}{
+		{got, ErrInvalidUnmarshal},
+		{(*enemy)(nil), ErrInvalidUnmarshal},
+		{new(int), ErrInvalidUnmarshal},
+		{&struct{ Name []string }{}, ErrUnsupportedField},
+		{&struct {
+			Speed int `tmx:"speed"`
+		}{}, strconv.ErrSyntax},
+	} {
+		err := p.Unmarshal(tc.dst)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("Unmarshal(%T) = %v, want %v", tc.dst, err, tc.want)
+		}
+	}
+	var perr *PropertyError
+	if err := (Properties{{Name: "hp", Value: "many"}}).Unmarshal(&stats{}); !errors.As(err, &perr) || perr.Name != "hp" {
+		t.Errorf("Unmarshal() with an invalid int = %v, want a PropertyError for hp", err)
+	}
+}
+
+func TestObjectProperties(t *testing.T) {
+	member := func(name, value string) PropertyMember {
+		return PropertyMember{Name: name, Type: "int", Value: json.RawMessage(value)}
+	}
+	types := NewTypeRegistry()
+	types.Register(
+		PropertyType{Name: "enemy", Type: "class", Members: []PropertyMember{member("hp", "10"), member("armor", "1"), member("speed", "1")}},
+		PropertyType{Name: "crate", Type: "class", Members: []PropertyMember{member("armor", "2"), member("loot", "0")}},
+	)
+	m := &Map{Tilesets: []Tileset{{FirstGID: 1, Tiles: []Tile{
+		{ID: 2, Type: "crate", Properties: Properties{{Name: "loot", Type: "int", Value: "3"}, {Name: "speed", Type: "int", Value: "0"}}},
+	}}}}
+	o := &Object{Type: "enemy", GID: 3, HorizontalFlip: true, Properties: Properties{{Name: "hp", Type: "int", Value: "30"}}}
+
+	got, err := m.ObjectProperties(o, types)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Properties{
+		{Name: "armor", Type: "int", Value: "2"},
+		{Name: "loot", Type: "int", Value: "3"},
+		{Name: "speed", Type: "int", Value: "0"},
+		{Name: "hp", Type: "int", Value: "30"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ObjectProperties() = %+v, want %+v", got, want)
+	}
+
+	got, err = m.ObjectProperties(&Object{GID: 3}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := m.Tilesets[0].Tiles[0].Properties; !reflect.DeepEqual(got, want) {
+		t.Errorf("ObjectProperties() without types = %+v, want %+v", got, want)
+	}
+	if _, err := m.ObjectProperties(&Object{GID: 3}, NewTypeRegistry()); err != nil {
+		t.Errorf("ObjectProperties() with an empty registry = %v", err)
+	}
+}
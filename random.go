@@ -0,0 +1,93 @@
+package tmx
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// probabilityScale converts tile probabilities to fixed-point integer weights.
+// Weighted picks use integer arithmetic only so that a given seed yields the
+// same results on every platform.
+const probabilityScale = 1e6
+
+// TilePicker picks tiles from a tileset at random, weighted by tile probability.
+// Tiles without a <tile> element have probability 1.
+//
+// The picker draws only from its rand.Source, so pickers created from sources
+// with identical seeds produce identical output.
+type TilePicker struct {
+	rng      *rand.Rand
+	firstGID GID
+	ids      []ID
+	cum      []int64 // Cumulative weights of ids.
+}
+
+// NewTilePicker returns a picker drawing tiles from ts using src.
+func NewTilePicker(ts *Tileset, src rand.Source) *TilePicker {
+	count := ts.Tilecount
+	for _, t := range ts.Tiles {
+		if int(t.ID) >= count {
+			count = int(t.ID) + 1
+		}
+	}
+
+	weights := make([]int64, count)
+	for i := range weights {
+		weights[i] = probabilityScale
+	}
+	for _, t := range ts.Tiles {
+		weights[t.ID] = int64(math.Round(float64(t.Probability) * probabilityScale))
+	}
+
+	p := &TilePicker{rng: rand.New(src), firstGID: ts.FirstGID}
+	var total int64
+	for id, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		total += w
+		p.ids = append(p.ids, ID(id))
+		p.cum = append(p.cum, total)
+	}
+	return p
+}
+
+// Pick returns a random tile ID or false if no tile has a positive probability.
+func (p *TilePicker) Pick() (ID, bool) {
+	if len(p.cum) == 0 {
+		return 0, false
+	}
+	n := p.rng.Int63n(p.cum[len(p.cum)-1])
+	i := sort.Search(len(p.cum), func(i int) bool { return p.cum[i] > n })
+	return p.ids[i], true
+}
+
+// PickGID returns the GID of a random tile or 0 if no tile can be picked.
+func (p *TilePicker) PickGID() GID {
+	id, ok := p.Pick()
+	if !ok {
+		return 0
+	}
+	return p.firstGID + GID(id)
+}
+
+// Fill sets every entry of gids to a randomly picked GID.
+func (p *TilePicker) Fill(gids []GID) {
+	for i := range gids {
+		gids[i] = p.PickGID()
+	}
+}
+
+// Scatter is a decoration pass setting each empty entry of gids to a randomly
+// picked GID with a chance of permille/1000.
+func (p *TilePicker) Scatter(gids []GID, permille int) {
+	for i := range gids {
+		if gids[i] != 0 {
+			continue
+		}
+		if p.rng.Intn(1000) < permille {
+			gids[i] = p.PickGID()
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package tmx
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTilePicker(t *testing.T) {
+	ts := &Tileset{
+		FirstGID:  10,
+		Tilecount: 4,
+		Tiles: []Tile{
+			{ID: 1, Probability: 0},
+			{ID: 2, Probability: 0.5},
+		},
+	}
+
+	pick := func(seed int64) []GID {
+		gids := make([]GID, 64)
+		NewTilePicker(ts, rand.NewSource(seed)).Fill(gids)
+		return gids
+	}
+
+	a, b := pick(1), pick(1)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("identical seeds produced different tiles")
+	}
+	if reflect.DeepEqual(a, pick(2)) {
+		t.Error("different seeds produced identical tiles")
+	}
+
+	// Fixed output guards the algorithm against platform or refactoring drift.
+	if got, want := a[:8], []GID{10, 13, 12, 10, 13, 13, 10, 13}; !reflect.DeepEqual(got, want) {
+		t.Errorf("seed 1 picks = %v, want %v", got, want)
+	}
+
+	for _, gid := range a {
+		if gid == 11 {
+			t.Fatal("picked tile with zero probability")
+		}
+	}
+}
+
+func TestTilePickerScatter(t *testing.T) {
+	ts := &Tileset{FirstGID: 1, Tilecount: 1}
+	gids := make([]GID, 1000)
+	gids[0] = 5
+
+	NewTilePicker(ts, rand.NewSource(1)).Scatter(gids, 100)
+
+	if gids[0] != 5 {
+		t.Error("Scatter overwrote a non-empty cell")
+	}
+	n := 0
+	for _, gid := range gids[1:] {
+		if gid != 0 {
+			n++
+		}
+	}
+	if n < 50 || n > 150 {
+		t.Errorf("Scatter placed %d tiles, want about 100", n)
+	}
+}
@@ -0,0 +1,295 @@
+package tmx
+
+import (
+	"cmp"
+	"errors"
+	"image"
+	"slices"
+)
+
+// Errors returned when remapping GIDs.
+var (
+	ErrTilesetOverlap   = errors.New("tmx: overlapping tileset GIDs")
+	ErrUnknownTilecount = errors.New("tmx: unknown tileset tile count")
+)
+
+// RemapGIDs moves the tiles of the tilesets with the first GIDs keyed in
+// firstGIDs to the first GIDs they map to: the FirstGID of the tilesets is
+// set and the GIDs of their tiles in tile layers and tile objects are
+// rewritten, keeping their flip flags. Tilesets are kept in increasing
+// FirstGID order. Tile layer data is encoded with its encoding and
+// compression. The error is ErrInvalidGID for keys that are not the first
+// GID of a tileset or for GIDs out of range, and ErrTilesetOverlap when the
+// GIDs of tilesets would overlap. Tilesets of unknown size, such as external
+// tilesets that are not loaded, keep the GIDs up to the next tileset. Nothing
+// is changed on error.
+func (m *Map) RemapGIDs(firstGIDs map[GID]GID) error {
+	index := make(map[GID]int, len(m.Tilesets))
+	for i := range m.Tilesets {
+		index[m.Tilesets[i].FirstGID] = i
+	}
+	for old, first := range firstGIDs {
+		if _, ok := index[old]; !ok || first == 0 || first > GIDMask {
+			return ErrInvalidGID
+		}
+	}
+	tilesets := slices.Clone(m.Tilesets)
+	spans := make([]GID, len(tilesets))
+	for i := range tilesets {
+		spans[i] = m.span(i)
+		if first, ok := firstGIDs[tilesets[i].FirstGID]; ok {
+			tilesets[i].FirstGID = first
+		}
+	}
+	from := make([]int, len(tilesets))
+	for i := range from {
+		from[i] = i
+	}
+	slices.SortStableFunc(from, func(i, j int) int {
+		return cmp.Compare(tilesets[i].FirstGID, tilesets[j].FirstGID)
+	})
+	for k, i := range from {
+		end := tilesets[i].FirstGID + spans[i]
+		if k+1 < len(from) && end > tilesets[from[k+1]].FirstGID {
+			return ErrTilesetOverlap
+		}
+		if end-1 > GIDMask {
+			return ErrInvalidGID
+		}
+	}
+	if err := m.rewriteGIDs(firstGIDs); err != nil {
+		return err
+	}
+	sorted := make([]Tileset, len(from))
+	for i, j := range from {
+		sorted[i] = tilesets[j]
+	}
+	m.setTilesets(sorted, from)
+	return nil
+}
+
+// InsertTileset inserts ts into Tilesets at index i and returns the inserted
+// tileset. First GIDs are then assigned in order like AddTileset, and the
+// GIDs of the tilesets after i are remapped like RemapGIDs. Inserting a
+// tileset of unknown size before others fails with ErrUnknownTilecount.
+func (m *Map) InsertTileset(i int, ts Tileset) (*Tileset, error) {
+	if i < 0 || i > len(m.Tilesets) {
+		return nil, ErrOutOfBounds
+	}
+	ts.index = nil
+	ts.deriveCounts()
+	from := make([]int, 0, len(m.Tilesets)+1)
+	for j := range m.Tilesets {
+		if j == i {
+			from = append(from, -1)
+		}
+		from = append(from, j)
+	}
+	if i == len(m.Tilesets) {
+		from = append(from, -1)
+	}
+	if err := m.repackTilesets(from, ts); err != nil {
+		return nil, err
+	}
+	return &m.Tilesets[i], nil
+}
+
+// RemoveTileset removes Tilesets[i] and clears its tiles from tile layers and
+// tile objects. The GIDs of the tilesets after it are remapped like
+// InsertTileset.
+func (m *Map) RemoveTileset(i int) error {
+	if i < 0 || i >= len(m.Tilesets) {
+		return ErrOutOfBounds
+	}
+	from := make([]int, 0, len(m.Tilesets)-1)
+	for j := range m.Tilesets {
+		if j != i {
+			from = append(from, j)
+		}
+	}
+	return m.repackTilesets(from, Tileset{})
+}
+
+// MoveTileset moves Tilesets[i] to index j, remapping GIDs like
+// InsertTileset.
+func (m *Map) MoveTileset(i, j int) error {
+	if i < 0 || i >= len(m.Tilesets) || j < 0 || j >= len(m.Tilesets) {
+		return ErrOutOfBounds
+	}
+	from := make([]int, 0, len(m.Tilesets))
+	for k := range m.Tilesets {
+		if k != i {
+			from = append(from, k)
+		}
+	}
+	from = slices.Insert(from, j, i)
+	return m.repackTilesets(from, Tileset{})
+}
+
+// repackTilesets sets Tilesets to the tilesets at the indexes in from, or to
+// added for -1, and remaps the GIDs of the kept tilesets. Tilesets before the
+// first change keep their first GIDs and the others are assigned first GIDs
+// in order. Tiles of the dropped tilesets are cleared.
+func (m *Map) repackTilesets(from []int, added Tileset) error {
+	tilesets := make([]Tileset, len(from))
+	firstGIDs := make(map[GID]GID, len(m.Tilesets))
+	for i := range m.Tilesets {
+		firstGIDs[m.Tilesets[i].FirstGID] = 0
+	}
+	next := GID(1)
+	kept := true
+	for i, j := range from {
+		var n GID
+		if j < 0 {
+			tilesets[i] = added
+			if n = added.gidCount(); n == 0 {
+				if i+1 < len(from) {
+					return ErrUnknownTilecount
+				}
+				n = added.size()
+			}
+		} else {
+			tilesets[i] = m.Tilesets[j]
+			n = m.span(j)
+		}
+		if kept = kept && i == j; kept {
+			next = m.Tilesets[j].FirstGID
+		}
+		tilesets[i].FirstGID = next
+		if j >= 0 {
+			firstGIDs[m.Tilesets[j].FirstGID] = next
+		}
+		next += n
+	}
+	if err := m.rewriteGIDs(firstGIDs); err != nil {
+		return err
+	}
+	m.setTilesets(tilesets, from)
+	return nil
+}
+
+// span returns the number of GIDs of Tilesets[i]. See spanOf.
+func (m *Map) span(i int) GID {
+	return m.spanOf(i, &m.Tilesets[i])
+}
+
+// spanOf returns the number of GIDs of ts, a copy of Tilesets[i] whose tiles
+// may have changed: its tile count when known, or else, as for external
+// tilesets that are not loaded, the GIDs of Tilesets[i] up to the next
+// tileset, or up to the last GID of the map using the last tileset.
+func (m *Map) spanOf(i int, ts *Tileset) GID {
+	if n := ts.gidCount(); n > 0 {
+		return n
+	}
+	first := m.Tilesets[i].FirstGID
+	if i+1 < len(m.Tilesets) {
+		return m.Tilesets[i+1].FirstGID - first
+	}
+	n := ts.size()
+	use := func(gid GID) {
+		if gid &= GIDMask; gid >= first {
+			n = max(n, gid-first+1)
+		}
+	}
+	for j := 0; j < len(m.Layers); j++ {
+		// Layers that do not decode are not remapped either.
+		gids, _ := m.Layers[j].Decoded()
+		for _, gid := range gids {
+			use(gid)
+		}
+	}
+	for j := 0; j < len(m.ObjectGroups); j++ {
+		for _, o := range m.ObjectGroups[j].Objects {
+			use(o.GID)
+		}
+	}
+	return n
+}
+
+// size returns the number of GIDs of the tileset, or of its listed tiles
+// when its tile count is unknown, and at least 1.
+func (ts *Tileset) size() GID {
+	if n := ts.gidCount(); n > 0 {
+		return n
+	}
+	n := GID(1)
+	for i := 0; i < len(ts.Tiles); i++ {
+		n = max(n, GID(ts.Tiles[i].ID)+1)
+	}
+	return n
+}
+
+// setTilesets sets Tilesets to tilesets, which holds the tilesets at the
+// indexes in from, or new ones for -1, keeping their recorded positions.
+func (m *Map) setTilesets(tilesets []Tileset, from []int) {
+	m.Tilesets = tilesets
+	if m.pos == nil || m.pos.tilesets == nil {
+		return
+	}
+	pos := make([]Position, len(from))
+	for i, j := range from {
+		if j >= 0 && j < len(m.pos.tilesets) {
+			pos[i] = m.pos.tilesets[j]
+		}
+	}
+	m.pos.tilesets = pos
+}
+
+// rewriteGIDs rewrites the GIDs of the tiles of the tilesets with the first
+// GIDs keyed in firstGIDs to the first GIDs they map to, or clears them for 0.
+func (m *Map) rewriteGIDs(firstGIDs map[GID]GID) error {
+	remap := func(gid GID) GID {
+		ts := m.TilesetForGID(gid & GIDMask)
+		if ts == nil {
+			return gid
+		}
+		first, ok := firstGIDs[ts.FirstGID]
+		switch {
+		case !ok:
+			return gid
+		case first == 0:
+			return 0
+		}
+		return gid&GIDMask - ts.FirstGID + first | gid&^GIDMask
+	}
+	return m.mapGIDs(remap)
+}
+
+// mapGIDs replaces the GIDs of tile layers and tile objects by fn, clearing
+// the flips of objects it maps to 0. Tile layer data is encoded with its
+// encoding and compression. Nothing is changed on error.
+func (m *Map) mapGIDs(fn func(GID) GID) error {
+	data := make([]Data, len(m.Layers))
+	sizes := make([]image.Point, len(m.Layers))
+	for i := 0; i < len(m.Layers); i++ {
+		l := &m.Layers[i]
+		gids, err := l.Decoded()
+		if err != nil {
+			return err
+		}
+		out := make([]GID, len(gids))
+		for j, gid := range gids {
+			out[j] = fn(gid)
+		}
+		if data[i], sizes[i], err = l.encodeLike(out, l.Width, m.CompressionLevel); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		objects := m.ObjectGroups[i].Objects
+		for j := 0; j < len(objects); j++ {
+			if o := &objects[j]; o.GID != 0 {
+				if o.GID = fn(o.GID); o.GID == 0 {
+					o.HorizontalFlip, o.VerticalFlip = false, false
+				}
+			}
+		}
+	}
+	for i := 0; i < len(m.Layers); i++ {
+		l := &m.Layers[i]
+		l.Width, l.Height = sizes[i].X, sizes[i].Y
+		l.Data, l.decoded, l.edited = data[i], nil, false
+	}
+	return nil
+}
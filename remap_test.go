@@ -0,0 +1,272 @@
+package tmx
+
+import (
+	"errors"
+	"image"
+	"reflect"
+	"testing"
+)
+
+// remapFixture returns a map using tiles of two tilesets of 4 and 2 tiles.
+func remapFixture(t *testing.T) *Map {
+	t.Helper()
+	m := NewMap(4, 1, 8, 8, MapOrthogonal)
+	m.AddTileset(Tileset{Name: "a", TileWidth: 8, TileHeight: 8, Tilecount: 4})
+	m.AddTileset(Tileset{Name: "b", TileWidth: 8, TileHeight: 8, Tilecount: 2})
+	l := m.AddTileLayer("ground")
+	if err := l.Encode([]GID{1, 4 | GIDHorizontalFlip, 5, 6 | GIDDiagonalFlip}, m.DataEncoder(Base64, Gzip)); err != nil {
+		t.Fatal(err)
+	}
+	m.AddObject(m.AddObjectGroup("objects"), Object{GID: 6, VerticalFlip: true, Visible: true})
+	return m
+}
+
+// remapState returns the names and first GIDs of the tilesets of m, its
+// layer GIDs and the GID of its object.
+func remapState(t *testing.T, m *Map) ([]string, []GID, []GID, GID) {
+	t.Helper()
+	var names []string
+	var firsts []GID
+	for _, ts := range m.Tilesets {
+		names = append(names, ts.Name)
+		firsts = append(firsts, ts.FirstGID)
+	}
+	gids, err := m.Layers[0].Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Layers[0].Data.Compression != Gzip {
+		t.Errorf("layer compression = %q, want gzip", m.Layers[0].Data.Compression)
+	}
+	return names, firsts, gids, m.ObjectGroups[0].Objects[0].GID
+}
+
+func TestRemapGIDs(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		edit   func(m *Map) error
+		names  []string
+		firsts []GID
+		gids   []GID
+		object GID
+	}{
+		{
+			"RemapGIDs",
+			func(m *Map) error { return m.RemapGIDs(map[GID]GID{1: 10, 5: 1}) },
+			[]string{"b", "a"}, []GID{1, 10},
+			[]GID{10, 13 | GIDHorizontalFlip, 1, 2 | GIDDiagonalFlip}, 2,
+		},
+		{
+			"InsertTileset",
+			func(m *Map) error {
+				_, err := m.InsertTileset(1, Tileset{Name: "c", TileWidth: 8, TileHeight: 8, Tilecount: 3})
+				return err
+			},
+			[]string{"a", "c", "b"}, []GID{1, 5, 8},
+			[]GID{1, 4 | GIDHorizontalFlip, 8, 9 | GIDDiagonalFlip}, 9,
+		},
+		{
+			"RemoveTileset",
+			func(m *Map) error { return m.RemoveTileset(0) },
+			[]string{"b"}, []GID{1},
+			[]GID{0, 0, 1, 2 | GIDDiagonalFlip}, 2,
+		},
+		{
+			"MoveTileset",
+			func(m *Map) error { return m.MoveTileset(1, 0) },
+			[]string{"b", "a"}, []GID{1, 3},
+			[]GID{3, 6 | GIDHorizontalFlip, 1, 2 | GIDDiagonalFlip}, 2,
+		},
+	} {
+		m := remapFixture(t)
+		if err := tc.edit(m); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		names, firsts, gids, object := remapState(t, m)
+		if !reflect.DeepEqual(names, tc.names) || !reflect.DeepEqual(firsts, tc.firsts) {
+			t.Errorf("%s: tilesets = %q %v, want %q %v", tc.name, names, firsts, tc.names, tc.firsts)
+		}
+		if !reflect.DeepEqual(gids, tc.gids) || object != tc.object {
+			t.Errorf("%s: GIDs = %v, object %d, want %v, %d", tc.name, gids, object, tc.gids, tc.object)
+		}
+		if o := m.ObjectGroups[0].Objects[0]; !o.VerticalFlip {
+			t.Errorf("%s: object lost its flip", tc.name)
+		}
+		if issues := m.Validate(); issues != nil {
+			t.Errorf("%s: Validate() = %v", tc.name, issues)
+		}
+	}
+
+	m := remapFixture(t)
+	if err := m.RemapGIDs(map[GID]GID{1: 0}); !errors.Is(err, ErrInvalidGID) {
+		t.Errorf("RemapGIDs() to 0 = %v, want %v", err, ErrInvalidGID)
+	}
+	if err := m.MoveTileset(0, 2); !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("MoveTileset() out of bounds = %v, want %v", err, ErrOutOfBounds)
+	}
+}
+
+// externalFixture returns a map using tile 49 of an external tileset that is
+// not loaded and tile 1 of an inline tileset of 4 tiles at first GID 100.
+func externalFixture(t *testing.T) *Map {
+	t.Helper()
+	m := NewMap(2, 1, 8, 8, MapOrthogonal)
+	m.Tilesets = []Tileset{
+		{FirstGID: 1, Source: "ext.tsx"},
+		{FirstGID: 100, Name: "a", TileWidth: 8, TileHeight: 8, Tilecount: 4},
+	}
+	if err := m.AddTileLayer("ground").Encode([]GID{50, 101}, m.DataEncoder(CSV, "")); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestExternalTilesetSpan(t *testing.T) {
+	// Tilesets copied into other maps keep the GIDs of external tilesets
+	// up to the next tileset.
+	for _, tc := range []struct {
+		name string
+		edit func(m *Map) (*Map, error)
+		gids []GID
+	}{
+		{
+			"Merge",
+			func(m *Map) (*Map, error) {
+				dst := NewMap(2, 1, 8, 8, MapOrthogonal)
+				dst.AddTileset(Tileset{Name: "d", TileWidth: 8, TileHeight: 8, Tilecount: 3})
+				return dst, Merge(dst, m, 0, 0)
+			},
+			[]GID{53, 104},
+		},
+		{
+			"Extract",
+			func(m *Map) (*Map, error) {
+				return m.Extract(image.Rect(0, 0, 2, 1))
+			},
+			[]GID{50, 101},
+		},
+		{
+			"StripUnusedTiles",
+			func(m *Map) (*Map, error) {
+				_, err := m.StripUnusedTiles(nil)
+				return m, err
+			},
+			[]GID{50, 101},
+		},
+	} {
+		m, err := tc.edit(externalFixture(t))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		gids, err := m.Layers[0].Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(gids, tc.gids) {
+			t.Errorf("%s: tiles = %v, want %v", tc.name, gids, tc.gids)
+		}
+		if ts := m.TilesetForGID(tc.gids[0]); ts == nil || ts.Source != "ext.tsx" {
+			t.Errorf("%s: GID %d is in tileset %v, want ext.tsx", tc.name, tc.gids[0], ts)
+		}
+		if ts := m.TilesetForGID(tc.gids[1]); ts == nil || ts.Name != "a" {
+			t.Errorf("%s: GID %d is in tileset %v, want a", tc.name, tc.gids[1], ts)
+		}
+	}
+}
+
+func TestRemapUnknownTilecount(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		edit   func(m *Map) error
+		firsts []GID
+		gids   []GID
+	}{
+		{
+			"InsertTileset at the end",
+			func(m *Map) error {
+				_, err := m.InsertTileset(2, Tileset{Name: "b", TileWidth: 8, TileHeight: 8, Tilecount: 2})
+				return err
+			},
+			[]GID{1, 100, 104}, []GID{50, 101},
+		},
+		{
+			"InsertTileset after the external tileset",
+			func(m *Map) error {
+				_, err := m.InsertTileset(1, Tileset{Name: "b", TileWidth: 8, TileHeight: 8, Tilecount: 2})
+				return err
+			},
+			[]GID{1, 100, 102}, []GID{50, 103},
+		},
+		{
+			"MoveTileset",
+			func(m *Map) error { return m.MoveTileset(1, 0) },
+			[]GID{1, 5}, []GID{54, 2},
+		},
+		{
+			"RemoveTileset",
+			func(m *Map) error { return m.RemoveTileset(1) },
+			[]GID{1}, []GID{50, 0},
+		},
+		{
+			"AddTileset",
+			func(m *Map) error {
+				m.AddTileset(Tileset{Name: "b", TileWidth: 8, TileHeight: 8, Tilecount: 2})
+				return nil
+			},
+			[]GID{1, 100, 104}, []GID{50, 101},
+		},
+	} {
+		m := externalFixture(t)
+		if err := tc.edit(m); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		var firsts []GID
+		for _, ts := range m.Tilesets {
+			firsts = append(firsts, ts.FirstGID)
+		}
+		gids, err := m.Layers[0].Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(firsts, tc.firsts) || !reflect.DeepEqual(gids, tc.gids) {
+			t.Errorf("%s: first GIDs = %v, GIDs %v, want %v, %v", tc.name, firsts, gids, tc.firsts, tc.gids)
+		}
+	}
+
+	// The last tileset of unknown size spans the GIDs the map uses.
+	m := externalFixture(t)
+	if err := m.RemoveTileset(1); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.AddTileset(Tileset{Name: "b", Tilecount: 1}).FirstGID; got != 51 {
+		t.Errorf("AddTileset() after an external tileset using GID 50: first GID %d, want 51", got)
+	}
+	if _, err := m.InsertTileset(0, Tileset{Source: "other.tsx"}); !errors.Is(err, ErrUnknownTilecount) {
+		t.Errorf("InsertTileset() of an external tileset before others = %v, want %v", err, ErrUnknownTilecount)
+	}
+}
+
+func TestRemapGIDsErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		firstGIDs map[GID]GID
+		want      error
+	}{
+		{"overlap", map[GID]GID{5: 3}, ErrTilesetOverlap},
+		{"unknown first GID", map[GID]GID{2: 10}, ErrInvalidGID},
+		{"out of range", map[GID]GID{5: GIDMask}, ErrInvalidGID},
+	} {
+		m := remapFixture(t)
+		if err := m.RemapGIDs(tc.firstGIDs); !errors.Is(err, tc.want) {
+			t.Errorf("%s: RemapGIDs() = %v, want %v", tc.name, err, tc.want)
+		}
+		if names, firsts, gids, _ := remapState(t, m); !reflect.DeepEqual(firsts, []GID{1, 5}) || gids[0] != 1 {
+			t.Errorf("%s: map changed on error: %q %v %v", tc.name, names, firsts, gids)
+		}
+	}
+
+	m := externalFixture(t)
+	if err := m.RemapGIDs(map[GID]GID{100: 40}); !errors.Is(err, ErrTilesetOverlap) {
+		t.Errorf("RemapGIDs() into an external tileset = %v, want %v", err, ErrTilesetOverlap)
+	}
+}
@@ -0,0 +1,246 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// DrawCommand draws the Src rectangle of an image at Dst, for renderers
+// feeding their own sprite batchers.
+type DrawCommand struct {
+	Layer   tmx.LayerRef    // Layer being drawn.
+	Tileset int             // Index of the tile's tileset in Map.Tilesets, or -1 for image layers.
+	Image   tmx.Image       // Image holding Src: a tileset, tile or image layer image.
+	Src     image.Rectangle // Source rectangle within Image.
+
+	// Dst is the destination rectangle in map pixels. Its size is that of
+	// Src, transposed for diagonally flipped tiles, except for tile objects
+	// scaled to the object size.
+	Dst image.Rectangle
+
+	// Rotation rotates Dst clockwise in degrees around its bottom-left corner.
+	// Only tile objects are rotated.
+	Rotation float64
+
+	// Flips applied to the source as with Transform.
+	HorizontalFlip bool
+	VerticalFlip   bool
+	DiagonalFlip   bool
+
+	Tint    color.NRGBA // Effective tint color of the layer, white if none.
+	Opacity float32     // Effective opacity of the layer.
+}
+
+// Batch returns the commands drawing the visible tile layers, image layers and
+// tile objects of the map from bottom to top, as Render draws them. Text
+// objects are not included; lay them out with LayoutText.
+func (r *Renderer) Batch() ([]DrawCommand, error) {
+	if _, err := r.projection(); err != nil {
+		return nil, err
+	}
+	return r.batch(r.Bounds(), false)
+}
+
+// BatchRegion returns the commands drawing the map within the camera
+// rectangle, as RenderRegion draws them. Subtract camera.Min from the
+// destinations to get screen positions.
+func (r *Renderer) BatchRegion(camera image.Rectangle) ([]DrawCommand, error) {
+	if _, err := r.projection(); err != nil {
+		return nil, err
+	}
+	return r.batch(camera, true)
+}
+
+// batch returns the commands drawing the visible layers intersecting the map
+// pixel rectangle view. If parallax is set, layers are scrolled by their
+// parallax factors relative to the center of view.
+func (r *Renderer) batch(view image.Rectangle, parallax bool) ([]DrawCommand, error) {
+	m := r.Map
+	var out []DrawCommand
+	for _, ref := range m.DrawOrder() {
+		l := m.Layer(ref)
+		if !m.EffectiveVisible(l) {
+			continue
+		}
+		var shift image.Point
+		if parallax {
+			shift = r.parallax(l, view)
+		}
+		var err error
+		if out, err = r.commands(out, ref, l, view, shift); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// commands appends the commands drawing the layer l referred to by ref within
+// view to out, with the layer moved by shift.
+func (r *Renderer) commands(out []DrawCommand, ref tmx.LayerRef, l tmx.AnyLayer, view image.Rectangle, shift image.Point) ([]DrawCommand, error) {
+	switch l := l.(type) {
+	case *tmx.Layer:
+		return r.layerCommands(out, ref, l, view, shift)
+	case *tmx.ImageLayer:
+		return r.imageLayerCommands(out, ref, l, view, shift)
+	case *tmx.ObjectGroup:
+		return r.objectCommands(out, ref, l, view, shift)
+	}
+	return out, nil
+}
+
+// layerCommands appends the commands drawing the tiles of l intersecting view
+// to out, in the map's render order, with the layer moved by shift.
+func (r *Renderer) layerCommands(out []DrawCommand, ref tmx.LayerRef, l *tmx.Layer, view image.Rectangle, shift image.Point) ([]DrawCommand, error) {
+	m := r.Map
+	p, err := r.projection()
+	if err != nil {
+		return nil, err
+	}
+	gids, err := l.Decoded()
+	if err != nil {
+		return nil, err
+	}
+
+	tint, opacity := m.EffectiveTint(l), m.EffectiveOpacity(l)
+	offset := image.Pt(m.EffectiveOffset(l)).Add(shift)
+	for _, c := range p.cells(l.Width, l.Height, r.pad(view.Sub(offset))) {
+		i := c.Y*l.Width + c.X
+		gid := gids[i]
+		if r.Animated {
+			if gid, err = m.ResolveAnimatedGID(gid, r.Time); err != nil {
+				return nil, &tmx.LayerError{Name: l.Name, ID: l.ID, Index: i, Err: err}
+			}
+		}
+		t, err := m.DecodeGID(gid)
+		if err != nil {
+			return nil, &tmx.LayerError{Name: l.Name, ID: l.ID, Index: i, Err: err}
+		}
+		if t.Nil {
+			continue
+		}
+		img, src, err := r.tileSource(t.Tileset, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		if src.Empty() {
+			continue
+		}
+		size := src.Size()
+		if t.DiagonalFlip {
+			size.X, size.Y = size.Y, size.X
+		}
+		off := t.Tileset.TileOffset
+		pt := p.anchor(c.X, c.Y).Add(image.Pt(off.X, off.Y-size.Y)).Add(offset)
+		out = append(out, DrawCommand{
+			Layer:          ref,
+			Tileset:        r.tilesetIndex(t.Tileset),
+			Image:          img,
+			Src:            src,
+			Dst:            image.Rectangle{pt, pt.Add(size)},
+			HorizontalFlip: t.HorizontalFlip,
+			VerticalFlip:   t.VerticalFlip,
+			DiagonalFlip:   t.DiagonalFlip,
+			Tint:           tint,
+			Opacity:        opacity,
+		})
+	}
+	return out, nil
+}
+
+// tileSource returns the image showing tile id of ts and the rectangle of the
+// tile within it. The rectangle is empty for tiles outside of the tileset image.
+func (r *Renderer) tileSource(ts *tmx.Tileset, id tmx.ID) (tmx.Image, image.Rectangle, error) {
+	for i := 0; i < len(ts.Tiles); i++ {
+		if ts.Tiles[i].ID == id && ts.Tiles[i].Image.Source != "" {
+			img := ts.Tiles[i].Image
+			size, err := r.size(img)
+			return img, image.Rectangle{Max: size}, err
+		}
+	}
+
+	size, err := r.size(ts.Image)
+	if err != nil {
+		return tmx.Image{}, image.Rectangle{}, err
+	}
+	rect := ts.TileRect(id)
+	if ts.Columns == 0 && ts.Image.Width != size.X {
+		// Derive the columns from the decoded image.
+		c := *ts
+		c.Image.Width = size.X
+		rect = c.TileRect(id)
+	}
+	if !rect.In(image.Rectangle{Max: size}) {
+		rect = image.Rectangle{}
+	}
+	return ts.Image, rect, nil
+}
+
+// tilesetIndex returns the index of ts in the map's tilesets or -1.
+func (r *Renderer) tilesetIndex(ts *tmx.Tileset) int {
+	for i := 0; i < len(r.Map.Tilesets); i++ {
+		if &r.Map.Tilesets[i] == ts {
+			return i
+		}
+	}
+	return -1
+}
+
+// draw rasterizes cmds onto dst, with the map pixel view.Min drawn at
+// dst.Bounds().Min.
+func (r *Renderer) draw(dst draw.Image, view image.Rectangle, cmds []DrawCommand) error {
+	shift := dst.Bounds().Min.Sub(view.Min)
+	var mask image.Image
+	var maskOpacity float32 = 1
+	for i := 0; i < len(cmds); i++ {
+		c := &cmds[i]
+		img, err := r.image(c.Image)
+		if err != nil {
+			return err
+		}
+		src := Transform(crop(img, c.Src.Add(img.Bounds().Min)), tmx.DecodedTile{
+			HorizontalFlip: c.HorizontalFlip,
+			VerticalFlip:   c.VerticalFlip,
+			DiagonalFlip:   c.DiagonalFlip,
+		})
+		if c.Tint != white {
+			src = tinted{src, c.Tint}
+		}
+		dr := c.Dst
+		if c.Rotation != 0 || dr.Size() != src.Bounds().Size() {
+			src = warped{src, c}
+			dr = src.Bounds()
+		}
+		if c.Opacity != maskOpacity {
+			maskOpacity, mask = c.Opacity, nil
+			if c.Opacity < 1 {
+				mask = image.NewUniform(color.Alpha{uint8(c.Opacity*255 + 0.5)})
+			}
+		}
+		draw.DrawMask(dst, dr.Add(shift), src, src.Bounds().Min, mask, image.Point{}, draw.Over)
+	}
+	return nil
+}
+
+// crop returns the rect of img.
+func crop(img image.Image, rect image.Rectangle) image.Image {
+	if rect == img.Bounds() {
+		return img
+	}
+	if s, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return s.SubImage(rect)
+	}
+	return cropped{img, rect}
+}
+
+// cropped is a view of the rect of an image without a SubImage method.
+type cropped struct {
+	image.Image
+	rect image.Rectangle
+}
+
+func (c cropped) Bounds() image.Rectangle { return c.rect }
@@ -0,0 +1,81 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+func TestBatch(t *testing.T) {
+	// 0x80000002 is tile 1 flipped horizontally.
+	m, l := loadMap(t, `<layer name="a" width="2" height="1" opacity="0.5"><data encoding="csv">1,2147483650</data></layer>
+<layer name="hidden" width="2" height="1" visible="0"><data encoding="csv">1,1</data></layer>
+<imagelayer name="image" offsety="1" tintcolor="#ff0000"><image source="tiles.png"/></imagelayer>`)
+	cmds, err := New(m, l).Batch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tilesPNG := m.Tilesets[0].Image
+	want := []DrawCommand{{
+		Layer:   tmx.LayerRef{Kind: tmx.TileLayerKind, Index: 0},
+		Image:   tilesPNG,
+		Src:     image.Rect(0, 0, 2, 2),
+		Dst:     image.Rect(0, 0, 2, 2),
+		Tint:    white,
+		Opacity: 0.5,
+	}, {
+		Layer:          tmx.LayerRef{Kind: tmx.TileLayerKind, Index: 0},
+		Image:          tilesPNG,
+		Src:            image.Rect(2, 0, 4, 2),
+		Dst:            image.Rect(2, 0, 4, 2),
+		HorizontalFlip: true,
+		Tint:           white,
+		Opacity:        0.5,
+	}, {
+		Layer:   tmx.LayerRef{Kind: tmx.ImageLayerKind, Index: 0},
+		Tileset: -1,
+		Image:   m.ImageLayers[0].Image,
+		Src:     image.Rect(0, 0, 4, 2),
+		Dst:     image.Rect(0, 1, 4, 3),
+		Tint:    color.NRGBA{0xff, 0, 0, 0xff},
+		Opacity: 1,
+	}}
+	if !reflect.DeepEqual(cmds, want) {
+		t.Errorf("Batch() = %+v, want %+v", cmds, want)
+	}
+}
+
+func TestBatchRegion(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,2</data></layer>
+<imagelayer name="fixed" parallaxx="0"><image source="tiles.png"/></imagelayer>`)
+	r := New(m, l)
+	camera := image.Rect(2, 0, 6, 2)
+	cmds, err := r.BatchRegion(camera)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []image.Rectangle
+	for _, c := range cmds {
+		got = append(got, c.Dst)
+	}
+	// Tile 0 lies within the padded camera; the image scrolls with the camera center.
+	if want := []image.Rectangle{image.Rect(0, 0, 2, 2), image.Rect(2, 0, 4, 2), image.Rect(4, 0, 8, 2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("BatchRegion() destinations = %v, want %v", got, want)
+	}
+
+	// Drawing the commands matches RenderRegion.
+	want := image.NewRGBA(image.Rect(0, 0, camera.Dx(), camera.Dy()))
+	if err := r.RenderRegion(want, camera); err != nil {
+		t.Fatal(err)
+	}
+	dst := image.NewRGBA(want.Rect)
+	if err := r.draw(dst, camera, cmds); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dst.Pix, want.Pix) {
+		t.Error("drawing BatchRegion() commands differs from RenderRegion()")
+	}
+}
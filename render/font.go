@@ -0,0 +1,38 @@
+package render
+
+import (
+	"image"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// FontFace returns a Face drawing the glyphs of f. The fixed-point metrics,
+// advances and kerning of f are rounded to whole pixels.
+func FontFace(f font.Face) Face {
+	return fontFace{f}
+}
+
+// fontFace adapts a golang.org/x/image/font.Face to Face.
+type fontFace struct {
+	f font.Face
+}
+
+func (f fontFace) Metrics() (height, ascent int) {
+	m := f.f.Metrics()
+	return m.Height.Round(), m.Ascent.Round()
+}
+
+func (f fontFace) Advance(r rune) (int, bool) {
+	adv, ok := f.f.GlyphAdvance(r)
+	return adv.Round(), ok
+}
+
+func (f fontFace) Kern(r0, r1 rune) int {
+	return f.f.Kern(r0, r1).Round()
+}
+
+func (f fontFace) Glyph(dot image.Point, r rune) (image.Rectangle, image.Image, image.Point, bool) {
+	dr, mask, maskp, _, ok := f.f.Glyph(fixed.P(dot.X, dot.Y), r)
+	return dr, mask, maskp, ok
+}
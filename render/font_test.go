@@ -0,0 +1,37 @@
+package render
+
+import (
+	"image"
+	"testing"
+
+	tmx "github.com/ajzaff/go-tmx"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestFontFace(t *testing.T) {
+	face := FontFace(basicfont.Face7x13)
+	if height, ascent := face.Metrics(); height != 13 || ascent != 11 {
+		t.Errorf("Metrics() = %d, %d, want 13, 11", height, ascent)
+	}
+	if adv, ok := face.Advance('A'); adv != 7 || !ok {
+		t.Errorf("Advance('A') = %d, %v, want 7, true", adv, ok)
+	}
+
+	text := &tmx.Text{Text: "Hello, world", Kerning: true}
+	lines := LayoutText(text, face, 200, 20)
+	if len(lines) != 1 {
+		t.Fatalf("LayoutText() = %d lines, want 1", len(lines))
+	}
+	if got, want := lines[0].Width, font.MeasureString(basicfont.Face7x13, text.Text).Round(); got != want {
+		t.Errorf("line width = %d, want %d", got, want)
+	}
+
+	dr, mask, _, ok := face.Glyph(image.Pt(7, 11), 'e')
+	if !ok || mask == nil {
+		t.Fatalf("Glyph('e') = %v, %v, want a glyph", mask, ok)
+	}
+	if want := image.Rect(7, 0, 13, 13); dr != want {
+		t.Errorf("Glyph('e') rectangle = %v, want %v", dr, want)
+	}
+}
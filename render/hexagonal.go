@@ -0,0 +1,125 @@
+package render
+
+import (
+	"image"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// hexagonal places cells on a hexagonal grid, following the layout of Tiled's
+// hexagonal renderer. Every other column (stagger axis x) or row (stagger
+// axis y) is shifted by half a cell. Staggered isometric maps are laid out as
+// hexagonal maps with sides of length zero.
+type hexagonal struct {
+	m *tmx.Map
+
+	tileWidth, tileHeight    int // Tile size rounded down to even numbers.
+	sideLengthX, sideLengthY int
+	sideOffsetX, sideOffsetY int
+	columnWidth, rowHeight   int
+	staggerX, staggerEven    bool
+}
+
+// newHexagonal returns the layout of m with hexagon sides of length sideLength.
+func newHexagonal(m *tmx.Map, sideLength int) hexagonal {
+	h := hexagonal{
+		m:           m,
+		tileWidth:   m.TileWidth &^ 1,
+		tileHeight:  m.TileHeight &^ 1,
+		staggerX:    m.StaggerAxis == tmx.StaggerX,
+		staggerEven: m.StaggerIndex == tmx.StaggerEven,
+	}
+	if h.staggerX {
+		h.sideLengthX = sideLength
+	} else {
+		h.sideLengthY = sideLength
+	}
+	h.sideOffsetX = (h.tileWidth - h.sideLengthX) / 2
+	h.sideOffsetY = (h.tileHeight - h.sideLengthY) / 2
+	h.columnWidth = h.sideOffsetX + h.sideLengthX
+	h.rowHeight = h.sideOffsetY + h.sideLengthY
+	return h
+}
+
+// staggered reports whether the column or row at index i is shifted.
+func (h hexagonal) staggered(i int) bool {
+	return (i&1 != 0) != h.staggerEven
+}
+
+func (h hexagonal) bounds() image.Rectangle {
+	w, ht := h.m.Width, h.m.Height
+	if h.staggerX {
+		size := image.Pt(w*h.columnWidth+h.sideOffsetX, ht*(h.tileHeight+h.sideLengthY))
+		if w > 1 {
+			size.Y += h.rowHeight
+		}
+		return image.Rectangle{Max: size}
+	}
+	size := image.Pt(w*(h.tileWidth+h.sideLengthX), ht*h.rowHeight+h.sideOffsetY)
+	if ht > 1 {
+		size.X += h.columnWidth
+	}
+	return image.Rectangle{Max: size}
+}
+
+// origin returns the top-left corner of the bounding box of cell (x, y).
+func (h hexagonal) origin(x, y int) image.Point {
+	if h.staggerX {
+		p := image.Pt(x*h.columnWidth, y*(h.tileHeight+h.sideLengthY))
+		if h.staggered(x) {
+			p.Y += h.rowHeight
+		}
+		return p
+	}
+	p := image.Pt(x*(h.tileWidth+h.sideLengthX), y*h.rowHeight)
+	if h.staggered(y) {
+		p.X += h.columnWidth
+	}
+	return p
+}
+
+func (h hexagonal) anchor(x, y int) image.Point {
+	return h.origin(x, y).Add(image.Pt(0, h.tileHeight))
+}
+
+// cells returns the cells by row. With stagger axis x, the raised columns of
+// a row are drawn before the lowered ones so that lower cells overdraw.
+func (h hexagonal) cells(w, ht int, view image.Rectangle) []image.Point {
+	var rect image.Rectangle
+	if h.staggerX {
+		rowHeight := h.tileHeight + h.sideLengthY
+		rect = image.Rect(
+			floorDiv(view.Min.X, max(h.columnWidth, 1))-1,
+			floorDiv(view.Min.Y-h.rowHeight, max(rowHeight, 1)),
+			floorDiv(view.Max.X, max(h.columnWidth, 1))+1,
+			floorDiv(view.Max.Y, max(rowHeight, 1))+1,
+		)
+	} else {
+		columnWidth := h.tileWidth + h.sideLengthX
+		rect = image.Rect(
+			floorDiv(view.Min.X-h.columnWidth, max(columnWidth, 1)),
+			floorDiv(view.Min.Y, max(h.rowHeight, 1))-1,
+			floorDiv(view.Max.X, max(columnWidth, 1))+1,
+			floorDiv(view.Max.Y, max(h.rowHeight, 1))+1,
+		)
+	}
+	rect = rect.Intersect(image.Rect(0, 0, w, ht))
+
+	out := make([]image.Point, 0, rect.Dx()*rect.Dy())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		if !h.staggerX {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				out = append(out, image.Pt(x, y))
+			}
+			continue
+		}
+		for _, lowered := range []bool{false, true} {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				if h.staggered(x) == lowered {
+					out = append(out, image.Pt(x, y))
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package render
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+func TestHexagonalLayout(t *testing.T) {
+	for _, tc := range []struct {
+		axis   tmx.StaggerAxis
+		index  tmx.StaggerIndex
+		bounds image.Rectangle
+		origin map[image.Point]image.Point
+		cells  []image.Point
+	}{{
+		axis:   tmx.StaggerY,
+		index:  tmx.StaggerOdd,
+		bounds: image.Rect(0, 0, 35, 30),
+		origin: map[image.Point]image.Point{{0, 0}: {0, 0}, {1, 0}: {14, 0}, {0, 1}: {7, 9}, {1, 2}: {14, 18}},
+		cells:  []image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0, 2}, {1, 2}},
+	}, {
+		axis:   tmx.StaggerX,
+		index:  tmx.StaggerEven,
+		bounds: image.Rect(0, 0, 13, 21),
+		origin: map[image.Point]image.Point{{0, 0}: {0, 3}, {1, 0}: {5, 0}, {0, 1}: {0, 9}, {1, 2}: {5, 12}},
+		cells:  []image.Point{{1, 0}, {0, 0}, {1, 1}, {0, 1}, {1, 2}, {0, 2}},
+	}} {
+		m := &tmx.Map{
+			MapOrientation: tmx.MapHexagonal, Width: 2, Height: 3, TileWidth: 8, TileHeight: 6,
+			HexSideLength: 2, StaggerAxis: tc.axis, StaggerIndex: tc.index,
+		}
+		if tc.axis == tmx.StaggerY {
+			m.TileWidth, m.TileHeight, m.HexSideLength = 14, 12, 6
+		}
+		h := newHexagonal(m, m.HexSideLength)
+		if got := h.bounds(); got != tc.bounds {
+			t.Errorf("%s/%s: bounds() = %v, want %v", tc.axis, tc.index, got, tc.bounds)
+		}
+		for c, want := range tc.origin {
+			if got := h.origin(c.X, c.Y); got != want {
+				t.Errorf("%s/%s: origin(%v) = %v, want %v", tc.axis, tc.index, c, got, want)
+			}
+		}
+		if got := h.cells(2, 3, h.bounds()); !reflect.DeepEqual(got, tc.cells) {
+			t.Errorf("%s/%s: cells() = %v, want %v", tc.axis, tc.index, got, tc.cells)
+		}
+	}
+}
+
+func TestRenderRegionHexagonal(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,2</data></layer>`)
+	m.MapOrientation = tmx.MapHexagonal
+	m.TileWidth, m.TileHeight, m.HexSideLength = 2, 2, 0
+	m.StaggerAxis, m.StaggerIndex = tmx.StaggerX, tmx.StaggerOdd
+	m.Width, m.Height = 13, 11
+	gids := make([]tmx.GID, 13*11)
+	for i := range gids {
+		gids[i] = tmx.GID(1 + i%3)
+	}
+	m.Layers[0].Width, m.Layers[0].Height = 13, 11
+	if err := m.Layers[0].Encode(gids, tmx.DataEncoder{Encoding: tmx.CSV}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(m, l)
+	full, err := r.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := full.Bounds(), image.Rect(0, 0, 14, 23); got != want {
+		t.Fatalf("Bounds() = %v, want %v", got, want)
+	}
+	camera := image.Rect(3, 5, 9, 14)
+	dst := image.NewRGBA(image.Rect(0, 0, camera.Dx(), camera.Dy()))
+	if err := r.RenderRegion(dst, camera); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < camera.Dy(); y++ {
+		for x := 0; x < camera.Dx(); x++ {
+			if got, want := dst.RGBAAt(x, y), full.RGBAAt(camera.Min.X+x, camera.Min.Y+y); got != want {
+				t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestStaggeredLayout(t *testing.T) {
+	m := &tmx.Map{MapOrientation: tmx.MapStaggered, Width: 3, Height: 4, TileWidth: 8, TileHeight: 4, HexSideLength: 5}
+	r := &Renderer{Map: m}
+	p, err := r.projection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.bounds(), image.Rect(0, 0, 28, 10); got != want {
+		t.Errorf("bounds() = %v, want %v", got, want)
+	}
+	for c, want := range map[image.Point]image.Point{
+		{0, 0}: {0, 4},
+		{1, 0}: {8, 4},
+		{0, 1}: {4, 6},
+		{2, 3}: {20, 10},
+	} {
+		if got := p.anchor(c.X, c.Y); got != want {
+			t.Errorf("anchor(%v) = %v, want %v", c, got, want)
+		}
+	}
+
+	m.StaggerAxis, m.StaggerIndex = tmx.StaggerX, tmx.StaggerEven
+	if p, err = r.projection(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.anchor(0, 0), image.Pt(0, 6); got != want {
+		t.Errorf("stagger x anchor(0,0) = %v, want %v", got, want)
+	}
+}
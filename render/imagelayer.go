@@ -0,0 +1,68 @@
+package render
+
+import (
+	"image"
+	"image/draw"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// DrawImageLayer draws the image of l onto dst at the layer offset, honoring
+// the opacity, offset and tint color of the layer and its parent groups.
+// Repeated images fill dst along their repeat axes. It draws l even if it is hidden.
+func (r *Renderer) DrawImageLayer(dst draw.Image, l *tmx.ImageLayer) error {
+	view := dst.Bounds()
+	cmds, err := r.imageLayerCommands(nil, r.ref(l), l, view, image.Point{})
+	if err != nil {
+		return err
+	}
+	return r.draw(dst, view, cmds)
+}
+
+// imageLayerCommands appends the commands drawing the image of l
+// intersecting view to out, with the layer moved by shift.
+func (r *Renderer) imageLayerCommands(out []DrawCommand, ref tmx.LayerRef, l *tmx.ImageLayer, view image.Rectangle, shift image.Point) ([]DrawCommand, error) {
+	if l.Image.Source == "" {
+		return out, nil
+	}
+	size, err := r.size(l.Image)
+	if err != nil {
+		return nil, err
+	}
+	if size.X == 0 || size.Y == 0 {
+		return out, nil
+	}
+
+	// at is the map position of the first copy of the image, last bounds the
+	// position of the last one.
+	at := image.Pt(r.Map.EffectiveOffset(l)).Add(shift)
+	last := at
+	if l.RepeatX {
+		at.X += floorDiv(view.Min.X-at.X, size.X) * size.X
+		last.X = view.Max.X - 1
+	}
+	if l.RepeatY {
+		at.Y += floorDiv(view.Min.Y-at.Y, size.Y) * size.Y
+		last.Y = view.Max.Y - 1
+	}
+
+	tint, opacity := r.Map.EffectiveTint(l), r.Map.EffectiveOpacity(l)
+	for y := at.Y; y <= last.Y; y += size.Y {
+		for x := at.X; x <= last.X; x += size.X {
+			dst := image.Rectangle{image.Pt(x, y), image.Pt(x, y).Add(size)}
+			if !dst.Overlaps(view) {
+				continue
+			}
+			out = append(out, DrawCommand{
+				Layer:   ref,
+				Tileset: -1,
+				Image:   l.Image,
+				Src:     image.Rectangle{Max: size},
+				Dst:     dst,
+				Tint:    tint,
+				Opacity: opacity,
+			})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,72 @@
+package render
+
+import (
+	"image"
+	"testing"
+)
+
+func TestRenderImageLayerOrder(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,1</data></layer>
+<imagelayer name="image"><image source="tiles.png" trans="ff00ff"/></imagelayer>
+<layer name="top" width="2" height="1"><data encoding="csv">2,0</data></layer>`)
+	img, err := Render(m, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		p    image.Point
+		r, g uint8
+	}{
+		{image.Pt(2, 0), 30, 10}, // Image above layer a.
+		{image.Pt(1, 0), 40, 10}, // Layer top above the image.
+		{image.Pt(3, 1), 20, 20}, // Layer a through the transparent color.
+	} {
+		if c := img.RGBAAt(tc.p.X, tc.p.Y); c.R != tc.r || c.G != tc.g || c.A != 0xff {
+			t.Errorf("pixel %v = %v, want R=%d G=%d", tc.p, c, tc.r, tc.g)
+		}
+	}
+}
+
+func TestRenderImageLayerRepeat(t *testing.T) {
+	m, l := loadMap(t, `<imagelayer name="image" offsetx="1" repeatx="1"><image source="tiles.png"/></imagelayer>`)
+	camera := image.Rect(-4, 0, 8, 3)
+	dst := image.NewRGBA(image.Rect(0, 0, camera.Dx(), camera.Dy()))
+	if err := New(m, l).RenderRegion(dst, camera); err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < camera.Dx(); x++ {
+		// Copies of the image start at map x = 1 + 4k.
+		want := uint8(10 * (1 + ((camera.Min.X+x-1)%4+4)%4))
+		if c := dst.RGBAAt(x, 0); c.R != want || c.A != 0xff {
+			t.Errorf("pixel (%d,0) = %v, want R=%d", x, c, want)
+		}
+	}
+	if c := dst.RGBAAt(0, 2); c.A != 0 {
+		t.Errorf("pixel (0,2) = %v, want transparent below the image", c)
+	}
+}
+
+func TestRenderRegionParallax(t *testing.T) {
+	m, l := loadMap(t, `<imagelayer name="fixed" parallaxx="0"><image source="tiles.png"/></imagelayer>`)
+	r := New(m, l)
+	camera := image.Rect(10, 0, 14, 2)
+	dst := image.NewRGBA(image.Rect(0, 0, camera.Dx(), camera.Dy()))
+	if err := r.RenderRegion(dst, camera); err != nil {
+		t.Fatal(err)
+	}
+	// The layer scrolls with the camera center at x = 12 and stays in view.
+	if c := dst.RGBAAt(0, 0); c.A != 0 {
+		t.Errorf("pixel (0,0) = %v, want transparent", c)
+	}
+	if c := dst.RGBAAt(2, 0); c.R != 10 || c.A != 0xff {
+		t.Errorf("pixel (2,0) = %v, want R=10", c)
+	}
+
+	img, err := r.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.RGBAAt(0, 0); c.R != 10 || c.A != 0xff {
+		t.Errorf("Render() pixel (0,0) = %v, want R=10 without parallax", c)
+	}
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"image"
+	"math"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// isometric places cells on a diamond grid. Cell (0, 0) is the top corner of
+// the map, x increases down to the right and y increases down to the left.
+type isometric struct{ m *tmx.Map }
+
+func (o isometric) bounds() image.Rectangle {
+	n := o.m.Width + o.m.Height
+	return image.Rect(0, 0, n*o.m.TileWidth/2, n*o.m.TileHeight/2)
+}
+
+// top returns the top corner of the diamond of cell (x, y).
+func (o isometric) top(x, y int) image.Point {
+	tw, th := o.m.TileWidth, o.m.TileHeight
+	return image.Pt((x-y+o.m.Height)*tw/2, (x+y)*th/2)
+}
+
+// anchor returns the left corner of the diamond, lowered to its bottom corner,
+// so tile images stand on the bottom corner of their cell as in Tiled.
+func (o isometric) anchor(x, y int) image.Point {
+	return o.top(x, y).Add(image.Pt(-o.m.TileWidth/2, o.m.TileHeight))
+}
+
+// cells returns the cells in rows of increasing screen y from left to right,
+// so that cells nearer to the viewer overdraw those behind them.
+func (o isometric) cells(w, h int, view image.Rectangle) []image.Point {
+	// Bound the cell coordinates of the view corners.
+	tw, th := float64(o.m.TileWidth), float64(o.m.TileHeight)
+	ox := float64(o.m.Height) * tw / 2
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range []image.Point{view.Min, view.Max, {view.Min.X, view.Max.Y}, {view.Max.X, view.Min.Y}} {
+		px, py := (float64(p.X)-ox)/tw, float64(p.Y)/th
+		x, y := py+px, py-px
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	rect := image.Rect(
+		int(math.Floor(minX)), int(math.Floor(minY)),
+		int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1,
+	).Intersect(image.Rect(0, 0, w, h))
+	if rect.Empty() {
+		return nil
+	}
+
+	var out []image.Point
+	for s := rect.Min.X + rect.Min.Y; s < rect.Max.X+rect.Max.Y-1; s++ {
+		for x := max(rect.Min.X, s-rect.Max.Y+1); x < rect.Max.X && x <= s-rect.Min.Y; x++ {
+			out = append(out, image.Pt(x, s-x))
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package render
+
+import (
+	"image"
+	"testing"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+func TestRenderIsometric(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,2</data></layer>`)
+	m.MapOrientation = tmx.MapIsometric
+
+	img, err := Render(m, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.Bounds(), image.Rect(0, 0, 3, 3); got != want {
+		t.Fatalf("Bounds() = %v, want %v", got, want)
+	}
+	for _, tc := range []struct {
+		p    image.Point
+		r, g uint8
+	}{
+		{image.Pt(0, 0), 10, 10}, // Cell (0,0), tile 0.
+		{image.Pt(1, 1), 30, 10}, // Cell (1,0) overdraws cell (0,0).
+		{image.Pt(2, 1), 40, 10}, // Cell (1,0), tile 1.
+	} {
+		if c := img.RGBAAt(tc.p.X, tc.p.Y); c.R != tc.r || c.G != tc.g || c.A != 0xff {
+			t.Errorf("pixel %v = %v, want R=%d G=%d", tc.p, c, tc.r, tc.g)
+		}
+	}
+}
+
+func TestIsometricCells(t *testing.T) {
+	m := &tmx.Map{Width: 3, Height: 2, TileWidth: 4, TileHeight: 2}
+	iso := isometric{m}
+	cells := iso.cells(3, 2, iso.bounds())
+	want := []image.Point{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {2, 0}, {2, 1}}
+	if len(cells) != len(want) {
+		t.Fatalf("cells() = %v, want %v", cells, want)
+	}
+	for i := range want {
+		if cells[i] != want[i] {
+			t.Errorf("cells() = %v, want %v", cells, want)
+			break
+		}
+	}
+
+	// Cells far outside the view are culled.
+	if cells := iso.cells(3, 2, image.Rect(0, 0, 1, 1)); len(cells) >= 6 {
+		t.Errorf("cells() in corner = %v", cells)
+	}
+}
+
+func TestRenderRegionIsometric(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,2</data></layer>`)
+	m.MapOrientation = tmx.MapIsometric
+	m.Width, m.Height = 12, 9
+	gids := make([]tmx.GID, 12*9)
+	for i := range gids {
+		gids[i] = tmx.GID(1 + i%3)
+	}
+	m.Layers[0].Width, m.Layers[0].Height = 12, 9
+	if err := m.Layers[0].Encode(gids, tmx.DataEncoder{Encoding: tmx.CSV}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(m, l)
+	full, err := r.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	camera := image.Rect(4, 6, 13, 15)
+	dst := image.NewRGBA(image.Rect(0, 0, camera.Dx(), camera.Dy()))
+	if err := r.RenderRegion(dst, camera); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < camera.Dy(); y++ {
+		for x := 0; x < camera.Dx(); x++ {
+			if got, want := dst.RGBAAt(x, y), full.RGBAAt(camera.Min.X+x, camera.Min.Y+y); got != want {
+				t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// drawObjects draws the visible tile and text objects of the group g referred
+// to by ref onto dst in depth order, with the map pixel view.Min drawn at
+// dst.Bounds().Min and the group moved by shift. Objects are placed at their
+// pixel position whatever the map orientation.
+// Text objects are skipped when the renderer has no Fonts.
+func (r *Renderer) drawObjects(dst draw.Image, ref tmx.LayerRef, g *tmx.ObjectGroup, view image.Rectangle, shift image.Point) error {
+	m := r.Map
+	tint, opacity := m.EffectiveTint(g), m.EffectiveOpacity(g)
+	offset := image.Pt(m.EffectiveOffset(g)).Add(shift)
+	origin := offset.Add(dst.Bounds().Min.Sub(view.Min))
+	var cmds []DrawCommand
+	for _, i := range g.DepthOrder() {
+		o := &g.Objects[i]
+		switch {
+		case !o.Visible:
+		case o.Text != nil:
+			if r.Fonts == nil {
+				continue
+			}
+			if err := r.drawText(dst, o, origin, tint, opacity); err != nil {
+				return err
+			}
+		case o.GID != 0:
+			var err error
+			if cmds, err = r.objectCommand(cmds[:0], ref, o, view, offset, tint, opacity); err != nil {
+				return err
+			}
+			if err := r.draw(dst, view, cmds); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// objectCommands appends the commands drawing the visible tile objects of the
+// group g referred to by ref within view to out in depth order, with the group
+// moved by shift.
+func (r *Renderer) objectCommands(out []DrawCommand, ref tmx.LayerRef, g *tmx.ObjectGroup, view image.Rectangle, shift image.Point) ([]DrawCommand, error) {
+	m := r.Map
+	tint, opacity := m.EffectiveTint(g), m.EffectiveOpacity(g)
+	offset := image.Pt(m.EffectiveOffset(g)).Add(shift)
+	for _, i := range g.DepthOrder() {
+		o := &g.Objects[i]
+		if !o.Visible || o.Text != nil || o.GID == 0 {
+			continue
+		}
+		var err error
+		if out, err = r.objectCommand(out, ref, o, view, offset, tint, opacity); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// objectCommand appends the command drawing the tile object o to out if it
+// intersects view. As in Tiled, the tile is scaled to the object size, its
+// bottom-left corner is at the object position and it is rotated around it.
+func (r *Renderer) objectCommand(out []DrawCommand, ref tmx.LayerRef, o *tmx.Object, view image.Rectangle, offset image.Point, tint color.NRGBA, opacity float32) ([]DrawCommand, error) {
+	gid := o.RawGID()
+	if r.Animated {
+		var err error
+		if gid, err = r.Map.ResolveAnimatedGID(gid, r.Time); err != nil {
+			return nil, &tmx.ObjectError{ID: o.ID, Err: err}
+		}
+	}
+	t, err := r.Map.DecodeGID(gid)
+	if err != nil {
+		return nil, &tmx.ObjectError{ID: o.ID, Err: err}
+	}
+	if t.Nil {
+		return out, nil
+	}
+	img, src, err := r.tileSource(t.Tileset, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	if src.Empty() {
+		return out, nil
+	}
+	size := src.Size()
+	if t.DiagonalFlip {
+		size.X, size.Y = size.Y, size.X
+	}
+	// Objects without a size show the tile at its own size.
+	if o.Width > 0 {
+		size.X = int(math.Round(o.Width))
+	}
+	if o.Height > 0 {
+		size.Y = int(math.Round(o.Height))
+	}
+	if size.X <= 0 || size.Y <= 0 {
+		return out, nil
+	}
+	off := t.Tileset.TileOffset
+	pt := image.Pt(int(math.Round(o.X)), int(math.Round(o.Y))).Add(image.Pt(off.X, off.Y-size.Y)).Add(offset)
+	c := DrawCommand{
+		Layer:          ref,
+		Tileset:        r.tilesetIndex(t.Tileset),
+		Image:          img,
+		Src:            src,
+		Dst:            image.Rectangle{pt, pt.Add(size)},
+		Rotation:       o.Rotation,
+		HorizontalFlip: t.HorizontalFlip,
+		VerticalFlip:   t.VerticalFlip,
+		DiagonalFlip:   t.DiagonalFlip,
+		Tint:           tint,
+		Opacity:        opacity,
+	}
+	if !c.Bounds().Overlaps(view) {
+		return out, nil
+	}
+	return append(out, c), nil
+}
+
+// Bounds returns the rectangle covered by Dst once rotated.
+func (c *DrawCommand) Bounds() image.Rectangle {
+	if c.Rotation == 0 {
+		return c.Dst
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range []image.Point{c.Dst.Min, {c.Dst.Max.X, c.Dst.Min.Y}, c.Dst.Max, {c.Dst.Min.X, c.Dst.Max.Y}} {
+		x, y := c.rotate(float64(p.X), float64(p.Y), 1)
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+	// Allow for rounding errors at multiples of 90 degrees.
+	const eps = 1e-9
+	return image.Rect(int(math.Floor(minX+eps)), int(math.Floor(minY+eps)), int(math.Ceil(maxX-eps)), int(math.Ceil(maxY-eps)))
+}
+
+// rotate rotates the point (x, y) clockwise by c.Rotation degrees around the
+// bottom-left corner of Dst, or counterclockwise if dir is -1.
+func (c *DrawCommand) rotate(x, y, dir float64) (float64, float64) {
+	sin, cos := math.Sincos(dir * c.Rotation * math.Pi / 180)
+	ox, oy := float64(c.Dst.Min.X), float64(c.Dst.Max.Y)
+	dx, dy := x-ox, y-oy
+	return ox + dx*cos - dy*sin, oy + dx*sin + dy*cos
+}
+
+// warped is a view of img scaled to c.Dst and rotated by c.Rotation, in map
+// pixels. It samples the nearest pixel of img.
+type warped struct {
+	img image.Image
+	c   *DrawCommand
+}
+
+func (w warped) ColorModel() color.Model { return w.img.ColorModel() }
+
+func (w warped) Bounds() image.Rectangle { return w.c.Bounds() }
+
+func (w warped) At(x, y int) color.Color {
+	ux, uy := w.c.rotate(float64(x)+0.5, float64(y)+0.5, -1)
+	b, dst := w.img.Bounds(), w.c.Dst
+	sx := int(math.Floor((ux - float64(dst.Min.X)) * float64(b.Dx()) / float64(dst.Dx())))
+	sy := int(math.Floor((uy - float64(dst.Min.Y)) * float64(b.Dy()) / float64(dst.Dy())))
+	if sx < 0 || sy < 0 || sx >= b.Dx() || sy >= b.Dy() {
+		return color.Transparent
+	}
+	return w.img.At(b.Min.X+sx, b.Min.Y+sy)
+}
@@ -0,0 +1,113 @@
+package render
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+func TestRenderTileObjects(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		object string
+		want   map[image.Point][2]uint8 // R and G of opaque pixels; others are transparent.
+	}{{
+		name:   "tile size",
+		object: `<object gid="1" x="2" y="2"/>`,
+		want:   map[image.Point][2]uint8{{2, 0}: {10, 10}, {3, 0}: {20, 10}, {2, 1}: {10, 20}, {3, 1}: {20, 20}},
+	}, {
+		name:   "scaled",
+		object: `<object gid="1" x="0" y="2" width="4" height="1"/>`,
+		want:   map[image.Point][2]uint8{{0, 1}: {10, 20}, {1, 1}: {10, 20}, {2, 1}: {20, 20}, {3, 1}: {20, 20}},
+	}, {
+		// 0x80000001 is tile 0 flipped horizontally.
+		name:   "flipped",
+		object: `<object gid="2147483649" x="0" y="2"/>`,
+		want:   map[image.Point][2]uint8{{0, 0}: {20, 10}, {1, 0}: {10, 10}, {0, 1}: {20, 20}, {1, 1}: {10, 20}},
+	}, {
+		// Rotating clockwise around the bottom-left corner at the origin.
+		name:   "rotated",
+		object: `<object gid="1" x="0" y="0" rotation="90"/>`,
+		want:   map[image.Point][2]uint8{{0, 0}: {10, 20}, {1, 0}: {10, 10}, {0, 1}: {20, 20}, {1, 1}: {20, 10}},
+	}, {
+		name:   "hidden",
+		object: `<object gid="1" x="0" y="2" visible="0"/>`,
+	}} {
+		m, l := loadMap(t, `<objectgroup name="objects">`+tc.object+`</objectgroup>`)
+		img, err := Render(m, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 4; x++ {
+				c := img.RGBAAt(x, y)
+				want, ok := tc.want[image.Pt(x, y)]
+				if !ok {
+					if c.A != 0 {
+						t.Errorf("%s: pixel (%d,%d) = %v, want transparent", tc.name, x, y, c)
+					}
+				} else if c.R != want[0] || c.G != want[1] || c.A != 0xff {
+					t.Errorf("%s: pixel (%d,%d) = %v, want R=%d G=%d", tc.name, x, y, c, want[0], want[1])
+				}
+			}
+		}
+	}
+}
+
+func TestBatchTileObjects(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,0</data></layer>
+<objectgroup name="objects" offsetx="1" opacity="0.5">
+ <object gid="2" x="1" y="3" width="4" height="2" rotation="45"/>
+ <object gid="1" x="10" y="10"/>
+ <object x="0" y="0" width="2" height="2"/>
+</objectgroup>`)
+	cmds, err := New(m, l).Batch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds) != 2 {
+		t.Fatalf("Batch() returned %d commands, want the tile and the visible tile object", len(cmds))
+	}
+	want := DrawCommand{
+		Layer:    tmx.LayerRef{Kind: tmx.ObjectGroupKind, Index: 0},
+		Image:    m.Tilesets[0].Image,
+		Src:      image.Rect(2, 0, 4, 2),
+		Dst:      image.Rect(2, 1, 6, 3),
+		Rotation: 45,
+		Tint:     white,
+		Opacity:  0.5,
+	}
+	if !reflect.DeepEqual(cmds[1], want) {
+		t.Errorf("Batch() object command = %+v, want %+v", cmds[1], want)
+	}
+	// The corners rotate around (2,3) to x in [2,2+3√2] and y in [3-√2,3+2√2].
+	if got, want := cmds[1].Bounds(), image.Rect(2, 1, 7, 6); got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+}
+
+func TestRenderTileObjectsDepth(t *testing.T) {
+	// Tile 1 at the back overlaps tile 0 drawn in front of it.
+	for _, tc := range []struct {
+		order string
+		want  [2]uint8
+	}{
+		{"", [2]uint8{30, 10}},
+		{"topdown", [2]uint8{30, 10}},
+		{"index", [2]uint8{20, 20}},
+	} {
+		m, l := loadMap(t, `<objectgroup name="objects" draworder="`+tc.order+`">
+ <object gid="2" x="1" y="2"/>
+ <object gid="1" x="0" y="1"/>
+</objectgroup>`)
+		img, err := Render(m, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c := img.RGBAAt(1, 0); c.R != tc.want[0] || c.G != tc.want[1] {
+			t.Errorf("draworder %q: pixel (1,0) = %v, want R=%d G=%d", tc.order, c, tc.want[0], tc.want[1])
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package render
+
+import (
+	"image"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// projection places the cells of a map with a given orientation.
+type projection interface {
+	// bounds returns the pixel bounds of the map.
+	bounds() image.Rectangle
+
+	// anchor returns the pixel at which the bottom-left corner of the tile
+	// image of cell (x, y) is drawn.
+	anchor(x, y int) image.Point
+
+	// cells returns the cells of a w×h layer whose grid positions intersect
+	// the pixel rectangle view, in the order their tiles are drawn.
+	cells(w, h int, view image.Rectangle) []image.Point
+}
+
+// projection returns the projection of the map orientation.
+func (r *Renderer) projection() (projection, error) {
+	m := r.Map
+	if m.TileWidth <= 0 || m.TileHeight <= 0 {
+		return nil, ErrInvalidTileSize
+	}
+	switch m.MapOrientation {
+	case "", tmx.MapOrthogonal:
+		return orthogonal{m}, nil
+	case tmx.MapIsometric:
+		return isometric{m}, nil
+	case tmx.MapHexagonal:
+		return newHexagonal(m, m.HexSideLength), nil
+	case tmx.MapStaggered:
+		return newHexagonal(m, 0), nil
+	}
+	return nil, ErrUnsupportedOrientation
+}
+
+// orthogonal places cells on a rectangular grid.
+type orthogonal struct{ m *tmx.Map }
+
+func (o orthogonal) bounds() image.Rectangle {
+	return image.Rect(0, 0, o.m.Width*o.m.TileWidth, o.m.Height*o.m.TileHeight)
+}
+
+func (o orthogonal) anchor(x, y int) image.Point {
+	return image.Pt(x*o.m.TileWidth, (y+1)*o.m.TileHeight)
+}
+
+// cells returns the cells in the map's render order.
+func (o orthogonal) cells(w, h int, view image.Rectangle) []image.Point {
+	tw, th := o.m.TileWidth, o.m.TileHeight
+	rect := image.Rect(
+		floorDiv(view.Min.X, tw),
+		floorDiv(view.Min.Y, th),
+		floorDiv(view.Max.X, tw)+1,
+		floorDiv(view.Max.Y, th)+1,
+	).Intersect(image.Rect(0, 0, w, h))
+	return o.m.MapRenderOrder.Cells(rect)
+}
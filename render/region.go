@@ -0,0 +1,60 @@
+package render
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// RenderRegion draws the visible tile and image layers of the map within the
+// camera rectangle onto dst, with camera.Min drawn at dst.Bounds().Min.
+// Only tiles intersecting the camera are drawn, so a region of a large map
+// can be drawn every frame.
+//
+// Layers with parallax factors other than 1 are scrolled relative to the
+// camera: when the camera is centered on the map's parallax origin every
+// layer is drawn at its position in the map.
+func (r *Renderer) RenderRegion(dst draw.Image, camera image.Rectangle) error {
+	return r.drawLayers(dst, camera, true)
+}
+
+// parallax returns the offset of l from its position in the map when the
+// camera is centered on view.
+// See: https://doc.mapeditor.org/en/stable/manual/layers/#parallax-scrolling-factor.
+func (r *Renderer) parallax(l tmx.AnyLayer, view image.Rectangle) image.Point {
+	fx, fy := r.Map.EffectiveParallax(l)
+	c := view.Min.Add(view.Max).Div(2)
+	return image.Pt(
+		int(math.Round((float64(c.X)-r.Map.ParallaxOriginX)*(1-fx))),
+		int(math.Round((float64(c.Y)-r.Map.ParallaxOriginY)*(1-fy))),
+	)
+}
+
+// pad returns view grown by the largest tile size and tile offset of the map's
+// tilesets, so that tiles larger than the map grid are not culled.
+func (r *Renderer) pad(view image.Rectangle) image.Rectangle {
+	var pad image.Point
+	for i := 0; i < len(r.Map.Tilesets); i++ {
+		ts := &r.Map.Tilesets[i]
+		pad.X = max(pad.X, ts.TileWidth+abs(ts.TileOffset.X))
+		pad.Y = max(pad.Y, ts.TileHeight+abs(ts.TileOffset.Y))
+	}
+	return image.Rectangle{view.Min.Sub(pad), view.Max.Add(pad)}
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+func TestRenderRegion(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1" offsetx="1"><data encoding="csv">1,2</data></layer>`)
+	m.Width, m.Height = 16, 16
+	gids := make([]tmx.GID, 16*16)
+	for i := range gids {
+		gids[i] = tmx.GID(1 + i%3)
+	}
+	m.Layers[0].Width, m.Layers[0].Height = 16, 16
+	if err := m.Layers[0].Encode(gids, tmx.DataEncoder{Encoding: tmx.CSV}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(m, l)
+	full, err := r.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	camera := image.Rect(5, 3, 12, 9)
+	dst := image.NewRGBA(image.Rect(0, 0, camera.Dx(), camera.Dy()))
+	if err := r.RenderRegion(dst, camera); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < camera.Dy(); y++ {
+		for x := 0; x < camera.Dx(); x++ {
+			if got, want := dst.RGBAAt(x, y), full.RGBAAt(camera.Min.X+x, camera.Min.Y+y); got != want {
+				t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	cells := orthogonal{m}.cells(16, 16, r.pad(camera.Sub(image.Pt(1, 0))))
+	var got image.Rectangle
+	for _, c := range cells {
+		got = got.Union(image.Rectangle{c, c.Add(image.Pt(1, 1))})
+	}
+	if want := image.Rect(1, 0, 7, 6); got != want || len(cells) != 36 {
+		t.Errorf("cells() = %d cells in %v, want 36 in %v", len(cells), got, want)
+	}
+}
+
+func TestRenderRegionOutside(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,2</data></layer>`)
+	dst := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	if err := New(m, l).RenderRegion(dst, image.Rect(-100, -100, -96, -96)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Trim(string(dst.Pix), "\x00") != "" {
+		t.Error("RenderRegion() drew tiles outside the camera")
+	}
+}
@@ -0,0 +1,224 @@
+// Package render rasterizes Tiled maps read by package tmx.
+package render
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"time"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// Flag error values returned by the renderer.
+var (
+	ErrUnsupportedOrientation = errors.New("render: unsupported map orientation")
+	ErrLayerNotFound          = errors.New("render: layer not found")
+	ErrInvalidTileSize        = errors.New("render: invalid map tile size")
+)
+
+// Renderer rasterizes the tile layers, image layers, tile objects and text
+// objects of orthogonal, isometric, staggered and hexagonal maps.
+// Tileset images are decoded on first use and reused by later renders until
+// Reset is called. Layer data is decoded with tmx.Layer.Decoded, so tiles
+// edited with tmx.Layer.SetTile are drawn by the next render.
+type Renderer struct {
+	Map    *tmx.Map
+	Loader *tmx.Loader // Loads tileset images. If nil, the zero Loader is used.
+
+	// Fonts resolves the faces of text objects. If nil, text objects are not drawn.
+	Fonts FontResolver
+
+	// Animated draws animated tiles at their frame at Time instead of the
+	// tile itself. All animations start at Time 0.
+	Animated bool
+	Time     time.Duration
+
+	images map[string]image.Image
+}
+
+// New returns a renderer for m loading tileset images with l.
+func New(m *tmx.Map, l *tmx.Loader) *Renderer {
+	return &Renderer{Map: m, Loader: l}
+}
+
+// Render rasterizes the visible layers of m with images loaded by l.
+func Render(m *tmx.Map, l *tmx.Loader) (*image.RGBA, error) {
+	return New(m, l).Render()
+}
+
+// Bounds returns the pixel bounds of the map, including oversized tiles and
+// image layers. See tmx.Map.Bounds.
+// It returns the empty rectangle for unsupported orientations.
+func (r *Renderer) Bounds() image.Rectangle {
+	if _, err := r.projection(); err != nil {
+		return image.Rectangle{}
+	}
+	return r.Map.Bounds()
+}
+
+// Render rasterizes the visible layers and objects of the map from bottom
+// to top. Parallax factors are ignored.
+func (r *Renderer) Render() (*image.RGBA, error) {
+	if _, err := r.projection(); err != nil {
+		return nil, err
+	}
+	dst := image.NewRGBA(r.Bounds())
+	if err := r.drawLayers(dst, dst.Bounds(), false); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// RenderAt rasterizes the map like Render with animated tiles drawn at their
+// frame at time t. It sets Animated and Time.
+func (r *Renderer) RenderAt(t time.Duration) (*image.RGBA, error) {
+	r.Animated, r.Time = true, t
+	return r.Render()
+}
+
+// drawLayers draws the visible layers of the map intersecting the map pixel
+// rectangle view onto dst, with view.Min drawn at dst.Bounds().Min. If
+// parallax is set, layers are scrolled by their parallax factors relative to
+// the center of view.
+func (r *Renderer) drawLayers(dst draw.Image, view image.Rectangle, parallax bool) error {
+	m := r.Map
+	var cmds []DrawCommand
+	for _, ref := range m.DrawOrder() {
+		l := m.Layer(ref)
+		if !m.EffectiveVisible(l) {
+			continue
+		}
+		var shift image.Point
+		if parallax {
+			shift = r.parallax(l, view)
+		}
+		var err error
+		if g, ok := l.(*tmx.ObjectGroup); ok {
+			// Draw the layers below before the objects.
+			if err := r.draw(dst, view, cmds); err != nil {
+				return err
+			}
+			cmds = cmds[:0]
+			err = r.drawObjects(dst, ref, g, view, shift)
+		} else {
+			cmds, err = r.commands(cmds, ref, l, view, shift)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return r.draw(dst, view, cmds)
+}
+
+// RenderLayer rasterizes the tile layer at index i of the map onto a
+// transparent image the size of the map, even if the layer is hidden.
+func (r *Renderer) RenderLayer(i int) (*image.RGBA, error) {
+	if i < 0 || i >= len(r.Map.Layers) {
+		return nil, ErrLayerNotFound
+	}
+	if _, err := r.projection(); err != nil {
+		return nil, err
+	}
+	dst := image.NewRGBA(r.Bounds())
+	if err := r.DrawLayer(dst, &r.Map.Layers[i]); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// RenderLayerNamed rasterizes the first tile layer with the given name like RenderLayer.
+func (r *Renderer) RenderLayerNamed(name string) (*image.RGBA, error) {
+	for i := 0; i < len(r.Map.Layers); i++ {
+		if r.Map.Layers[i].Name == name {
+			return r.RenderLayer(i)
+		}
+	}
+	return nil, ErrLayerNotFound
+}
+
+// RenderLayers rasterizes each tile layer of the map onto its own image.
+// Entry i holds the layer at index i of the map.
+func (r *Renderer) RenderLayers() ([]*image.RGBA, error) {
+	out := make([]*image.RGBA, len(r.Map.Layers))
+	for i := range out {
+		var err error
+		if out[i], err = r.RenderLayer(i); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// DrawLayer draws the tiles of l onto dst in the map's render order, honoring
+// the opacity, offset and tint color of the layer and its parent groups,
+// tileset tile offsets and flipped tiles. It draws l even if it is hidden.
+func (r *Renderer) DrawLayer(dst draw.Image, l *tmx.Layer) error {
+	return r.drawLayer(dst, l, dst.Bounds())
+}
+
+// drawLayer draws the tiles of l intersecting the map pixel rectangle view
+// onto dst, with view.Min drawn at dst.Bounds().Min.
+func (r *Renderer) drawLayer(dst draw.Image, l *tmx.Layer, view image.Rectangle) error {
+	cmds, err := r.layerCommands(nil, r.ref(l), l, view, image.Point{})
+	if err != nil {
+		return err
+	}
+	return r.draw(dst, view, cmds)
+}
+
+// ref returns the reference to the layer l of the map. Layers outside of the
+// map have a negative index.
+func (r *Renderer) ref(l tmx.AnyLayer) tmx.LayerRef {
+	for _, ref := range r.Map.DrawOrder() {
+		if r.Map.Layer(ref) == l {
+			return ref
+		}
+	}
+	switch l.(type) {
+	case *tmx.ImageLayer:
+		return tmx.LayerRef{Kind: tmx.ImageLayerKind, Index: -1}
+	}
+	return tmx.LayerRef{Kind: tmx.TileLayerKind, Index: -1}
+}
+
+// Reset discards the images cached by the renderer.
+// Call it after changing the map's tilesets.
+func (r *Renderer) Reset() {
+	r.images = nil
+}
+
+// size returns the size of img from its attributes, decoding it when they
+// are missing.
+func (r *Renderer) size(img tmx.Image) (image.Point, error) {
+	if img.Width > 0 && img.Height > 0 {
+		return image.Pt(img.Width, img.Height), nil
+	}
+	dec, err := r.image(img)
+	if err != nil {
+		return image.Point{}, err
+	}
+	return dec.Bounds().Size(), nil
+}
+
+// image returns the decoded image with its transparent color applied,
+// loading it on first use.
+func (r *Renderer) image(img tmx.Image) (image.Image, error) {
+	name := img.ResolvedSource()
+	if out, ok := r.images[name]; ok {
+		return out, nil
+	}
+	l := r.Loader
+	if l == nil {
+		l = new(tmx.Loader)
+	}
+	out, err := l.DecodeImage(img)
+	if err != nil {
+		return nil, err
+	}
+	if r.images == nil {
+		r.images = make(map[string]image.Image)
+	}
+	r.images[name] = out
+	return out, nil
+}
@@ -0,0 +1,239 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// tilesPNG returns a 4x2 image holding two 2x2 tiles with distinct pixels.
+func tilesPNG(t *testing.T) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			img.Set(x, y, color.NRGBA{uint8(10 * (x + 1)), uint8(10 * (y + 1)), 0, 0xff})
+		}
+	}
+	img.Set(3, 1, color.NRGBA{0xff, 0, 0xff, 0xff})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func loadMap(t *testing.T, layers string) (*tmx.Map, *tmx.Loader) {
+	fsys := fstest.MapFS{
+		"tiles.png": {Data: tilesPNG(t)},
+		"map.tmx": {Data: []byte(`<map version="1.2" orientation="orthogonal" width="2" height="1" tilewidth="2" tileheight="2">
+ <tileset firstgid="1" name="tiles" tilewidth="2" tileheight="2" columns="2">
+  <image source="tiles.png" trans="ff00ff" width="4" height="2"/>
+ </tileset>
+` + layers + `
+</map>`)},
+	}
+	l := tmx.NewLoader(fsys)
+	m, err := l.ReadFile("map.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, l
+}
+
+func TestRender(t *testing.T) {
+	// 0x80000001 is tile 0 flipped horizontally, 0x20000001 diagonally.
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">2147483649,536870913</data></layer>
+<layer name="b" width="2" height="1" visible="0"><data encoding="csv">2,2</data></layer>`)
+	img, err := Render(m, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.Bounds(), image.Rect(0, 0, 4, 2); got != want {
+		t.Fatalf("Bounds() = %v, want %v", got, want)
+	}
+	want := [][2]uint8{
+		// Horizontally flipped tile 0.
+		{20, 10}, {10, 10},
+		// Diagonally flipped tile 0.
+		{10, 10}, {10, 20},
+	}
+	for i, p := range []image.Point{{0, 0}, {1, 0}, {2, 0}, {3, 0}} {
+		c := img.RGBAAt(p.X, p.Y)
+		if c.R != want[i][0] || c.G != want[i][1] || c.A != 0xff {
+			t.Errorf("pixel %v = %v, want R=%d G=%d", p, c, want[i][0], want[i][1])
+		}
+	}
+	if c := img.RGBAAt(2, 1); c.R != 20 || c.G != 10 {
+		t.Errorf("pixel (2,1) = %v, want R=20 G=10", c)
+	}
+}
+
+func TestRenderEditedTiles(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,0</data></layer>`)
+	r := New(m, l)
+	if _, err := r.Render(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Layers[0].SetTile(1, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+	img, err := r.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tile 1 starts at pixel (2, 0) of the tileset image.
+	if c := img.RGBAAt(2, 0); c.R != 30 || c.G != 10 || c.A != 0xff {
+		t.Errorf("pixel (2,0) after SetTile = %v, want R=30 G=10", c)
+	}
+}
+
+func TestRenderOpacityOffsetTrans(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1" opacity="0.5" offsetx="2"><data encoding="csv">2,0</data></layer>`)
+	img, err := Render(m, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.RGBAAt(0, 0); c.A != 0 {
+		t.Errorf("pixel (0,0) = %v, want transparent", c)
+	}
+	if c := img.RGBAAt(2, 0); c.A != 128 {
+		t.Errorf("pixel (2,0) alpha = %d, want 128", c.A)
+	}
+	if c := img.RGBAAt(3, 1); c.A != 0 {
+		t.Errorf("trans pixel (3,1) = %v, want transparent", c)
+	}
+}
+
+func TestRenderUnsupportedOrientation(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,1</data></layer>`)
+	m.MapOrientation = "oblique"
+	if _, err := Render(m, l); err != ErrUnsupportedOrientation {
+		t.Errorf("Render() error = %v, want ErrUnsupportedOrientation", err)
+	}
+}
+
+func TestRenderLayer(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,0</data></layer>
+<layer name="b" width="2" height="1" visible="0"><data encoding="csv">0,2</data></layer>`)
+	r := New(m, l)
+
+	img, err := r.RenderLayerNamed("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.RGBAAt(0, 0); c.A != 0 {
+		t.Errorf("layer b pixel (0,0) = %v, want transparent", c)
+	}
+	if c := img.RGBAAt(2, 0); c.R != 30 || c.A != 0xff {
+		t.Errorf("layer b pixel (2,0) = %v, want R=30", c)
+	}
+
+	imgs, err := r.RenderLayers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imgs) != 2 {
+		t.Fatalf("RenderLayers() = %d images, want 2", len(imgs))
+	}
+	if c := imgs[0].RGBAAt(0, 0); c.R != 10 || c.A != 0xff {
+		t.Errorf("layer a pixel (0,0) = %v, want R=10", c)
+	}
+	if c := imgs[0].RGBAAt(2, 0); c.A != 0 {
+		t.Errorf("layer a pixel (2,0) = %v, want transparent", c)
+	}
+
+	if _, err := r.RenderLayerNamed("c"); err != ErrLayerNotFound {
+		t.Errorf("RenderLayerNamed(c) error = %v, want ErrLayerNotFound", err)
+	}
+	if _, err := r.RenderLayer(2); err != ErrLayerNotFound {
+		t.Errorf("RenderLayer(2) error = %v, want ErrLayerNotFound", err)
+	}
+}
+
+func TestRenderOrder(t *testing.T) {
+	// Tiles are twice as wide as the grid, so neighbors overlap at x=1.
+	for _, tc := range []struct {
+		order tmx.MapRenderOrder
+		r     uint8
+	}{
+		{tmx.RenderRightDown, 30}, // Cell (1,0) drawn last: tile 1 at x=0.
+		{tmx.RenderLeftDown, 20},  // Cell (0,0) drawn last: tile 0 at x=1.
+	} {
+		m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,2</data></layer>`)
+		m.TileWidth = 1
+		m.MapRenderOrder = tc.order
+		img, err := Render(m, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c := img.RGBAAt(1, 0); c.R != tc.r {
+			t.Errorf("%s: pixel (1,0) = %v, want R=%d", tc.order, c, tc.r)
+		}
+	}
+}
+
+func TestRenderTintAndGroups(t *testing.T) {
+	m, l := loadMap(t, `<group name="g" opacity="0.5" offsetx="1">
+ <layer name="a" width="2" height="1" tintcolor="#ff0000"><data encoding="csv">2,0</data></layer>
+</group>`)
+	img, err := Render(m, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.RGBAAt(0, 0); c.A != 0 {
+		t.Errorf("pixel (0,0) = %v, want transparent", c)
+	}
+	// Tile 1 pixel (0,0) is R=30 G=10, tinted red and drawn at half opacity.
+	if c := img.RGBAAt(1, 0); c.R != 15 || c.G != 0 || c.A != 128 {
+		t.Errorf("pixel (1,0) = %v, want R=15 G=0 A=128", c)
+	}
+
+	m.Groups[0].Visible = false
+	if img, err = Render(m, l); err != nil {
+		t.Fatal(err)
+	}
+	if c := img.RGBAAt(1, 0); c.A != 0 {
+		t.Errorf("hidden group pixel (1,0) = %v, want transparent", c)
+	}
+}
+
+func TestRenderAt(t *testing.T) {
+	m, l := loadMap(t, `<layer name="a" width="2" height="1"><data encoding="csv">1,2</data></layer>`)
+	m.Tilesets[0].Tiles = []tmx.Tile{{ID: 0, Animation: tmx.Animation{Frames: []tmx.Frame{{TileID: 0, Duration: 100}, {TileID: 1, Duration: 100}}}}}
+	r := New(m, l)
+	for _, tc := range []struct {
+		t    time.Duration
+		want uint8
+	}{
+		{0, 10},
+		{150 * time.Millisecond, 30},
+		{250 * time.Millisecond, 10},
+	} {
+		img, err := r.RenderAt(tc.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c := img.RGBAAt(0, 0); c.R != tc.want {
+			t.Errorf("RenderAt(%v) pixel (0,0) = %v, want R=%d", tc.t, c, tc.want)
+		}
+		// Tiles without an animation are drawn as is.
+		if c := img.RGBAAt(2, 0); c.R != 30 {
+			t.Errorf("RenderAt(%v) pixel (2,0) = %v, want R=30", tc.t, c)
+		}
+	}
+
+	r.Animated = false
+	img, err := r.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.RGBAAt(0, 0); c.R != 10 {
+		t.Errorf("Render() pixel (0,0) = %v, want the static tile", c)
+	}
+}
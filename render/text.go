@@ -0,0 +1,210 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// Face provides the glyphs of a font at a given size in pixels. FontFace
+// returns a Face for a golang.org/x/image/font.Face. All values are in pixels.
+type Face interface {
+	// Metrics returns the distance between the baselines of consecutive
+	// lines and the distance from the top of a line to its baseline.
+	Metrics() (height, ascent int)
+
+	// Advance returns the distance from the baseline origin of r to that of
+	// the next rune, and whether the face has a glyph for r.
+	Advance(r rune) (advance int, ok bool)
+
+	// Kern returns the adjustment to the advance of r0 when followed by r1.
+	Kern(r0, r1 rune) int
+
+	// Glyph returns the rectangle covered by r drawn with its baseline
+	// origin at dot, and the alpha mask drawn there from point maskp of the
+	// mask, or false if the face has no glyph for r.
+	Glyph(dot image.Point, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, ok bool)
+}
+
+// FontResolver returns the face drawing t in its font family and pixel size,
+// bold or italic as requested.
+type FontResolver func(t *tmx.Text) (Face, error)
+
+// TextLine is a line of laid out text.
+type TextLine struct {
+	Dot    image.Point // Baseline origin of the line, relative to the object.
+	Width  int
+	Glyphs []Glyph
+}
+
+// Glyph is a rune of laid out text.
+type Glyph struct {
+	Rune rune
+	Dot  image.Point // Baseline origin of the glyph, relative to the object.
+}
+
+// LayoutText lays out the text of t in an object of the given size.
+// Lines break at newlines and, if t.Wrap is set, at the last space before
+// exceeding the width, or at any rune within words wider than the object.
+// Lines are aligned by t.HAlign and t.VAlign; justified lines stretch their
+// spaces to the width except for the last line of each paragraph.
+// Runes missing from face are skipped.
+func LayoutText(t *tmx.Text, face Face, width, height int) []TextLine {
+	lineHeight, ascent := face.Metrics()
+	var out []TextLine
+	var last []bool // Whether each line ends a paragraph.
+	for _, para := range strings.Split(t.Text, "\n") {
+		lines := wrapText([]rune(para), t, face, width)
+		for i, runes := range lines {
+			out = append(out, layoutLine(runes, t, face))
+			last = append(last, i == len(lines)-1)
+		}
+	}
+
+	y := 0
+	switch t.VAlign {
+	case "center":
+		y = (height - len(out)*lineHeight) / 2
+	case "bottom":
+		y = height - len(out)*lineHeight
+	}
+	for i := range out {
+		l := &out[i]
+		x := 0
+		switch t.HAlign {
+		case "center":
+			x = (width - l.Width) / 2
+		case "right":
+			x = width - l.Width
+		case "justify":
+			if !last[i] {
+				justify(l, width)
+			}
+		}
+		l.Dot = image.Pt(x, y+i*lineHeight+ascent)
+		for j := range l.Glyphs {
+			l.Glyphs[j].Dot = l.Glyphs[j].Dot.Add(l.Dot)
+		}
+	}
+	return out
+}
+
+// wrapText splits a paragraph into lines no wider than width if t.Wrap is set.
+func wrapText(para []rune, t *tmx.Text, face Face, width int) [][]rune {
+	if !t.Wrap || width <= 0 {
+		return [][]rune{para}
+	}
+	var lines [][]rune
+	for len(para) > 0 {
+		n, space := 0, -1
+		for n < len(para) && (n == 0 || textWidth(para[:n+1], t, face) <= width) {
+			if para[n] == ' ' {
+				space = n
+			}
+			n++
+		}
+		if n < len(para) {
+			if para[n] == ' ' {
+				space = n
+			}
+			if space > 0 {
+				n = space
+			}
+		}
+		lines = append(lines, para[:n])
+		para = para[n:]
+		if n == space {
+			para = para[1:] // Drop the space the line breaks at.
+		}
+	}
+	return lines
+}
+
+// textWidth returns the advance width of runes.
+func textWidth(runes []rune, t *tmx.Text, face Face) int {
+	return layoutLine(runes, t, face).Width
+}
+
+// layoutLine places runes along a baseline starting at the origin.
+func layoutLine(runes []rune, t *tmx.Text, face Face) TextLine {
+	var l TextLine
+	prev := rune(-1)
+	for _, r := range runes {
+		adv, ok := face.Advance(r)
+		if !ok {
+			continue
+		}
+		if t.Kerning && prev >= 0 {
+			l.Width += face.Kern(prev, r)
+		}
+		l.Glyphs = append(l.Glyphs, Glyph{Rune: r, Dot: image.Pt(l.Width, 0)})
+		l.Width += adv
+		prev = r
+	}
+	return l
+}
+
+// justify spreads the spaces of l so that it is width wide.
+func justify(l *TextLine, width int) {
+	spaces := 0
+	for _, g := range l.Glyphs {
+		if g.Rune == ' ' {
+			spaces++
+		}
+	}
+	extra := width - l.Width
+	if spaces == 0 || extra <= 0 {
+		return
+	}
+	shift, seen := 0, 0
+	for i := range l.Glyphs {
+		l.Glyphs[i].Dot.X += shift
+		if l.Glyphs[i].Rune == ' ' {
+			seen++
+			shift = extra * seen / spaces
+		}
+	}
+	l.Width = width
+}
+
+// drawText draws the text object o onto dst with the map origin at origin.
+// Rotated text objects are drawn unrotated.
+func (r *Renderer) drawText(dst draw.Image, o *tmx.Object, origin image.Point, tint color.NRGBA, opacity float32) error {
+	face, err := r.Fonts(o.Text)
+	if err != nil {
+		return err
+	}
+	c := color.NRGBA{A: 0xff}
+	if o.Text.Color != "" {
+		if c, err = o.Text.Color.NRGBA(); err != nil {
+			return err
+		}
+	}
+	c = color.NRGBA{mul(c.R, tint.R), mul(c.G, tint.G), mul(c.B, tint.B), mul(c.A, tint.A)}
+	c.A = uint8(float32(c.A)*opacity + 0.5)
+	src := image.NewUniform(c)
+
+	_, ascent := face.Metrics()
+	thickness := max(1, o.Text.PixelSize/16)
+	at := origin.Add(image.Pt(int(o.X), int(o.Y)))
+	for _, l := range LayoutText(o.Text, face, int(o.Width), int(o.Height)) {
+		for _, g := range l.Glyphs {
+			dr, mask, maskp, ok := face.Glyph(at.Add(g.Dot), g.Rune)
+			if ok {
+				draw.DrawMask(dst, dr, src, image.Point{}, mask, maskp, draw.Over)
+			}
+		}
+		dot := at.Add(l.Dot)
+		if o.Text.Underline {
+			draw.Draw(dst, image.Rect(dot.X, dot.Y+1, dot.X+l.Width, dot.Y+1+thickness), src, image.Point{}, draw.Over)
+		}
+		if o.Text.Strikeout {
+			y := dot.Y - ascent/3
+			draw.Draw(dst, image.Rect(dot.X, y, dot.X+l.Width, y+thickness), src, image.Point{}, draw.Over)
+		}
+	}
+	return nil
+}
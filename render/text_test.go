@@ -0,0 +1,146 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// boxFace draws each rune but 'x' as a 3x5 box with an advance of 4 pixels
+// and kerns "AV" by -1.
+type boxFace struct{}
+
+func (boxFace) Metrics() (height, ascent int) { return 6, 5 }
+
+func (boxFace) Advance(r rune) (int, bool) { return 4, r != 'x' }
+
+func (boxFace) Kern(r0, r1 rune) int {
+	if r0 == 'A' && r1 == 'V' {
+		return -1
+	}
+	return 0
+}
+
+func (boxFace) Glyph(dot image.Point, r rune) (image.Rectangle, image.Image, image.Point, bool) {
+	if r == 'x' || r == ' ' {
+		return image.Rectangle{}, nil, image.Point{}, false
+	}
+	return image.Rect(dot.X, dot.Y-5, dot.X+3, dot.Y), image.Opaque, image.Point{}, true
+}
+
+// layout returns the baseline origins of the lines and glyphs of t.
+func layout(t *tmx.Text, width, height int) (lines, glyphs []image.Point) {
+	for _, l := range LayoutText(t, boxFace{}, width, height) {
+		lines = append(lines, l.Dot)
+		for _, g := range l.Glyphs {
+			glyphs = append(glyphs, g.Dot)
+		}
+	}
+	return lines, glyphs
+}
+
+func TestLayoutText(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		text          tmx.Text
+		width, height int
+		lines, glyphs []image.Point
+	}{{
+		name:   "newline",
+		text:   tmx.Text{Text: "ab\nc"},
+		width:  20,
+		height: 20,
+		lines:  []image.Point{{0, 5}, {0, 11}},
+		glyphs: []image.Point{{0, 5}, {4, 5}, {0, 11}},
+	}, {
+		name:   "wrap at space",
+		text:   tmx.Text{Text: "ab cd", Wrap: true},
+		width:  12,
+		height: 20,
+		lines:  []image.Point{{0, 5}, {0, 11}},
+		glyphs: []image.Point{{0, 5}, {4, 5}, {0, 11}, {4, 11}},
+	}, {
+		name:   "wrap long word",
+		text:   tmx.Text{Text: "abcd", Wrap: true},
+		width:  10,
+		height: 20,
+		lines:  []image.Point{{0, 5}, {0, 11}},
+		glyphs: []image.Point{{0, 5}, {4, 5}, {0, 11}, {4, 11}},
+	}, {
+		name:   "center bottom",
+		text:   tmx.Text{Text: "ab", HAlign: "center", VAlign: "bottom"},
+		width:  20,
+		height: 20,
+		lines:  []image.Point{{6, 19}},
+		glyphs: []image.Point{{6, 19}, {10, 19}},
+	}, {
+		name:   "right center",
+		text:   tmx.Text{Text: "ab", HAlign: "right", VAlign: "center"},
+		width:  20,
+		height: 20,
+		lines:  []image.Point{{12, 12}},
+		glyphs: []image.Point{{12, 12}, {16, 12}},
+	}, {
+		name:   "justify",
+		text:   tmx.Text{Text: "a b c d", HAlign: "justify", Wrap: true},
+		width:  22,
+		height: 20,
+		lines:  []image.Point{{0, 5}, {0, 11}},
+		glyphs: []image.Point{{0, 5}, {4, 5}, {9, 5}, {13, 5}, {18, 5}, {0, 11}},
+	}, {
+		name:   "kerning and missing runes",
+		text:   tmx.Text{Text: "AxV", Kerning: true},
+		width:  20,
+		height: 20,
+		lines:  []image.Point{{0, 5}},
+		glyphs: []image.Point{{0, 5}, {3, 5}},
+	}} {
+		lines, glyphs := layout(&tc.text, tc.width, tc.height)
+		if !reflect.DeepEqual(lines, tc.lines) || !reflect.DeepEqual(glyphs, tc.glyphs) {
+			t.Errorf("%s: LayoutText() = lines %v glyphs %v, want %v %v", tc.name, lines, glyphs, tc.lines, tc.glyphs)
+		}
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	m, l := loadMap(t, `<objectgroup name="labels" opacity="0.5">
+ <object x="1" y="0" width="8" height="2"><text color="#0000ff" underline="1">a</text></object>
+ <object x="0" y="0" visible="0"><text>b</text></object>
+</objectgroup>`)
+	m.Width, m.Height = 5, 4
+	r := New(m, l)
+	img, err := r.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.RGBAAt(1, 0); c.A != 0 {
+		t.Errorf("pixel (1,0) = %v, want nothing drawn without Fonts", c)
+	}
+
+	var got *tmx.Text
+	r.Fonts = func(t *tmx.Text) (Face, error) {
+		got = t
+		return boxFace{}, nil
+	}
+	if img, err = r.Render(); err != nil {
+		t.Fatal(err)
+	}
+	if got != m.ObjectGroups[0].Objects[0].Text {
+		t.Errorf("Fonts called with %+v", got)
+	}
+	// The glyph box spans (1,0)-(4,5) and the underline row 6.
+	want := color.RGBA{0, 0, 0x80, 0x80}
+	for _, p := range []image.Point{{1, 0}, {3, 4}, {1, 6}, {4, 6}} {
+		if c := img.RGBAAt(p.X, p.Y); c != want {
+			t.Errorf("pixel %v = %v, want %v", p, c, want)
+		}
+	}
+	for _, p := range []image.Point{{0, 0}, {4, 0}, {1, 5}} {
+		if c := img.RGBAAt(p.X, p.Y); c.A != 0 {
+			t.Errorf("pixel %v = %v, want transparent", p, c)
+		}
+	}
+}
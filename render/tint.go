@@ -0,0 +1,30 @@
+package render
+
+import (
+	"image"
+	"image/color"
+)
+
+var white = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+
+// tinted is a view of an image with its colors multiplied by a tint color.
+type tinted struct {
+	image.Image
+	tint color.NRGBA
+}
+
+func (t tinted) ColorModel() color.Model { return color.NRGBAModel }
+
+func (t tinted) At(x, y int) color.Color {
+	c := color.NRGBAModel.Convert(t.Image.At(x, y)).(color.NRGBA)
+	return color.NRGBA{
+		mul(c.R, t.tint.R),
+		mul(c.G, t.tint.G),
+		mul(c.B, t.tint.B),
+		mul(c.A, t.tint.A),
+	}
+}
+
+func mul(a, b uint8) uint8 {
+	return uint8((uint32(a)*uint32(b) + 127) / 255)
+}
@@ -0,0 +1,58 @@
+package render
+
+import (
+	"image"
+	"image/color"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// Transform returns a view of the tile image img with the flips of the
+// decoded tile applied as Tiled does: the diagonal flip first, followed by
+// the horizontal and vertical flips. Diagonally flipped tiles swap their width
+// and height, so combined with a horizontal or vertical flip they appear
+// rotated by 90 or 270 degrees. See tmx.DecodedTile.Rotation.
+//
+// The returned image has its origin at (0, 0). Unflipped images are
+// returned unchanged.
+func Transform(img image.Image, t tmx.DecodedTile) image.Image {
+	if !t.HorizontalFlip && !t.VerticalFlip && !t.DiagonalFlip {
+		return img
+	}
+	return &transformed{img, t.HorizontalFlip, t.VerticalFlip, t.DiagonalFlip}
+}
+
+// transformed is a view of img with Tiled's flips applied.
+type transformed struct {
+	img        image.Image
+	h, v, diag bool
+}
+
+func (f *transformed) ColorModel() color.Model { return f.img.ColorModel() }
+
+func (f *transformed) Bounds() image.Rectangle {
+	b := f.img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if f.diag {
+		w, h = h, w
+	}
+	return image.Rect(0, 0, w, h)
+}
+
+func (f *transformed) At(x, y int) color.Color {
+	b := f.Bounds()
+	if !image.Pt(x, y).In(b) {
+		return color.Transparent
+	}
+	if f.h {
+		x = b.Dx() - 1 - x
+	}
+	if f.v {
+		y = b.Dy() - 1 - y
+	}
+	if f.diag {
+		x, y = y, x
+	}
+	min := f.img.Bounds().Min
+	return f.img.At(min.X+x, min.Y+y)
+}
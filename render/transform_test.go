@@ -0,0 +1,74 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	tmx "github.com/ajzaff/go-tmx"
+)
+
+// rotate returns img rotated clockwise by 90 degrees.
+func rotate(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.Set(b.Dy()-1-y, x, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+// mirror returns img mirrored horizontally.
+func mirror(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.Set(b.Dx()-1-x, y, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+func TestTransform(t *testing.T) {
+	src := image.NewRGBA(image.Rect(5, 5, 8, 7))
+	for y := 5; y < 7; y++ {
+		for x := 5; x < 8; x++ {
+			src.Set(x, y, color.RGBA{uint8(x), uint8(y), 0, 0xff})
+		}
+	}
+
+	for flags := 0; flags < 8; flags++ {
+		tile := tmx.DecodedTile{
+			HorizontalFlip: flags&1 != 0,
+			VerticalFlip:   flags&2 != 0,
+			DiagonalFlip:   flags&4 != 0,
+		}
+		var want image.Image = src
+		degrees, mirrored := tile.Rotation()
+		for i := 0; i < degrees/90; i++ {
+			want = rotate(want)
+		}
+		if mirrored {
+			want = mirror(want)
+		}
+
+		got := Transform(src, tile)
+		gb, wb := got.Bounds(), want.Bounds()
+		if gb.Size() != wb.Size() {
+			t.Errorf("%+v: Transform() size = %v, want %v", tile, gb.Size(), wb.Size())
+			continue
+		}
+		for y := 0; y < gb.Dy(); y++ {
+			for x := 0; x < gb.Dx(); x++ {
+				g := got.At(gb.Min.X+x, gb.Min.Y+y)
+				w := want.At(wb.Min.X+x, wb.Min.Y+y)
+				if color.RGBAModel.Convert(g) != color.RGBAModel.Convert(w) {
+					t.Errorf("%+v: pixel (%d,%d) = %v, want %v", tile, x, y, g, w)
+				}
+			}
+		}
+	}
+}
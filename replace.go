@@ -0,0 +1,40 @@
+package tmx
+
+// ReplaceTile replaces tile fromID of the tileset from with tile toID of the
+// tileset to in all tile layers and tile objects, keeping their flip flags,
+// and returns the number of tiles replaced. Both tilesets must be tilesets
+// of the map holding the tiles, or the error is ErrInvalidGID. Tile layer
+// data is encoded with its encoding and compression. Nothing is changed on
+// error.
+func (m *Map) ReplaceTile(from *Tileset, fromID ID, to *Tileset, toID ID) (int, error) {
+	fromGID, ok := m.tileGID(from, fromID)
+	if !ok {
+		return 0, ErrInvalidGID
+	}
+	toGID, ok := m.tileGID(to, toID)
+	if !ok {
+		return 0, ErrInvalidGID
+	}
+	n := 0
+	err := m.mapGIDs(func(gid GID) GID {
+		if gid&GIDMask != fromGID {
+			return gid
+		}
+		n++
+		return toGID | gid&^GIDMask
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// tileGID returns the GID of tile id of ts, or false if ts is not a tileset
+// of the map holding the tile.
+func (m *Map) tileGID(ts *Tileset, id ID) (GID, bool) {
+	gid := ts.FirstGID + GID(id)
+	if ts.FirstGID == 0 || gid&^GIDMask != 0 || m.TilesetForGID(gid) != ts {
+		return 0, false
+	}
+	return gid, true
+}
@@ -0,0 +1,39 @@
+package tmx
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReplaceTile(t *testing.T) {
+	m := remapFixture(t)
+	// Tile 1 of "b" is on the layer with a diagonal flip and on the object.
+	n, err := m.ReplaceTile(&m.Tilesets[1], 1, &m.Tilesets[0], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("ReplaceTile() = %d, want 2", n)
+	}
+	_, _, gids, object := remapState(t, m)
+	if want := []GID{1, 4 | GIDHorizontalFlip, 5, 3 | GIDDiagonalFlip}; !slices.Equal(gids, want) {
+		t.Errorf("tiles = %v, want %v", gids, want)
+	}
+	if o := m.ObjectGroups[0].Objects[0]; object != 3 || !o.VerticalFlip {
+		t.Errorf("object GID = %d, vertical flip %v, want 3, true", object, o.VerticalFlip)
+	}
+
+	for _, tc := range []struct {
+		name string
+		from *Tileset
+		id   ID
+	}{
+		{"ID past the tileset", &m.Tilesets[0], 4},
+		{"tileset of another map", &Tileset{FirstGID: 1, Tilecount: 4}, 0},
+	} {
+		if _, err := m.ReplaceTile(tc.from, tc.id, &m.Tilesets[0], 0); !errors.Is(err, ErrInvalidGID) {
+			t.Errorf("%s: ReplaceTile() = %v, want %v", tc.name, err, ErrInvalidGID)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package tmx
+
+import (
+	"errors"
+	"image"
+)
+
+// ErrInvalidSize is returned when resizing a map to less than one tile.
+var ErrInvalidSize = errors.New("tmx: invalid map size")
+
+// Anchor selects the part of a map kept in place when it is resized.
+type Anchor uint8
+
+// Anchor values.
+const (
+	AnchorTopLeft Anchor = iota
+	AnchorTop
+	AnchorTopRight
+	AnchorLeft
+	AnchorCenter
+	AnchorRight
+	AnchorBottomLeft
+	AnchorBottom
+	AnchorBottomRight
+)
+
+// Offset returns the cell of a map resized from width by height tiles to
+// newWidth by newHeight tiles that its cell (0, 0) moves to.
+func (a Anchor) Offset(width, height, newWidth, newHeight int) image.Point {
+	col, row := int(a%3), int(a/3)
+	return image.Pt((newWidth-width)*col/2, (newHeight-height)*row/2)
+}
+
+// Resize grows or crops the map to width by height tiles keeping the part
+// selected by anchor in place, like the Resize Map dialog of Tiled. Tile
+// layers are resized to the map, filling new cells with NilTile and
+// encoding their data with its encoding and compression. Objects are moved
+// with the tiles and kept when cropped out. Nothing is changed on error.
+func (m *Map) Resize(width, height int, anchor Anchor) error {
+	if width <= 0 || height <= 0 {
+		return ErrInvalidSize
+	}
+	off := anchor.Offset(m.Width, m.Height, width, height)
+
+	data := make([]Data, len(m.Layers))
+	sizes := make([]image.Point, len(m.Layers))
+	for i := 0; i < len(m.Layers); i++ {
+		l := &m.Layers[i]
+		gids, err := l.Decoded()
+		if err != nil {
+			return err
+		}
+		out := make([]GID, width*height)
+		for y := max(0, -off.Y); y < l.Height && y+off.Y < height; y++ {
+			for x := max(0, -off.X); x < l.Width && x+off.X < width; x++ {
+				out[(y+off.Y)*width+x+off.X] = gids[y*l.Width+x]
+			}
+		}
+		if data[i], sizes[i], err = l.encodeLike(out, width, m.CompressionLevel); err != nil {
+			return err
+		}
+	}
+
+	dx, dy := m.objectOffset(off)
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		objects := m.ObjectGroups[i].Objects
+		for j := 0; j < len(objects); j++ {
+			objects[j].X += dx
+			objects[j].Y += dy
+		}
+	}
+	for i := 0; i < len(m.Layers); i++ {
+		l := &m.Layers[i]
+		l.Width, l.Height = sizes[i].X, sizes[i].Y
+		l.Data, l.decoded, l.edited = data[i], nil, false
+	}
+	m.Width, m.Height = width, height
+	return nil
+}
+
+// objectOffset returns the object position offset of moving the cells of
+// the map by off.
+func (m *Map) objectOffset(off image.Point) (dx, dy float64) {
+	if m.MapOrientation == MapIsometric {
+		return float64(off.X * m.TileHeight), float64(off.Y * m.TileHeight)
+	}
+	x0, y0 := m.TileToPixel(0, 0)
+	x1, y1 := m.TileToPixel(off.X, off.Y)
+	return x1 - x0, y1 - y0
+}
@@ -0,0 +1,70 @@
+package tmx
+
+import (
+	"errors"
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestAnchorOffset(t *testing.T) {
+	for _, tc := range []struct {
+		a    Anchor
+		want image.Point
+	}{
+		{AnchorTopLeft, image.Pt(0, 0)},
+		{AnchorTop, image.Pt(2, 0)},
+		{AnchorCenter, image.Pt(2, 1)},
+		{AnchorBottomRight, image.Pt(4, 3)},
+	} {
+		if got := tc.a.Offset(2, 2, 6, 5); got != tc.want {
+			t.Errorf("Anchor(%d).Offset() = %v, want %v", tc.a, got, tc.want)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	m := NewMap(3, 2, 16, 8, MapOrthogonal)
+	l := m.AddTileLayer("ground")
+	if err := l.Encode([]GID{1, 2, 3, 4, 5, 6}, m.DataEncoder(Base64, Zlib)); err != nil {
+		t.Fatal(err)
+	}
+	g := m.AddObjectGroup("objects")
+	m.AddObject(g, Object{X: 16, Y: 8, Visible: true})
+
+	if err := m.Resize(5, 4, AnchorCenter); err != nil {
+		t.Fatal(err)
+	}
+	want := []GID{
+		0, 0, 0, 0, 0,
+		0, 1, 2, 3, 0,
+		0, 4, 5, 6, 0,
+		0, 0, 0, 0, 0,
+	}
+	if got, err := m.Layers[0].Decode(); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Resize() grown layer = %v, %v, want %v", got, err, want)
+	}
+	if l := m.Layers[0]; m.Width != 5 || m.Height != 4 || l.Width != 5 || l.Height != 4 || l.Data.Compression != Zlib {
+		t.Errorf("Resize() map %dx%d, layer %dx%d %q, want 5x4 zlib", m.Width, m.Height, l.Width, l.Height, l.Data.Compression)
+	}
+	if o := m.ObjectGroups[0].Objects[0]; o.X != 32 || o.Y != 16 {
+		t.Errorf("Resize() moved object to (%v, %v), want (32, 16)", o.X, o.Y)
+	}
+
+	if err := m.Resize(2, 1, AnchorBottomRight); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := m.Layers[0].Decode(); err != nil || !reflect.DeepEqual(got, []GID{0, 0}) {
+		t.Errorf("Resize() cropped layer = %v, %v, want [0 0]", got, err)
+	}
+	if o := m.ObjectGroups[0].Objects[0]; o.X != -16 || o.Y != -8 {
+		t.Errorf("Resize() moved object to (%v, %v), want (-16, -8)", o.X, o.Y)
+	}
+
+	if err := m.Resize(0, 1, AnchorTopLeft); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Resize(0, 1) = %v, want %v", err, ErrInvalidSize)
+	}
+	if m.Width != 2 || m.Height != 1 {
+		t.Errorf("Resize(0, 1) changed the map size to %dx%d", m.Width, m.Height)
+	}
+}
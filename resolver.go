@@ -0,0 +1,43 @@
+package tmx
+
+import "io"
+
+// ResourceKind identifies the kind of resource a Loader opens.
+type ResourceKind int
+
+// Resource kinds opened by a Loader.
+const (
+	MapResource ResourceKind = iota
+	TilesetResource
+	TemplateResource
+	ImageResource
+)
+
+func (k ResourceKind) String() string {
+	switch k {
+	case MapResource:
+		return "map"
+	case TilesetResource:
+		return "tileset"
+	case TemplateResource:
+		return "template"
+	case ImageResource:
+		return "image"
+	}
+	return "unknown"
+}
+
+// Resolver opens the resources of a Loader, such as from a database or an
+// encrypted archive. Names are slash-separated paths resolved relative to the
+// referencing resource.
+type Resolver interface {
+	Open(kind ResourceKind, name string) (io.ReadCloser, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface.
+type ResolverFunc func(kind ResourceKind, name string) (io.ReadCloser, error)
+
+// Open calls f(kind, name).
+func (f ResolverFunc) Open(kind ResourceKind, name string) (io.ReadCloser, error) {
+	return f(kind, name)
+}
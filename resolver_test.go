@@ -0,0 +1,37 @@
+package tmx
+
+import (
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestLoaderResolver(t *testing.T) {
+	type request struct {
+		kind ResourceKind
+		name string
+	}
+	var got []request
+	l := &Loader{Resolver: ResolverFunc(func(kind ResourceKind, name string) (io.ReadCloser, error) {
+		got = append(got, request{kind, name})
+		return os.Open(path.Join("testdata", name))
+	})}
+
+	m, err := l.ReadFile("world/map_0_0.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Image(m.Tilesets[0].Image.ResolvedSource()); err != nil {
+		t.Fatal(err)
+	}
+	want := []request{
+		{MapResource, "world/map_0_0.tmx"},
+		{TilesetResource, "tiles.tsx"},
+		{ImageResource, "tiles.png"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requests = %v, want %v", got, want)
+	}
+}
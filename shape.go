@@ -0,0 +1,93 @@
+package tmx
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ShapeKind identifies the shape of an object.
+type ShapeKind uint8
+
+// ShapeKind values.
+const (
+	RectShape     ShapeKind = iota // Rectangles, tile and text objects.
+	EllipseShape                   // Ellipses, outlined by their bounding rectangle.
+	PointShape                     // Points.
+	PolygonShape                   // Closed polygons.
+	PolylineShape                  // Open polylines.
+)
+
+// Shape is the outline of an object after its translation and rotation.
+type Shape struct {
+	Kind ShapeKind
+
+	// Points holds the vertices of polygons and polylines, the position of
+	// points, and the corners of rectangles and of the bounding rectangles
+	// of ellipses, clockwise from the top-left corner before rotation.
+	Points []Point
+}
+
+// WorldShape returns the shape of o in map coordinates. As in Tiled, the
+// shape is rotated clockwise by o.Rotation degrees around the object
+// position, which is the top-left corner of rectangles and ellipses and the
+// bottom-left corner of tile objects.
+// Objects on isometric maps have positions along the tile axes; convert the
+// points with Map.ObjectToPixel to place them on the map.
+func (o *Object) WorldShape() (Shape, error) {
+	var s Shape
+	w, h := o.Width, o.Height
+	switch {
+	case len(o.Polygons) > 0:
+		s.Kind = PolygonShape
+	case len(o.PolyLines) > 0:
+		s.Kind = PolylineShape
+	case o.Point != nil:
+		s.Kind = PointShape
+		s.Points = []Point{{}}
+	case o.Ellipse != nil:
+		s.Kind = EllipseShape
+		s.Points = []Point{{0, 0}, {w, 0}, {w, h}, {0, h}}
+	case o.GID != 0:
+		s.Points = []Point{{0, -h}, {w, -h}, {w, 0}, {0, 0}}
+	default:
+		s.Points = []Point{{0, 0}, {w, 0}, {w, h}, {0, h}}
+	}
+	if s.Kind == PolygonShape || s.Kind == PolylineShape {
+		p := o.Polygons
+		if s.Kind == PolylineShape {
+			p = o.PolyLines
+		}
+		var err error
+		if s.Points, err = parsePoints(p[0].Points); err != nil {
+			return Shape{}, &ObjectError{ID: o.ID, Err: err}
+		}
+	}
+
+	sin, cos := math.Sincos(o.Rotation * math.Pi / 180)
+	for i, p := range s.Points {
+		s.Points[i] = Point{o.X + p.X*cos - p.Y*sin, o.Y + p.X*sin + p.Y*cos}
+	}
+	return s, nil
+}
+
+// parsePoints parses the points attribute of a polygon or polyline: pairs
+// of comma separated coordinates separated by spaces.
+func parsePoints(s string) ([]Point, error) {
+	fields := strings.Fields(s)
+	out := make([]Point, len(fields))
+	for i, f := range fields {
+		x, y, ok := strings.Cut(f, ",")
+		if !ok {
+			return nil, ErrInvalidPointsField
+		}
+		var err error
+		if out[i].X, err = strconv.ParseFloat(x, 64); err != nil {
+			return nil, err
+		}
+		if out[i].Y, err = strconv.ParseFloat(y, 64); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
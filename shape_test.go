@@ -0,0 +1,58 @@
+package tmx
+
+import (
+	"errors"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestWorldShape(t *testing.T) {
+	f, err := os.Open("testdata/shapes.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m, err := Read(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Shape{
+		// Rotated a quarter turn clockwise around the top-left corner.
+		"box":   {RectShape, []Point{{10, 20}, {10, 50}, {0, 50}, {0, 20}}},
+		"ring":  {EllipseShape, []Point{{40, 40}, {60, 40}, {60, 50}, {40, 50}}},
+		"spawn": {PointShape, []Point{{12.5, 7.25}}},
+		"wedge": {PolygonShape, []Point{{64, 32}, {65.5, 28.75}, {62, 36}}},
+		"path":  {PolylineShape, []Point{{0, 100}, {-10, 100}}},
+		// Tile objects stand on their position.
+		"crate": {RectShape, []Point{{80, 64}, {112, 64}, {112, 96}, {80, 96}}},
+	}
+	objects := m.ObjectGroups[0].Objects
+	if len(objects) != len(want) {
+		t.Fatalf("read %d objects, want %d", len(objects), len(want))
+	}
+	for i := 0; i < len(objects); i++ {
+		o := &objects[i]
+		got, err := o.WorldShape()
+		if err != nil {
+			t.Fatalf("%s: WorldShape() returned error: %v", o.Name, err)
+		}
+		w := want[o.Name]
+		if got.Kind != w.Kind || len(got.Points) != len(w.Points) {
+			t.Errorf("%s: WorldShape() = %+v, want %+v", o.Name, got, w)
+			continue
+		}
+		for j, p := range got.Points {
+			if math.Abs(p.X-w.Points[j].X) > 1e-9 || math.Abs(p.Y-w.Points[j].Y) > 1e-9 {
+				t.Errorf("%s: WorldShape() = %+v, want %+v", o.Name, got, w)
+				break
+			}
+		}
+	}
+
+	o := Object{Polygons: []Polygon{{Points: "0,0 1;2"}}}
+	if _, err := o.WorldShape(); !errors.Is(err, ErrInvalidPointsField) {
+		t.Errorf("WorldShape() with invalid points returned %v, want ErrInvalidPointsField", err)
+	}
+}
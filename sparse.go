@@ -0,0 +1,169 @@
+package tmx
+
+import (
+	"image"
+	"iter"
+	"sort"
+)
+
+// DefaultChunkSize is the width and height of the chunks Tiled stores
+// infinite maps in.
+const DefaultChunkSize = 16
+
+// SparseLayer stores the GIDs of an unbounded tile layer in square chunks,
+// allocating only the chunks holding tiles. Cells may have negative
+// coordinates. The zero SparseLayer uses chunks of DefaultChunkSize.
+type SparseLayer struct {
+	size   int
+	chunks map[image.Point]*chunk // Keyed by chunk coordinates.
+}
+
+// chunk holds the GIDs of a chunk and how many of them are set.
+type chunk struct {
+	gids []GID
+	n    int
+}
+
+// NewSparseLayer returns an empty layer stored in chunks of size by size
+// cells, or DefaultChunkSize if size is not positive.
+func NewSparseLayer(size int) *SparseLayer {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	return &SparseLayer{size: size}
+}
+
+// Sparse returns the tiles of l in a sparse layer with chunks of size by
+// size cells, or DefaultChunkSize if size is not positive. The chunks of
+// infinite maps are decoded in place, keeping their cells, and edits made by
+// SetTile are included.
+func (l *Layer) Sparse(size int) (*SparseLayer, error) {
+	s := NewSparseLayer(size)
+	chunks, err := l.Data.Chunks()
+	if err != nil {
+		return nil, l.errorAt(-1, err)
+	}
+	if chunks == nil || l.edited {
+		gids, err := l.Decoded()
+		if err != nil {
+			return nil, err
+		}
+		origin := chunkBounds(chunks).Min
+		for i, gid := range gids {
+			s.SetTile(origin.X+i%l.Width, origin.Y+i/l.Width, gid)
+		}
+		return s, nil
+	}
+	var gids []GID
+	for _, c := range chunks {
+		if gids, err = c.DecodeInto(gids); err != nil {
+			return nil, l.errorAt(-1, err)
+		}
+		for i, gid := range gids {
+			s.SetTile(c.X+i%c.Width, c.Y+i/c.Width, gid)
+		}
+	}
+	return s, nil
+}
+
+// ChunkSize returns the width and height of the chunks of the layer.
+func (s *SparseLayer) ChunkSize() int {
+	if s.size <= 0 {
+		return DefaultChunkSize
+	}
+	return s.size
+}
+
+// locate returns the coordinates of the chunk holding cell (x, y) and the
+// index of the cell within it.
+func (s *SparseLayer) locate(x, y int) (image.Point, int) {
+	n := s.ChunkSize()
+	cx, cy := floorDiv(x, n), floorDiv(y, n)
+	return image.Pt(cx, cy), (y-cy*n)*n + x - cx*n
+}
+
+// floorDiv returns a/b rounded toward negative infinity for b > 0.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+	return q
+}
+
+// TileAt returns the GID of cell (x, y), or 0 if it is empty.
+func (s *SparseLayer) TileAt(x, y int) GID {
+	key, i := s.locate(x, y)
+	c, ok := s.chunks[key]
+	if !ok {
+		return 0
+	}
+	return c.gids[i]
+}
+
+// SetTile sets the GID of cell (x, y). Setting 0 empties the cell and frees
+// its chunk once the chunk is empty.
+func (s *SparseLayer) SetTile(x, y int, gid GID) {
+	key, i := s.locate(x, y)
+	c, ok := s.chunks[key]
+	if !ok {
+		if gid == 0 {
+			return
+		}
+		if s.chunks == nil {
+			s.chunks = make(map[image.Point]*chunk)
+		}
+		n := s.ChunkSize()
+		c = &chunk{gids: make([]GID, n*n)}
+		s.chunks[key] = c
+	}
+	switch {
+	case c.gids[i] == 0 && gid != 0:
+		c.n++
+	case c.gids[i] != 0 && gid == 0:
+		c.n--
+	}
+	c.gids[i] = gid
+	if c.n == 0 {
+		delete(s.chunks, key)
+	}
+}
+
+// Chunks returns an iterator over the populated chunks from top to bottom
+// and left to right, yielding the cells each covers and its GIDs in row
+// major order. The GIDs must not be modified.
+func (s *SparseLayer) Chunks() iter.Seq2[image.Rectangle, []GID] {
+	return func(yield func(image.Rectangle, []GID) bool) {
+		n := s.ChunkSize()
+		for _, key := range s.keys() {
+			min := key.Mul(n)
+			if !yield(image.Rectangle{min, min.Add(image.Pt(n, n))}, s.chunks[key].gids) {
+				return
+			}
+		}
+	}
+}
+
+// keys returns the coordinates of the populated chunks in row major order.
+func (s *SparseLayer) keys() []image.Point {
+	keys := make([]image.Point, 0, len(s.chunks))
+	for key := range s.chunks {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Y != keys[j].Y {
+			return keys[i].Y < keys[j].Y
+		}
+		return keys[i].X < keys[j].X
+	})
+	return keys
+}
+
+// Bounds returns the cells covered by the populated chunks.
+func (s *SparseLayer) Bounds() image.Rectangle {
+	var out image.Rectangle
+	for r := range s.Chunks() {
+		out = out.Union(r)
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package tmx
+
+import (
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSparseLayer(t *testing.T) {
+	var s SparseLayer
+	if got := s.ChunkSize(); got != DefaultChunkSize {
+		t.Errorf("ChunkSize() = %d, want %d", got, DefaultChunkSize)
+	}
+
+	s = *NewSparseLayer(4)
+	s.SetTile(1, 2, 7)
+	s.SetTile(-1, -5, 3)
+	s.SetTile(100, 0, 0)
+	for _, tc := range []struct {
+		x, y int
+		want GID
+	}{
+		{1, 2, 7},
+		{-1, -5, 3},
+		{0, 0, 0},
+		{-4, -8, 0},
+		{100, 0, 0},
+	} {
+		if got := s.TileAt(tc.x, tc.y); got != tc.want {
+			t.Errorf("TileAt(%d, %d) = %d, want %d", tc.x, tc.y, got, tc.want)
+		}
+	}
+
+	var rects []image.Rectangle
+	for r, gids := range s.Chunks() {
+		rects = append(rects, r)
+		if len(gids) != 16 {
+			t.Errorf("chunk %v has %d GIDs, want 16", r, len(gids))
+		}
+	}
+	if want := []image.Rectangle{image.Rect(-4, -8, 0, -4), image.Rect(0, 0, 4, 4)}; !reflect.DeepEqual(rects, want) {
+		t.Errorf("Chunks() = %v, want %v", rects, want)
+	}
+	if got, want := s.Bounds(), image.Rect(-4, -8, 4, 4); got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+
+	s.SetTile(-1, -5, 0)
+	if len(s.chunks) != 1 {
+		t.Errorf("emptied chunk was kept: %d chunks", len(s.chunks))
+	}
+}
+
+func TestLayerSparse(t *testing.T) {
+	m, err := Read(strings.NewReader(`<map width="3" height="2">
+ <layer width="3" height="2"><data encoding="csv">1,0,0,0,0,2</data></layer>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := m.Layers[0].Sparse(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.TileAt(0, 0) != 1 || s.TileAt(2, 1) != 2 || s.TileAt(1, 1) != 0 {
+		t.Errorf("Sparse() tiles = %d, %d, %d", s.TileAt(0, 0), s.TileAt(2, 1), s.TileAt(1, 1))
+	}
+	if len(s.chunks) != 2 {
+		t.Errorf("Sparse() has %d chunks, want 2", len(s.chunks))
+	}
+}
@@ -0,0 +1,208 @@
+package tmx
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// ErrNotMap is returned by Stream when the document root is not a <map>.
+var ErrNotMap = errors.New("tmx: document is not a map")
+
+// StreamHandler holds the callbacks Stream calls as it decodes a map.
+// Elements whose callbacks are nil are skipped without being decoded.
+// Returning an error from a callback stops the stream with that error.
+type StreamHandler struct {
+	// Map is called with the attributes and properties of the map before
+	// any of its tilesets or layers.
+	Map func(m *Map) error
+
+	Tileset func(ts *Tileset) error
+
+	// Group is called with the attributes and properties of a group layer
+	// before its children. Layers nested in the group have it as Parent.
+	Group func(g *Group) error
+
+	Layer      func(l *Layer) error
+	ImageLayer func(l *ImageLayer) error
+
+	// ObjectGroup is called with the attributes and properties of an object
+	// group before its objects, which are passed to Object one at a time.
+	ObjectGroup func(g *ObjectGroup) error
+	Object      func(g *ObjectGroup, o *Object) error
+}
+
+// Stream decodes the map read from r element by element, passing each
+// tileset, layer and object to the callbacks of h in document order
+// without keeping them, so that maps of any size are read with bounded
+// memory. Unlike Read, it does not normalize legacy maps and does not
+// assign IDs to groups without one.
+func Stream(r io.Reader, h StreamHandler) error {
+	return StreamContext(context.Background(), r, h)
+}
+
+// StreamContext is like Stream but stops reading once ctx is done.
+func StreamContext(ctx context.Context, r io.Reader, h StreamHandler) error {
+	d := xml.NewDecoder(contextReadCloser{ctx, io.NopCloser(r)})
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return ErrNotMap
+		}
+		if err != nil {
+			return err
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			if se.Name.Local != "map" {
+				return ErrNotMap
+			}
+			return h.streamMap(d, se)
+		}
+	}
+}
+
+// streamMap streams the children of the <map> element start.
+func (h *StreamHandler) streamMap(d *xml.Decoder, start xml.StartElement) error {
+	type tmxMap Map
+	m := &Map{CompressionLevel: DefaultCompressionLevel}
+	if err := decodeAttrs(start, (*tmxMap)(m)); err != nil {
+		return err
+	}
+	header := pending(func() error { return call(h.Map, m) })
+	err := decodeChildren(d, func(se xml.StartElement) error {
+		if se.Name.Local == "properties" {
+			return d.DecodeElement(&propertyList{&m.Properties}, &se)
+		}
+		if err := header.flush(); err != nil {
+			return err
+		}
+		if se.Name.Local == "tileset" {
+			if h.Tileset == nil {
+				return d.Skip()
+			}
+			var ts Tileset
+			if err := d.DecodeElement(&ts, &se); err != nil {
+				return err
+			}
+			return h.Tileset(&ts)
+		}
+		return h.streamLayer(d, se, 0)
+	})
+	if err != nil {
+		return err
+	}
+	return header.flush()
+}
+
+// streamLayer streams the layer se nested in the group parent, skipping
+// other elements.
+func (h *StreamHandler) streamLayer(d *xml.Decoder, se xml.StartElement, parent ID) error {
+	switch se.Name.Local {
+	case "layer":
+		if h.Layer == nil {
+			return d.Skip()
+		}
+		var l Layer
+		if err := d.DecodeElement(&l, &se); err != nil {
+			return err
+		}
+		l.Parent = parent
+		return h.Layer(&l)
+	case "imagelayer":
+		if h.ImageLayer == nil {
+			return d.Skip()
+		}
+		var l ImageLayer
+		if err := d.DecodeElement(&l, &se); err != nil {
+			return err
+		}
+		l.Parent = parent
+		return h.ImageLayer(&l)
+	case "objectgroup":
+		return h.streamObjectGroup(d, se, parent)
+	case "group":
+		return h.streamGroup(d, se, parent)
+	}
+	return d.Skip()
+}
+
+// streamGroup streams the group layer se and its children.
+func (h *StreamHandler) streamGroup(d *xml.Decoder, se xml.StartElement, parent ID) error {
+	g := &Group{Visible: true, Opacity: 1, ParallaxX: 1, ParallaxY: 1}
+	if err := decodeAttrs(se, g); err != nil {
+		return err
+	}
+	g.Parent = parent
+	header := pending(func() error { return call(h.Group, g) })
+	err := decodeChildren(d, func(child xml.StartElement) error {
+		if child.Name.Local == "properties" {
+			return d.DecodeElement(&propertyList{&g.Properties}, &child)
+		}
+		if err := header.flush(); err != nil {
+			return err
+		}
+		return h.streamLayer(d, child, g.ID)
+	})
+	if err != nil {
+		return err
+	}
+	return header.flush()
+}
+
+// streamObjectGroup streams the object group se and its objects.
+func (h *StreamHandler) streamObjectGroup(d *xml.Decoder, se xml.StartElement, parent ID) error {
+	if h.ObjectGroup == nil && h.Object == nil {
+		return d.Skip()
+	}
+	type objectGroup ObjectGroup
+	g := &ObjectGroup{Visible: true, Opacity: 1, ParallaxX: 1, ParallaxY: 1}
+	if err := decodeAttrs(se, (*objectGroup)(g)); err != nil {
+		return err
+	}
+	g.Parent = parent
+	header := pending(func() error { return call(h.ObjectGroup, g) })
+	err := decodeChildren(d, func(child xml.StartElement) error {
+		switch child.Name.Local {
+		case "properties":
+			return d.DecodeElement(&propertyList{&g.Properties}, &child)
+		case "object":
+			if err := header.flush(); err != nil {
+				return err
+			}
+			if h.Object == nil {
+				return d.Skip()
+			}
+			var o Object
+			if err := d.DecodeElement(&o, &child); err != nil {
+				return err
+			}
+			return h.Object(g, &o)
+		}
+		return d.Skip()
+	})
+	if err != nil {
+		return err
+	}
+	return header.flush()
+}
+
+// pending is a callback run at most once, when it is first flushed.
+type pending func() error
+
+func (p *pending) flush() error {
+	if *p == nil {
+		return nil
+	}
+	fn := *p
+	*p = nil
+	return fn()
+}
+
+// call calls fn with v unless fn is nil.
+func call[T any](fn func(T) error, v T) error {
+	if fn == nil {
+		return nil
+	}
+	return fn(v)
+}
@@ -0,0 +1,111 @@
+package tmx
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	want, err := ReadFile("testdata/groups.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open("testdata/groups.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var events []string
+	var layers []Layer
+	err = Stream(f, StreamHandler{
+		Map: func(m *Map) error {
+			events = append(events, "map")
+			if m.Width != 2 || m.NextLayerID != 6 || len(m.Tilesets) != 0 {
+				t.Errorf("Map() = %+v", m)
+			}
+			return nil
+		},
+		Tileset: func(ts *Tileset) error {
+			events = append(events, "tileset "+ts.Name)
+			return nil
+		},
+		Group: func(g *Group) error {
+			events = append(events, "group "+g.Name)
+			return nil
+		},
+		Layer: func(l *Layer) error {
+			events = append(events, "layer "+l.Name)
+			layers = append(layers, *l)
+			return nil
+		},
+		ObjectGroup: func(g *ObjectGroup) error {
+			events = append(events, "objectgroup "+g.Name)
+			if g.Parent != 2 || !g.Visible || g.Opacity != 1 {
+				t.Errorf("ObjectGroup() = %+v", g)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"map", "tileset default", "layer Top", "group Outer", "group Inner", "layer Nested", "objectgroup Objects"}; !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %q, want %q", events, want)
+	}
+	if !reflect.DeepEqual(layers, want.Layers) {
+		t.Errorf("layers = %+v, want %+v", layers, want.Layers)
+	}
+}
+
+func TestStreamObjects(t *testing.T) {
+	want, err := ReadFile("testdata/shapes.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open("testdata/shapes.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var objects []Object
+	err = Stream(f, StreamHandler{
+		Object: func(g *ObjectGroup, o *Object) error {
+			if len(g.Objects) != 0 {
+				t.Errorf("object group holds %d objects", len(g.Objects))
+			}
+			objects = append(objects, *o)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(objects, want.ObjectGroups[0].Objects) {
+		t.Errorf("objects = %+v, want %+v", objects, want.ObjectGroups[0].Objects)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := Stream(strings.NewReader(`<map><layer name="a"/><layer name="b"/></map>`), StreamHandler{
+		Layer: func(l *Layer) error {
+			calls++
+			return stop
+		},
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Stream() = %v after %d calls, want %v after 1", err, calls, stop)
+	}
+	if err := Stream(strings.NewReader(`<tileset/>`), StreamHandler{}); err != ErrNotMap {
+		t.Errorf("Stream() of a tileset = %v, want %v", err, ErrNotMap)
+	}
+	if err := Stream(strings.NewReader(``), StreamHandler{}); err != ErrNotMap {
+		t.Errorf("Stream() of an empty document = %v, want %v", err, ErrNotMap)
+	}
+}
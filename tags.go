@@ -0,0 +1,118 @@
+package tmx
+
+import (
+	"sort"
+	"strings"
+)
+
+// TagProperty names the layer property holding comma or space separated tags.
+const TagProperty = "tags"
+
+// Tags returns the tags of the layer.
+// Tags are taken from "#tag" tokens in the layer name and from the TagProperty.
+func (l Layer) Tags() []string {
+	return parseTags(l.Name, l.Properties)
+}
+
+// Tags returns the tags of the object group.
+// Tags are taken from "#tag" tokens in the group name and from the TagProperty.
+func (g ObjectGroup) Tags() []string {
+	return parseTags(g.Name, g.Properties)
+}
+
+// parseTags returns the unique lower-cased tags found in name and props.
+func parseTags(name string, props Properties) []string {
+	var out []string
+	add := func(tag string) {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			return
+		}
+		for _, t := range out {
+			if t == tag {
+				return
+			}
+		}
+		out = append(out, tag)
+	}
+
+	for _, field := range strings.Fields(name) {
+		if strings.HasPrefix(field, "#") {
+			add(strings.TrimRight(field[1:], ",;"))
+		}
+	}
+
+	for _, p := range props {
+		if p.Name != TagProperty {
+			continue
+		}
+		for _, tag := range strings.FieldsFunc(p.Value, isTagSeparator) {
+			add(tag)
+		}
+	}
+	return out
+}
+
+func isTagSeparator(r rune) bool {
+	return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n'
+}
+
+// TagIndex maps tags to the layers and object groups carrying them.
+// The index holds pointers into the Map it was built from.
+type TagIndex struct {
+	layers       map[string][]*Layer
+	objectGroups map[string][]*ObjectGroup
+}
+
+// TagIndex builds an index of the tags on the map's layers and object groups.
+// The index should be rebuilt after the map's layers change.
+func (m *Map) TagIndex() *TagIndex {
+	x := &TagIndex{
+		layers:       make(map[string][]*Layer),
+		objectGroups: make(map[string][]*ObjectGroup),
+	}
+	for i := 0; i < len(m.Layers); i++ {
+		l := &m.Layers[i]
+		for _, tag := range l.Tags() {
+			x.layers[tag] = append(x.layers[tag], l)
+		}
+	}
+	for i := 0; i < len(m.ObjectGroups); i++ {
+		g := &m.ObjectGroups[i]
+		for _, tag := range g.Tags() {
+			x.objectGroups[tag] = append(x.objectGroups[tag], g)
+		}
+	}
+	return x
+}
+
+// Layers returns the tile layers tagged with tag in map order.
+func (x *TagIndex) Layers(tag string) []*Layer {
+	return x.layers[strings.ToLower(tag)]
+}
+
+// ObjectGroups returns the object groups tagged with tag in map order.
+func (x *TagIndex) ObjectGroups(tag string) []*ObjectGroup {
+	return x.objectGroups[strings.ToLower(tag)]
+}
+
+// Has returns whether any layer or object group is tagged with tag.
+func (x *TagIndex) Has(tag string) bool {
+	tag = strings.ToLower(tag)
+	return len(x.layers[tag]) > 0 || len(x.objectGroups[tag]) > 0
+}
+
+// Tags returns all indexed tags in sorted order.
+func (x *TagIndex) Tags() []string {
+	var out []string
+	for tag := range x.layers {
+		out = append(out, tag)
+	}
+	for tag := range x.objectGroups {
+		if _, ok := x.layers[tag]; !ok {
+			out = append(out, tag)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,34 @@
+package tmx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	m := &Map{
+		Layers: []Layer{
+			{Name: "Ground"},
+			{Name: "Walls #Collision #foreground"},
+			{Name: "Roof", Properties: []Property{{Name: TagProperty, Value: "foreground, roof"}}},
+		},
+		ObjectGroups: []ObjectGroup{
+			{Name: "Triggers #collision"},
+		},
+	}
+
+	x := m.TagIndex()
+
+	if got, want := x.Tags(), []string{"collision", "foreground", "roof"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags() = %v, want %v", got, want)
+	}
+	if got := x.Layers("foreground"); len(got) != 2 || got[0] != &m.Layers[1] || got[1] != &m.Layers[2] {
+		t.Errorf("Layers(foreground) = %v", got)
+	}
+	if got := x.ObjectGroups("COLLISION"); len(got) != 1 || got[0] != &m.ObjectGroups[0] {
+		t.Errorf("ObjectGroups(collision) = %v", got)
+	}
+	if x.Has("water") {
+		t.Error("Has(water) = true")
+	}
+}
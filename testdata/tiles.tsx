@@ -0,0 +1,9 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset name="external" tilewidth="8" tileheight="8" tilecount="28" columns="14">
+ <image source="tiles.png" width="112" height="16"/>
+ <tile id="2" type="crate">
+  <properties>
+   <property name="solid" value="true"/>
+  </properties>
+ </tile>
+</tileset>
@@ -0,0 +1,34 @@
+package tmx
+
+import "encoding/xml"
+
+// DefaultPixelSize is the font pixel size of text objects omitting it.
+const DefaultPixelSize = 16
+
+// Text models a v1.2 text object <text>.
+// See: https://doc.mapeditor.org/en/stable/reference/tmx-map-format/#text.
+type Text struct {
+	FontFamily string `xml:"fontfamily,attr"` // "sans-serif" when empty.
+	PixelSize  int    `xml:"pixelsize,attr"`
+	Wrap       bool   `xml:"wrap,attr"`
+	Color      Color  `xml:"color,attr"` // Black when empty.
+	Bold       bool   `xml:"bold,attr"`
+	Italic     bool   `xml:"italic,attr"`
+	Underline  bool   `xml:"underline,attr"`
+	Strikeout  bool   `xml:"strikeout,attr"`
+	Kerning    bool   `xml:"kerning,attr"`
+	HAlign     string `xml:"halign,attr"` // "left", "center", "right" or "justify"; left when empty.
+	VAlign     string `xml:"valign,attr"` // "top", "center" or "bottom"; top when empty.
+	Text       string `xml:",chardata"`
+}
+
+// UnmarshalXML decodes the text, defaulting PixelSize and Kerning when absent.
+func (t *Text) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type text Text
+	v := text{PixelSize: DefaultPixelSize, Kerning: true}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*t = Text(v)
+	return nil
+}
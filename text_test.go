@@ -0,0 +1,35 @@
+package tmx
+
+import "testing"
+
+func TestReadText(t *testing.T) {
+	m, err := ReadFile("testdata/text.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := m.ObjectGroups[0].Objects
+	title, sign := objects[0].Text, objects[1].Text
+	if title == nil || *title != (Text{PixelSize: 16, Kerning: true, Text: "Hello & welcome"}) {
+		t.Errorf("title text = %+v", title)
+	}
+	want := Text{
+		FontFamily: "Serif",
+		PixelSize:  8,
+		Wrap:       true,
+		Color:      "#80ff0000",
+		Bold:       true,
+		Italic:     true,
+		Underline:  true,
+		Strikeout:  true,
+		HAlign:     "center",
+		VAlign:     "bottom",
+		Text:       "Keep\nout",
+	}
+	if sign == nil || *sign != want {
+		t.Errorf("sign text = %+v, want %+v", sign, want)
+	}
+
+	if clone := objects[1].Clone(); clone.Text == sign || *clone.Text != *sign {
+		t.Errorf("Clone() text = %p %+v, want a copy of %p", clone.Text, clone.Text, sign)
+	}
+}
@@ -0,0 +1,120 @@
+package tmx
+
+import "image"
+
+// columns returns the number of tile columns of the tileset image imageWidth
+// pixels wide, or the Columns attribute when set.
+func (ts *Tileset) columns(imageWidth int) int {
+	if ts.Columns > 0 {
+		return ts.Columns
+	}
+	stride := ts.TileWidth + ts.Spacing
+	if stride <= 0 {
+		return 0
+	}
+	return (imageWidth - 2*ts.Margin + ts.Spacing) / stride
+}
+
+// TileRect returns the rectangle of tile id within the tileset image.
+// The columns are derived from the image width when the tileset omits them.
+// TileRect returns the empty rectangle if the tileset has no columns.
+func (ts *Tileset) TileRect(id ID) image.Rectangle {
+	return ts.tileRect(id, ts.Image.Width)
+}
+
+func (ts *Tileset) tileRect(id ID, imageWidth int) image.Rectangle {
+	cols := ts.columns(imageWidth)
+	if cols <= 0 {
+		return image.Rectangle{}
+	}
+	col, row := int(id)%cols, int(id)/cols
+	min := image.Pt(
+		ts.Margin+col*(ts.TileWidth+ts.Spacing),
+		ts.Margin+row*(ts.TileHeight+ts.Spacing),
+	)
+	return image.Rectangle{min, min.Add(image.Pt(ts.TileWidth, ts.TileHeight))}
+}
+
+// SubImage returns the part of the decoded tileset image img showing tile id.
+// Columns omitted by the tileset are derived from the bounds of img.
+// SubImage returns nil if the tile lies outside of img.
+func (ts *Tileset) SubImage(img image.Image, id ID) image.Image {
+	b := img.Bounds()
+	rect := ts.tileRect(id, b.Dx()).Add(b.Min)
+	if rect.Empty() || !rect.In(b) {
+		return nil
+	}
+	if s, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return s.SubImage(rect)
+	}
+	return subImage{img, rect}
+}
+
+// subImage is a view of the rect of an image without a SubImage method.
+type subImage struct {
+	image.Image
+	rect image.Rectangle
+}
+
+func (s subImage) Bounds() image.Rectangle { return s.rect }
+
+// gidCount returns the number of GIDs the tileset spans, or 0 if its tile
+// count is unknown: the tile count, or more for image collections whose tile
+// IDs have gaps.
+func (ts *Tileset) gidCount() GID {
+	if ts.Tilecount <= 0 {
+		return 0
+	}
+	n := GID(ts.Tilecount)
+	for i := 0; i < len(ts.Tiles); i++ {
+		n = max(n, GID(ts.Tiles[i].ID)+1)
+	}
+	return n
+}
+
+// tileIndex maps the IDs of the tiles of a tileset to their index in Tiles.
+type tileIndex struct {
+	tiles []Tile // Tiles the index was built from.
+	byID  map[ID]int
+}
+
+// TileByID returns the tile metadata of tile id, or nil if the tileset has
+// none for it. The index it looks tiles up in is built on first use and
+// rebuilt when Tiles is replaced or resized, or when the tile found for id
+// no longer has it.
+// TileByID is not safe for concurrent use.
+func (ts *Tileset) TileByID(id ID) *Tile {
+	if len(ts.Tiles) == 0 {
+		return nil
+	}
+	if !ts.index.valid(ts.Tiles) {
+		ts.index = newTileIndex(ts.Tiles)
+	}
+	i, ok := ts.index.byID[id]
+	if !ok {
+		return nil
+	}
+	if ts.Tiles[i].ID != id {
+		// The IDs were changed in place.
+		ts.index = newTileIndex(ts.Tiles)
+		if i, ok = ts.index.byID[id]; !ok {
+			return nil
+		}
+	}
+	return &ts.Tiles[i]
+}
+
+func newTileIndex(tiles []Tile) *tileIndex {
+	x := &tileIndex{tiles: tiles, byID: make(map[ID]int, len(tiles))}
+	for i := len(tiles) - 1; i >= 0; i-- {
+		x.byID[tiles[i].ID] = i
+	}
+	return x
+}
+
+// valid reports whether the index was built from tiles.
+func (x *tileIndex) valid(tiles []Tile) bool {
+	return x != nil && len(x.tiles) == len(tiles) && &x.tiles[0] == &tiles[0]
+}
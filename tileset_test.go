@@ -0,0 +1,81 @@
+package tmx
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestTileRect(t *testing.T) {
+	ts := &Tileset{TileWidth: 8, TileHeight: 8, Margin: 1, Spacing: 2, Image: Image{Width: 42}}
+	for _, tc := range []struct {
+		id   ID
+		want image.Rectangle
+	}{
+		{0, image.Rect(1, 1, 9, 9)},
+		{3, image.Rect(31, 1, 39, 9)},
+		{4, image.Rect(1, 11, 9, 19)},
+	} {
+		if got := ts.TileRect(tc.id); got != tc.want {
+			t.Errorf("TileRect(%d) = %v, want %v", tc.id, got, tc.want)
+		}
+	}
+
+	ts.Columns = 2
+	if got, want := ts.TileRect(3), image.Rect(11, 11, 19, 19); got != want {
+		t.Errorf("TileRect(3) with columns = %v, want %v", got, want)
+	}
+	if got := (&Tileset{}).TileRect(1); !got.Empty() {
+		t.Errorf("TileRect() without columns = %v", got)
+	}
+}
+
+func TestSubImage(t *testing.T) {
+	img := image.NewGray(image.Rect(10, 10, 26, 18))
+	img.SetGray(18, 10, color.Gray{42})
+	ts := &Tileset{TileWidth: 8, TileHeight: 8}
+
+	sub := ts.SubImage(img, 1)
+	if sub == nil {
+		t.Fatal("SubImage(1) = nil")
+	}
+	if got, want := sub.Bounds(), image.Rect(18, 10, 26, 18); got != want {
+		t.Errorf("SubImage(1) bounds = %v, want %v", got, want)
+	}
+	if got := sub.At(18, 10); got != (color.Gray{42}) {
+		t.Errorf("SubImage(1) pixel = %v", got)
+	}
+	if sub := ts.SubImage(img, 2); sub != nil {
+		t.Errorf("SubImage(2) = %v, want nil", sub.Bounds())
+	}
+}
+
+func TestTileByID(t *testing.T) {
+	ts := &Tileset{Tiles: []Tile{{ID: 3, Type: "a"}, {ID: 7, Type: "b"}}}
+	if tile := ts.TileByID(7); tile != &ts.Tiles[1] {
+		t.Errorf("TileByID(7) = %v, want %v", tile, &ts.Tiles[1])
+	}
+	if tile := ts.TileByID(4); tile != nil {
+		t.Errorf("TileByID(4) = %v, want nil", tile)
+	}
+
+	ts.Tiles = append(ts.Tiles, Tile{ID: 4, Type: "c"})
+	if tile := ts.TileByID(4); tile == nil || tile.Type != "c" {
+		t.Errorf("TileByID(4) after append = %v", tile)
+	}
+	ts.Tiles[0].ID = 5
+	if tile := ts.TileByID(3); tile != nil {
+		t.Errorf("TileByID(3) after changing ID = %v, want nil", tile)
+	}
+	if tile := ts.TileByID(5); tile == nil || tile.Type != "a" {
+		t.Errorf("TileByID(5) after changing ID = %v", tile)
+	}
+
+	clone := ts.Clone()
+	if tile := clone.TileByID(7); tile != &clone.Tiles[1] {
+		t.Errorf("Clone().TileByID(7) = %v, want %v", tile, &clone.Tiles[1])
+	}
+	if tile := (&Tileset{}).TileByID(0); tile != nil {
+		t.Errorf("TileByID(0) without tiles = %v", tile)
+	}
+}
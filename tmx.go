@@ -3,14 +3,14 @@ package tmx
 
 import (
 	"bytes"
-	"compress/gzip"
-	"compress/zlib"
+	"context"
 	"encoding/base64"
 	"encoding/xml"
 	"errors"
+	"fmt"
+	"image"
 	"io"
-	"io/ioutil"
-	"os"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -24,6 +24,9 @@ const (
 	GIDMask           = 0x0fffffff
 )
 
+// DefaultCompressionLevel selects the default level of the layer compression method.
+const DefaultCompressionLevel = -1
+
 // Flag error values returned by various operations.
 var (
 	ErrUnsupportedEncoding    = errors.New("tmx: unsupported encoding scheme")
@@ -31,8 +34,42 @@ var (
 	ErrInvalidDecodedDataLen  = errors.New("tmx: invalid decoded data length")
 	ErrInvalidGID             = errors.New("tmx: invalid GID")
 	ErrInvalidPointsField     = errors.New("tmx: invalid points string")
+	ErrInvalidWangID          = errors.New("tmx: invalid wang ID")
+	ErrInvalidColor           = errors.New("tmx: invalid color")
+	ErrOutOfBounds            = errors.New("tmx: tile out of bounds")
 )
 
+// LayerError reports an error decoding the data of a tile layer, such as an
+// invalid GID at tile Index in row major order. Index is -1 for errors
+// concerning all of the data.
+type LayerError struct {
+	Name  string
+	ID    ID
+	Index int
+	Err   error
+}
+
+func (e *LayerError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("tmx: layer %q (id %d): %v", e.Name, e.ID, e.Err)
+	}
+	return fmt.Sprintf("tmx: layer %q (id %d): tile %d: %v", e.Name, e.ID, e.Index, e.Err)
+}
+
+func (e *LayerError) Unwrap() error { return e.Err }
+
+// ObjectError reports an error decoding an object, such as invalid points.
+type ObjectError struct {
+	ID  ID
+	Err error
+}
+
+func (e *ObjectError) Error() string {
+	return fmt.Sprintf("tmx: object %d: %v", e.ID, e.Err)
+}
+
+func (e *ObjectError) Unwrap() error { return e.Err }
+
 var (
 	// NilTile values are present in empty decoded map layers.
 	NilTile = DecodedTile{Nil: true}
@@ -45,45 +82,118 @@ type (
 	ID uint32
 )
 
+// MakeGID returns the GID of tile id of the tileset starting at
+// tilesetFirstGID with the given flip flags set.
+func MakeGID(id ID, tilesetFirstGID GID, h, v, d bool) GID {
+	gid := (tilesetFirstGID + GID(id)) & GIDMask
+	if h {
+		gid |= GIDHorizontalFlip
+	}
+	if v {
+		gid |= GIDVerticalFlip
+	}
+	if d {
+		gid |= GIDDiagonalFlip
+	}
+	return gid
+}
+
+// TileID returns the GID with its flag bits masked off.
+func (gid GID) TileID() GID {
+	return gid & GIDMask
+}
+
+// Flips returns the horizontal, vertical and diagonal flip flags of the GID.
+func (gid GID) Flips() (h, v, d bool) {
+	return gid&GIDHorizontalFlip != 0, gid&GIDVerticalFlip != 0, gid&GIDDiagonalFlip != 0
+}
+
 // Map models a v1.1 XML Tiled <map>.
 // See: https://doc.mapeditor.org/en/stable/reference/tmx-map-format/.
 type Map struct {
-	Version        string         `xml:"title,attr"`
-	MapOrientation MapOrientation `xml:"orientation,attr"`
-	MapRenderOrder MapRenderOrder `xml:"renderorder,attr"`
-	Width          int            `xml:"width,attr"`
-	Height         int            `xml:"height,attr"`
-	TileWidth      int            `xml:"tilewidth,attr"`
-	TileHeight     int            `xml:"tileheight,attr"`
-	Properties     []Property     `xml:"properties>property"`
-	Tilesets       []Tileset      `xml:"tileset"`
-	Layers         []Layer        `xml:"layer"`
-	ObjectGroups   []ObjectGroup  `xml:"objectgroup"`
+	Version          string         `xml:"version,attr"`
+	TiledVersion     string         `xml:"tiledversion,attr"`
+	MapOrientation   MapOrientation `xml:"orientation,attr"`
+	MapRenderOrder   MapRenderOrder `xml:"renderorder,attr"`
+	Width            int            `xml:"width,attr"`
+	Height           int            `xml:"height,attr"`
+	TileWidth        int            `xml:"tilewidth,attr"`
+	TileHeight       int            `xml:"tileheight,attr"`
+	HexSideLength    int            `xml:"hexsidelength,attr"` // Only for hexagonal maps.
+	StaggerAxis      StaggerAxis    `xml:"staggeraxis,attr"`   // Only for staggered and hexagonal maps.
+	StaggerIndex     StaggerIndex   `xml:"staggerindex,attr"`  // Only for staggered and hexagonal maps.
+	ParallaxOriginX  float64        `xml:"parallaxoriginx,attr"`
+	ParallaxOriginY  float64        `xml:"parallaxoriginy,attr"`
+	BackgroundColor  Color          `xml:"backgroundcolor,attr"`
+	Infinite         bool           `xml:"infinite,attr"` // Whether tile layer data is chunked. See Layer.Sparse.
+	NextLayerID      ID             `xml:"nextlayerid,attr"`
+	NextObjectID     ID             `xml:"nextobjectid,attr"`
+	CompressionLevel int            `xml:"compressionlevel,attr"` // DefaultCompressionLevel when absent.
+	Properties       Properties     `xml:"properties>property"`
+	Tilesets         []Tileset      `xml:"tileset"`
+	Layers           []Layer        `xml:"layer"`
+	ObjectGroups     []ObjectGroup  `xml:"objectgroup"`
+	ImageLayers      []ImageLayer   `xml:"imagelayer"`
+	Groups           []Group        `xml:"-"` // Group layers, whose children are flattened into the other layer slices.
+	Order            []LayerRef     `xml:"-"` // Layers from bottom to top in document order. See DrawOrder.
+
+	path string     // Name the map was loaded from, if any.
+	loc  location   // Location map references are relative to.
+	pos  *positions // Positions recorded by readTMX, if any.
+
+	templates bool // Whether object templates were applied.
 }
 
 // DecodedLayers decodes each map layer and returns all decoded layers.
+// Layers are decoded concurrently like DecodeLayers; their data is decoded
+// once and cached, see Layer.Decoded.
 func (m *Map) DecodedLayers() ([]DecodedLayer, error) {
-	var out []DecodedLayer
-	for i := 0; i < len(m.Layers); i++ {
-		l := m.Layers[i]
-		gids, err := l.Decode()
+	out := make([]DecodedLayer, len(m.Layers))
+	err := parallel(len(m.Layers), 0, func(i int) error {
+		c, err := m.CompactLayer(&m.Layers[i])
 		if err != nil {
-			return nil, err
-		}
-
-		d := DecodedLayer{}
-		for j := 0; j < len(gids); j++ {
-			t, err := m.DecodeGID(gids[j])
-			if err != nil {
-				return nil, err
-			}
-			d.DecodedTiles = append(d.DecodedTiles, t)
+			return err
 		}
-		out = append(out, d)
+		out[i] = c.Decoded()
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return out, nil
 }
 
+// DecodedMap is a map along with its decoded tile layers.
+type DecodedMap struct {
+	*Map
+	Decoded []DecodedLayer // Entry i holds the tiles of Map.Layers[i].
+}
+
+// Decode returns the map along with its decoded tile layers.
+func (m *Map) Decode() (*DecodedMap, error) {
+	layers, err := m.DecodedLayers()
+	if err != nil {
+		return nil, err
+	}
+	return &DecodedMap{Map: m, Decoded: layers}, nil
+}
+
+// Rotation returns the flips of the tile as a clockwise rotation by a multiple
+// of 90 degrees followed by an optional horizontal mirror.
+// For example, a tile flipped diagonally and horizontally is rotated by 90 degrees.
+func (t DecodedTile) Rotation() (degrees int, mirror bool) {
+	switch {
+	case !t.DiagonalFlip && !t.VerticalFlip:
+		return 0, t.HorizontalFlip
+	case !t.DiagonalFlip:
+		return 180, !t.HorizontalFlip
+	case !t.VerticalFlip:
+		return 90, !t.HorizontalFlip
+	default:
+		return 270, t.HorizontalFlip
+	}
+}
+
 // DecodeGID returns and decodes the tile referenced by gid or returns an error.
 // The error will be ErrInvalidGID if gid is not found in m.
 func (m *Map) DecodeGID(gid GID) (DecodedTile, error) {
@@ -93,38 +203,37 @@ func (m *Map) DecodeGID(gid GID) (DecodedTile, error) {
 
 	umaskedGID := gid &^ GIDFlip
 
-	for i := len(m.Tilesets) - 1; i >= 0; i-- {
-		if m.Tilesets[i].FirstGID <= umaskedGID {
-			return DecodedTile{
-				ID:             ID(umaskedGID - m.Tilesets[i].FirstGID),
-				Tileset:        &m.Tilesets[i],
-				HorizontalFlip: gid&GIDHorizontalFlip != 0,
-				VerticalFlip:   gid&GIDVerticalFlip != 0,
-				DiagonalFlip:   gid&GIDDiagonalFlip != 0,
-			}, nil
-		}
+	ts := m.TilesetForGID(umaskedGID)
+	if ts == nil {
+		return NilTile, ErrInvalidGID
 	}
-
-	return NilTile, ErrInvalidGID
+	return DecodedTile{
+		ID:             ID(umaskedGID - ts.FirstGID),
+		Tileset:        ts,
+		HorizontalFlip: gid&GIDHorizontalFlip != 0,
+		VerticalFlip:   gid&GIDVerticalFlip != 0,
+		DiagonalFlip:   gid&GIDDiagonalFlip != 0,
+	}, nil
 }
 
-func getTileset(m *Map, l DecodedLayer) (tileset *Tileset, isEmpty, usesMultipleTilesets bool) {
-	for i := 0; i < len(l.DecodedTiles); i++ {
-		tile := l.DecodedTiles[i]
-		if !tile.Nil {
-			if tileset == nil {
-				tileset = tile.Tileset
-			} else if tileset != tile.Tileset {
-				return tileset, false, true
-			}
-		}
+// TilesetForGID returns the tileset holding the tile of gid, ignoring its
+// flip flags, or nil if no tileset holds it. It finds the tileset with the
+// largest FirstGID not after gid by binary search, which requires Tilesets
+// to be in increasing FirstGID order as Tiled writes them, and checks that
+// gid is within the tiles of the tileset when their count is known.
+func (m *Map) TilesetForGID(gid GID) *Tileset {
+	gid &^= GIDFlip
+	i := sort.Search(len(m.Tilesets), func(i int) bool {
+		return m.Tilesets[i].FirstGID > gid
+	})
+	if i == 0 || gid == 0 {
+		return nil
 	}
-
-	if tileset == nil {
-		return nil, true, false
+	ts := &m.Tilesets[i-1]
+	if n := ts.gidCount(); n > 0 && gid-ts.FirstGID >= n {
+		return nil
 	}
-
-	return tileset, false, false
+	return ts
 }
 
 // MapOrientation represents an layout for map tiles.
@@ -139,6 +248,28 @@ const (
 	MapHexagonal  MapOrientation = "hexagonal"
 )
 
+// StaggerAxis represents the axis of a staggered or hexagonal map along
+// which every other row or column is shifted.
+// See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#map.
+type StaggerAxis string
+
+// Valid stagger axes.
+const (
+	StaggerX StaggerAxis = "x"
+	StaggerY StaggerAxis = "y"
+)
+
+// StaggerIndex represents whether the odd or even rows or columns of a
+// staggered or hexagonal map are shifted.
+// See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#map.
+type StaggerIndex string
+
+// Valid stagger indices.
+const (
+	StaggerOdd  StaggerIndex = "odd"
+	StaggerEven StaggerIndex = "even"
+)
+
 // MapRenderOrder represents an order for rendering map tiles.
 // See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#map.
 type MapRenderOrder string
@@ -151,6 +282,16 @@ const (
 	RenderLeftUp    MapRenderOrder = "left-up"
 )
 
+// DrawOrder represents an order for drawing the objects of an object group.
+// See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#objectgroup.
+type DrawOrder string
+
+// Valid draw orders.
+const (
+	DrawTopDown DrawOrder = "topdown" // Sorted by Y coordinate.
+	DrawIndex   DrawOrder = "index"   // In order of appearance.
+)
+
 // Tileset models a v1.1 XML <tileset>.
 // See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#tileset.
 type Tileset struct {
@@ -165,11 +306,15 @@ type Tileset struct {
 	Columns    int        `xml:"columns,attr"`
 	TileOffset TileOffset `xml:"tileoffset"`
 	Grid       Grid       `xml:"grid"`
-	Properties []Property `xml:"properties>property"`
+	Properties Properties `xml:"properties>property"`
 	Image      Image      `xml:"image"`
 	Terrains   []Terrain  `xml:"terraintypes>terrain"`
 	Tiles      []Tile     `xml:"tile"`
 	WangSets   []WangSet  `xml:"wangsets>wangset"`
+
+	source string     // Resolved path of the external tileset file, if any.
+	loc    location   // Location tileset references are relative to.
+	index  *tileIndex // Index of Tiles built by TileByID.
 }
 
 // TileOffset models a v1 tileset <tileoffset>.
@@ -193,15 +338,44 @@ type TileOrientation string
 
 // Valid tileset orientations.
 const (
-	TileOrthoganal TileOrientation = "orthoganal"
+	TileOrthogonal TileOrientation = "orthogonal"
 	TileIsometric  TileOrientation = "isometric"
+
+	// Deprecated: Use TileOrthogonal.
+	TileOrthoganal = TileOrthogonal
 )
 
 // Property models a v1 named <property>.
 // See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#property.
 type Property struct {
-	Name  string `xml:"name,attr"`
-	Value string `xml:"value,attr"`
+	Name         string     `xml:"name,attr"`
+	Type         string     `xml:"type,attr"`         // Empty for string properties.
+	PropertyType string     `xml:"propertytype,attr"` // Custom class or enum type name.
+	Value        string     `xml:"value,attr"`
+	Properties   Properties `xml:"properties>property"` // Members of class properties.
+}
+
+// UnmarshalXML decodes the property, taking the value from the element text
+// when the value attribute is absent, as Tiled writes multiline strings.
+func (p *Property) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type property Property
+	var v struct {
+		property
+		Text string `xml:",chardata"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*p = Property(v.property)
+	for _, a := range start.Attr {
+		if a.Name.Local == "value" {
+			return nil
+		}
+	}
+	if p.Type != "class" {
+		p.Value = v.Text
+	}
+	return nil
 }
 
 // Terrain models a v1 tileset <terrain>.
@@ -209,22 +383,26 @@ type Property struct {
 type Terrain struct {
 	Name       string     `xml:"name,attr"`
 	TileID     ID         `xml:"tile,attr"`
-	Properties []Property `xml:"properties>property"`
+	Properties Properties `xml:"properties>property"`
 }
 
 // WangSet models a v1 tileset <wangset>.
 // See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#wangset.
 type WangSet struct {
 	Name    string      `xml:"name,attr"`
+	Type    string      `xml:"type,attr"` // Corner, edge or mixed since v1.5.
 	TileID  ID          `xml:"tile,attr"`
 	Corners []WangColor `xml:"wangcornercolor"`
 	Edges   []WangColor `xml:"wangedgecolor"`
+	Colors  []WangColor `xml:"wangcolor"` // Colors of both corners and edges since v1.5.
 	Tiles   []WangTile  `xml:"wangtile"`
 }
 
-// WangColor models a v1.1 wangset color.
+// WangColor models a v1.1 wangset corner or edge color, or a v1.5 wangset
+// color.
 // See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#wangcornercolor.
 // See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#wangedgecolor.
+// See: https://doc.mapeditor.org/en/stable/reference/tmx-map-format/#wangcolor.
 type WangColor struct {
 	Name        string  `xml:"name,attr"`
 	Color       string  `xml:"color,attr"`
@@ -236,7 +414,52 @@ type WangColor struct {
 // See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#wangtile.
 type WangTile struct {
 	TileID ID     `xml:"tileid,attr"`
-	WangID uint32 `xml:"wangid,attr"`
+	WangID WangID `xml:"wangid,attr"`
+}
+
+// WangID packs one wang color index per nibble, clockwise from the top edge.
+type WangID uint32
+
+// UnmarshalXMLAttr parses a hexadecimal "0x" wang ID as written by Tiled 1.1 to 1.4,
+// or the comma-separated list of eight color indexes written by later versions.
+func (id *WangID) UnmarshalXMLAttr(attr xml.Attr) error {
+	s := strings.TrimSpace(attr.Value)
+	if strings.Contains(s, ",") {
+		parts := strings.Split(s, ",")
+		if len(parts) != 8 {
+			return ErrInvalidWangID
+		}
+		var v WangID
+		for i, part := range parts {
+			c, err := strconv.ParseUint(strings.TrimSpace(part), 10, 4)
+			if err != nil {
+				return ErrInvalidWangID
+			}
+			v |= WangID(c) << (4 * uint(i))
+		}
+		*id = v
+		return nil
+	}
+
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return ErrInvalidWangID
+	}
+	*id = WangID(v)
+	return nil
+}
+
+// list returns the comma-separated color indexes of id written by Tiled 1.5
+// and later.
+func (id WangID) list() string {
+	b := make([]byte, 0, 16)
+	for i := 0; i < 8; i++ {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendUint(b, uint64(id>>(4*uint(i))&0xf), 10)
+	}
+	return string(b)
 }
 
 // Tile models a v1.0 <tile>.
@@ -246,32 +469,45 @@ type Tile struct {
 	Type         string        `xml:"type,attr"`
 	Terrain      string        `xml:"terrain,attr"`
 	Probability  float32       `xml:"probability,attr"`
-	Properties   []Property    `xml:"properties>property"`
+	Properties   Properties    `xml:"properties>property"`
 	Image        Image         `xml:"image"` // Unset if using tileset image.
 	ObjectGroups []ObjectGroup `xml:"objectgroup"`
 	Animation    Animation     `xml:"animation"`
 }
 
+// UnmarshalXML decodes the tile, defaulting Probability when absent.
+func (t *Tile) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type tile Tile
+	v := tile{Probability: 1}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*t = Tile(v)
+	return nil
+}
+
 // Image models a v1 tile <image>.
 // See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#image.
 type Image struct {
 	Source string `xml:"source,attr"`
-	Trans  string `xml:"trans,attr"`
+	Trans  Color  `xml:"trans,attr"` // Without '#'.
 	Width  int    `xml:"width,attr"`
 	Height int    `xml:"height,attr"`
+
+	loc location // Location Source is relative to.
 }
 
 // Animation models a v1 tile <animation>.
 // See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#animation.
 type Animation struct {
-	Frames []Frame `xml:"frames>frame"`
+	Frames []Frame `xml:"frame"`
 }
 
 // Frame models a v1 tile animation <frame>.
 // See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#frame.
 type Frame struct {
 	TileID   ID  `xml:"tileid,attr"`
-	Duration int `xml:"duration,attr"`
+	Duration int `xml:"duration,attr"` // In milliseconds. See DurationD.
 }
 
 // Layer models a v1.2 map layer.
@@ -285,8 +521,27 @@ type Layer struct {
 	Visible    bool       `xml:"visible,attr"`
 	OffsetX    int        `xml:"offsetx,attr"`
 	OffsetY    int        `xml:"offsety,attr"`
-	Properties []Property `xml:"properties>property"`
+	TintColor  Color      `xml:"tintcolor,attr"`
+	ParallaxX  float64    `xml:"parallaxx,attr"`
+	ParallaxY  float64    `xml:"parallaxy,attr"`
+	Properties Properties `xml:"properties>property"`
 	Data       Data       `xml:"data"`
+	Parent     ID         `xml:"-"` // ID of the parent group or 0 at the top level.
+
+	decoded []GID // Data decoded by Decoded, until evicted.
+	edited  bool  // Whether decoded holds edits not yet encoded into Data.
+}
+
+// UnmarshalXML decodes the layer, defaulting Visible, Opacity and parallax
+// factors when absent.
+func (l *Layer) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type layer Layer
+	v := layer{Visible: true, Opacity: 1, ParallaxX: 1, ParallaxY: 1}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*l = Layer(v)
+	return nil
 }
 
 // Data models v1 map layer data.
@@ -295,57 +550,226 @@ type Data struct {
 	Encoding    LayerEncoding    `xml:"encoding,attr"`
 	Compression LayerCompression `xml:"compression,attr"`
 	Bytes       []byte           `xml:",innerxml"`
+
+	maxBytes int64 // Limit of decoded bytes, if positive. See ResourceLimits.
+	chunked  bool  // Whether empty data holds no chunks rather than no tiles.
 }
 
 // Decode and decompress the data object to yield a slice of tile GIDs.
+// The chunks of infinite maps yield the cells from the layer Origin.
+// Errors are reported as a *LayerError.
 func (l Layer) Decode() ([]GID, error) {
-	dataBytes, err := l.Data.decodeBytes()
+	return l.DecodeInto(nil)
+}
+
+// DecodeInto decodes the layer data like Decode into dst, reusing its
+// storage when it has the capacity for the layer, and returns the GIDs.
+// Edits made by SetTile are included.
+func (l Layer) DecodeInto(dst []GID) ([]GID, error) {
+	if l.edited {
+		return append(dst[:0], l.decoded...), nil
+	}
+	var gids []GID
+	var err error
+	if l.Data.Chunked() {
+		gids, err = l.Data.decodeChunks(dst, l.Width, l.Height)
+	} else {
+		gids, err = l.Data.DecodeInto(dst, l.Width*l.Height)
+	}
+	if err != nil {
+		return nil, l.errorAt(-1, err)
+	}
+	return gids, nil
+}
+
+// errorAt returns err as a *LayerError of the layer at tile index.
+func (l *Layer) errorAt(index int, err error) error {
+	return &LayerError{Name: l.Name, ID: l.ID, Index: index, Err: err}
+}
+
+// Decoded returns the GIDs of the layer like Decode, decoding them on first
+// use and returning the same slice until EvictDecoded is called or the layer
+// is encoded. The slice must not be modified.
+// Decoded is not safe for concurrent use.
+func (l *Layer) Decoded() ([]GID, error) {
+	if l.decoded != nil {
+		return l.decoded, nil
+	}
+	gids, err := l.Decode()
 	if err != nil {
 		return nil, err
 	}
+	l.decoded = gids
+	return gids, nil
+}
+
+// EvictDecoded discards the GIDs cached by Decoded.
+// Call it after changing Data directly.
+// GIDs holding edits made by SetTile are kept until they are encoded.
+func (l *Layer) EvictDecoded() {
+	if !l.edited {
+		l.decoded = nil
+	}
+}
 
-	if len(dataBytes) != l.Width*l.Height*4 {
+// DecodeInto decodes the n GIDs of the data, such as those of a layer or of
+// a chunk of its size, into dst, reusing its storage when it has the capacity.
+// Chunks of infinite maps are decoded with Chunk.DecodeInto.
+// It returns the GIDs, or an error if the data does not hold n GIDs, in
+// which case the contents of dst are unspecified. Data read with
+// ResourceLimits.MaxDecodedBytes fails with ErrResourceLimit when the n GIDs
+// exceed it.
+func (d Data) DecodeInto(dst []GID, n int) ([]GID, error) {
+	if n < 0 {
 		return nil, ErrInvalidDecodedDataLen
 	}
+	if d.maxBytes > 0 && int64(n) > d.maxBytes/4 {
+		return nil, ErrResourceLimit
+	}
+	gids := dst[:0]
+	if cap(gids) < n {
+		gids = make([]GID, n)
+	}
+	gids = gids[:n]
+	var err error
+	switch d.Encoding {
+	case Base64:
+		err = d.decodeBase64(gids)
+	case CSV:
+		err = d.decodeCSV(gids)
+	case XML:
+		err = d.decodeXML(gids)
+	default:
+		err = ErrUnsupportedEncoding
+	}
+	if err != nil {
+		return nil, err
+	}
+	return gids, nil
+}
 
-	gids := make([]GID, l.Width*l.Height)
+func (d Data) decodeBase64(gids []GID) error {
+	buf := byteBuffers.Get().(*[]byte)
+	defer byteBuffers.Put(buf)
+	if cap(*buf) < len(gids)*4 {
+		*buf = make([]byte, len(gids)*4)
+	}
+	dataBytes := (*buf)[:len(gids)*4]
+	if err := d.decodeBytes(dataBytes); err != nil {
+		return err
+	}
 
-	j := 0
-	for y := 0; y < l.Height; y++ {
-		for x := 0; x < l.Width; x++ {
-			gid := GID(dataBytes[j]) +
-				GID(dataBytes[j+1])<<8 +
-				GID(dataBytes[j+2])<<16 +
-				GID(dataBytes[j+3])<<24
-			j += 4
+	for i, j := 0, 0; i < len(gids); i, j = i+1, j+4 {
+		gids[i] = GID(dataBytes[j]) +
+			GID(dataBytes[j+1])<<8 +
+			GID(dataBytes[j+2])<<16 +
+			GID(dataBytes[j+3])<<24
+	}
+	return nil
+}
 
-			gids[y*l.Width+x] = gid
+func (d Data) decodeCSV(gids []GID) error {
+	fields := strings.FieldsFunc(string(d.Bytes), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if len(fields) != len(gids) {
+		return ErrInvalidDecodedDataLen
+	}
+
+	for i, field := range fields {
+		gid, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return err
 		}
+		gids[i] = GID(gid)
 	}
+	return nil
+}
 
-	return gids, nil
+func (d Data) decodeXML(gids []GID) error {
+	var tiles struct {
+		Tiles []struct {
+			GID GID `xml:"gid,attr"`
+		} `xml:"tile"`
+	}
+	src := io.MultiReader(
+		strings.NewReader("<data>"),
+		bytes.NewReader(d.Bytes),
+		strings.NewReader("</data>"))
+	if err := xml.NewDecoder(src).Decode(&tiles); err != nil {
+		return err
+	}
+	if len(tiles.Tiles) != len(gids) {
+		return ErrInvalidDecodedDataLen
+	}
+
+	for i, t := range tiles.Tiles {
+		gids[i] = t.GID
+	}
+	return nil
 }
 
-func (d Data) decodeBytes() ([]byte, error) {
-	encoder := base64.NewDecoder(
+// decodeBytes reads the base64 decoded and decompressed data into dst.
+// It returns ErrInvalidDecodedDataLen unless the data fills dst exactly.
+// No more than one byte past dst is decompressed.
+func (d Data) decodeBytes(dst []byte) error {
+	src := base64.NewDecoder(
 		base64.StdEncoding,
 		bytes.NewReader(bytes.TrimSpace(d.Bytes)))
 
-	var err error
 	var zr io.Reader
 	switch d.Compression {
+	case Uncompressed:
+		zr = src
 	case Gzip:
-		zr, err = gzip.NewReader(encoder)
+		r, err := gzipReader(src)
+		if err != nil {
+			return err
+		}
+		defer gzipReaders.Put(r)
+		zr = r
 	case Zlib:
-		zr, err = zlib.NewReader(encoder)
+		r, err := zlibReader(src)
+		if err != nil {
+			return err
+		}
+		defer zlibReaders.Put(r)
+		zr = r
+	case Zstd:
+		b, err := io.ReadAll(src)
+		if err != nil {
+			return err
+		}
+		b, err = zstdDecompress(b, len(dst)+1)
+		switch err {
+		case nil:
+		case errZstdTooLarge:
+			return ErrInvalidDecodedDataLen
+		default:
+			return err
+		}
+		zr = bytes.NewReader(b)
 	default:
-		return nil, ErrUnsupportedCompression
-	}
-	if err != nil {
-		return nil, err
+		return ErrUnsupportedCompression
 	}
+	zr = io.LimitReader(zr, int64(len(dst))+1)
 
-	return ioutil.ReadAll(zr)
+	if _, err := io.ReadFull(zr, dst); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrInvalidDecodedDataLen
+		}
+		return err
+	}
+	// Read to the end to check for trailing data and verify checksums.
+	var extra [1]byte
+	switch _, err := io.ReadFull(zr, extra[:]); err {
+	case io.EOF:
+		return nil
+	case nil:
+		return ErrInvalidDecodedDataLen
+	default:
+		return err
+	}
 }
 
 // LayerEncoding represents the type of encoding used in tile layer data.
@@ -353,26 +777,40 @@ type LayerEncoding string
 
 // Various layer encodings.
 const (
-	XML    LayerEncoding = ""    // unsupported
-	CSV    LayerEncoding = "csv" // unsupported
+	XML    LayerEncoding = ""
+	CSV    LayerEncoding = "csv"
 	Base64 LayerEncoding = "base64"
 )
 
 // LayerCompression represents the type of compression used in tile layers.
 type LayerCompression string
 
-// Supported layer compression types.
+// Various layer compression types.
 const (
 	Uncompressed LayerCompression = ""
 	Gzip         LayerCompression = "gzip"
 	Zlib         LayerCompression = "zlib"
+	Zstd         LayerCompression = "zstd"
 )
 
 // DecodedLayer is outputted from the layer <data> decoder.
 type DecodedLayer struct {
-	DecodedTiles []DecodedTile // Tile entry (x,y) is at l.DecodedTiles[y*map.Width+x].
-	Tileset      *Tileset      // Only set when the layer uses a single tileset and Empty is false.
-	Empty        bool          // Set when all entries of the layer are NilTile.
+	Width, Height int
+	Origin        image.Point   // Cell of the first tile. See Layer.Origin.
+	DecodedTiles  []DecodedTile // Tile entry (x,y) is at l.DecodedTiles[y*l.Width+x] from the Origin. See TileAt.
+	Tileset       *Tileset      // Only set when the layer uses a single tileset and Empty is false.
+	Empty         bool          // Set when all entries of the layer are NilTile.
+}
+
+// TileAt returns the tile of cell (x, y), or false if the cell is outside
+// of the layer. Cells of the chunked layers of infinite maps are those of
+// their chunks, from the Origin.
+func (l DecodedLayer) TileAt(x, y int) (DecodedTile, bool) {
+	x, y = x-l.Origin.X, y-l.Origin.Y
+	if x < 0 || y < 0 || x >= l.Width || y >= l.Height || y*l.Width+x >= len(l.DecodedTiles) {
+		return NilTile, false
+	}
+	return l.DecodedTiles[y*l.Width+x], true
 }
 
 // DecodedTile is outputted from the layer <data> decoder.
@@ -390,16 +828,45 @@ func (t DecodedTile) IsNil() bool {
 	return t.Nil
 }
 
+// Meta returns the metadata of the tile in its tileset, holding its type,
+// properties, animation and collision shapes, or nil if there is none.
+// Like Tileset.TileByID, Meta is not safe for concurrent use.
+func (t DecodedTile) Meta() *Tile {
+	if t.Nil || t.Tileset == nil {
+		return nil
+	}
+	return t.Tileset.TileByID(t.ID)
+}
+
 // ObjectGroup models a v1.2 map <objectgroup>.
 // See: https://doc.mapeditor.org/de/stable/reference/tmx-map-format/#objectgroup.
 type ObjectGroup struct {
 	ID         ID         `xml:"id,attr"`
 	Name       string     `xml:"name,attr"`
-	Color      string     `xml:"color,attr"`
+	Color      Color      `xml:"color,attr"`
 	Opacity    float32    `xml:"opacity,attr"`
 	Visible    bool       `xml:"visible,attr"`
-	Properties []Property `xml:"properties>property"`
+	OffsetX    int        `xml:"offsetx,attr"`
+	OffsetY    int        `xml:"offsety,attr"`
+	TintColor  Color      `xml:"tintcolor,attr"`
+	ParallaxX  float64    `xml:"parallaxx,attr"`
+	ParallaxY  float64    `xml:"parallaxy,attr"`
+	DrawOrder  DrawOrder  `xml:"draworder,attr"`
+	Properties Properties `xml:"properties>property"`
 	Objects    []Object   `xml:"object"`
+	Parent     ID         `xml:"-"` // ID of the parent group or 0 at the top level.
+}
+
+// UnmarshalXML decodes the object group, defaulting Visible, Opacity and
+// parallax factors when absent.
+func (g *ObjectGroup) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type objectGroup ObjectGroup
+	v := objectGroup{Visible: true, Opacity: 1, ParallaxX: 1, ParallaxY: 1}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*g = ObjectGroup(v)
+	return nil
 }
 
 // Object models a v1.2 object group <object>.
@@ -413,11 +880,58 @@ type Object struct {
 	Width      float64    `xml:"width,attr"`
 	Height     float64    `xml:"height,attr"`
 	Rotation   float64    `xml:"rotation,attr"`
-	GID        int        `xml:"gid,attr"`
+	GID        GID        `xml:"gid,attr"` // Set for tile objects, without flip flags.
 	Visible    bool       `xml:"visible,attr"`
+	Template   string     `xml:"template,attr"`
+	Ellipse    *struct{}  `xml:"ellipse"` // Set for ellipse objects.
+	Point      *struct{}  `xml:"point"`   // Set for point objects.
 	Polygons   []Polygon  `xml:"polygon"`
 	PolyLines  []Polygon  `xml:"polyline"`
-	Properties []Property `xml:"properties>property"`
+	Text       *Text      `xml:"text"` // Set for text objects.
+	Properties Properties `xml:"properties>property"`
+
+	// Flips of tile objects, stored in the GID attribute.
+	HorizontalFlip bool `xml:"-"`
+	VerticalFlip   bool `xml:"-"`
+}
+
+// UnmarshalXML decodes the object, defaulting Visible when absent.
+func (o *Object) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type object Object
+	v := object{Visible: true}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*o = Object(v)
+	o.setGID(o.GID)
+	return nil
+}
+
+// objectFlip holds the flip flags Tiled sets on the GIDs of tile objects.
+const objectFlip = GIDHorizontalFlip | GIDVerticalFlip
+
+// setGID sets the GID and flips of the object from gid as stored in files.
+// Other flag bits, which Tiled does not set on objects, are kept in GID.
+func (o *Object) setGID(gid GID) {
+	o.GID = gid &^ objectFlip
+	o.HorizontalFlip = gid&GIDHorizontalFlip != 0
+	o.VerticalFlip = gid&GIDVerticalFlip != 0
+}
+
+// RawGID returns the GID of the object with its flip flags, as stored in
+// files, or 0 if it is not a tile object.
+func (o *Object) RawGID() GID {
+	gid := o.GID
+	if gid == 0 {
+		return 0
+	}
+	if o.HorizontalFlip {
+		gid |= GIDHorizontalFlip
+	}
+	if o.VerticalFlip {
+		gid |= GIDVerticalFlip
+	}
+	return gid
 }
 
 // Polygon models a v1 object <polygon> or <polyline>.
@@ -428,70 +942,146 @@ type Polygon struct {
 
 // Point represents a 2D point in a decoded polygon.
 type Point struct {
-	X int
-	Y int
+	X float64
+	Y float64
 }
 
 // Decode and return a slice of points from the polygon.
+// Coordinates may be fractional, as Tiled writes them.
 func (p Polygon) Decode() ([]Point, error) {
-	parts := strings.Split(p.Points, " ")
-	out := make([]Point, len(parts))
+	return parsePoints(p.Points)
+}
 
-	for i, part := range parts {
-		coords := strings.Split(part, ",")
-		if len(coords) != 2 {
-			return nil, ErrInvalidPointsField
-		}
+// Read a map from the reader r or returns an error.
+// External tilesets and object templates are not loaded unless enabled
+// with WithExternalTilesets.
+func Read(r io.Reader, opts ...ReadOption) (*Map, error) {
+	return ReadContext(context.Background(), r, opts...)
+}
 
-		var err error
-		if out[i].X, err = strconv.Atoi(coords[0]); err != nil {
-			return nil, err
-		}
-		if out[i].Y, err = strconv.Atoi(coords[1]); err != nil {
-			return nil, err
-		}
-	}
-	return out, nil
+// ReadContext is like Read but stops reading once ctx is done.
+func ReadContext(ctx context.Context, r io.Reader, opts ...ReadOption) (*Map, error) {
+	return newReadOptions(false, opts).read(ctx, r)
 }
 
-// Read a map from the reader r or returns an error.
-func Read(r io.Reader) (*Map, error) {
-	d := xml.NewDecoder(r)
-	out := new(Map)
+// ReadMetadata reads a map from the reader r like Read but skips the
+// content of layer <data> elements while parsing, for tools that only need
+// the structure, tilesets, properties or objects of the map. The Data of
+// its layers only holds their encoding and compression.
+func ReadMetadata(r io.Reader) (*Map, error) {
+	return readTMX(context.Background(), r, &Loader{SkipData: true})
+}
+
+// readTMX reads a TMX map, skipping its layer data if l.SkipData is set and
+// recording the positions of its elements if l.Positions is set.
+// Decoding errors are reported as a *ParseError.
+func readTMX(ctx context.Context, r io.Reader, l *Loader) (*Map, error) {
+	r = contextReadCloser{ctx, io.NopCloser(r)}
+
+	// Positions are recorded in a second pass over a copy of the document,
+	// since innerxml fields are not filled when decoding from a TokenReader.
+	var doc *bytes.Buffer
+	if l.Positions {
+		doc = new(bytes.Buffer)
+		r = io.TeeReader(r, doc)
+	}
+
+	raw := xml.NewDecoder(r)
+	d := raw
+	if l.SkipData {
+		d = xml.NewTokenDecoder(&dataSkipper{d: raw})
+	}
+	out := &Map{CompressionLevel: DefaultCompressionLevel}
 
 	if err := d.Decode(out); err != nil {
-		return nil, err
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, &ParseError{inputPos(raw), err}
+	}
+	if doc != nil {
+		out.pos = recordPositions(doc)
 	}
 
-	layers, err := out.DecodedLayers()
-	if err != nil {
+	if err := out.init(); err != nil {
 		return nil, err
 	}
+	return out, nil
+}
 
-	for i := 0; i < len(layers); i++ {
-		l := layers[i]
+// dataSkipper reads the tokens of d, dropping the content of <data> elements.
+type dataSkipper struct {
+	d   *xml.Decoder
+	end *xml.EndElement // End of the skipped <data> element to return next.
+}
 
-		tileset, isEmpty, usesMultipleTilesets := getTileset(out, l)
-		if usesMultipleTilesets {
-			continue
+func (s *dataSkipper) Token() (xml.Token, error) {
+	if end := s.end; end != nil {
+		s.end = nil
+		return *end, nil
+	}
+	tok, err := s.d.Token()
+	if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "data" {
+		if err := s.d.Skip(); err != nil {
+			return nil, err
 		}
-		l.Empty, l.Tileset = isEmpty, tileset
+		end := se.End()
+		s.end = &end
 	}
+	return tok, err
+}
 
-	return out, nil
+// init normalizes a freshly decoded map.
+// Layer data is decoded on first use; see Layer.Decoded.
+func (m *Map) init() error {
+	m.normalizeEnums()
+	if m.Infinite {
+		for i := 0; i < len(m.Layers); i++ {
+			m.Layers[i].Data.chunked = true
+		}
+	}
+	if m.IsLegacy() {
+		m.normalizeLegacy()
+	}
+	return nil
 }
 
-// ReadFile reads a map from a file path or returns an error.
-func ReadFile(filepath string) (*Map, error) {
-	f, err := os.Open(filepath)
+// EvictDecoded discards the layer data cached by Layer.Decoded.
+func (m *Map) EvictDecoded() {
+	for i := 0; i < len(m.Layers); i++ {
+		m.Layers[i].EvictDecoded()
+	}
+}
+
+// ReadDecoded reads a map from the reader r and decodes its tile layers or
+// returns an error.
+func ReadDecoded(r io.Reader) (*DecodedMap, error) {
+	m, err := Read(r)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	return m.Decode()
+}
 
-	out, err := Read(f)
+// ReadDecodedFile reads a map from a file path like ReadFile and decodes its
+// tile layers or returns an error.
+func ReadDecodedFile(filepath string) (*DecodedMap, error) {
+	m, err := ReadFile(filepath)
 	if err != nil {
 		return nil, err
 	}
-	return out, err
+	return m.Decode()
+}
+
+// ReadFile reads a map from a file path or returns an error.
+// External tilesets and object templates are loaded relative to the map file
+// unless disabled with WithExternalTilesets.
+func ReadFile(filepath string, opts ...ReadOption) (*Map, error) {
+	return ReadFileContext(context.Background(), filepath, opts...)
+}
+
+// ReadFileContext is like ReadFile but stops loading once ctx is done.
+func ReadFileContext(ctx context.Context, filepath string, opts ...ReadOption) (*Map, error) {
+	o := newReadOptions(true, opts)
+	return o.loader.readFile(ctx, filepath, o.external)
 }
@@ -1,12 +1,17 @@
 package tmx
 
 import (
+	"bytes"
+	"encoding/xml"
+	"errors"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
 var (
-	testfiles = []string{"testdata/base64-gzip.tmx", "testdata/base64-zlib.tmx"}
+	testfiles = []string{"testdata/base64-gzip.tmx", "testdata/base64-zlib.tmx", "testdata/base64-zstd.tmx"}
 
 	layer0Data = []GID{
 		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 7, 8, 7, 8, 7, 8, 7, 8, 7, 8, 7, 8, 7, 8, 7, 8, 7, 8,
@@ -107,3 +112,526 @@ func TestProperties(t *testing.T) {
 	t.Fatal("No property found")
 
 }
+
+func TestLayerDecoded(t *testing.T) {
+	m, err := ReadFile("testdata/base64-zlib.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &m.Layers[0]
+	want, err := l.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := l.Decoded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decoded() = %v, want %v", got, want)
+	}
+	if again, _ := l.Decoded(); &again[0] != &got[0] {
+		t.Error("Decoded() decoded the layer again")
+	}
+
+	l.Data = Data{Encoding: CSV, Bytes: []byte("1,2")}
+	if again, _ := l.Decoded(); &again[0] != &got[0] {
+		t.Error("Decoded() did not return the cached data")
+	}
+	m.EvictDecoded()
+	if _, err := l.Decoded(); !errors.Is(err, ErrInvalidDecodedDataLen) {
+		t.Errorf("Decoded() after EvictDecoded = %v, want %v", err, ErrInvalidDecodedDataLen)
+	}
+
+	gids := make([]GID, l.Width*l.Height)
+	gids[0] = 1
+	if err := l.Encode(gids, DataEncoder{Encoding: CSV}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := l.Decoded(); err != nil || !reflect.DeepEqual(got, gids) {
+		t.Errorf("Decoded() after Encode = %v, %v, want %v", got, err, gids)
+	}
+}
+
+func TestReadMetadata(t *testing.T) {
+	m, err := ReadMetadata(strings.NewReader(`<map width="2" height="1">
+ <layer name="xml" width="2" height="1"><data><tile gid="1"/><tile/></data></layer>
+ <layer name="zlib" width="2" height="1"><data encoding="base64" compression="zlib">eJxjZGBgYAJiZiBmAWIAAGAACw==</data></layer>
+ <objectgroup><object id="3" x="1"/></objectgroup>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Layers) != 2 || m.Layers[1].Name != "zlib" || len(m.ObjectGroups[0].Objects) != 1 {
+		t.Fatalf("ReadMetadata() = %+v", m)
+	}
+	for _, l := range m.Layers {
+		if len(l.Data.Bytes) != 0 {
+			t.Errorf("layer %s has data %q", l.Name, l.Data.Bytes)
+		}
+	}
+	if d := m.Layers[1].Data; d.Encoding != Base64 || d.Compression != Zlib {
+		t.Errorf("layer data = %+v, want base64 zlib", d)
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	for _, name := range append(testfiles, "testdata/groups.tmx", "testdata/infinite.tmx") {
+		m, err := ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := m.Layers[0]
+		want, err := l.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf := make([]GID, 1, len(want)+8)
+		got, err := l.DecodeInto(buf)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: DecodeInto() = %v, want %v", name, got, want)
+		}
+		if &got[0] != &buf[0] {
+			t.Errorf("%s: DecodeInto() did not reuse the buffer", name)
+		}
+		if got, err := l.DecodeInto(make([]GID, 0, 1)); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: DecodeInto() of a small buffer = %v, %v", name, got, err)
+		}
+	}
+
+	chunk := Data{Encoding: CSV, Bytes: []byte("1,2,\n3,4")}
+	if got, err := chunk.DecodeInto(nil, 4); err != nil || !reflect.DeepEqual(got, []GID{1, 2, 3, 4}) {
+		t.Errorf("Data.DecodeInto() = %v, %v", got, err)
+	}
+	if _, err := chunk.DecodeInto(nil, 2); !errors.Is(err, ErrInvalidDecodedDataLen) {
+		t.Errorf("Data.DecodeInto() of the wrong size = %v, want %v", err, ErrInvalidDecodedDataLen)
+	}
+
+	m, err := ReadFile("testdata/infinite.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := m.Layers[0].Data.Chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]GID, 0, 256)
+	for _, c := range chunks {
+		got, err := c.DecodeInto(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 256 || &got[0] != &buf[:1][0] {
+			t.Errorf("Chunk.DecodeInto() of chunk %v = %d GIDs, want 256 in the buffer", c.Bounds(), len(got))
+		}
+	}
+}
+
+func TestReadLazyDecode(t *testing.T) {
+	m, err := Read(strings.NewReader(`<map width="1" height="1"><layer width="1" height="1"><data encoding="base64">!!</data></layer></map>`))
+	if err != nil {
+		t.Fatalf("Read() of undecodable layer data = %v", err)
+	}
+	if _, err := m.DecodedLayers(); err == nil {
+		t.Error("DecodedLayers() of undecodable layer data succeeded")
+	}
+}
+
+func TestReadDecoded(t *testing.T) {
+	m, err := ReadDecodedFile("testdata/base64-zlib.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Decoded) != 1 {
+		t.Fatalf("Decoded has %d layers, want 1", len(m.Decoded))
+	}
+	if l := m.Decoded[0]; l.Tileset != &m.Tilesets[0] || l.Empty || len(l.DecodedTiles) != 32*32 {
+		t.Errorf("Decoded[0] = {Tileset: %p, Empty: %v, %d tiles}, want {Tileset: %p, Empty: false, 1024 tiles}", l.Tileset, l.Empty, len(l.DecodedTiles), &m.Tilesets[0])
+	}
+
+	m, err = ReadDecoded(strings.NewReader(`<map width="2" height="1">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8" tilecount="1"/>
+ <tileset firstgid="2" name="b" tilewidth="8" tileheight="8" tilecount="1"/>
+ <layer width="2" height="1"><data encoding="csv">0,0</data></layer>
+ <layer width="2" height="1"><data encoding="csv">1,2</data></layer>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := m.Decoded[0]; !l.Empty || l.Tileset != nil {
+		t.Errorf("empty layer = {Tileset: %p, Empty: %v}, want {nil, true}", l.Tileset, l.Empty)
+	}
+	if l := m.Decoded[1]; l.Empty || l.Tileset != nil {
+		t.Errorf("layer with two tilesets = {Tileset: %p, Empty: %v}, want {nil, false}", l.Tileset, l.Empty)
+	}
+}
+
+func TestDecodedLayerTileAt(t *testing.T) {
+	m, err := ReadDecoded(strings.NewReader(`<map width="3" height="2">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8"/>
+ <layer width="3" height="2"><data encoding="csv">1,0,0,0,0,3</data></layer>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := m.Decoded[0]
+	for _, tc := range []struct {
+		x, y int
+		id   ID
+		nil  bool
+		ok   bool
+	}{
+		{0, 0, 0, false, true},
+		{2, 1, 2, false, true},
+		{1, 0, 0, true, true},
+		{3, 0, 0, true, false},
+		{0, 2, 0, true, false},
+		{-1, 0, 0, true, false},
+	} {
+		got, ok := l.TileAt(tc.x, tc.y)
+		if ok != tc.ok || got.Nil != tc.nil || got.ID != tc.id {
+			t.Errorf("TileAt(%d, %d) = %+v, %v, want ID %d, Nil %v, %v", tc.x, tc.y, got, ok, tc.id, tc.nil, tc.ok)
+		}
+	}
+	if _, ok := (DecodedLayer{Width: 2, Height: 2}).TileAt(1, 1); ok {
+		t.Error("TileAt() of a layer without tiles succeeded")
+	}
+
+	// Cells of chunked layers are those of their chunks.
+	m, err = ReadDecodedFile("testdata/infinite.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l = m.Decoded[0]
+	for _, tc := range []struct {
+		x, y int
+		id   ID
+		nil  bool
+		ok   bool
+	}{
+		{-16, 0, 0, false, true},
+		{15, 15, 3, false, true},
+		{-17, 0, 0, true, false},
+		{16, 0, 0, true, false},
+	} {
+		got, ok := l.TileAt(tc.x, tc.y)
+		if ok != tc.ok || got.Nil != tc.nil || got.ID != tc.id {
+			t.Errorf("chunked TileAt(%d, %d) = %+v, %v, want ID %d, Nil %v, %v", tc.x, tc.y, got, ok, tc.id, tc.nil, tc.ok)
+		}
+	}
+}
+
+func TestDecodedTileMeta(t *testing.T) {
+	m, err := ReadDecoded(strings.NewReader(`<map width="3" height="1">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8" tilecount="4">
+  <tile id="2" type="door">
+   <properties><property name="locked" type="bool" value="true"/></properties>
+   <objectgroup><object id="1" width="8" height="8"/></objectgroup>
+  </tile>
+ </tileset>
+ <layer width="3" height="1"><data encoding="csv">0,1,2147483651</data></layer>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tiles := m.Decoded[0].DecodedTiles
+	if meta := tiles[0].Meta(); meta != nil {
+		t.Errorf("Meta() of nil tile = %v, want nil", meta)
+	}
+	if meta := tiles[1].Meta(); meta != nil {
+		t.Errorf("Meta() of tile 0 = %v, want nil", meta)
+	}
+	meta := tiles[2].Meta()
+	if meta == nil {
+		t.Fatal("Meta() of flipped tile 2 = nil")
+	}
+	if locked, ok := meta.Properties.Bool("locked"); meta.Type != "door" || !locked || !ok || len(meta.ObjectGroups) != 1 {
+		t.Errorf("Meta() of flipped tile 2 = %+v", meta)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	m, err := Read(strings.NewReader(`<map width="2" height="1">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8" tilecount="2"/>
+ <layer id="3" name="ground" width="2" height="1"><data encoding="csv">1,5</data></layer>
+ <layer id="4" name="short" width="2" height="1"><data encoding="csv">1</data></layer>
+ <objectgroup id="5"><object id="7"><polygon points="0,0 1"/></object></objectgroup>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var le *LayerError
+	_, err = m.CompactLayer(&m.Layers[0])
+	if !errors.As(err, &le) || !errors.Is(err, ErrInvalidGID) || le.Name != "ground" || le.ID != 3 || le.Index != 1 {
+		t.Errorf("CompactLayer() = %v, want layer error at tile 1 wrapping %v", err, ErrInvalidGID)
+	}
+	if got, want := err.Error(), `tmx: layer "ground" (id 3): tile 1: tmx: invalid GID`; got != want {
+		t.Errorf("CompactLayer() error = %q, want %q", got, want)
+	}
+
+	_, err = m.Layers[1].Decoded()
+	if !errors.As(err, &le) || !errors.Is(err, ErrInvalidDecodedDataLen) || le.ID != 4 || le.Index != -1 {
+		t.Errorf("Decoded() = %v, want layer error wrapping %v", err, ErrInvalidDecodedDataLen)
+	}
+
+	var oe *ObjectError
+	_, err = m.ObjectGroups[0].Objects[0].WorldShape()
+	if !errors.As(err, &oe) || !errors.Is(err, ErrInvalidPointsField) || oe.ID != 7 {
+		t.Errorf("WorldShape() = %v, want object error wrapping %v", err, ErrInvalidPointsField)
+	}
+}
+
+func TestObjectFlips(t *testing.T) {
+	m, err := Read(strings.NewReader(`<map width="1" height="1">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8" tilecount="4"/>
+ <objectgroup id="1">
+  <object id="1" gid="2147483651"/>
+  <object id="2" gid="1073741826"/>
+  <object id="3" gid="4"/>
+ </objectgroup>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(name string, m *Map) {
+		t.Helper()
+		for i, want := range []struct {
+			gid  GID
+			h, v bool
+		}{
+			{3, true, false},
+			{2, false, true},
+			{4, false, false},
+		} {
+			o := m.ObjectGroups[0].Objects[i]
+			if o.GID != want.gid || o.HorizontalFlip != want.h || o.VerticalFlip != want.v {
+				t.Errorf("%s object %d GID, flips = %d, %v, %v, want %d, %v, %v", name, o.ID, o.GID, o.HorizontalFlip, o.VerticalFlip, want.gid, want.h, want.v)
+			}
+		}
+	}
+	check("Read()", m)
+	if got, want := m.ObjectGroups[0].Objects[0].RawGID(), GID(3|GIDHorizontalFlip); got != want {
+		t.Errorf("RawGID() = %#x, want %#x", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	j, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("ReadJSON()", j)
+
+	buf.Reset()
+	if err := Write(&buf, j); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `gid="2147483651"`) {
+		t.Errorf("Write() lost the flips of object 1:\n%s", buf.String())
+	}
+}
+
+func TestMultilineProperty(t *testing.T) {
+	var props struct {
+		List Properties `xml:"property"`
+	}
+	err := xml.Unmarshal([]byte(`<properties>
+	<property name="text">first
+second</property>
+	<property name="empty" value=""/>
+	<property name="int" type="int">7</property>
+</properties>`), &props)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Properties{
+		{Name: "text", Value: "first\nsecond"},
+		{Name: "empty"},
+		{Name: "int", Type: "int", Value: "7"},
+	}
+	if !reflect.DeepEqual(props.List, want) {
+		t.Errorf("properties = %+v, want %+v", props.List, want)
+	}
+}
+
+func TestCompressionLevel(t *testing.T) {
+	m, err := ReadFile("testdata/base64-zlib.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.CompressionLevel != DefaultCompressionLevel {
+		t.Errorf("CompressionLevel = %d, want %d", m.CompressionLevel, DefaultCompressionLevel)
+	}
+
+	m, err = Read(strings.NewReader(`<map version="1.4" compressionlevel="9"></map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.CompressionLevel != 9 {
+		t.Errorf("CompressionLevel = %d, want 9", m.CompressionLevel)
+	}
+}
+
+func TestDecodedTileRotation(t *testing.T) {
+	for _, tc := range []struct {
+		gid     GID
+		degrees int
+		mirror  bool
+	}{
+		{1, 0, false},
+		{1 | GIDHorizontalFlip, 0, true},
+		{1 | GIDVerticalFlip, 180, true},
+		{1 | GIDHorizontalFlip | GIDVerticalFlip, 180, false},
+		{1 | GIDDiagonalFlip, 90, true},
+		{1 | GIDDiagonalFlip | GIDHorizontalFlip, 90, false},
+		{1 | GIDDiagonalFlip | GIDVerticalFlip, 270, false},
+		{1 | GIDFlip, 270, true},
+	} {
+		m := &Map{Tilesets: []Tileset{{FirstGID: 1}}}
+		tile, err := m.DecodeGID(tc.gid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if degrees, mirror := tile.Rotation(); degrees != tc.degrees || mirror != tc.mirror {
+			t.Errorf("Rotation(%#x) = %d, %v, want %d, %v", tc.gid, degrees, mirror, tc.degrees, tc.mirror)
+		}
+	}
+}
+
+func TestGIDHelpers(t *testing.T) {
+	for _, tc := range []struct {
+		id      ID
+		first   GID
+		h, v, d bool
+		want    GID
+	}{
+		{0, 1, false, false, false, 1},
+		{4, 10, true, false, false, 14 | GIDHorizontalFlip},
+		{4, 10, false, true, true, 14 | GIDVerticalFlip | GIDDiagonalFlip},
+		{0, 1, true, true, true, 1 | GIDFlip},
+	} {
+		gid := MakeGID(tc.id, tc.first, tc.h, tc.v, tc.d)
+		if gid != tc.want {
+			t.Errorf("MakeGID(%d, %d, %v, %v, %v) = %#x, want %#x", tc.id, tc.first, tc.h, tc.v, tc.d, gid, tc.want)
+		}
+		if got := gid.TileID(); got != tc.first+GID(tc.id) {
+			t.Errorf("%#x.TileID() = %d, want %d", gid, got, tc.first+GID(tc.id))
+		}
+		if h, v, d := gid.Flips(); h != tc.h || v != tc.v || d != tc.d {
+			t.Errorf("%#x.Flips() = %v, %v, %v, want %v, %v, %v", gid, h, v, d, tc.h, tc.v, tc.d)
+		}
+		m := &Map{Tilesets: []Tileset{{FirstGID: tc.first}}}
+		tile, err := m.DecodeGID(gid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tile.ID != tc.id || tile.HorizontalFlip != tc.h || tile.VerticalFlip != tc.v || tile.DiagonalFlip != tc.d {
+			t.Errorf("DecodeGID(%#x) = %+v", gid, tile)
+		}
+	}
+}
+
+func TestTilesetForGID(t *testing.T) {
+	m := &Map{Tilesets: []Tileset{{FirstGID: 3}, {FirstGID: 10}, {FirstGID: 11}, {FirstGID: 40}}}
+	for _, tc := range []struct {
+		gid  GID
+		want int // Index of the tileset or -1.
+	}{
+		{0, -1},
+		{2, -1},
+		{3, 0},
+		{9, 0},
+		{10, 1},
+		{11, 2},
+		{39, 2},
+		{40, 3},
+		{1000, 3},
+		{40 | GIDHorizontalFlip, 3},
+		{GIDFlip, -1},
+	} {
+		var want *Tileset
+		if tc.want >= 0 {
+			want = &m.Tilesets[tc.want]
+		}
+		if got := m.TilesetForGID(tc.gid); got != want {
+			t.Errorf("TilesetForGID(%#x) = %p, want %p", tc.gid, got, want)
+		}
+	}
+	if got := (&Map{}).TilesetForGID(1); got != nil {
+		t.Errorf("TilesetForGID() without tilesets = %p", got)
+	}
+}
+
+func TestTilesetForGIDBounds(t *testing.T) {
+	m := &Map{Tilesets: []Tileset{
+		{FirstGID: 1, Tilecount: 4},
+		{FirstGID: 10, Tilecount: 2, Tiles: []Tile{{ID: 0}, {ID: 5}}}, // Image collection with a gap.
+		{FirstGID: 20},
+	}}
+	for _, tc := range []struct {
+		gid  GID
+		want int // Index of the tileset or -1.
+	}{
+		{4, 0},
+		{5, -1},
+		{9, -1},
+		{15, 1},
+		{16, -1},
+		{1000, 2},
+	} {
+		var want *Tileset
+		if tc.want >= 0 {
+			want = &m.Tilesets[tc.want]
+		}
+		if got := m.TilesetForGID(tc.gid); got != want {
+			t.Errorf("TilesetForGID(%d) = %p, want %p", tc.gid, got, want)
+		}
+	}
+	if _, err := m.DecodeGID(5); !errors.Is(err, ErrInvalidGID) {
+		t.Errorf("DecodeGID() past the tile count = %v, want %v", err, ErrInvalidGID)
+	}
+}
+
+func BenchmarkDecodeGID(b *testing.B) {
+	m := new(Map)
+	for i := 0; i < 64; i++ {
+		m.Tilesets = append(m.Tilesets, Tileset{FirstGID: GID(1 + 100*i)})
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := m.DecodeGID(GID(1 + i%6400)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestReadHexagonal(t *testing.T) {
+	m, err := ReadFile("testdata/hexagonal.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MapOrientation != MapHexagonal || m.HexSideLength != 6 || m.StaggerAxis != StaggerY || m.StaggerIndex != StaggerOdd {
+		t.Errorf("hexagonal map = %+v", m)
+	}
+}
+
+func TestPolygonDecode(t *testing.T) {
+	got, err := Polygon{Points: "0,0 1.5,-3.25  -2,4e1"}.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Point{{0, 0}, {1.5, -3.25}, {-2, 40}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %v, want %v", got, want)
+	}
+	for _, points := range []string{"0,0 1", "0,0 1,x"} {
+		if _, err := (Polygon{Points: points}).Decode(); err == nil {
+			t.Errorf("Decode(%q) returned no error", points)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package tmx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// IssueCode identifies the kind of problem reported by Validate.
+type IssueCode string
+
+// Issue codes reported by Validate.
+const (
+	IssueMissingTileset   IssueCode = "missing-tileset"    // An external tileset was not loaded.
+	IssueZeroTileSize     IssueCode = "zero-tile-size"     // The map or a tileset has no tile width or height.
+	IssueDataLength       IssueCode = "data-length"        // Layer data does not hold width * height GIDs.
+	IssueInvalidData      IssueCode = "invalid-data"       // Layer data does not decode otherwise.
+	IssueInvalidGID       IssueCode = "invalid-gid"        // A layer holds GIDs outside of the tilesets.
+	IssueDuplicateLayerID IssueCode = "duplicate-layer-id" // Several layers share an ID.
+	IssueTileCount        IssueCode = "tile-count"         // Tileset tilecount or columns disagree with its image.
+	IssueGIDOverlap       IssueCode = "gid-overlap"        // The GID ranges of tilesets overlap.
+	IssueAnimationFrame   IssueCode = "animation-frame"    // An animation frame shows a tile the tileset lacks.
+	IssueWangColor        IssueCode = "wang-color"         // A wang tile uses a color its wang set lacks.
+	IssueUnknownValue     IssueCode = "unknown-value"      // An enumerated attribute holds an unknown value.
+)
+
+// Issue is a problem found by Validate.
+type Issue struct {
+	Code    IssueCode
+	Message string
+	Pos     Position // Position of the offending element, if recorded.
+}
+
+func (i Issue) String() string {
+	if i.Pos.IsValid() {
+		return fmt.Sprintf("%v: %s: %s", i.Pos, i.Code, i.Message)
+	}
+	return string(i.Code) + ": " + i.Message
+}
+
+// Validate checks the map for problems that would otherwise surface one at
+// a time when it is used, and returns all of them, or nil if there are none.
+// Tile layers are decoded and cached like Layer.Decoded.
+func (m *Map) Validate() []Issue {
+	var out []Issue
+	add := func(pos Position, code IssueCode, format string, args ...any) {
+		out = append(out, Issue{code, fmt.Sprintf(format, args...), pos})
+	}
+
+	if m.TileWidth <= 0 || m.TileHeight <= 0 {
+		add(Position{}, IssueZeroTileSize, "map tile size is %dx%d", m.TileWidth, m.TileHeight)
+	}
+	if err := m.checkEnums(); err != nil {
+		add(Position{}, IssueUnknownValue, "%v", err)
+	}
+	for i := 0; i < len(m.Tilesets); i++ {
+		ts := &m.Tilesets[i]
+		pos, _ := m.TilesetPosition(i)
+		switch {
+		case ts.Source != "" && ts.TileWidth == 0 && ts.TileHeight == 0 && ts.Name == "":
+			add(pos, IssueMissingTileset, "tileset %q (firstgid %d) is not loaded", ts.Source, ts.FirstGID)
+			continue
+		case ts.TileWidth <= 0 || ts.TileHeight <= 0:
+			add(pos, IssueZeroTileSize, "tileset %q tile size is %dx%d", ts.Name, ts.TileWidth, ts.TileHeight)
+		default:
+			if cols, n, ok := ts.imageCounts(); ok && (ts.Columns != 0 && ts.Columns != cols || ts.Tilecount != 0 && ts.Tilecount != n) {
+				add(pos, IssueTileCount, "tileset %q has %d tiles in %d columns, its %dx%d image holds %d in %d",
+					ts.Name, ts.Tilecount, ts.Columns, ts.Image.Width, ts.Image.Height, n, cols)
+			}
+		}
+		for j := 0; j < len(ts.Tiles); j++ {
+			t := &ts.Tiles[j]
+			for _, f := range t.Animation.Frames {
+				if !ts.hasTile(f.TileID) {
+					add(pos, IssueAnimationFrame, "tileset %q tile %d animation shows missing tile %d", ts.Name, t.ID, f.TileID)
+				}
+			}
+		}
+		for j := 0; j < len(ts.WangSets); j++ {
+			ws := &ts.WangSets[j]
+			for _, t := range ws.Tiles {
+				if c, ok := ws.undeclaredColor(t.WangID); ok {
+					add(pos, IssueWangColor, "tileset %q wang set %q tile %d uses undeclared color %d", ts.Name, ws.Name, t.TileID, c)
+				}
+			}
+		}
+	}
+
+	byFirstGID := make([]int, len(m.Tilesets))
+	for i := range byFirstGID {
+		byFirstGID[i] = i
+	}
+	sort.SliceStable(byFirstGID, func(i, j int) bool {
+		return m.Tilesets[byFirstGID[i]].FirstGID < m.Tilesets[byFirstGID[j]].FirstGID
+	})
+	for k := 1; k < len(byFirstGID); k++ {
+		prev, ts := &m.Tilesets[byFirstGID[k-1]], &m.Tilesets[byFirstGID[k]]
+		if n := prev.gidCount(); prev.FirstGID == ts.FirstGID || n > 0 && prev.FirstGID+n > ts.FirstGID {
+			pos, _ := m.TilesetPosition(byFirstGID[k])
+			add(pos, IssueGIDOverlap, "tileset %q (firstgid %d) overlaps tileset %q (firstgid %d)",
+				ts.Name, ts.FirstGID, prev.Name, prev.FirstGID)
+		}
+	}
+
+	for i := 0; i < len(m.Layers); i++ {
+		l := &m.Layers[i]
+		pos, _ := m.Position(LayerRef{TileLayerKind, i})
+		gids, err := l.Decoded()
+		switch {
+		case errors.Is(err, ErrInvalidDecodedDataLen):
+			add(pos, IssueDataLength, "layer %q (id %d) data does not hold %dx%d tiles", l.Name, l.ID, l.Width, l.Height)
+			continue
+		case err != nil:
+			add(pos, IssueInvalidData, "%v", err)
+			continue
+		}
+		first, n := -1, 0
+		for j, gid := range gids {
+			if gid != 0 && m.TilesetForGID(gid) == nil {
+				if n == 0 {
+					first = j
+				}
+				n++
+			}
+		}
+		if n > 0 {
+			add(pos, IssueInvalidGID, "layer %q (id %d) has %d GIDs outside of the tilesets, first %d at tile %d",
+				l.Name, l.ID, n, gids[first], first)
+		}
+	}
+
+	seen := make(map[ID]bool)
+	reported := make(map[ID]bool)
+	for _, r := range m.defaultOrder() {
+		id := m.layerID(r)
+		if id == 0 {
+			continue
+		}
+		if seen[id] && !reported[id] {
+			reported[id] = true
+			pos, _ := m.Position(r)
+			add(pos, IssueDuplicateLayerID, "several layers have id %d", id)
+		}
+		seen[id] = true
+	}
+	return out
+}
+
+// imageCounts returns the number of columns and tiles the tileset image
+// holds, or false for image collection tilesets and unknown image sizes.
+func (ts *Tileset) imageCounts() (cols, n int, ok bool) {
+	if ts.Image.Source == "" || ts.Image.Width <= 0 || ts.Image.Height <= 0 {
+		return 0, 0, false
+	}
+	c := Tileset{
+		TileWidth:  ts.TileWidth,
+		TileHeight: ts.TileHeight,
+		Spacing:    ts.Spacing,
+		Margin:     ts.Margin,
+		Image:      ts.Image,
+	}
+	c.deriveCounts()
+	return c.Columns, c.Tilecount, true
+}
+
+// hasTile reports whether the tileset holds tile id, or whether it might
+// when its tile count is unknown. Image collection tilesets only hold the
+// tiles they list.
+func (ts *Tileset) hasTile(id ID) bool {
+	if ts.Image.Source == "" {
+		return ts.TileByID(id) != nil
+	}
+	n := ts.gidCount()
+	return n == 0 || GID(id) < n
+}
+
+// undeclaredColor returns the first color index of id that the wang set
+// does not declare. The Colors of Tiled 1.5 and later are used at every
+// position of id; otherwise edges are at even and corners at odd positions.
+func (ws *WangSet) undeclaredColor(id WangID) (int, bool) {
+	for i := 0; i < 8; i++ {
+		c := int(id>>(4*uint(i))) & 0xf
+		colors := ws.Colors
+		if len(colors) == 0 {
+			colors = ws.Edges
+			if i%2 == 1 {
+				colors = ws.Corners
+			}
+		}
+		if c > len(colors) {
+			return c, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,96 @@
+package tmx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	m, err := ReadFile("testdata/external.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := m.Validate(); issues != nil {
+		t.Errorf("Validate() = %v, want nil", issues)
+	}
+
+	m, err = Read(strings.NewReader(`<map width="2" height="1" tilewidth="8">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8" tilecount="2"/>
+ <tileset firstgid="10" source="missing.tsx"/>
+ <layer id="1" name="ground" width="2" height="1"><data encoding="csv">1,5</data></layer>
+ <layer id="2" name="short" width="2" height="1"><data encoding="csv">1</data></layer>
+ <layer id="2" name="bad" width="2" height="1"><data encoding="base64" compression="lz4">AQAAAA==</data></layer>
+ <objectgroup id="1"/>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codes []IssueCode
+	for _, issue := range m.Validate() {
+		codes = append(codes, issue.Code)
+		if issue.Message == "" {
+			t.Errorf("Validate() issue %s without message", issue.Code)
+		}
+	}
+	want := []IssueCode{
+		IssueZeroTileSize,
+		IssueUnknownValue,
+		IssueMissingTileset,
+		IssueInvalidGID,
+		IssueDataLength,
+		IssueInvalidData,
+		IssueDuplicateLayerID,
+		IssueDuplicateLayerID,
+	}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("Validate() codes = %v, want %v", codes, want)
+	}
+}
+
+func TestValidateConsistency(t *testing.T) {
+	m, err := Read(strings.NewReader(`<map width="1" height="1" tilewidth="8" tileheight="8">
+ <tileset firstgid="1" name="a" tilewidth="8" tileheight="8" tilecount="6" columns="3">
+  <image source="a.png" width="16" height="16"/>
+  <tile id="0"><animation><frame tileid="1" duration="100"/><frame tileid="9" duration="100"/></animation></tile>
+  <wangsets>
+   <wangset name="w" tile="0">
+    <wangcornercolor name="c" tile="0"/>
+    <wangtile tileid="0" wangid="0,1,0,1,0,1,0,1"/>
+    <wangtile tileid="1" wangid="0,2,0,1,0,1,0,1"/>
+    <wangtile tileid="2" wangid="1,1,0,1,0,1,0,1"/>
+   </wangset>
+   <wangset name="m" type="mixed" tile="0">
+    <wangcolor name="grass" color="#00ff00" tile="0" probability="1"/>
+    <wangtile tileid="0" wangid="1,1,1,1,1,1,1,1"/>
+    <wangtile tileid="1" wangid="0,1,0,2,0,1,0,1"/>
+   </wangset>
+  </wangsets>
+ </tileset>
+ <tileset firstgid="4" name="b" tilewidth="8" tileheight="8" tilecount="1">
+  <image source="b.png" width="8" height="8"/>
+ </tileset>
+ <tileset firstgid="5" name="c" tilewidth="8" tileheight="8">
+  <tile id="3"><image source="c.png" width="8" height="8"/><animation><frame tileid="3" duration="100"/><frame tileid="0" duration="100"/></animation></tile>
+ </tileset>
+</map>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codes []IssueCode
+	for _, issue := range m.Validate() {
+		codes = append(codes, issue.Code)
+	}
+	want := []IssueCode{
+		IssueTileCount,
+		IssueAnimationFrame,
+		IssueWangColor,
+		IssueWangColor,
+		IssueWangColor,
+		IssueAnimationFrame,
+		IssueGIDOverlap,
+	}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("Validate() codes = %v, want %v", codes, want)
+	}
+}
@@ -0,0 +1,113 @@
+package tmx
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultWatchInterval is the polling interval of a Watcher with no Interval set.
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// Watcher reloads a map whenever the map file or one of its dependencies
+// changes. Files are polled for changes to their size and modification time.
+type Watcher struct {
+	Loader   *Loader           // Loader used to read the map. If nil, the zero Loader is used.
+	Name     string            // Name of the map file.
+	Interval time.Duration     // Polling interval or zero for DefaultWatchInterval.
+	OnChange func(*Map, error) // Called with each freshly loaded map or the error loading it.
+
+	stamps map[string]fileStamp
+}
+
+// fileStamp identifies a version of a watched file.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+	exists  bool
+}
+
+// NewWatcher returns a watcher reloading the named map with l and calling fn
+// with the result.
+func NewWatcher(l *Loader, name string, fn func(*Map, error)) *Watcher {
+	return &Watcher{Loader: l, Name: name, OnChange: fn}
+}
+
+// Run loads the map, calls OnChange with it and then polls for changes until
+// ctx is done, calling OnChange after each reload. Changed tilesets and
+// templates are removed from the loader's Cache before reloading.
+// Run returns the context's error.
+func (w *Watcher) Run(ctx context.Context) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	w.reload(ctx, nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if changed := w.changed(); len(changed) > 0 {
+				w.reload(ctx, changed)
+			}
+		}
+	}
+}
+
+// loader returns the watcher's loader.
+func (w *Watcher) loader() *Loader {
+	if w.Loader == nil {
+		return new(Loader)
+	}
+	return w.Loader
+}
+
+// reload evicts the changed files from the cache, loads the map and starts
+// watching its dependencies. If loading fails the previous files stay watched.
+func (w *Watcher) reload(ctx context.Context, changed []string) {
+	l := w.loader()
+	for _, name := range changed {
+		l.Cache.forget(l.key(name))
+	}
+
+	m, err := l.ReadFileContext(ctx, w.Name)
+	if ctx.Err() != nil {
+		return
+	}
+	if err == nil {
+		stamps := map[string]fileStamp{w.Name: w.stamp(w.Name)}
+		for _, name := range m.Dependencies() {
+			stamps[name] = w.stamp(name)
+		}
+		w.stamps = stamps
+	} else if w.stamps == nil {
+		w.stamps = map[string]fileStamp{w.Name: w.stamp(w.Name)}
+	}
+	if w.OnChange != nil {
+		w.OnChange(m, err)
+	}
+}
+
+// changed returns the watched files that changed since the last poll.
+func (w *Watcher) changed() []string {
+	var changed []string
+	for name, old := range w.stamps {
+		if s := w.stamp(name); s != old {
+			w.stamps[name] = s
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// stamp returns the current version of the named file.
+func (w *Watcher) stamp(name string) fileStamp {
+	fi, err := w.loader().stat(name)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{size: fi.Size(), modTime: fi.ModTime(), exists: true}
+}
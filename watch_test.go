@@ -0,0 +1,76 @@
+package tmx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("map.tmx", `<map version="1.2"><tileset firstgid="1" source="tiles.tsx"/></map>`)
+	write("tiles.tsx", `<tileset name="before" tilewidth="8" tileheight="8"/>`)
+
+	maps := make(chan *Map)
+	w := NewWatcher(&Loader{Cache: NewCache()}, filepath.Join(dir, "map.tmx"), func(m *Map, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		maps <- m
+	})
+	w.Interval = 5 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	next := func() *Map {
+		select {
+		case m := <-maps:
+			return m
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for reload")
+			return nil
+		}
+	}
+	if m := next(); m.Tilesets[0].Name != "before" {
+		t.Errorf("initial tileset = %+v", m.Tilesets[0])
+	}
+	write("tiles.tsx", `<tileset name="after!" tilewidth="8" tileheight="8" />`)
+	if m := next(); m.Tilesets[0].Name != "after!" {
+		t.Errorf("reloaded tileset = %+v", m.Tilesets[0])
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() = %v", err)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	m, err := ReadFile(filepath.Join("testdata", "external.tmx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := m.Dependencies()
+	want := map[string]bool{
+		filepath.Join("testdata", "tiles.png"): true,
+		filepath.Join("testdata", "tiles.tsx"): true,
+		filepath.Join("testdata", "crate.tx"):  true,
+	}
+	if len(got) != len(want) {
+		t.Errorf("Dependencies() = %q", got)
+	}
+	for _, name := range got {
+		if !want[name] {
+			t.Errorf("Dependencies() contains %q", name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package tmx
+
+import (
+	"encoding/json"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// World models a Tiled .world file placing maps in a shared pixel space.
+// See: https://doc.mapeditor.org/en/stable/manual/worlds/.
+type World struct {
+	Maps                 []WorldMap     `json:"maps"`
+	Patterns             []WorldPattern `json:"patterns"`
+	OnlyShowAdjacentMaps bool           `json:"onlyShowAdjacentMaps"`
+	Type                 string         `json:"type"`
+
+	dir string // Directory of the world file, set by LoadWorld.
+}
+
+// WorldMap places a map file at a pixel position of the world.
+type WorldMap struct {
+	FileName string `json:"fileName"` // Relative to the world file.
+	X        int    `json:"x"`
+	Y        int    `json:"y"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// Rect returns the pixel rectangle covered by the map.
+func (m WorldMap) Rect() image.Rectangle {
+	return image.Rect(m.X, m.Y, m.X+m.Width, m.Y+m.Height)
+}
+
+// WorldPattern places every map file whose name matches RegExp.
+// The first two capture groups of the match give the map's x and y indexes.
+type WorldPattern struct {
+	RegExp      string `json:"regexp"`
+	MultiplierX int    `json:"multiplierX"`
+	MultiplierY int    `json:"multiplierY"`
+	OffsetX     int    `json:"offsetX"`
+	OffsetY     int    `json:"offsetY"`
+	MapWidth    int    `json:"mapWidth,omitempty"`  // MultiplierX when zero.
+	MapHeight   int    `json:"mapHeight,omitempty"` // MultiplierY when zero.
+}
+
+// match returns the placement of the file name or false if it does not match.
+func (p WorldPattern) match(re *regexp.Regexp, name string) (WorldMap, bool) {
+	sub := re.FindStringSubmatch(name)
+	if len(sub) < 3 {
+		return WorldMap{}, false
+	}
+	x, err := strconv.Atoi(sub[1])
+	if err != nil {
+		return WorldMap{}, false
+	}
+	y, err := strconv.Atoi(sub[2])
+	if err != nil {
+		return WorldMap{}, false
+	}
+
+	wm := WorldMap{
+		FileName: name,
+		X:        x*p.MultiplierX + p.OffsetX,
+		Y:        y*p.MultiplierY + p.OffsetY,
+		Width:    p.MapWidth,
+		Height:   p.MapHeight,
+	}
+	if wm.Width == 0 {
+		wm.Width = p.MultiplierX
+	}
+	if wm.Height == 0 {
+		wm.Height = p.MultiplierY
+	}
+	return wm, true
+}
+
+// ReadWorld reads a world from the reader r or returns an error.
+// Patterns are not expanded since the world's directory is unknown.
+func ReadWorld(r io.Reader) (*World, error) {
+	out := new(World)
+	if err := json.NewDecoder(r).Decode(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LoadWorld reads a world file and adds the maps matched by its patterns
+// in the world's directory to Maps.
+func LoadWorld(path string) (*World, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	out, err := ReadWorld(f)
+	if err != nil {
+		return nil, err
+	}
+	out.dir = filepath.Dir(path)
+
+	if len(out.Patterns) == 0 {
+		return out, nil
+	}
+
+	entries, err := os.ReadDir(out.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, p := range out.Patterns {
+		re, err := regexp.Compile(p.RegExp)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if wm, ok := p.match(re, name); ok {
+				out.Maps = append(out.Maps, wm)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Path returns the file path of the member map relative to the working directory.
+func (w *World) Path(m WorldMap) string {
+	return filepath.Join(w.dir, m.FileName)
+}
+
+// ReadMap reads the member map m, sharing external resources through the cache c.
+// The cache may be nil.
+func (w *World) ReadMap(m WorldMap, c *Cache) (*Map, error) {
+	return (&Loader{Cache: c}).ReadFile(w.Path(m))
+}
+
+// MapAt returns the first member map covering the world pixel (x, y).
+func (w *World) MapAt(x, y int) (WorldMap, bool) {
+	pt := image.Pt(x, y)
+	for _, m := range w.Maps {
+		if pt.In(m.Rect()) {
+			return m, true
+		}
+	}
+	return WorldMap{}, false
+}
+
+// MapsIn returns the member maps overlapping the world rectangle r.
+func (w *World) MapsIn(r image.Rectangle) []WorldMap {
+	var out []WorldMap
+	for _, m := range w.Maps {
+		if m.Rect().Overlaps(r) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
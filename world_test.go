@@ -0,0 +1,51 @@
+package tmx
+
+import (
+	"image"
+	"testing"
+)
+
+func TestLoadWorld(t *testing.T) {
+	w, err := LoadWorld("testdata/world/test.world")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []WorldMap{
+		{FileName: "hub.tmx", X: -16, Y: 0, Width: 16, Height: 16},
+		{FileName: "map_0_0.tmx", X: 0, Y: 32, Width: 16, Height: 16},
+		{FileName: "map_1_0.tmx", X: 16, Y: 32, Width: 16, Height: 16},
+	}
+	if len(w.Maps) != len(want) {
+		t.Fatalf("Maps = %+v, want %+v", w.Maps, want)
+	}
+	for i := range want {
+		if w.Maps[i] != want[i] {
+			t.Errorf("Maps[%d] = %+v, want %+v", i, w.Maps[i], want[i])
+		}
+	}
+
+	if m, ok := w.MapAt(20, 40); !ok || m.FileName != "map_1_0.tmx" {
+		t.Errorf("MapAt(20, 40) = %+v, %v", m, ok)
+	}
+	if _, ok := w.MapAt(0, 0); ok {
+		t.Error("MapAt(0, 0) found a map")
+	}
+	if got := w.MapsIn(image.Rect(-4, 0, 4, 40)); len(got) != 2 {
+		t.Errorf("MapsIn() = %+v", got)
+	}
+
+	c := NewCache()
+	for _, wm := range w.Maps {
+		m, err := w.ReadMap(wm, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Tilesets[0].Name != "external" {
+			t.Errorf("%s: tileset = %+v", wm.FileName, m.Tilesets[0])
+		}
+	}
+	if c.Len() != 1 {
+		t.Errorf("cache Len() = %d, want 1", c.Len())
+	}
+}
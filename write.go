@@ -0,0 +1,585 @@
+package tmx
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Write writes the map m to w as a TMX document or returns an error.
+// Tilesets with a Source are written as references to their external file.
+func Write(w io.Writer, m *Map) error {
+	_, err := m.WriteTo(w)
+	return err
+}
+
+// WriteFile writes the map m to a file path or returns an error.
+func WriteFile(path string, m *Map) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, m); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteTileset writes ts to w as a standalone TSX document or returns an error.
+// The FirstGID and Source of ts are not written.
+func WriteTileset(w io.Writer, ts *Tileset) error {
+	tw := newTMXWriter(w)
+	tw.header()
+	tw.writeTileset(ts, false)
+	_, err := tw.finish()
+	return err
+}
+
+// WriteTilesetFile writes ts to a TSX file path or returns an error.
+func WriteTilesetFile(path string, ts *Tileset) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteTileset(f, ts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteTo writes the map to w as a TMX document.
+// It implements io.WriterTo.
+func (m *Map) WriteTo(w io.Writer) (int64, error) {
+	tw := newTMXWriter(w)
+	tw.header()
+	tw.writeMap(m)
+	return tw.finish()
+}
+
+// tmxWriter emits TMX elements in the order and format written by Tiled.
+// The first error is retained and makes all later writes no-ops.
+type tmxWriter struct {
+	cw  *countWriter
+	bw  *bufio.Writer
+	e   *xml.Encoder
+	err error
+}
+
+// countWriter counts the bytes written to w.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func newTMXWriter(w io.Writer) *tmxWriter {
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	e := xml.NewEncoder(bw)
+	e.Indent("", " ")
+	return &tmxWriter{cw: cw, bw: bw, e: e}
+}
+
+func (w *tmxWriter) header() {
+	w.raw([]byte(xml.Header))
+}
+
+// finish flushes all pending output and returns the byte count and first error.
+func (w *tmxWriter) finish() (int64, error) {
+	if w.err == nil {
+		w.err = w.e.Flush()
+	}
+	w.raw([]byte("\n"))
+	if w.err == nil {
+		w.err = w.bw.Flush()
+	}
+	return w.cw.n, w.err
+}
+
+func (w *tmxWriter) start(name string, attrs attrList) {
+	if w.err == nil {
+		w.err = w.e.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs})
+	}
+}
+
+func (w *tmxWriter) end(name string) {
+	if w.err == nil {
+		w.err = w.e.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+	}
+}
+
+// empty writes an element without content.
+func (w *tmxWriter) empty(name string, attrs attrList) {
+	w.start(name, attrs)
+	w.end(name)
+}
+
+// text writes s as escaped character data.
+func (w *tmxWriter) text(s string) {
+	if w.err == nil {
+		w.err = w.e.EncodeToken(xml.CharData(s))
+	}
+}
+
+// raw writes p unescaped after flushing any pending encoder output.
+func (w *tmxWriter) raw(p []byte) {
+	if w.err == nil {
+		w.err = w.e.Flush()
+	}
+	if w.err == nil {
+		_, w.err = w.bw.Write(p)
+	}
+}
+
+// attrList builds element attributes, omitting those holding default values.
+type attrList []xml.Attr
+
+func (a *attrList) add(name, value string) {
+	*a = append(*a, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+func (a *attrList) str(name, value string) {
+	if value != "" {
+		a.add(name, value)
+	}
+}
+
+func (a *attrList) int(name string, value int) {
+	if value != 0 {
+		a.add(name, strconv.Itoa(value))
+	}
+}
+
+func (a *attrList) uint(name string, value uint32) {
+	if value != 0 {
+		a.add(name, strconv.FormatUint(uint64(value), 10))
+	}
+}
+
+func (a *attrList) float(name string, value float64) {
+	if value != 0 {
+		a.add(name, formatFloat(value))
+	}
+}
+
+// flag adds a boolean attribute set to 1 when value is true.
+func (a *attrList) flag(name string, value bool) {
+	if value {
+		a.add(name, "1")
+	}
+}
+
+// visible adds a visible="0" attribute for hidden elements only.
+func (a *attrList) visible(visible bool) {
+	if !visible {
+		a.add("visible", "0")
+	}
+}
+
+// opacity adds an opacity attribute for translucent elements only.
+func (a *attrList) opacity(opacity float32) {
+	if opacity != 1 {
+		a.add("opacity", formatFloat32(opacity))
+	}
+}
+
+// parallax adds parallax factor attributes other than the default of 1.
+func (a *attrList) parallax(x, y float64) {
+	if x != 1 {
+		a.add("parallaxx", formatFloat(x))
+	}
+	if y != 1 {
+		a.add("parallaxy", formatFloat(y))
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatFloat32(v float32) string {
+	return strconv.FormatFloat(float64(v), 'f', -1, 32)
+}
+
+func (w *tmxWriter) writeMap(m *Map) {
+	var a attrList
+	a.str("version", m.Version)
+	a.str("tiledversion", m.TiledVersion)
+	a.str("orientation", string(m.MapOrientation))
+	a.str("renderorder", string(m.MapRenderOrder))
+	if m.CompressionLevel != DefaultCompressionLevel {
+		a.add("compressionlevel", strconv.Itoa(m.CompressionLevel))
+	}
+	a.add("width", strconv.Itoa(m.Width))
+	a.add("height", strconv.Itoa(m.Height))
+	a.add("tilewidth", strconv.Itoa(m.TileWidth))
+	a.add("tileheight", strconv.Itoa(m.TileHeight))
+	a.int("hexsidelength", m.HexSideLength)
+	a.str("staggeraxis", string(m.StaggerAxis))
+	a.str("staggerindex", string(m.StaggerIndex))
+	a.float("parallaxoriginx", m.ParallaxOriginX)
+	a.float("parallaxoriginy", m.ParallaxOriginY)
+	a.str("backgroundcolor", string(m.BackgroundColor))
+	a.flag("infinite", m.Infinite)
+	a.uint("nextlayerid", uint32(m.NextLayerID))
+	a.uint("nextobjectid", uint32(m.NextObjectID))
+
+	w.start("map", a)
+	w.writeProperties(m.Properties)
+	for i := 0; i < len(m.Tilesets); i++ {
+		w.writeTileset(&m.Tilesets[i], true)
+	}
+	w.writeChildren(m, m.DrawOrder(), 0)
+	w.end("map")
+}
+
+// writeChildren writes the layers in order with the given parent.
+func (w *tmxWriter) writeChildren(m *Map, order []LayerRef, parent ID) {
+	for _, r := range order {
+		if m.Layer(r).common().parent != parent {
+			continue
+		}
+		switch r.Kind {
+		case TileLayerKind:
+			w.writeLayer(&m.Layers[r.Index], m.CompressionLevel)
+		case ObjectGroupKind:
+			w.writeObjectGroup(&m.ObjectGroups[r.Index])
+		case ImageLayerKind:
+			w.writeImageLayer(&m.ImageLayers[r.Index])
+		case GroupKind:
+			if g := &m.Groups[r.Index]; g.ID != parent {
+				w.writeGroup(m, order, g)
+			}
+		}
+	}
+}
+
+func (w *tmxWriter) writeGroup(m *Map, order []LayerRef, g *Group) {
+	var a attrList
+	a.uint("id", uint32(g.ID))
+	a.str("name", g.Name)
+	a.int("offsetx", g.OffsetX)
+	a.int("offsety", g.OffsetY)
+	a.visible(g.Visible)
+	a.opacity(g.Opacity)
+	a.str("tintcolor", string(g.TintColor))
+	a.parallax(g.ParallaxX, g.ParallaxY)
+
+	w.start("group", a)
+	w.writeProperties(g.Properties)
+	w.writeChildren(m, order, g.ID)
+	w.end("group")
+}
+
+func (w *tmxWriter) writeProperties(props Properties) {
+	if len(props) == 0 {
+		return
+	}
+	w.start("properties", nil)
+	for _, p := range props {
+		var a attrList
+		a.add("name", p.Name)
+		a.str("type", p.Type)
+		a.str("propertytype", p.PropertyType)
+		multiline := p.Type != "class" && strings.Contains(p.Value, "\n")
+		if p.Type != "class" && !multiline {
+			a.add("value", p.Value)
+		}
+		w.start("property", a)
+		if multiline {
+			// Tiled writes multiline values as element text.
+			w.text(p.Value)
+		}
+		w.writeProperties(p.Properties)
+		w.end("property")
+	}
+	w.end("properties")
+}
+
+// writeTileset writes ts. Within a map, tilesets with a Source are written
+// as a reference to the external file.
+func (w *tmxWriter) writeTileset(ts *Tileset, inMap bool) {
+	var a attrList
+	if inMap {
+		a.uint("firstgid", uint32(ts.FirstGID))
+		if ts.Source != "" {
+			a.add("source", ts.Source)
+			w.empty("tileset", a)
+			return
+		}
+	}
+	a.str("name", ts.Name)
+	a.add("tilewidth", strconv.Itoa(ts.TileWidth))
+	a.add("tileheight", strconv.Itoa(ts.TileHeight))
+	a.int("spacing", ts.Spacing)
+	a.int("margin", ts.Margin)
+	a.int("tilecount", ts.Tilecount)
+	a.int("columns", ts.Columns)
+
+	w.start("tileset", a)
+	if ts.TileOffset != (TileOffset{}) {
+		var a attrList
+		a.add("x", strconv.Itoa(ts.TileOffset.X))
+		a.add("y", strconv.Itoa(ts.TileOffset.Y))
+		w.empty("tileoffset", a)
+	}
+	if ts.Grid != (Grid{}) {
+		var a attrList
+		a.str("orientation", string(ts.Grid.TileOrientation))
+		a.add("width", strconv.Itoa(ts.Grid.Width))
+		a.add("height", strconv.Itoa(ts.Grid.Height))
+		w.empty("grid", a)
+	}
+	w.writeProperties(ts.Properties)
+	w.writeImage(ts.Image)
+	if len(ts.Terrains) > 0 {
+		w.start("terraintypes", nil)
+		for _, t := range ts.Terrains {
+			var a attrList
+			a.add("name", t.Name)
+			a.add("tile", strconv.FormatUint(uint64(t.TileID), 10))
+			if len(t.Properties) == 0 {
+				w.empty("terrain", a)
+				continue
+			}
+			w.start("terrain", a)
+			w.writeProperties(t.Properties)
+			w.end("terrain")
+		}
+		w.end("terraintypes")
+	}
+	for i := 0; i < len(ts.Tiles); i++ {
+		w.writeTile(&ts.Tiles[i])
+	}
+	if len(ts.WangSets) > 0 {
+		w.start("wangsets", nil)
+		for i := 0; i < len(ts.WangSets); i++ {
+			w.writeWangSet(&ts.WangSets[i])
+		}
+		w.end("wangsets")
+	}
+	w.end("tileset")
+}
+
+func (w *tmxWriter) writeImage(img Image) {
+	if img.Source == "" && img.Trans == "" && img.Width == 0 && img.Height == 0 {
+		return
+	}
+	var a attrList
+	a.str("source", img.Source)
+	a.str("trans", string(img.Trans))
+	a.int("width", img.Width)
+	a.int("height", img.Height)
+	w.empty("image", a)
+}
+
+func (w *tmxWriter) writeTile(t *Tile) {
+	var a attrList
+	a.add("id", strconv.FormatUint(uint64(t.ID), 10))
+	a.str("type", t.Type)
+	a.str("terrain", t.Terrain)
+	if t.Probability != 1 {
+		a.add("probability", formatFloat32(t.Probability))
+	}
+
+	w.start("tile", a)
+	w.writeProperties(t.Properties)
+	w.writeImage(t.Image)
+	for i := 0; i < len(t.ObjectGroups); i++ {
+		w.writeObjectGroup(&t.ObjectGroups[i])
+	}
+	if len(t.Animation.Frames) > 0 {
+		w.start("animation", nil)
+		for _, f := range t.Animation.Frames {
+			var a attrList
+			a.add("tileid", strconv.FormatUint(uint64(f.TileID), 10))
+			a.add("duration", strconv.Itoa(f.Duration))
+			w.empty("frame", a)
+		}
+		w.end("animation")
+	}
+	w.end("tile")
+}
+
+func (w *tmxWriter) writeWangSet(ws *WangSet) {
+	var a attrList
+	a.add("name", ws.Name)
+	a.str("type", ws.Type)
+	a.add("tile", strconv.FormatUint(uint64(ws.TileID), 10))
+	w.start("wangset", a)
+	for _, c := range ws.Corners {
+		w.writeWangColor("wangcornercolor", c)
+	}
+	for _, c := range ws.Edges {
+		w.writeWangColor("wangedgecolor", c)
+	}
+	for _, c := range ws.Colors {
+		w.writeWangColor("wangcolor", c)
+	}
+	for _, t := range ws.Tiles {
+		var a attrList
+		a.add("tileid", strconv.FormatUint(uint64(t.TileID), 10))
+		if len(ws.Colors) > 0 {
+			a.add("wangid", t.WangID.list())
+		} else {
+			a.add("wangid", fmt.Sprintf("0x%x", uint32(t.WangID)))
+		}
+		w.empty("wangtile", a)
+	}
+	w.end("wangset")
+}
+
+func (w *tmxWriter) writeWangColor(name string, c WangColor) {
+	var a attrList
+	a.add("name", c.Name)
+	a.add("color", c.Color)
+	a.add("tile", strconv.FormatUint(uint64(c.TileID), 10))
+	a.add("probability", formatFloat32(c.Probability))
+	w.empty(name, a)
+}
+
+func (w *tmxWriter) writeLayer(l *Layer, level int) {
+	d, size, err := l.encodedData(level)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	var a attrList
+	a.uint("id", uint32(l.ID))
+	a.str("name", l.Name)
+	a.add("width", strconv.Itoa(size.X))
+	a.add("height", strconv.Itoa(size.Y))
+	a.visible(l.Visible)
+	a.opacity(l.Opacity)
+	a.int("offsetx", l.OffsetX)
+	a.int("offsety", l.OffsetY)
+	a.str("tintcolor", string(l.TintColor))
+	a.parallax(l.ParallaxX, l.ParallaxY)
+	w.start("layer", a)
+	w.writeProperties(l.Properties)
+	w.writeData(&d)
+	w.end("layer")
+}
+
+func (w *tmxWriter) writeData(d *Data) {
+	var a attrList
+	a.str("encoding", string(d.Encoding))
+	a.str("compression", string(d.Compression))
+	w.start("data", a)
+	w.raw(d.Bytes)
+	w.end("data")
+}
+
+func (w *tmxWriter) writeObjectGroup(g *ObjectGroup) {
+	var a attrList
+	a.uint("id", uint32(g.ID))
+	a.str("name", g.Name)
+	a.str("color", string(g.Color))
+	a.visible(g.Visible)
+	a.opacity(g.Opacity)
+	a.int("offsetx", g.OffsetX)
+	a.int("offsety", g.OffsetY)
+	a.str("tintcolor", string(g.TintColor))
+	a.parallax(g.ParallaxX, g.ParallaxY)
+	a.str("draworder", string(g.DrawOrder))
+
+	w.start("objectgroup", a)
+	w.writeProperties(g.Properties)
+	for i := 0; i < len(g.Objects); i++ {
+		w.writeObject(&g.Objects[i])
+	}
+	w.end("objectgroup")
+}
+
+func (w *tmxWriter) writeImageLayer(l *ImageLayer) {
+	var a attrList
+	a.uint("id", uint32(l.ID))
+	a.str("name", l.Name)
+	a.int("offsetx", l.OffsetX)
+	a.int("offsety", l.OffsetY)
+	a.visible(l.Visible)
+	a.opacity(l.Opacity)
+	a.str("tintcolor", string(l.TintColor))
+	a.parallax(l.ParallaxX, l.ParallaxY)
+	if l.RepeatX {
+		a.add("repeatx", "1")
+	}
+	if l.RepeatY {
+		a.add("repeaty", "1")
+	}
+
+	w.start("imagelayer", a)
+	w.writeProperties(l.Properties)
+	w.writeImage(l.Image)
+	w.end("imagelayer")
+}
+
+func (w *tmxWriter) writeObject(o *Object) {
+	var a attrList
+	a.uint("id", uint32(o.ID))
+	a.str("template", o.Template)
+	a.str("name", o.Name)
+	a.str("type", o.Type)
+	a.uint("gid", uint32(o.RawGID()))
+	a.add("x", formatFloat(o.X))
+	a.add("y", formatFloat(o.Y))
+	a.float("width", o.Width)
+	a.float("height", o.Height)
+	a.float("rotation", o.Rotation)
+	a.visible(o.Visible)
+
+	w.start("object", a)
+	w.writeProperties(o.Properties)
+	if o.Ellipse != nil {
+		w.empty("ellipse", nil)
+	}
+	if o.Point != nil {
+		w.empty("point", nil)
+	}
+	for _, p := range o.Polygons {
+		var a attrList
+		a.add("points", p.Points)
+		w.empty("polygon", a)
+	}
+	for _, p := range o.PolyLines {
+		var a attrList
+		a.add("points", p.Points)
+		w.empty("polyline", a)
+	}
+	if o.Text != nil {
+		w.writeText(o.Text)
+	}
+	w.end("object")
+}
+
+func (w *tmxWriter) writeText(t *Text) {
+	var a attrList
+	a.str("fontfamily", t.FontFamily)
+	if t.PixelSize != DefaultPixelSize {
+		a.add("pixelsize", strconv.Itoa(t.PixelSize))
+	}
+	a.flag("wrap", t.Wrap)
+	a.str("color", string(t.Color))
+	a.flag("bold", t.Bold)
+	a.flag("italic", t.Italic)
+	a.flag("underline", t.Underline)
+	a.flag("strikeout", t.Strikeout)
+	if !t.Kerning {
+		a.add("kerning", "0")
+	}
+	a.str("halign", t.HAlign)
+	a.str("valign", t.VAlign)
+	w.start("text", a)
+	w.text(t.Text)
+	w.end("text")
+}
@@ -0,0 +1,154 @@
+package tmx
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+var roundTripFiles = []string{
+	"testdata/base64-gzip.tmx",
+	"testdata/base64-zlib.tmx",
+	"testdata/base64-zstd.tmx",
+	"testdata/infinite.tmx",
+	"testdata/poly.tmx",
+	"testdata/legacy.tmx",
+	"testdata/external.tmx",
+	"testdata/animated.tmx",
+	"testdata/hexagonal.tmx",
+	"testdata/groups.tmx",
+	"testdata/imagelayer.tmx",
+	"testdata/text.tmx",
+	"testdata/shapes.tmx",
+	"testdata/properties.tmx",
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	for _, name := range roundTripFiles {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := Read(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := Write(&buf, want); err != nil {
+			t.Fatal(name, err)
+		}
+
+		got, err := Read(&buf)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: round trip = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	m, err := ReadFile("testdata/external.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := m.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo() = %d, wrote %d bytes", n, buf.Len())
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>` + "\n<map ",
+		`<tileset firstgid="29" source="tiles.tsx"></tileset>`,
+		`<layer id="1" name="Ground" width="2" height="2">`,
+		"   eJxjZGBgYAJiZiBmAWIAAGAACw==\n  </data>",
+	} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteProperties(t *testing.T) {
+	m, err := ReadFile("testdata/properties.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<property name="intro">You wake up.` + "\nIt is dark.</property>",
+		`<property name="depth" type="int" value="3"></property>`,
+		`<property name="mood" propertytype="mood" value="gloomy"></property>`,
+		`<property name="spawner" type="class" propertytype="spawner">` + "\n   <properties>",
+		`<property name="gold" type="int" value="12"></property>`,
+	} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestWriteTileset(t *testing.T) {
+	want, err := ReadTilesetFile("testdata/tiles.tsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTileset(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadTileset(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteTilesetEmbedded(t *testing.T) {
+	m, err := ReadFile("testdata/animated.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTileset(&buf, &m.Tilesets[0]); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("firstgid")) {
+		t.Errorf("standalone tileset contains firstgid:\n%s", buf.String())
+	}
+	// Wang sets with the colors of Tiled 1.5 and later list their wang IDs.
+	for _, want := range []string{`<wangcolor name="grass"`, `wangid="0,1,0,1,0,1,0,1"`, `wangid="0x10001000"`} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("standalone tileset missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	got, err := ReadTileset(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := m.Tilesets[0]
+	want.FirstGID = 0
+	want.setLocation("", location{})
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("round trip = %+v, want %+v", *got, want)
+	}
+}
@@ -0,0 +1,1201 @@
+package tmx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// The zstd format is specified by RFC 8878. The decoder supports all frames
+// but those using dictionaries. The encoder writes frames with the content
+// size and checksum, whose blocks hold raw literals and matches coded with
+// the predefined tables.
+
+// Errors of zstd data that does not decode.
+var (
+	errZstdCorrupt    = errors.New("tmx: corrupt zstd data")
+	errZstdDictionary = errors.New("tmx: zstd dictionaries are not supported")
+	errZstdChecksum   = errors.New("tmx: zstd checksum mismatch")
+	errZstdTooLarge   = errors.New("tmx: zstd data larger than expected")
+	errZstdLevel      = errors.New("tmx: invalid zstd compression level")
+)
+
+const (
+	zstdMagic        = 0xfd2fb528
+	zstdMaxBlockSize = 128 << 10
+	zstdMaxHuffLog   = 11
+)
+
+// Baselines and extra bits of the literals length and match length codes.
+var (
+	zstdLLBase = [36]uint32{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512, 1024, 2048, 4096,
+		8192, 16384, 32768, 65536,
+	}
+	zstdLLBits = [36]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9, 10, 11, 12,
+		13, 14, 15, 16,
+	}
+	zstdMLBase = [53]uint32{
+		3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
+		19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+		35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515, 1027, 2051,
+		4099, 8195, 16387, 32771, 65539,
+	}
+	zstdMLBits = [53]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9, 10, 11,
+		12, 13, 14, 15, 16,
+	}
+)
+
+// Predefined distributions of the literals length, match length and offset
+// codes.
+var (
+	zstdLLNorm = []int16{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1,
+	}
+	zstdMLNorm = []int16{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1,
+	}
+	zstdOFNorm = []int16{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+	}
+)
+
+// Predefined decoding and encoding tables.
+var (
+	zstdLLTable = mustFSETable(zstdLLNorm, 6)
+	zstdMLTable = mustFSETable(zstdMLNorm, 6)
+	zstdOFTable = mustFSETable(zstdOFNorm, 5)
+
+	zstdLLEnc = newFSEEncoder(zstdLLNorm, 6)
+	zstdMLEnc = newFSEEncoder(zstdMLNorm, 6)
+	zstdOFEnc = newFSEEncoder(zstdOFNorm, 5)
+)
+
+// zstdDecompress returns the content of the zstd frames of src, or
+// errZstdTooLarge if it holds more than limit bytes.
+func zstdDecompress(src []byte, limit int) ([]byte, error) {
+	d := zstdDecoder{limit: limit}
+	for len(src) > 0 {
+		if len(src) < 4 {
+			return nil, errZstdCorrupt
+		}
+		magic := binary.LittleEndian.Uint32(src)
+		if magic&^0xf == 0x184d2a50 {
+			// Skippable frame.
+			if len(src) < 8 || uint64(len(src)-8) < uint64(binary.LittleEndian.Uint32(src[4:])) {
+				return nil, errZstdCorrupt
+			}
+			src = src[8+int(binary.LittleEndian.Uint32(src[4:])):]
+			continue
+		}
+		if magic != zstdMagic {
+			return nil, errZstdCorrupt
+		}
+		n, err := d.frame(src[4:])
+		if err != nil {
+			return nil, err
+		}
+		src = src[4+n:]
+	}
+	return d.out, nil
+}
+
+// zstdUsesDictionary reports whether the base64 encoded zstd frame of src
+// needs a dictionary to decode.
+func zstdUsesDictionary(src []byte) bool {
+	src = bytes.TrimSpace(src)
+	var b [12]byte
+	n, _ := base64.StdEncoding.Decode(b[:], src[:min(16, len(src))])
+	if n < 6 || binary.LittleEndian.Uint32(b[:]) != zstdMagic {
+		return false
+	}
+	fhd := b[4]
+	pos := 5
+	if fhd&0x20 == 0 {
+		pos++
+	}
+	for i := 0; i < [4]int{0, 1, 2, 4}[fhd&3] && pos+i < n; i++ {
+		if b[pos+i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// zstdDecoder holds the state of decoding zstd frames.
+type zstdDecoder struct {
+	out   []byte
+	limit int
+	start int // Start of the content of the frame in out.
+
+	reps       [3]int
+	huff       *huffTable
+	ll, of, ml *fseTable
+}
+
+// frame decodes the frame of src following its magic number and returns
+// the number of bytes read.
+func (d *zstdDecoder) frame(src []byte) (int, error) {
+	if len(src) < 1 {
+		return 0, errZstdCorrupt
+	}
+	fhd := src[0]
+	pos := 1
+	if fhd&0x08 != 0 {
+		return 0, errZstdCorrupt
+	}
+	single := fhd&0x20 != 0
+	checksum := fhd&0x04 != 0
+	dictSize := [4]int{0, 1, 2, 4}[fhd&3]
+	fcsSize := [4]int{0, 2, 4, 8}[fhd>>6]
+	if fcsSize == 0 && single {
+		fcsSize = 1
+	}
+	if !single {
+		pos++ // Window descriptor. The whole content is kept.
+	}
+	if len(src) < pos+dictSize+fcsSize {
+		return 0, errZstdCorrupt
+	}
+	for i := 0; i < dictSize; i++ {
+		if src[pos+i] != 0 {
+			return 0, errZstdDictionary
+		}
+	}
+	pos += dictSize
+	var fcs uint64
+	switch fcsSize {
+	case 1:
+		fcs = uint64(src[pos])
+	case 2:
+		fcs = uint64(binary.LittleEndian.Uint16(src[pos:])) + 256
+	case 4:
+		fcs = uint64(binary.LittleEndian.Uint32(src[pos:]))
+	case 8:
+		fcs = binary.LittleEndian.Uint64(src[pos:])
+	}
+	pos += fcsSize
+	if fcsSize > 0 && fcs > uint64(d.limit-len(d.out)) {
+		return 0, errZstdTooLarge
+	}
+
+	d.start = len(d.out)
+	d.reps = [3]int{1, 4, 8}
+	d.huff, d.ll, d.of, d.ml = nil, nil, nil, nil
+	for last := false; !last; {
+		if len(src) < pos+3 {
+			return 0, errZstdCorrupt
+		}
+		h := uint32(src[pos]) | uint32(src[pos+1])<<8 | uint32(src[pos+2])<<16
+		pos += 3
+		last = h&1 != 0
+		size := int(h >> 3)
+		switch h >> 1 & 3 {
+		case 0:
+			if len(src) < pos+size {
+				return 0, errZstdCorrupt
+			}
+			if err := d.grow(size); err != nil {
+				return 0, err
+			}
+			d.out = append(d.out, src[pos:pos+size]...)
+			pos += size
+		case 1:
+			if len(src) < pos+1 {
+				return 0, errZstdCorrupt
+			}
+			if err := d.grow(size); err != nil {
+				return 0, err
+			}
+			for i := 0; i < size; i++ {
+				d.out = append(d.out, src[pos])
+			}
+			pos++
+		case 2:
+			if size > zstdMaxBlockSize || len(src) < pos+size {
+				return 0, errZstdCorrupt
+			}
+			if err := d.block(src[pos : pos+size]); err != nil {
+				return 0, err
+			}
+			pos += size
+		default:
+			return 0, errZstdCorrupt
+		}
+	}
+	if fcsSize > 0 && uint64(len(d.out)-d.start) != fcs {
+		return 0, errZstdCorrupt
+	}
+	if checksum {
+		if len(src) < pos+4 {
+			return 0, errZstdCorrupt
+		}
+		if uint32(xxh64(d.out[d.start:])) != binary.LittleEndian.Uint32(src[pos:]) {
+			return 0, errZstdChecksum
+		}
+		pos += 4
+	}
+	return pos, nil
+}
+
+// grow checks that n more bytes of content are within the limit.
+func (d *zstdDecoder) grow(n int) error {
+	if n > d.limit-len(d.out) {
+		return errZstdTooLarge
+	}
+	return nil
+}
+
+// block decodes a compressed block.
+func (d *zstdDecoder) block(src []byte) error {
+	lits, n, err := d.literals(src)
+	if err != nil {
+		return err
+	}
+	src = src[n:]
+
+	if len(src) < 1 {
+		return errZstdCorrupt
+	}
+	nseq, pos := int(src[0]), 1
+	switch {
+	case nseq == 0:
+		if len(src) != 1 {
+			return errZstdCorrupt
+		}
+		if err := d.grow(len(lits)); err != nil {
+			return err
+		}
+		d.out = append(d.out, lits...)
+		return nil
+	case nseq < 128:
+	case nseq < 255:
+		if len(src) < 2 {
+			return errZstdCorrupt
+		}
+		nseq, pos = (nseq-128)<<8+int(src[1]), 2
+	default:
+		if len(src) < 3 {
+			return errZstdCorrupt
+		}
+		nseq, pos = int(src[1])+int(src[2])<<8+0x7f00, 3
+	}
+	if len(src) < pos+1 {
+		return errZstdCorrupt
+	}
+	modes := src[pos]
+	pos++
+	if modes&3 != 0 {
+		return errZstdCorrupt
+	}
+	for _, t := range []struct {
+		mode   byte
+		table  **fseTable
+		predef *fseTable
+		maxSym int
+		maxLog uint8
+	}{
+		{modes >> 6, &d.ll, zstdLLTable, 35, 9},
+		{modes >> 4 & 3, &d.of, zstdOFTable, 31, 8},
+		{modes >> 2 & 3, &d.ml, zstdMLTable, 52, 9},
+	} {
+		n, err := seqTable(src[pos:], t.mode, t.table, t.predef, t.maxSym, t.maxLog)
+		if err != nil {
+			return err
+		}
+		pos += n
+	}
+	return d.sequences(src[pos:], nseq, lits)
+}
+
+// seqTable sets table to the sequence decoding table described by src in
+// mode, and returns the number of bytes read.
+func seqTable(src []byte, mode byte, table **fseTable, predef *fseTable, maxSym int, maxLog uint8) (int, error) {
+	switch mode {
+	case 0:
+		*table = predef
+		return 0, nil
+	case 1:
+		if len(src) < 1 || int(src[0]) > maxSym {
+			return 0, errZstdCorrupt
+		}
+		*table = &fseTable{entries: []fseEntry{{symbol: src[0]}}}
+		return 1, nil
+	case 2:
+		norm, log, n, err := readFSENorm(src, maxSym, maxLog)
+		if err != nil {
+			return 0, err
+		}
+		if *table, err = newFSETable(norm, log); err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+	if *table == nil {
+		return 0, errZstdCorrupt
+	}
+	return 0, nil
+}
+
+// sequences decodes and executes the nseq sequences of src with lits.
+func (d *zstdDecoder) sequences(src []byte, nseq int, lits []byte) error {
+	br, err := newBackwardBits(src)
+	if err != nil {
+		return err
+	}
+	ll, of, ml := d.ll, d.of, d.ml
+	llState, ofState, mlState := br.read(ll.log), br.read(of.log), br.read(ml.log)
+	for i := 0; i < nseq; i++ {
+		ofCode := of.entries[ofState].symbol
+		mlCode := ml.entries[mlState].symbol
+		llCode := ll.entries[llState].symbol
+		offsetValue := 1<<ofCode + br.read(ofCode)
+		matchLen := int(zstdMLBase[mlCode]) + br.read(zstdMLBits[mlCode])
+		litLen := int(zstdLLBase[llCode]) + br.read(zstdLLBits[llCode])
+
+		var offset int
+		if offsetValue > 3 {
+			offset = offsetValue - 3
+			d.reps = [3]int{offset, d.reps[0], d.reps[1]}
+		} else {
+			idx := offsetValue - 1
+			if litLen == 0 {
+				idx++
+			}
+			switch idx {
+			case 0:
+				offset = d.reps[0]
+			case 3:
+				offset = d.reps[0] - 1
+			default:
+				offset = d.reps[idx]
+			}
+			if idx > 0 {
+				if idx > 1 {
+					d.reps[2] = d.reps[1]
+				}
+				d.reps[1], d.reps[0] = d.reps[0], offset
+			}
+		}
+
+		if i < nseq-1 {
+			llState = ll.next(llState, &br)
+			mlState = ml.next(mlState, &br)
+			ofState = of.next(ofState, &br)
+		}
+
+		if litLen > len(lits) {
+			return errZstdCorrupt
+		}
+		if err := d.grow(litLen + matchLen); err != nil {
+			return err
+		}
+		d.out = append(d.out, lits[:litLen]...)
+		lits = lits[litLen:]
+		if offset <= 0 || offset > len(d.out)-d.start {
+			return errZstdCorrupt
+		}
+		for j := len(d.out) - offset; matchLen > 0; j, matchLen = j+1, matchLen-1 {
+			d.out = append(d.out, d.out[j])
+		}
+	}
+	if br.pos != 0 {
+		return errZstdCorrupt
+	}
+	if err := d.grow(len(lits)); err != nil {
+		return err
+	}
+	d.out = append(d.out, lits...)
+	return nil
+}
+
+// literals decodes the literals section of a block and returns the literals
+// and the number of bytes read.
+func (d *zstdDecoder) literals(src []byte) ([]byte, int, error) {
+	if len(src) < 1 {
+		return nil, 0, errZstdCorrupt
+	}
+	typ, format := src[0]&3, src[0]>>2&3
+	if typ < 2 {
+		var size, n int
+		switch format {
+		case 0, 2:
+			size, n = int(src[0]>>3), 1
+		case 1:
+			if len(src) < 2 {
+				return nil, 0, errZstdCorrupt
+			}
+			size, n = int(src[0]>>4)+int(src[1])<<4, 2
+		case 3:
+			if len(src) < 3 {
+				return nil, 0, errZstdCorrupt
+			}
+			size, n = int(src[0]>>4)+int(src[1])<<4+int(src[2])<<12, 3
+		}
+		if size > zstdMaxBlockSize {
+			return nil, 0, errZstdCorrupt
+		}
+		if typ == 0 {
+			if len(src) < n+size {
+				return nil, 0, errZstdCorrupt
+			}
+			return src[n : n+size], n + size, nil
+		}
+		if len(src) < n+1 {
+			return nil, 0, errZstdCorrupt
+		}
+		lits := make([]byte, size)
+		for i := range lits {
+			lits[i] = src[n]
+		}
+		return lits, n + 1, nil
+	}
+
+	var h uint64
+	n := [4]int{3, 3, 4, 5}[format]
+	if len(src) < n {
+		return nil, 0, errZstdCorrupt
+	}
+	for i := n - 1; i >= 0; i-- {
+		h = h<<8 | uint64(src[i])
+	}
+	sizeBits := [4]uint{10, 10, 14, 18}[format]
+	regen := int(h >> 4 & (1<<sizeBits - 1))
+	size := int(h >> (4 + sizeBits) & (1<<sizeBits - 1))
+	if regen > zstdMaxBlockSize || len(src) < n+size {
+		return nil, 0, errZstdCorrupt
+	}
+	data := src[n : n+size]
+	if typ == 2 {
+		t, m, err := readHuffTable(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		d.huff = t
+		data = data[m:]
+	} else if d.huff == nil {
+		return nil, 0, errZstdCorrupt
+	}
+
+	lits := make([]byte, regen)
+	if format == 0 {
+		if err := d.huff.decode(data, lits); err != nil {
+			return nil, 0, err
+		}
+		return lits, n + size, nil
+	}
+	if len(data) < 6 {
+		return nil, 0, errZstdCorrupt
+	}
+	sizes := [4]int{
+		int(binary.LittleEndian.Uint16(data)),
+		int(binary.LittleEndian.Uint16(data[2:])),
+		int(binary.LittleEndian.Uint16(data[4:])),
+	}
+	data = data[6:]
+	sizes[3] = len(data) - sizes[0] - sizes[1] - sizes[2]
+	seg := (regen + 3) / 4
+	if sizes[3] < 0 || 3*seg > regen {
+		return nil, 0, errZstdCorrupt
+	}
+	for i, s := range sizes {
+		dst := lits[i*seg:]
+		if i < 3 {
+			dst = dst[:seg]
+		}
+		if err := d.huff.decode(data[:s], dst); err != nil {
+			return nil, 0, err
+		}
+		data = data[s:]
+	}
+	return lits, n + size, nil
+}
+
+// backwardBits reads a bitstream from its last bit to its first, starting
+// below the highest set bit of its last byte.
+type backwardBits struct {
+	b   []byte
+	pos int // Number of bits left; reads past the start yield zeros.
+}
+
+func newBackwardBits(b []byte) (backwardBits, error) {
+	if len(b) == 0 || b[len(b)-1] == 0 {
+		return backwardBits{}, errZstdCorrupt
+	}
+	return backwardBits{b, (len(b)-1)*8 + bits.Len8(b[len(b)-1]) - 1}, nil
+}
+
+// read returns the next n bits.
+func (r *backwardBits) read(n uint8) int {
+	r.pos -= int(n)
+	return int(loadBits(r.b, r.pos, uint(n)))
+}
+
+// loadBits returns the n bits of b starting at bit start, counting from the
+// least significant bit of b[0]. Bits before the start of b are zeros.
+func loadBits(b []byte, start int, n uint) uint64 {
+	if n == 0 || start+int(n) <= 0 {
+		return 0
+	}
+	var shift uint
+	if start < 0 {
+		shift = uint(-start)
+		n -= shift
+		start = 0
+	}
+	var v uint64
+	for i, j := start>>3, 0; j < 8 && i+j < len(b); j++ {
+		v |= uint64(b[i+j]) << (8 * j)
+	}
+	return (v >> uint(start&7) & (1<<n - 1)) << shift
+}
+
+// fseEntry is a state of an FSE decoding table.
+type fseEntry struct {
+	symbol uint8
+	nbBits uint8
+	base   uint16
+}
+
+// fseTable is an FSE decoding table.
+type fseTable struct {
+	log     uint8
+	entries []fseEntry
+}
+
+// next returns the state following state.
+func (t *fseTable) next(state int, br *backwardBits) int {
+	e := t.entries[state]
+	return int(e.base) + br.read(e.nbBits)
+}
+
+// readFSENorm reads an FSE table description from src and returns the
+// normalized counts of its symbols, its accuracy log and the number of bytes
+// read.
+func readFSENorm(src []byte, maxSym int, maxLog uint8) ([]int16, uint8, int, error) {
+	if len(src) < 1 {
+		return nil, 0, 0, errZstdCorrupt
+	}
+	bitPos := 0
+	read := func(n uint) int {
+		v := loadBits(src, bitPos, n)
+		bitPos += int(n)
+		return int(v)
+	}
+	log := uint8(read(4)) + 5
+	if log > maxLog {
+		return nil, 0, 0, errZstdCorrupt
+	}
+	var norm []int16
+	remaining := 1<<log + 1
+	threshold := 1 << log
+	nbBits := uint(log) + 1
+	prev0 := false
+	for remaining > 1 && len(norm) <= maxSym {
+		if prev0 {
+			for {
+				r := read(2)
+				for i := 0; i < r; i++ {
+					norm = append(norm, 0)
+				}
+				if r != 3 {
+					break
+				}
+			}
+			if len(norm) > maxSym {
+				return nil, 0, 0, errZstdCorrupt
+			}
+		}
+		max := 2*threshold - 1 - remaining
+		count := int(loadBits(src, bitPos, nbBits))
+		if low := count & (threshold - 1); low < max {
+			count = low
+			bitPos += int(nbBits) - 1
+		} else {
+			count &= 2*threshold - 1
+			if count >= threshold {
+				count -= max
+			}
+			bitPos += int(nbBits)
+		}
+		count--
+		if count < 0 {
+			remaining += count
+		} else {
+			remaining -= count
+		}
+		norm = append(norm, int16(count))
+		prev0 = count == 0
+		for remaining < threshold {
+			nbBits--
+			threshold >>= 1
+		}
+	}
+	n := (bitPos + 7) / 8
+	if remaining != 1 || n > len(src) {
+		return nil, 0, 0, errZstdCorrupt
+	}
+	return norm, log, n, nil
+}
+
+// newFSETable returns the decoding table of the normalized counts norm with
+// accuracy log.
+func newFSETable(norm []int16, log uint8) (*fseTable, error) {
+	size := 1 << log
+	t := &fseTable{log: log, entries: make([]fseEntry, size)}
+	next := make([]uint16, len(norm))
+	high := size - 1
+	for s, c := range norm {
+		if c == -1 {
+			t.entries[high].symbol = uint8(s)
+			high--
+			next[s] = 1
+		} else {
+			next[s] = uint16(c)
+		}
+	}
+	pos := 0
+	for s, c := range norm {
+		for i := 0; i < int(c); i++ {
+			t.entries[pos].symbol = uint8(s)
+			for pos = fseStep(pos, size); pos > high; pos = fseStep(pos, size) {
+			}
+		}
+	}
+	if pos != 0 {
+		return nil, errZstdCorrupt
+	}
+	for i := range t.entries {
+		e := &t.entries[i]
+		state := next[e.symbol]
+		next[e.symbol]++
+		e.nbBits = log - uint8(bits.Len16(state)-1)
+		e.base = state<<e.nbBits - uint16(size)
+	}
+	return t, nil
+}
+
+// fseStep returns the position following pos when spreading the symbols of
+// an FSE table of the given size.
+func fseStep(pos, size int) int {
+	return (pos + size>>1 + size>>3 + 3) & (size - 1)
+}
+
+func mustFSETable(norm []int16, log uint8) *fseTable {
+	t, err := newFSETable(norm, log)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// huffEntry is an entry of a Huffman decoding table.
+type huffEntry struct {
+	symbol uint8
+	nbBits uint8
+}
+
+// huffTable is a Huffman decoding table indexed by the next log bits.
+type huffTable struct {
+	log     uint8
+	entries []huffEntry
+}
+
+// readHuffTable reads a Huffman tree description from src and returns its
+// decoding table and the number of bytes read.
+func readHuffTable(src []byte) (*huffTable, int, error) {
+	if len(src) < 1 {
+		return nil, 0, errZstdCorrupt
+	}
+	var weights []uint8
+	n := 1
+	if h := int(src[0]); h < 128 {
+		if len(src) < 1+h {
+			return nil, 0, errZstdCorrupt
+		}
+		var err error
+		if weights, err = readHuffWeights(src[1 : 1+h]); err != nil {
+			return nil, 0, err
+		}
+		n += h
+	} else {
+		weights = make([]uint8, h-127)
+		if len(src) < 1+(len(weights)+1)/2 {
+			return nil, 0, errZstdCorrupt
+		}
+		for i := range weights {
+			b := src[1+i/2]
+			if i%2 == 0 {
+				b >>= 4
+			}
+			weights[i] = b & 0xf
+		}
+		n += (len(weights) + 1) / 2
+	}
+
+	total := 0
+	for _, w := range weights {
+		if w > zstdMaxHuffLog {
+			return nil, 0, errZstdCorrupt
+		}
+		if w > 0 {
+			total += 1 << (w - 1)
+		}
+	}
+	if total == 0 {
+		return nil, 0, errZstdCorrupt
+	}
+	log := uint8(bits.Len(uint(total)))
+	rest := 1<<log - total
+	if log > zstdMaxHuffLog || rest&(rest-1) != 0 || len(weights) > 255 {
+		return nil, 0, errZstdCorrupt
+	}
+	weights = append(weights, uint8(bits.Len(uint(rest))))
+
+	var start [zstdMaxHuffLog + 2]int
+	for _, w := range weights {
+		if w > 0 {
+			start[w] += 1 << (w - 1)
+		}
+	}
+	next := 0
+	for w := 1; w <= int(log); w++ {
+		next, start[w] = next+start[w], next
+	}
+	t := &huffTable{log: log, entries: make([]huffEntry, 1<<log)}
+	for s, w := range weights {
+		if w == 0 {
+			continue
+		}
+		e := huffEntry{uint8(s), log + 1 - w}
+		for i := 0; i < 1<<(w-1); i++ {
+			t.entries[start[w]+i] = e
+		}
+		start[w] += 1 << (w - 1)
+	}
+	return t, n, nil
+}
+
+// readHuffWeights decodes the FSE compressed Huffman weights of src.
+func readHuffWeights(src []byte) ([]uint8, error) {
+	norm, log, n, err := readFSENorm(src, 255, 6)
+	if err != nil {
+		return nil, err
+	}
+	t, err := newFSETable(norm, log)
+	if err != nil {
+		return nil, err
+	}
+	br, err := newBackwardBits(src[n:])
+	if err != nil {
+		return nil, err
+	}
+	// Two interleaved states decode until the bitstream is exhausted.
+	states := [2]int{br.read(log), br.read(log)}
+	var out []uint8
+	for i := 0; ; i ^= 1 {
+		if len(out) >= 254 {
+			return nil, errZstdCorrupt
+		}
+		out = append(out, t.entries[states[i]].symbol)
+		states[i] = t.next(states[i], &br)
+		if br.pos < 0 {
+			return append(out, t.entries[states[i^1]].symbol), nil
+		}
+	}
+}
+
+// decode decodes the Huffman coded stream src into dst.
+func (t *huffTable) decode(src, dst []byte) error {
+	br, err := newBackwardBits(src)
+	if err != nil {
+		return err
+	}
+	for i := range dst {
+		e := t.entries[loadBits(br.b, br.pos-int(t.log), uint(t.log))]
+		dst[i] = e.symbol
+		br.pos -= int(e.nbBits)
+	}
+	if br.pos != 0 {
+		return errZstdCorrupt
+	}
+	return nil
+}
+
+// zstdCompress returns src compressed as a zstd frame holding its content
+// size and checksum. Levels 1 to 22 search more candidate matches as they
+// increase; DefaultCompressionLevel and 0 select level 3 like the reference
+// implementation.
+func zstdCompress(src []byte, level int) ([]byte, error) {
+	switch {
+	case level == DefaultCompressionLevel || level == 0:
+		level = 3
+	case level < 1 || level > 22:
+		return nil, errZstdLevel
+	}
+	out := binary.LittleEndian.AppendUint32(nil, zstdMagic)
+	switch n := len(src); {
+	case n < 256:
+		out = append(out, 0x24, byte(n))
+	case n < 1<<16+256:
+		out = append(out, 0x64)
+		out = binary.LittleEndian.AppendUint16(out, uint16(n-256))
+	default:
+		out = append(out, 0xa4)
+		out = binary.LittleEndian.AppendUint32(out, uint32(n))
+	}
+	e := zstdEncoder{src: src, depth: 1 << ((level - 1) / 3)}
+	if e.depth > 1 {
+		e.chain = make([]int32, len(src))
+	}
+	for start := 0; ; {
+		end := min(start+zstdMaxBlockSize, len(src))
+		out = e.block(out, start, end, end == len(src))
+		if start = end; start == len(src) {
+			break
+		}
+	}
+	return binary.LittleEndian.AppendUint32(out, uint32(xxh64(src))), nil
+}
+
+const zstdHashLog = 14
+
+// zstdEncoder finds matches in src through a hash table of the positions of
+// its 8 byte sequences, chained to the previous positions of the same hash
+// when searching more than one candidate.
+type zstdEncoder struct {
+	src    []byte
+	table  [1 << zstdHashLog]int32 // Last position + 1 of each hash.
+	chain  []int32                 // Previous position + 1 of the hash of each position.
+	depth  int                     // Candidates searched per position.
+	offset int                     // Offset of the last match.
+}
+
+// insert adds position i with hash h to the table.
+func (e *zstdEncoder) insert(h uint32, i int) {
+	if e.chain != nil {
+		e.chain[i] = e.table[h]
+	}
+	e.table[h] = int32(i + 1)
+}
+
+// zstdSeq is a sequence of literals followed by a match.
+type zstdSeq struct {
+	litLen, matchLen, offset int
+}
+
+// block appends the block of src[start:end] to out.
+func (e *zstdEncoder) block(out []byte, start, end int, last bool) []byte {
+	header := func(typ, size int) []byte {
+		h := size<<3 | typ<<1
+		if last {
+			h |= 1
+		}
+		return append(out, byte(h), byte(h>>8), byte(h>>16))
+	}
+	src := e.src[start:end]
+	if len(src) > 1 && rle(src) {
+		return append(header(1, len(src)), src[0])
+	}
+	if body := e.compress(start, end); body != nil && len(body) < len(src) {
+		return append(header(2, len(body)), body...)
+	}
+	return append(header(0, len(src)), src...)
+}
+
+// rle reports whether all bytes of b are the same.
+func rle(b []byte) bool {
+	for _, c := range b[1:] {
+		if c != b[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// compress returns the compressed block of src[start:end], or nil if it
+// holds no matches.
+func (e *zstdEncoder) compress(start, end int) []byte {
+	const maxMatch = 65539 + 1<<16 - 1
+	src := e.src
+	hash := func(i int) uint32 {
+		return uint32(binary.LittleEndian.Uint64(src[i:]) * 0x9e3779b185ebca87 >> (64 - zstdHashLog))
+	}
+	matchLen := func(c, i int) int {
+		m := 0
+		for i+m < end && m < maxMatch && src[c+m] == src[i+m] {
+			m++
+		}
+		return m
+	}
+	var lits []byte
+	var seqs []zstdSeq
+	litStart := start
+	for i := start; i+8 <= end; {
+		h := hash(i)
+		c, m := -1, 0
+		for cand, n := int(e.table[h])-1, 0; cand >= 0 && n < e.depth; n++ {
+			if l := matchLen(cand, i); l > m {
+				c, m = cand, l
+			}
+			if e.chain == nil {
+				break
+			}
+			cand = int(e.chain[cand]) - 1
+		}
+		e.insert(h, i)
+		// Matches at the last offset, common in periodic tile data, are
+		// tried along with the positions of the hash.
+		if e.offset > 0 && e.offset <= i {
+			if r := matchLen(i-e.offset, i); r > m {
+				c, m = i-e.offset, r
+			}
+		}
+		if m < 4 {
+			i++
+			continue
+		}
+		lits = append(lits, src[litStart:i]...)
+		seqs = append(seqs, zstdSeq{i - litStart, m, i - c})
+		e.offset = i - c
+		for j := i + 1; j < i+m && j+8 <= end; j++ {
+			e.insert(hash(j), j)
+		}
+		i += m
+		litStart = i
+	}
+	if len(seqs) == 0 {
+		return nil
+	}
+	lits = append(lits, src[litStart:end]...)
+
+	// Raw literals.
+	var out []byte
+	switch n := len(lits); {
+	case n < 32:
+		out = append(out, byte(n<<3))
+	case n < 4096:
+		out = append(out, byte(1<<2|n<<4), byte(n>>4))
+	default:
+		out = append(out, byte(3<<2|n<<4), byte(n>>4), byte(n>>12))
+	}
+	out = append(out, lits...)
+
+	// Sequences coded with the predefined tables.
+	switch n := len(seqs); {
+	case n < 128:
+		out = append(out, byte(n))
+	case n < 0x7f00:
+		out = append(out, byte(n>>8+128), byte(n))
+	default:
+		out = append(out, 255, byte(n-0x7f00), byte((n-0x7f00)>>8))
+	}
+	out = append(out, 0)
+
+	type codes struct {
+		ll, ml, of             uint8
+		llExtra, mlExtra, ofEx uint64
+	}
+	code := func(s zstdSeq) codes {
+		var c codes
+		c.ll = zstdCode(zstdLLBase[:], uint32(s.litLen))
+		c.llExtra = uint64(uint32(s.litLen) - zstdLLBase[c.ll])
+		c.ml = zstdCode(zstdMLBase[:], uint32(s.matchLen))
+		c.mlExtra = uint64(uint32(s.matchLen) - zstdMLBase[c.ml])
+		offsetValue := uint32(s.offset + 3)
+		c.of = uint8(bits.Len32(offsetValue) - 1)
+		c.ofEx = uint64(offsetValue - 1<<c.of)
+		return c
+	}
+	var w bitWriter
+	w.out = out
+	c := code(seqs[len(seqs)-1])
+	ml, of, ll := zstdMLEnc.init(c.ml), zstdOFEnc.init(c.of), zstdLLEnc.init(c.ll)
+	w.add(c.llExtra, uint(zstdLLBits[c.ll]))
+	w.add(c.mlExtra, uint(zstdMLBits[c.ml]))
+	w.add(c.ofEx, uint(c.of))
+	for i := len(seqs) - 2; i >= 0; i-- {
+		c := code(seqs[i])
+		of = zstdOFEnc.encode(&w, of, c.of)
+		ml = zstdMLEnc.encode(&w, ml, c.ml)
+		ll = zstdLLEnc.encode(&w, ll, c.ll)
+		w.add(c.llExtra, uint(zstdLLBits[c.ll]))
+		w.add(c.mlExtra, uint(zstdMLBits[c.ml]))
+		w.add(c.ofEx, uint(c.of))
+	}
+	w.add(uint64(ml), uint(zstdMLEnc.log))
+	w.add(uint64(of), uint(zstdOFEnc.log))
+	w.add(uint64(ll), uint(zstdLLEnc.log))
+	return w.close()
+}
+
+// zstdCode returns the code of value in the baselines base.
+func zstdCode(base []uint32, value uint32) uint8 {
+	c := len(base) - 1
+	for base[c] > value {
+		c--
+	}
+	return uint8(c)
+}
+
+// bitWriter writes a bitstream from its first bit to its last.
+type bitWriter struct {
+	out []byte
+	acc uint64
+	n   uint
+}
+
+// add writes the low nb bits of v.
+func (w *bitWriter) add(v uint64, nb uint) {
+	w.acc |= (v & (1<<nb - 1)) << w.n
+	for w.n += nb; w.n >= 8; w.n -= 8 {
+		w.out = append(w.out, byte(w.acc))
+		w.acc >>= 8
+	}
+}
+
+// close ends the bitstream with a set bit and returns its bytes.
+func (w *bitWriter) close() []byte {
+	w.add(1, 1)
+	if w.n > 0 {
+		w.out = append(w.out, byte(w.acc))
+	}
+	return w.out
+}
+
+// fseSymbol holds the transforms encoding a symbol of an FSE table.
+type fseSymbol struct {
+	deltaNbBits    uint32
+	deltaFindState int32
+}
+
+// fseEncoder is an FSE encoding table. States range from 1<<log to 2<<log.
+type fseEncoder struct {
+	log     uint8
+	states  []uint16
+	symbols []fseSymbol
+}
+
+// newFSEEncoder returns the encoding table of the normalized counts norm
+// with accuracy log, spreading symbols like newFSETable.
+func newFSEEncoder(norm []int16, log uint8) *fseEncoder {
+	size := 1 << log
+	e := &fseEncoder{log: log, states: make([]uint16, size), symbols: make([]fseSymbol, len(norm))}
+	spread := make([]uint8, size)
+	cumul := make([]int, len(norm)+1)
+	high := size - 1
+	for s, c := range norm {
+		if c == -1 {
+			cumul[s+1] = cumul[s] + 1
+			spread[high] = uint8(s)
+			high--
+		} else {
+			cumul[s+1] = cumul[s] + int(c)
+		}
+	}
+	pos := 0
+	for s, c := range norm {
+		for i := 0; i < int(c); i++ {
+			spread[pos] = uint8(s)
+			for pos = fseStep(pos, size); pos > high; pos = fseStep(pos, size) {
+			}
+		}
+	}
+	for u, s := range spread {
+		e.states[cumul[s]] = uint16(size + u)
+		cumul[s]++
+	}
+	total := 0
+	for s, c := range norm {
+		switch c {
+		case 0:
+		case -1, 1:
+			e.symbols[s] = fseSymbol{uint32(log)<<16 - uint32(size), int32(total - 1)}
+			total++
+		default:
+			maxBitsOut := uint32(log) - uint32(bits.Len16(uint16(c-1))-1)
+			e.symbols[s] = fseSymbol{maxBitsOut<<16 - uint32(c)<<maxBitsOut, int32(total - int(c))}
+			total += int(c)
+		}
+	}
+	return e
+}
+
+// init returns the state encoding symbol last.
+func (e *fseEncoder) init(symbol uint8) uint32 {
+	t := e.symbols[symbol]
+	nbBitsOut := (t.deltaNbBits + 1<<15) >> 16
+	value := nbBitsOut<<16 - t.deltaNbBits
+	return uint32(e.states[int32(value>>nbBitsOut)+t.deltaFindState])
+}
+
+// encode writes the bits of state leading to symbol and returns the state
+// encoding symbol.
+func (e *fseEncoder) encode(w *bitWriter, state uint32, symbol uint8) uint32 {
+	t := e.symbols[symbol]
+	nbBitsOut := (state + t.deltaNbBits) >> 16
+	w.add(uint64(state), uint(nbBitsOut))
+	return uint32(e.states[int32(state>>nbBitsOut)+t.deltaFindState])
+}
+
+// xxh64 returns the XXH64 hash of b with seed 0.
+func xxh64(b []byte) uint64 {
+	const (
+		p1 = 11400714785074694791
+		p2 = 14029467366897019727
+		p3 = 1609587929392839161
+		p4 = 9650029242287828579
+		p5 = 2870177450012600261
+	)
+	round := func(acc, v uint64) uint64 {
+		return bits.RotateLeft64(acc+v*p2, 31) * p1
+	}
+	n := uint64(len(b))
+	var h uint64
+	if len(b) >= 32 {
+		prime1, prime2 := uint64(p1), uint64(p2)
+		v := [4]uint64{prime1 + prime2, prime2, 0, -prime1}
+		for ; len(b) >= 32; b = b[32:] {
+			for i := range v {
+				v[i] = round(v[i], binary.LittleEndian.Uint64(b[8*i:]))
+			}
+		}
+		h = bits.RotateLeft64(v[0], 1) + bits.RotateLeft64(v[1], 7) + bits.RotateLeft64(v[2], 12) + bits.RotateLeft64(v[3], 18)
+		for _, x := range v {
+			h = (h^round(0, x))*p1 + p4
+		}
+	} else {
+		h = p5
+	}
+	h += n
+	for ; len(b) >= 8; b = b[8:] {
+		h = bits.RotateLeft64(h^round(0, binary.LittleEndian.Uint64(b)), 27)*p1 + p4
+	}
+	if len(b) >= 4 {
+		h = bits.RotateLeft64(h^uint64(binary.LittleEndian.Uint32(b))*p1, 23)*p2 + p3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h = bits.RotateLeft64(h^uint64(c)*p5, 11) * p1
+	}
+	h ^= h >> 33
+	h *= p2
+	h ^= h >> 29
+	h *= p3
+	h ^= h >> 32
+	return h
+}
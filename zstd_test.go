@@ -0,0 +1,146 @@
+package tmx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestXXH64(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+	} {
+		if got := xxh64([]byte(tc.in)); got != tc.want {
+			t.Errorf("xxh64(%q) = %#x, want %#x", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestZstdRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	noise := make([]byte, 5000)
+	r.Read(noise)
+	var tiles []byte
+	for i := 0; i < 100000; i++ {
+		tiles = binary.LittleEndian.AppendUint32(tiles, uint32(i*i%50))
+	}
+	for _, tc := range []struct {
+		name string
+		in   []byte
+	}{
+		{"empty", nil},
+		{"byte", []byte{7}},
+		{"run", bytes.Repeat([]byte{1, 0, 0, 0}, 1000)},
+		{"noise", noise},
+		{"tiles", tiles},
+	} {
+		for _, level := range []int{DefaultCompressionLevel, 1, 22} {
+			z, err := zstdCompress(tc.in, level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := zstdDecompress(z, len(tc.in))
+			if err != nil {
+				t.Errorf("%s level %d: %v", tc.name, level, err)
+			} else if !bytes.Equal(got, tc.in) {
+				t.Errorf("%s level %d: round trip differs", tc.name, level)
+			}
+		}
+	}
+}
+
+func TestZstdCompressLevel(t *testing.T) {
+	// Tiles repeating with different periods have more candidate matches
+	// than the fastest level searches.
+	var tiles []byte
+	for i := 0; i < 20000; i++ {
+		tiles = binary.LittleEndian.AppendUint32(tiles, uint32(i%7*i%11+i%13))
+	}
+	fast, err := zstdCompress(tiles, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, err := zstdCompress(tiles, 19)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(best) >= len(fast) {
+		t.Errorf("level 19 = %d bytes, want fewer than the %d of level 1", len(best), len(fast))
+	}
+
+	for _, level := range []int{-2, 23} {
+		if _, err := zstdCompress(tiles, level); err != errZstdLevel {
+			t.Errorf("level %d error = %v, want %v", level, err, errZstdLevel)
+		}
+	}
+}
+
+func TestZstdDecompress(t *testing.T) {
+	// Frames written by the reference implementation hold their content size
+	// and checksum, which are verified.
+	for _, name := range []string{"testdata/zstd/text.zst", "testdata/zstd/tiles.zst"} {
+		z, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := zstdDecompress(z, 1<<20); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestZstdDecompressErrors(t *testing.T) {
+	z, err := zstdCompress(bytes.Repeat([]byte("tile"), 100), DefaultCompressionLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := zstdDecompress(z, 399); err != errZstdTooLarge {
+		t.Errorf("limit error = %v, want %v", err, errZstdTooLarge)
+	}
+
+	bad := bytes.Clone(z)
+	bad[len(bad)-1] ^= 1
+	if _, err := zstdDecompress(bad, 400); err != errZstdChecksum {
+		t.Errorf("checksum error = %v, want %v", err, errZstdChecksum)
+	}
+
+	dict := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x21, 0x07, 0x01, 0x01, 0x00, 0x00}
+	if _, err := zstdDecompress(dict, 10); err != errZstdDictionary {
+		t.Errorf("dictionary error = %v, want %v", err, errZstdDictionary)
+	}
+
+	for i := 1; i < len(z); i++ {
+		if _, err := zstdDecompress(z[:i], 400); err == nil {
+			t.Errorf("truncated to %d bytes: no error", i)
+		}
+	}
+}
+
+func TestZstdLayer(t *testing.T) {
+	l := Layer{Width: 2, Height: 1, Data: Data{
+		Encoding:    Base64,
+		Compression: Zstd,
+		Bytes:       []byte("KLUv/SAIQQAAAQAAAAIAAAA="),
+	}}
+	gids, err := l.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []GID{1, 2}; !slices.Equal(gids, want) {
+		t.Errorf("Decode() = %v, want %v", gids, want)
+	}
+
+	l.Width = 1
+	if _, err := l.Decode(); !errors.Is(err, ErrInvalidDecodedDataLen) {
+		t.Errorf("Decode() of a smaller layer = %v, want %v", err, ErrInvalidDecodedDataLen)
+	}
+}